@@ -8,9 +8,12 @@ import (
 	"os"
 
 	"github.com/coreos/pkg/flagutil"
+	oauth2lib "golang.org/x/oauth2"
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
 	"k8s.io/klog/v2"
 
 	"github.com/openshift/console/cmd/bridge/config/flagvalues"
@@ -18,6 +21,7 @@ import (
 	"github.com/openshift/console/pkg/auth"
 	"github.com/openshift/console/pkg/auth/csrfverifier"
 	"github.com/openshift/console/pkg/auth/oauth2"
+	"github.com/openshift/console/pkg/auth/requestheader"
 	"github.com/openshift/console/pkg/auth/static"
 	"github.com/openshift/console/pkg/flags"
 	"github.com/openshift/console/pkg/proxy"
@@ -42,6 +46,14 @@ type AuthOptions struct {
 
 	InactivityTimeoutSeconds int
 	LogoutRedirect           string
+
+	// OIDCProviders can only be set in the config file.
+	OIDCProviders []serverconfig.OIDCProvider
+
+	RequestHeaderClientCAFile    string
+	RequestHeaderAllowedNames    flagutil.StringSliceFlag
+	RequestHeaderUsernameHeaders flagutil.StringSliceFlag
+	RequestHeaderGroupHeaders    flagutil.StringSliceFlag
 }
 
 type CompletedOptions struct {
@@ -62,6 +74,13 @@ type completedOptions struct {
 
 	InactivityTimeoutSeconds int
 	LogoutRedirectURL        *url.URL
+
+	OIDCProviders []oauth2.ProviderConfig
+
+	RequestHeaderClientCAFile    string
+	RequestHeaderAllowedNames    []string
+	RequestHeaderUsernameHeaders []string
+	RequestHeaderGroupHeaders    []string
 }
 
 func NewAuthOptions() *AuthOptions {
@@ -69,7 +88,7 @@ func NewAuthOptions() *AuthOptions {
 }
 
 func (c *AuthOptions) AddFlags(fs *flag.FlagSet) {
-	fs.Var(&c.AuthType, "user-auth", "User authentication provider type. Possible values: disabled, oidc, openshift. Defaults to 'openshift'")
+	fs.Var(&c.AuthType, "user-auth", "User authentication provider type. Possible values: disabled, oidc, openshift, request-header. Defaults to 'openshift'")
 	fs.StringVar(&c.IssuerURL, "user-auth-oidc-issuer-url", "", "The OIDC/OAuth2 issuer URL.")
 	fs.StringVar(&c.ClientID, "user-auth-oidc-client-id", "", "The OIDC/OAuth2 Client ID.")
 	fs.StringVar(&c.ClientSecret, "user-auth-oidc-client-secret", "", "The OIDC/OAuth2 Client Secret.")
@@ -82,6 +101,11 @@ func (c *AuthOptions) AddFlags(fs *flag.FlagSet) {
 
 	fs.IntVar(&c.InactivityTimeoutSeconds, "inactivity-timeout", 0, "Number of seconds, after which user will be logged out if inactive. Ignored if less than 300 seconds (5 minutes).")
 	fs.StringVar(&c.LogoutRedirect, "user-auth-logout-redirect", "", "Optional redirect URL on logout needed for some single sign-on identity providers.")
+
+	fs.StringVar(&c.RequestHeaderClientCAFile, "user-auth-request-header-client-ca-file", "", "PEM file with the CA that signs the client certificate of the authenticating front proxy. Required when --user-auth=\"request-header\".")
+	fs.Var(&c.RequestHeaderAllowedNames, "user-auth-request-header-allowed-names", "Comma-separated list of client certificate common names allowed to pass user identity headers. If empty, any certificate signed by the client CA is accepted.")
+	fs.Var(&c.RequestHeaderUsernameHeaders, "user-auth-request-header-username-headers", "Comma-separated list of request headers to inspect for the username. Defaults to X-Remote-User.")
+	fs.Var(&c.RequestHeaderGroupHeaders, "user-auth-request-header-group-headers", "Comma-separated list of request headers to inspect for the user groups. Defaults to X-Remote-Group.")
 }
 
 func (c *AuthOptions) ApplyConfig(config *serverconfig.Auth) {
@@ -100,6 +124,21 @@ func (c *AuthOptions) ApplyConfig(config *serverconfig.Auth) {
 	if len(c.ExtraScopes) == 0 {
 		c.ExtraScopes = config.OIDCExtraScopes
 	}
+
+	if len(c.OIDCProviders) == 0 {
+		c.OIDCProviders = config.OIDCProviders
+	}
+
+	serverconfig.SetIfUnset(&c.RequestHeaderClientCAFile, config.RequestHeader.ClientCAFile)
+	if len(c.RequestHeaderAllowedNames) == 0 {
+		c.RequestHeaderAllowedNames = config.RequestHeader.AllowedNames
+	}
+	if len(c.RequestHeaderUsernameHeaders) == 0 {
+		c.RequestHeaderUsernameHeaders = config.RequestHeader.UsernameHeaders
+	}
+	if len(c.RequestHeaderGroupHeaders) == 0 {
+		c.RequestHeaderGroupHeaders = config.RequestHeader.GroupHeaders
+	}
 }
 
 func (c *AuthOptions) Complete() (*CompletedOptions, error) {
@@ -126,6 +165,11 @@ func (c *AuthOptions) Complete() (*CompletedOptions, error) {
 		InactivityTimeoutSeconds: c.InactivityTimeoutSeconds,
 		OCLoginCommand:           c.OCLoginCommand,
 		StaticUserBearerToken:    c.StaticUserBearerToken,
+
+		RequestHeaderClientCAFile:    c.RequestHeaderClientCAFile,
+		RequestHeaderAllowedNames:    c.RequestHeaderAllowedNames,
+		RequestHeaderUsernameHeaders: c.RequestHeaderUsernameHeaders,
+		RequestHeaderGroupHeaders:    c.RequestHeaderGroupHeaders,
 	}
 
 	if len(c.IssuerURL) > 0 {
@@ -152,34 +196,92 @@ func (c *AuthOptions) Complete() (*CompletedOptions, error) {
 		completed.ClientSecret = string(buf)
 	}
 
+	for _, provider := range c.OIDCProviders {
+		completedProvider, err := completeOIDCProvider(provider)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OIDC provider %q: %w", provider.Name, err)
+		}
+		completed.OIDCProviders = append(completed.OIDCProviders, *completedProvider)
+	}
+
 	return &CompletedOptions{
 		completedOptions: completed,
 	}, nil
 }
 
+func completeOIDCProvider(provider serverconfig.OIDCProvider) (*oauth2.ProviderConfig, error) {
+	if _, err := url.Parse(provider.Issuer); err != nil {
+		return nil, fmt.Errorf("invalid issuer URL: %w", err)
+	}
+
+	clientSecret, err := os.ReadFile(provider.ClientSecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client secret file: %w", err)
+	}
+
+	completed := &oauth2.ProviderConfig{
+		Name:         provider.Name,
+		DisplayName:  provider.DisplayName,
+		IssuerURL:    provider.Issuer,
+		IssuerCA:     provider.CAFile,
+		ClientID:     provider.ClientID,
+		ClientSecret: string(clientSecret),
+		Scope:        append(append([]string{}, provider.ExtraScopes...), "openid"),
+	}
+
+	if len(provider.LogoutRedirect) > 0 {
+		logoutURL, err := url.Parse(provider.LogoutRedirect)
+		if err != nil {
+			return nil, fmt.Errorf("invalid logout redirect URL: %w", err)
+		}
+		completed.LogoutRedirectOverride = logoutURL.String()
+	}
+
+	return completed, nil
+}
+
 func (c *AuthOptions) Validate() []error {
 	var errs []error
 
+	if len(c.OIDCProviders) > 0 {
+		errs = append(errs, c.validateOIDCProviders()...)
+	}
+
 	switch c.AuthType {
 	case flagvalues.AuthTypeOpenShift, flagvalues.AuthTypeOIDC:
-		if len(c.ClientID) == 0 {
-			errs = append(errs, flags.NewRequiredFlagError("user-auth-oidc-client-id"))
+		// with multiple OIDC providers the client settings are validated per provider
+		if len(c.OIDCProviders) == 0 {
+			if len(c.ClientID) == 0 {
+				errs = append(errs, flags.NewRequiredFlagError("user-auth-oidc-client-id"))
+			}
+
+			if c.ClientSecret == "" && c.ClientSecretFilePath == "" {
+				errs = append(errs, fmt.Errorf("must provide either --user-auth-oidc-client-secret or --user-auth-oidc-client-secret-file"))
+			}
+
+			if c.ClientSecret != "" && c.ClientSecretFilePath != "" {
+				errs = append(errs, fmt.Errorf("cannot provide both --user-auth-oidc-client-secret and --user-auth-oidc-client-secret-file"))
+			}
 		}
 
-		if c.ClientSecret == "" && c.ClientSecretFilePath == "" {
-			errs = append(errs, fmt.Errorf("must provide either --user-auth-oidc-client-secret or --user-auth-oidc-client-secret-file"))
+		if c.StaticUserBearerToken != "" {
+			errs = append(errs, flags.NewInvalidFlagError("k8s-auth-bearer-token", "cannot be used with --user-auth=\"oidc\" or --user-auth=\"openshift\""))
 		}
-
-		if c.ClientSecret != "" && c.ClientSecretFilePath != "" {
-			errs = append(errs, fmt.Errorf("cannot provide both --user-auth-oidc-client-secret and --user-auth-oidc-client-secret-file"))
+	case flagvalues.AuthTypeHeader:
+		if len(c.RequestHeaderClientCAFile) == 0 {
+			errs = append(errs, fmt.Errorf("--user-auth-request-header-client-ca-file must be set if --user-auth=request-header"))
 		}
 
 		if c.StaticUserBearerToken != "" {
-			errs = append(errs, flags.NewInvalidFlagError("k8s-auth-bearer-token", "cannot be used with --user-auth=\"oidc\" or --user-auth=\"openshift\""))
+			errs = append(errs, flags.NewInvalidFlagError("k8s-auth-bearer-token", "cannot be used with --user-auth=\"request-header\""))
 		}
 	case flagvalues.AuthTypeDisabled:
 	default:
-		errs = append(errs, flags.NewInvalidFlagError("user-auth", "must be one of: oidc, openshift, disabled"))
+		errs = append(errs, flags.NewInvalidFlagError("user-auth", "must be one of: oidc, openshift, request-header, disabled"))
+	}
+
+	if c.AuthType != flagvalues.AuthTypeHeader && len(c.RequestHeaderClientCAFile) != 0 {
+		errs = append(errs, flags.NewInvalidFlagError("user-auth-request-header-client-ca-file", "can only be used with --user-auth=\"request-header\""))
 	}
 
 	switch c.AuthType {
@@ -197,7 +299,7 @@ func (c *AuthOptions) Validate() []error {
 		}
 
 	case flagvalues.AuthTypeOIDC:
-		if len(c.IssuerURL) == 0 {
+		if len(c.IssuerURL) == 0 && len(c.OIDCProviders) == 0 {
 			errs = append(errs, fmt.Errorf("--user-auth-oidc-issuer-url must be set if --user-auth=oidc"))
 		}
 	}
@@ -213,6 +315,49 @@ func (c *AuthOptions) Validate() []error {
 	return errs
 }
 
+func (c *AuthOptions) validateOIDCProviders() []error {
+	var errs []error
+
+	if c.AuthType != flagvalues.AuthTypeOIDC {
+		return []error{fmt.Errorf("auth.oidcProviders can only be used with --user-auth=oidc")}
+	}
+
+	for flagName, isSet := range map[string]bool{
+		"user-auth-oidc-issuer-url":         len(c.IssuerURL) > 0,
+		"user-auth-oidc-client-id":          len(c.ClientID) > 0,
+		"user-auth-oidc-client-secret":      len(c.ClientSecret) > 0,
+		"user-auth-oidc-client-secret-file": len(c.ClientSecretFilePath) > 0,
+		"user-auth-oidc-ca-file":            len(c.CAFilePath) > 0,
+		"user-auth-oidc-token-scopes":       len(c.ExtraScopes) > 0,
+	} {
+		if isSet {
+			errs = append(errs, flags.NewInvalidFlagError(flagName, "cannot be used together with auth.oidcProviders"))
+		}
+	}
+
+	names := sets.New[string]()
+	for i, provider := range c.OIDCProviders {
+		if len(provider.Name) == 0 {
+			errs = append(errs, fmt.Errorf("auth.oidcProviders[%d].name must be set", i))
+		} else if names.Has(provider.Name) {
+			errs = append(errs, fmt.Errorf("auth.oidcProviders[%d].name %q is not unique", i, provider.Name))
+		}
+		names.Insert(provider.Name)
+
+		if len(provider.Issuer) == 0 {
+			errs = append(errs, fmt.Errorf("auth.oidcProviders[%d].issuer must be set", i))
+		}
+		if len(provider.ClientID) == 0 {
+			errs = append(errs, fmt.Errorf("auth.oidcProviders[%d].clientID must be set", i))
+		}
+		if len(provider.ClientSecretFile) == 0 {
+			errs = append(errs, fmt.Errorf("auth.oidcProviders[%d].clientSecretFile must be set", i))
+		}
+	}
+
+	return errs
+}
+
 func (c *completedOptions) ApplyTo(
 	srv *server.Server,
 	k8sEndpoint *url.URL,
@@ -273,6 +418,10 @@ func (c *completedOptions) getAuthenticator(
 
 	flags.FatalIfFailed(flags.ValidateFlagNotEmpty("base-address", baseURL.String()))
 
+	if c.AuthType == flagvalues.AuthTypeHeader {
+		return c.getRequestHeaderAuthenticator(baseURL, k8sClientConfig)
+	}
+
 	var (
 		err                      error
 		userAuthOIDCIssuerURL    *url.URL
@@ -303,10 +452,15 @@ func (c *completedOptions) getAuthenticator(
 
 	}
 
+	var issuerURL string
+	if userAuthOIDCIssuerURL != nil {
+		issuerURL = userAuthOIDCIssuerURL.String()
+	}
+
 	// Config for logging into console.
 	oidcClientConfig := &oauth2.Config{
 		AuthSource:         authSource,
-		IssuerURL:          userAuthOIDCIssuerURL.String(),
+		IssuerURL:          issuerURL,
 		IssuerCA:           c.CAFilePath,
 		ClientID:           c.ClientID,
 		ClientSecret:       oidcClientSecret,
@@ -336,6 +490,14 @@ func (c *completedOptions) getAuthenticator(
 		oidcClientConfig.LogoutRedirectOverride = c.LogoutRedirectURL.String()
 	}
 
+	if len(c.OIDCProviders) > 0 {
+		authenticator, err := oauth2.NewMultiProviderAuthenticator(context.Background(), oidcClientConfig, c.OIDCProviders)
+		if err != nil {
+			klog.Fatalf("Error initializing authenticator: %v", err)
+		}
+		return authenticator, nil
+	}
+
 	authenticator, err := oauth2.NewOAuth2Authenticator(context.Background(), oidcClientConfig)
 	if err != nil {
 		klog.Fatalf("Error initializing authenticator: %v", err)
@@ -343,3 +505,38 @@ func (c *completedOptions) getAuthenticator(
 
 	return authenticator, nil
 }
+
+func (c *completedOptions) getRequestHeaderAuthenticator(baseURL *url.URL, k8sClientConfig *rest.Config) (auth.Authenticator, error) {
+	// Users authenticated by the front proxy have no token of their own, the console
+	// service account impersonates them instead.
+	var tokenSource oauth2lib.TokenSource
+	switch {
+	case k8sClientConfig.BearerTokenFile != "":
+		tokenSource = transport.NewCachedFileTokenSource(k8sClientConfig.BearerTokenFile)
+	case k8sClientConfig.BearerToken != "":
+		tokenSource = oauth2lib.StaticTokenSource(&oauth2lib.Token{AccessToken: k8sClientConfig.BearerToken})
+	default:
+		return nil, fmt.Errorf("request header authentication requires a service account bearer token")
+	}
+
+	requestHeaderConfig := &requestheader.Config{
+		ClientCAFile:    c.RequestHeaderClientCAFile,
+		AllowedNames:    c.RequestHeaderAllowedNames,
+		UsernameHeaders: c.RequestHeaderUsernameHeaders,
+		GroupHeaders:    c.RequestHeaderGroupHeaders,
+		TokenSource:     tokenSource,
+		SuccessURL:      proxy.SingleJoiningSlash(baseURL.Path, server.AuthLoginSuccessEndpoint),
+	}
+
+	if c.LogoutRedirectURL != nil {
+		requestHeaderConfig.LogoutRedirectOverride = c.LogoutRedirectURL.String()
+	}
+
+	authenticator, err := requestheader.NewRequestHeaderAuthenticator(requestHeaderConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing request header authenticator: %w", err)
+	}
+
+	klog.Infof("trusting user identity headers from front proxies with client certificates signed by %s", c.RequestHeaderClientCAFile)
+	return authenticator, nil
+}
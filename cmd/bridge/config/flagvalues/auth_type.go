@@ -8,6 +8,7 @@ const (
 	AuthTypeDisabled  AuthType = "disabled"
 	AuthTypeOIDC      AuthType = "oidc"
 	AuthTypeOpenShift AuthType = "openshift"
+	AuthTypeHeader    AuthType = "request-header"
 )
 
 func (a *AuthType) Set(value string) error {
@@ -18,9 +19,11 @@ func (a *AuthType) Set(value string) error {
 		*a = AuthTypeOIDC
 	case "openshift":
 		*a = AuthTypeOpenShift
+	case "request-header":
+		*a = AuthTypeHeader
 	case "":
 	default:
-		return fmt.Errorf("invalid auth type: %q. Must be one of [openshift, oidc, disabled]", value)
+		return fmt.Errorf("invalid auth type: %q. Must be one of [openshift, oidc, request-header, disabled]", value)
 	}
 	return nil
 }
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -21,21 +20,32 @@ import (
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	authopts "github.com/openshift/console/cmd/bridge/config/auth"
+	"github.com/openshift/console/cmd/bridge/config/flagvalues"
 	"github.com/openshift/console/cmd/bridge/config/session"
 	"github.com/openshift/console/pkg/auth"
+	"github.com/openshift/console/pkg/auth/tokenexchange"
 	"github.com/openshift/console/pkg/controllers"
+	"github.com/openshift/console/pkg/devfile"
 	"github.com/openshift/console/pkg/flags"
 	"github.com/openshift/console/pkg/knative"
+	"github.com/openshift/console/pkg/middleware"
+	"github.com/openshift/console/pkg/notifications"
 	"github.com/openshift/console/pkg/olm"
 	"github.com/openshift/console/pkg/proxy"
+	"github.com/openshift/console/pkg/readiness"
+	"github.com/openshift/console/pkg/reload"
 	"github.com/openshift/console/pkg/server"
 	"github.com/openshift/console/pkg/serverconfig"
+	"github.com/openshift/console/pkg/tracing"
 	oscrypto "github.com/openshift/library-go/pkg/crypto"
 	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	"github.com/patrickmn/go-cache"
 	"golang.org/x/net/http2"
 	kruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
 	klog "k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -150,6 +160,8 @@ func main() {
 	fGrafanaPublicURL := fs.String("grafana-public-url", "", "Public URL of the cluster's Grafana server.")
 	fPrometheusPublicURL := fs.String("prometheus-public-url", "", "Public URL of the cluster's Prometheus server.")
 	fThanosPublicURL := fs.String("thanos-public-url", "", "Public URL of the cluster's Thanos server.")
+	fThanosTokenExchange := fs.String("thanos-token-exchange", "", "Token exchange settings used to obtain tokens for the Thanos proxies. (JSON as string)")
+	fAlertmanagerTokenExchange := fs.String("alertmanager-token-exchange", "", "Token exchange settings used to obtain tokens for the Alertmanager proxies. (JSON as string)")
 
 	enabledPlugins := serverconfig.MultiKeyValue{}
 	fs.Var(&enabledPlugins, "plugins", "List of plugin entries that are enabled for the console. Each entry consist of plugin-name as a key and plugin-endpoint as a value.")
@@ -159,6 +171,14 @@ func main() {
 
 	consoleCSPFlags := serverconfig.MultiKeyValue{}
 	fs.Var(&consoleCSPFlags, "content-security-policy", "List of CSP directives that are enabled for the console. Each entry consist of csp-directive-name as a key and csp-directive-value as a value. Example --content-security-policy script-src='localhost:9000',font-src='localhost:9001'")
+	fCSPMode := fs.String("content-security-policy-mode", "report-only", "Whether the CSP is only reported on or enforced (report-only | enforce). Enforce it once /api/console/csp-violations lists no violations.")
+
+	fAccessLog := fs.Bool("access-log", false, "Write a JSON access log line for each request to stdout. The values of credential headers and of the proxy header deny list are redacted.")
+	fTracingOTLPEndpoint := fs.String("tracing-otlp-endpoint", "", "URL of an OTLP/HTTP collector the OpenTelemetry traces of the console server are exported to. Example: http://otel-collector.observability.svc:4318. Tracing is disabled if empty.")
+	fTracingSamplingRatio := fs.Float64("tracing-sampling-ratio", 0.1, "Ratio of the traces started by the console server that are sampled, from 0 to 1. Traces started by the browser keep their sampling decision.")
+	fRateLimitRequestsPerSecond := fs.Float64("rate-limit-requests-per-second", 10, "Rate the request budget of a user of the expensive console endpoints refills at. Rate limiting is disabled if 0.")
+	fRateLimitBurst := fs.Int("rate-limit-burst", 100, "Largest request budget of a user of the expensive console endpoints.")
+	fRateLimitRouteCosts := fs.String("rate-limit-route-costs", "", "Budget a request to a rate limited endpoint takes, overriding the default costs. A cost of 0 exempts the endpoint. Example: {\"/api/api-discovery\": 20}. (JSON as string)")
 
 	telemetryFlags := serverconfig.MultiKeyValue{}
 	fs.Var(&telemetryFlags, "telemetry", "Telemetry configuration that can be used by console plugins. Each entry should be a key=value pair.")
@@ -172,6 +192,7 @@ func main() {
 	fAddPage := fs.String("add-page", "", "DEV ONLY. Allow add page customization. (JSON as string)")
 	fProjectAccessClusterRoles := fs.String("project-access-cluster-roles", "", "The list of Cluster Roles assignable for the project access page. (JSON as string)")
 	fPerspectives := fs.String("perspectives", "", "Allow enabling/disabling of perspectives in the console. (JSON as string)")
+	fDevfileRegistries := fs.String("devfile-registries", "", "Devfile registries used for devfile samples and parent devfiles, e.g. mirrors in disconnected clusters. (JSON as string)")
 	fCapabilities := fs.String("capabilities", "", "Allow enabling/disabling of capabilities in the console. (JSON as string)")
 	fControlPlaneTopology := fs.String("control-plane-topology-mode", "", "Defines the topology mode of the control-plane nodes (External | HighlyAvailable | HighlyAvailableArbiter | DualReplica | SingleReplica)")
 	fReleaseVersion := fs.String("release-version", "", "Defines the release version of the cluster")
@@ -343,44 +364,48 @@ func main() {
 	}
 
 	srv := &server.Server{
-		PublicDir:                    *fPublicDir,
-		BaseURL:                      baseURL,
-		AdditionalBaseURLs:           additionalBaseURLs,
-		Branding:                     branding,
-		CustomProductName:            *fCustomProductName,
-		CustomLogoFiles:              customLogoFlags,
-		CustomFaviconFiles:           customFaviconFlags,
-		ControlPlaneTopology:         *fControlPlaneTopology,
-		StatuspageID:                 *fStatuspageID,
-		DocumentationBaseURL:         documentationBaseURL,
-		AlertManagerUserWorkloadHost: *fAlertmanagerUserWorkloadHost,
-		AlertManagerTenancyHost:      *fAlertmanagerTenancyHost,
-		AlertManagerPublicURL:        alertManagerPublicURL,
-		GrafanaPublicURL:             grafanaPublicURL,
-		PrometheusPublicURL:          prometheusPublicURL,
-		ThanosPublicURL:              thanosPublicURL,
-		LoadTestFactor:               *fLoadTestFactor,
-		DevCatalogCategories:         *fDevCatalogCategories,
-		DevCatalogTypes:              *fDevCatalogTypes,
-		UserSettingsLocation:         *fUserSettingsLocation,
-		EnabledPlugins:               enabledPlugins,
-		EnabledPluginsOrder:          enabledPluginsOrder,
-		I18nNamespaces:               i18nNamespaces,
-		PluginProxy:                  *fPluginProxy,
-		ContentSecurityPolicy:        consoleCSPFlags,
-		QuickStarts:                  *fQuickStarts,
-		AddPage:                      *fAddPage,
-		ProjectAccessClusterRoles:    *fProjectAccessClusterRoles,
-		Perspectives:                 *fPerspectives,
-		Telemetry:                    telemetryFlags,
-		ReleaseVersion:               *fReleaseVersion,
-		NodeArchitectures:            nodeArchitectures,
-		NodeOperatingSystems:         nodeOperatingSystems,
-		K8sMode:                      *fK8sMode,
-		CopiedCSVsDisabled:           *fCopiedCSVsDisabled,
-		TechPreview:                  *fTechPreview,
-		OLMLifecycleMetadata:         *fOLMLifecycleMetadata,
-		Capabilities:                 capabilities,
+		AccessLog:                     *fAccessLog,
+		Notifications:                 notifications.NewBroker(),
+		Readiness:                     readiness.NewChecker(readiness.DefaultInterval),
+		PublicDir:                     *fPublicDir,
+		BaseURL:                       baseURL,
+		AdditionalBaseURLs:            additionalBaseURLs,
+		Branding:                      branding,
+		CustomProductName:             *fCustomProductName,
+		CustomLogoFiles:               customLogoFlags,
+		CustomFaviconFiles:            customFaviconFlags,
+		ControlPlaneTopology:          *fControlPlaneTopology,
+		StatuspageID:                  *fStatuspageID,
+		DocumentationBaseURL:          documentationBaseURL,
+		AlertManagerUserWorkloadHost:  *fAlertmanagerUserWorkloadHost,
+		AlertManagerTenancyHost:       *fAlertmanagerTenancyHost,
+		AlertManagerPublicURL:         alertManagerPublicURL,
+		GrafanaPublicURL:              grafanaPublicURL,
+		PrometheusPublicURL:           prometheusPublicURL,
+		ThanosPublicURL:               thanosPublicURL,
+		LoadTestFactor:                *fLoadTestFactor,
+		DevCatalogCategories:          *fDevCatalogCategories,
+		DevCatalogTypes:               *fDevCatalogTypes,
+		UserSettingsLocation:          *fUserSettingsLocation,
+		EnabledPlugins:                enabledPlugins,
+		EnabledPluginsOrder:           enabledPluginsOrder,
+		I18nNamespaces:                i18nNamespaces,
+		PluginProxy:                   *fPluginProxy,
+		ContentSecurityPolicy:         consoleCSPFlags,
+		ContentSecurityPolicyEnforced: *fCSPMode == "enforce",
+		QuickStarts:                   *fQuickStarts,
+		AddPage:                       *fAddPage,
+		ProjectAccessClusterRoles:     *fProjectAccessClusterRoles,
+		Perspectives:                  *fPerspectives,
+		Telemetry:                     telemetryFlags,
+		ReleaseVersion:                *fReleaseVersion,
+		NodeArchitectures:             nodeArchitectures,
+		NodeOperatingSystems:          nodeOperatingSystems,
+		K8sMode:                       *fK8sMode,
+		CopiedCSVsDisabled:            *fCopiedCSVsDisabled,
+		TechPreview:                   *fTechPreview,
+		OLMLifecycleMetadata:          *fOLMLifecycleMetadata,
+		Capabilities:                  capabilities,
 	}
 
 	completedAuthnOptions, err := authOptions.Complete()
@@ -409,27 +434,45 @@ func main() {
 		klog.Warningf("DEPRECATED: --log-level is now deprecated, use verbosity flag --v=Level instead")
 	}
 
-	var (
-		// Hold on to raw certificates so we can render them in kubeconfig files.
-		k8sCertPEM []byte
-	)
+	if *fRateLimitRequestsPerSecond < 0 {
+		flags.FatalIfFailed(flags.NewInvalidFlagError("rate-limit-requests-per-second", "must not be negative"))
+	}
+	if *fRateLimitBurst < 1 {
+		flags.FatalIfFailed(flags.NewInvalidFlagError("rate-limit-burst", "must be at least 1"))
+	}
+	rateLimitRouteCosts, err := serverconfig.ValidateRateLimitRouteCosts(*fRateLimitRouteCosts)
+	if err != nil {
+		flags.FatalIfFailed(flags.NewInvalidFlagError("rate-limit-route-costs", "%v", err))
+	}
+	srv.RateLimits = middleware.RateLimiterConfig{
+		RequestsPerSecond: *fRateLimitRequestsPerSecond,
+		Burst:             *fRateLimitBurst,
+		RouteCosts:        rateLimitRouteCosts,
+	}
+
+	// CA bundles are reloaded once they are rotated, see startReloaders.
+	var caBundles []*reload.CABundle
+
+	devfileRegistryConfigs, err := serverconfig.ValidateDevfileRegistries(*fDevfileRegistries)
+	if err != nil {
+		klog.Fatalf("Error parsing devfile registries: %v", err)
+	}
+	srv.DevfileRegistries, err = devfile.NewRegistries(devfileRegistryConfigs)
+	if err != nil {
+		klog.Fatalf("Error configuring devfile registries: %v", err)
+	}
+	caBundles = append(caBundles, srv.DevfileRegistries.CABundles()...)
 
 	var k8sEndpoint *url.URL
 	switch *fK8sMode {
 	case "in-cluster":
 		k8sEndpoint = &url.URL{Scheme: "https", Host: "kubernetes.default.svc"}
-		var err error
-		k8sCertPEM, err = os.ReadFile(k8sInClusterCA)
+		k8sCABundle, err := reload.NewCABundle("kube-apiserver-ca", k8sInClusterCA)
 		if err != nil {
 			klog.Fatalf("Error inferring Kubernetes config from environment: %v", err)
 		}
-		rootCAs := x509.NewCertPool()
-		if !rootCAs.AppendCertsFromPEM(k8sCertPEM) {
-			klog.Fatal("No CA found for the API server")
-		}
-		tlsConfig := oscrypto.SecureTLSConfig(&tls.Config{
-			RootCAs: rootCAs,
-		})
+		caBundles = append(caBundles, k8sCABundle)
+		tlsConfig := k8sCABundle.ClientTLSConfig()
 
 		srv.InternalProxiedK8SClientConfig = &rest.Config{
 			Host:            k8sEndpoint.String(),
@@ -447,17 +490,12 @@ func main() {
 
 		// If running in an OpenShift cluster, set up a proxy to the prometheus-k8s service running in the openshift-monitoring namespace.
 		if *fServiceCAFile != "" {
-			serviceCertPEM, err := os.ReadFile(*fServiceCAFile)
+			serviceCABundle, err := reload.NewCABundle("service-ca", *fServiceCAFile)
 			if err != nil {
 				klog.Fatalf("failed to read service-ca.crt file: %v", err)
 			}
-			serviceProxyRootCAs := x509.NewCertPool()
-			if !serviceProxyRootCAs.AppendCertsFromPEM(serviceCertPEM) {
-				klog.Fatal("no CA found for Kubernetes services")
-			}
-			serviceProxyTLSConfig := oscrypto.SecureTLSConfig(&tls.Config{
-				RootCAs: serviceProxyRootCAs,
-			})
+			caBundles = append(caBundles, serviceCABundle)
+			serviceProxyTLSConfig := serviceCABundle.ClientTLSConfig()
 
 			srv.ServiceClient = &http.Client{
 				Transport: &http.Transport{
@@ -611,6 +649,24 @@ func main() {
 		flags.FatalIfFailed(flags.NewInvalidFlagError("k8s-mode", "must be one of: in-cluster, off-cluster"))
 	}
 
+	if *fThanosTokenExchange != "" {
+		thanosTokenExchanger := newTokenExchanger("thanos-token-exchange", *fThanosTokenExchange)
+		for _, proxyConfig := range []*proxy.Config{srv.ThanosProxyConfig, srv.ThanosTenancyProxyConfig, srv.ThanosTenancyProxyForRulesConfig} {
+			if proxyConfig != nil {
+				proxyConfig.TokenExchanger = thanosTokenExchanger
+			}
+		}
+	}
+
+	if *fAlertmanagerTokenExchange != "" {
+		alertmanagerTokenExchanger := newTokenExchanger("alertmanager-token-exchange", *fAlertmanagerTokenExchange)
+		for _, proxyConfig := range []*proxy.Config{srv.AlertManagerProxyConfig, srv.AlertManagerTenancyProxyConfig, srv.AlertManagerUserWorkloadProxyConfig} {
+			if proxyConfig != nil {
+				proxyConfig.TokenExchanger = alertmanagerTokenExchanger
+			}
+		}
+	}
+
 	// Set up signal handler for graceful shutdown on first interrupt
 	// This context will be used by both the controller manager and HTTP server
 	ctx, cancel := context.WithCancel(context.Background())
@@ -641,7 +697,7 @@ func main() {
 		catalogService := olm.NewCatalogService(srv.ServiceClient, srv.CatalogdProxyConfig, cache)
 		srv.CatalogService = catalogService
 
-		if err = controllers.NewClusterCatalogReconciler(mgr, catalogService).SetupWithManager(mgr); err != nil {
+		if err = controllers.NewClusterCatalogReconciler(mgr, catalogService, srv.Notifications).SetupWithManager(mgr); err != nil {
 			klog.Errorf("failed to start ClusterCatalog reconciler: %v", err)
 		}
 
@@ -674,46 +730,49 @@ func main() {
 		klog.Warning("DEPRECATED: --k8s-auth is deprecated and setting it has no effect")
 	}
 
-	internalProxiedK8SRT, err := rest.TransportFor(srv.InternalProxiedK8SClientConfig)
+	internalProxiedDynamic, err := dynamic.NewForConfig(srv.InternalProxiedK8SClientConfig)
 	if err != nil {
-		klog.Fatalf("Failed to create k8s HTTP client: %v", err)
+		klog.Fatalf("Failed to create k8s dynamic client: %v", err)
 	}
 	srv.MonitoringDashboardConfigMapLister = server.NewResourceLister(
-		&url.URL{
-			Scheme: k8sEndpoint.Scheme,
-			Host:   k8sEndpoint.Host,
-			Path:   k8sEndpoint.Path + "/api/v1/namespaces/openshift-config-managed/configmaps",
-			RawQuery: url.Values{
-				"labelSelector": {"console.openshift.io/dashboard=true"},
-			}.Encode(),
+		ctx,
+		internalProxiedDynamic,
+		server.ListedResource{
+			Resource:      schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+			ListKind:      "ConfigMapList",
+			Namespace:     "openshift-config-managed",
+			LabelSelector: "console.openshift.io/dashboard=true",
 		},
-		internalProxiedK8SRT,
 		nil,
 	)
 
+	internalProxiedMetadata, err := metadata.NewForConfig(srv.InternalProxiedK8SClientConfig)
+	if err != nil {
+		klog.Fatalf("Failed to create k8s metadata client: %v", err)
+	}
+	srv.APIDiscoveryCache = server.NewAPIDiscoveryCache()
+	srv.APIDiscoveryCache.WatchCRDs(ctx, internalProxiedMetadata)
+
+	crdResource := schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
 	srv.KnativeEventSourceCRDLister = server.NewResourceLister(
-		&url.URL{
-			Scheme: k8sEndpoint.Scheme,
-			Host:   k8sEndpoint.Host,
-			Path:   k8sEndpoint.Path + "/apis/apiextensions.k8s.io/v1/customresourcedefinitions",
-			RawQuery: url.Values{
-				"labelSelector": {"duck.knative.dev/source=true"},
-			}.Encode(),
+		ctx,
+		internalProxiedDynamic,
+		server.ListedResource{
+			Resource:      crdResource,
+			ListKind:      "CustomResourceDefinitionList",
+			LabelSelector: "duck.knative.dev/source=true",
 		},
-		internalProxiedK8SRT,
 		knative.EventSourceFilter,
 	)
 
 	srv.KnativeChannelCRDLister = server.NewResourceLister(
-		&url.URL{
-			Scheme: k8sEndpoint.Scheme,
-			Host:   k8sEndpoint.Host,
-			Path:   k8sEndpoint.Path + "/apis/apiextensions.k8s.io/v1/customresourcedefinitions",
-			RawQuery: url.Values{
-				"labelSelector": {"duck.knative.dev/addressable=true,messaging.knative.dev/subscribable=true"},
-			}.Encode(),
+		ctx,
+		internalProxiedDynamic,
+		server.ListedResource{
+			Resource:      crdResource,
+			ListKind:      "CustomResourceDefinitionList",
+			LabelSelector: "duck.knative.dev/addressable=true,messaging.knative.dev/subscribable=true",
 		},
-		internalProxiedK8SRT,
 		knative.ChannelFilter,
 	)
 
@@ -751,12 +810,40 @@ func main() {
 		flags.FatalIfFailed(flags.NewInvalidFlagError("listen", "scheme must be one of: http, https"))
 	}
 
+	// The front proxy identifies itself with a client certificate, which requires TLS.
+	requestClientCert := completedAuthnOptions.AuthType == flagvalues.AuthTypeHeader
+	if requestClientCert && listenURL.Scheme != "https" {
+		flags.FatalIfFailed(flags.NewInvalidFlagError("listen", "scheme must be https when --user-auth=\"request-header\""))
+	}
+
+	shutdownTracing := func(context.Context) error { return nil }
+	tracingEndpoint, err := flags.ValidateFlagIsURL("tracing-otlp-endpoint", *fTracingOTLPEndpoint, true)
+	flags.FatalIfFailed(err)
+	if *fTracingSamplingRatio < 0 || *fTracingSamplingRatio > 1 {
+		flags.FatalIfFailed(flags.NewInvalidFlagError("tracing-sampling-ratio", "must be between 0 and 1"))
+	}
+	if *fTracingOTLPEndpoint != "" {
+		shutdownTracing, err = tracing.Setup(tracing.Config{
+			Endpoint:      tracingEndpoint,
+			SamplingRatio: *fTracingSamplingRatio,
+		})
+		if err != nil {
+			klog.Fatalf("failed to set up tracing: %v", err)
+		}
+		klog.Infof("Exporting traces to %s", tracingEndpoint)
+	}
+
 	handler, err := srv.HTTPHandler()
 	if err != nil {
 		klog.Fatalf("failed to set up HTTP handler: %v", err)
 	}
 
+	// the backends are registered by the handler
+	go srv.Readiness.Run(ctx)
+
 	httpsrv := &http.Server{Handler: handler}
+	// end the notification streams, the server waits for them otherwise
+	httpsrv.RegisterOnShutdown(srv.Notifications.Close)
 
 	if listenURL.Scheme == "https" {
 		if err := http2.ConfigureServer(httpsrv, &http2.Server{}); err != nil {
@@ -765,7 +852,17 @@ func main() {
 		klog.Info("HTTP/2 enabled")
 	}
 
-	listener, err := listen(listenURL.Scheme, listenURL.Host, *fTLSCertFile, *fTLSKeyFile, cfg.ServingInfo.MinTLSVersion, cfg.ServingInfo.CipherSuites)
+	var servingCert *reload.ServingCertificate
+	if listenURL.Scheme == "https" {
+		servingCert, err = reload.NewServingCertificate(*fTLSCertFile, *fTLSKeyFile)
+		if err != nil {
+			klog.Fatalf("failed to load serving certificate: %v", err)
+		}
+	}
+
+	startReloaders(ctx, srv, fs.Lookup("config").Value.String(), servingCert, caBundles)
+
+	listener, err := listen(listenURL.Scheme, listenURL.Host, servingCert, cfg.ServingInfo.MinTLSVersion, cfg.ServingInfo.CipherSuites, requestClientCert)
 	if err != nil {
 		klog.Fatalf("error getting listener, %v", err)
 	}
@@ -780,6 +877,9 @@ func main() {
 		if err = httpsrv.Shutdown(shutdownCtx); err != nil {
 			klog.Errorf("Error shutting down server: %v", err)
 		}
+		if err = shutdownTracing(shutdownCtx); err != nil {
+			klog.Errorf("Error exporting the remaining spans: %v", err)
+		}
 	}()
 
 	if *fRedirectPort != 0 {
@@ -804,7 +904,54 @@ func main() {
 	httpsrv.Serve(listener)
 }
 
-func listen(scheme, host, certFile, keyFile, minTLSVersion string, cipherSuites []string) (net.Listener, error) {
+func newTokenExchanger(flagName, value string) *tokenexchange.Exchanger {
+	tokenExchangeConfig := &serverconfig.TokenExchange{}
+	if err := json.Unmarshal([]byte(value), tokenExchangeConfig); err != nil {
+		flags.FatalIfFailed(flags.NewInvalidFlagError(flagName, "invalid JSON: %v", err))
+	}
+
+	exchanger, err := tokenexchange.NewExchangerFromConfig(tokenExchangeConfig)
+	if err != nil {
+		flags.FatalIfFailed(flags.NewInvalidFlagError(flagName, "%v", err))
+	}
+	return exchanger
+}
+
+// startReloaders watches the serving certificate, the CA bundles and the config
+// file, so that rotating them does not require a restart. Only the parts of the
+// config file that are safe to change at runtime are reloaded.
+func startReloaders(ctx context.Context, srv *server.Server, configFile string, servingCert *reload.ServingCertificate, caBundles []*reload.CABundle) {
+	if servingCert != nil {
+		if err := servingCert.Run(ctx); err != nil {
+			klog.Errorf("serving certificate will not be reloaded: %v", err)
+		}
+	}
+
+	for _, caBundle := range caBundles {
+		if err := caBundle.Run(ctx); err != nil {
+			klog.Errorf("CA bundle will not be reloaded: %v", err)
+		}
+	}
+
+	if configFile == "" {
+		return
+	}
+	err := reload.WatchFiles(ctx, func() {
+		customization, err := serverconfig.LoadReloadableCustomization(configFile)
+		reload.RecordReload("config", err)
+		if err != nil {
+			klog.Errorf("failed to reload config file %s, keeping the previous customization: %v", configFile, err)
+			return
+		}
+		srv.UpdateCustomization(customization)
+		klog.Infof("reloaded customization from config file %s", configFile)
+	}, configFile)
+	if err != nil {
+		klog.Errorf("config file will not be reloaded: %v", err)
+	}
+}
+
+func listen(scheme, host string, servingCert *reload.ServingCertificate, minTLSVersion string, cipherSuites []string, requestClientCert bool) (net.Listener, error) {
 	klog.Infof("Binding to %s...", host)
 	if scheme == "http" {
 		klog.Info("Not using TLS")
@@ -812,15 +959,14 @@ func listen(scheme, host, certFile, keyFile, minTLSVersion string, cipherSuites
 	}
 	klog.Info("Using TLS")
 	tlsConfig := &tls.Config{
-		NextProtos: []string{"h2", "http/1.1"},
-		GetCertificate: func(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
-			klog.V(4).Infof("Getting TLS certs.")
-			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
-			if err != nil {
-				return nil, err
-			}
-			return &cert, nil
-		},
+		NextProtos:     []string{"h2", "http/1.1"},
+		GetCertificate: servingCert.GetCertificate,
+	}
+
+	// Client certificates are verified by the request header authenticator, which
+	// only trusts identity headers from connections signed by its client CA.
+	if requestClientCert {
+		tlsConfig.ClientAuth = tls.RequestClientCert
 	}
 
 	if minTLSVersion != "" {
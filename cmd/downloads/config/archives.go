@@ -0,0 +1,253 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	klog "k8s.io/klog/v2"
+)
+
+// archiveSource describes how an archive is generated
+type archiveSource struct {
+	// artifactPath is the path of the artifact in TempDir
+	artifactPath string
+	format       string
+}
+
+// cachedArchive is an archive that is being generated or cached in TempDir
+type cachedArchive struct {
+	relPath string
+	// ready is closed once the archive has been generated or failed to generate
+	ready  chan struct{}
+	err    error
+	digest fileDigest
+	// inUse counts the requests serving the archive, archives in use are not evicted
+	inUse    int
+	lastUsed time.Time
+}
+
+// clientWriter forwards an archive to the client while it is generated. Errors writing
+// to the client are remembered rather than returned so that the archive is still cached.
+type clientWriter struct {
+	w       io.Writer
+	written bool
+	err     error
+}
+
+func (c *clientWriter) Write(p []byte) (int, error) {
+	if c.err == nil {
+		c.written = true
+		_, c.err = c.w.Write(p)
+	}
+	return len(p), nil
+}
+
+// archivePath returns the path of the archive the path refers to. Besides the archive itself,
+// this is its checksum file and, when signing is enabled, its signature.
+func (c *DownloadsServerConfig) archivePath(relPath string) (string, bool) {
+	archivePath := strings.TrimSuffix(relPath, archiveChecksumExtension)
+	if c.Signer != nil && archivePath == relPath {
+		archivePath = strings.TrimSuffix(relPath, signatureExtension)
+	}
+	_, ok := c.archiveSources[archivePath]
+	return archivePath, ok
+}
+
+// serveArchive serves an archive, its checksum file or its signature, generating the archive
+// first if it is not cached. A plain GET for an archive that nobody else is generating is
+// streamed to the client while the archive is written, all other requests are served from
+// the cache.
+func (c *DownloadsServerConfig) serveArchive(w http.ResponseWriter, r *http.Request, fs http.Handler, relPath, archivePath string) {
+	isArchive := archivePath == relPath
+
+	var stream *clientWriter
+	if isArchive && r.Method == http.MethodGet && r.Header.Get("Range") == "" {
+		stream = &clientWriter{w: w}
+		w.Header().Set("Content-Type", archiveContentType(archivePath))
+	}
+
+	archive, err := c.acquireArchive(r.Context(), archivePath, stream)
+	if err != nil {
+		if stream != nil && stream.written {
+			// the response has already been started, all we can do is cut it short
+			klog.Errorf("Failed to stream archive %s: %v", archivePath, err)
+			return
+		}
+		klog.Errorf("Failed to generate archive %s: %v", archivePath, err)
+		http.Error(w, fmt.Sprintf("failed to generate archive: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer c.releaseArchive(archive)
+
+	if stream != nil && stream.written {
+		return
+	}
+	if isArchive {
+		w.Header().Set("ETag", `"`+hex.EncodeToString(archive.digest.sha256)+`"`)
+	}
+	fs.ServeHTTP(w, r)
+}
+
+func archiveContentType(relPath string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(relPath)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// acquireArchive returns the cached archive, generating it if needed. If the archive is
+// generated by this call, it is also written to stream. The archive is not evicted until
+// it is released.
+func (c *DownloadsServerConfig) acquireArchive(ctx context.Context, relPath string, stream *clientWriter) (*cachedArchive, error) {
+	c.archivesLock.Lock()
+	if c.archives == nil {
+		c.archives = map[string]*cachedArchive{}
+	}
+	archive, cached := c.archives[relPath]
+	if !cached {
+		archive = &cachedArchive{relPath: relPath, ready: make(chan struct{})}
+		c.archives[relPath] = archive
+	}
+	archive.inUse++
+	archive.lastUsed = time.Now()
+	c.archivesLock.Unlock()
+
+	if !cached {
+		var output io.Writer
+		if stream != nil {
+			output = stream
+		}
+		digest, err := c.generateArchive(relPath, output)
+
+		c.archivesLock.Lock()
+		if err != nil {
+			archive.err = err
+			// forget the failed archive so that the next request tries again
+			delete(c.archives, relPath)
+		} else {
+			archive.digest = digest
+			c.archivesSize += digest.size
+			klog.Infof("Generated archive %s (%d bytes, %d bytes cached)", relPath, digest.size, c.archivesSize)
+		}
+		c.archivesLock.Unlock()
+		close(archive.ready)
+	}
+
+	select {
+	case <-archive.ready:
+	case <-ctx.Done():
+		c.releaseArchive(archive)
+		return nil, ctx.Err()
+	}
+	if archive.err != nil {
+		c.releaseArchive(archive)
+		return nil, archive.err
+	}
+	return archive, nil
+}
+
+// releaseArchive marks the archive as no longer in use and evicts archives over the disk budget.
+func (c *DownloadsServerConfig) releaseArchive(archive *cachedArchive) {
+	c.archivesLock.Lock()
+	defer c.archivesLock.Unlock()
+	archive.inUse--
+	archive.lastUsed = time.Now()
+	c.evictArchives()
+}
+
+// evictArchives removes the least recently used archives that are not in use until the
+// cached archives fit into the disk budget. archivesLock must be held.
+func (c *DownloadsServerConfig) evictArchives() {
+	if c.ArchiveCacheSize <= 0 {
+		return
+	}
+	for c.archivesSize > c.ArchiveCacheSize {
+		var oldest *cachedArchive
+		for _, archive := range c.archives {
+			// archives being generated are always in use
+			if archive.inUse > 0 {
+				continue
+			}
+			if oldest == nil || archive.lastUsed.Before(oldest.lastUsed) {
+				oldest = archive
+			}
+		}
+		if oldest == nil {
+			return
+		}
+
+		delete(c.archives, oldest.relPath)
+		c.archivesSize -= oldest.digest.size
+		archivePath := filepath.Join(c.TempDir, oldest.relPath)
+		if err := os.Remove(archivePath); err != nil {
+			klog.Errorf("Failed to remove archive %s: %v", archivePath, err)
+		}
+		os.Remove(archivePath + archiveChecksumExtension)
+		os.Remove(archivePath + signatureExtension)
+		klog.Infof("Evicted archive %s from the cache", oldest.relPath)
+	}
+}
+
+// generateArchive writes the archive and its checksum file into TempDir and signs it when a
+// signing key is configured. The archive is also written to stream if it is not nil.
+func (c *DownloadsServerConfig) generateArchive(relPath string, stream io.Writer) (fileDigest, error) {
+	source := c.archiveSources[relPath]
+	archivePath := filepath.Join(c.TempDir, relPath)
+
+	// fail before anything is streamed if the artifact cannot be read
+	if _, err := os.Stat(source.artifactPath); err != nil {
+		return fileDigest{}, err
+	}
+
+	// the archive is written to a temporary file first so that it is never served partially
+	file, err := os.CreateTemp(filepath.Dir(archivePath), "."+filepath.Base(archivePath)+"-*")
+	if err != nil {
+		return fileDigest{}, err
+	}
+	defer os.Remove(file.Name())
+
+	hasher := sha256.New()
+	counter := &countingWriter{}
+	outputs := []io.Writer{file, hasher, counter}
+	if stream != nil {
+		outputs = append(outputs, stream)
+	}
+	err = writeArchive(io.MultiWriter(outputs...), source.artifactPath, source.format)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fileDigest{}, err
+	}
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return fileDigest{}, err
+	}
+	if err := os.Rename(file.Name(), archivePath); err != nil {
+		return fileDigest{}, err
+	}
+
+	digest := fileDigest{size: counter.n, sha256: hasher.Sum(nil)}
+	if err := c.writeArchiveChecksum(relPath, digest); err != nil {
+		os.Remove(archivePath)
+		return fileDigest{}, err
+	}
+	if c.Signer != nil {
+		if err := c.sign(relPath, digest.sha256); err != nil {
+			os.Remove(archivePath)
+			os.Remove(archivePath + archiveChecksumExtension)
+			return fileDigest{}, err
+		}
+	}
+	// archives are generated deterministically, so the digest stays valid after eviction
+	c.recordDigest(relPath, digest)
+	return digest, nil
+}
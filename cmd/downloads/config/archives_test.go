@@ -0,0 +1,160 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func newArchivesTestConfig(t *testing.T) (*DownloadsServerConfig, string) {
+	t.Helper()
+	binaryPath := filepath.Join(t.TempDir(), "oc")
+	cfg := newTestConfig(t, []ArtifactSpec{{Arch: "amd64", OperatingSystem: "linux", Path: binaryPath}})
+	if _, err := cfg.generateDirFileContents(); err != nil {
+		t.Fatalf("generateDirFileContents() error: %v", err)
+	}
+	return cfg, binaryPath
+}
+
+func getArchive(t *testing.T, handler http.Handler, relPath string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+relPath, nil))
+	return rec
+}
+
+func TestArchiveCacheEviction(t *testing.T) {
+	cfg, _ := newArchivesTestConfig(t)
+	handler := cfg.Handler()
+
+	tarRec := getArchive(t, handler, "amd64/linux/oc.tar")
+	if tarRec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", tarRec.Code)
+	}
+	tarPath := filepath.Join(cfg.TempDir, "amd64/linux/oc.tar")
+	if _, err := os.Stat(tarPath); err != nil {
+		t.Fatalf("tar archive was not cached: %v", err)
+	}
+
+	// only the tar archive fits into the budget, the compressed one is smaller
+	cfg.ArchiveCacheSize = int64(tarRec.Body.Len())
+	gzRec := getArchive(t, handler, "amd64/linux/oc.tar.gz")
+	if gzRec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", gzRec.Code)
+	}
+
+	if _, err := os.Stat(tarPath); !os.IsNotExist(err) {
+		t.Error("expected the least recently used archive to be evicted")
+	}
+	if _, err := os.Stat(filepath.Join(cfg.TempDir, "amd64/linux/oc.tar.gz")); err != nil {
+		t.Errorf("expected the recently used archive to stay cached: %v", err)
+	}
+	if cfg.archivesSize != int64(gzRec.Body.Len()) {
+		t.Errorf("archivesSize = %d, want %d", cfg.archivesSize, gzRec.Body.Len())
+	}
+
+	// evicted archives are generated again
+	rec := getArchive(t, handler, "amd64/linux/oc.tar")
+	if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), tarRec.Body.Bytes()) {
+		t.Errorf("expected the evicted archive to be generated again, got %d", rec.Code)
+	}
+}
+
+func TestArchiveChecksum(t *testing.T) {
+	cfg, _ := newArchivesTestConfig(t)
+	handler := cfg.Handler()
+
+	// the checksum file of an archive that is not cached yet generates the archive first
+	checksumRec := getArchive(t, handler, "amd64/linux/oc.tar.zst.sha256")
+	if checksumRec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", checksumRec.Code)
+	}
+	archiveRec := getArchive(t, handler, "amd64/linux/oc.tar.zst")
+	if archiveRec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", archiveRec.Code)
+	}
+
+	sum := sha256.Sum256(archiveRec.Body.Bytes())
+	expected := hex.EncodeToString(sum[:]) + "  oc.tar.zst\n"
+	if checksumRec.Body.String() != expected {
+		t.Errorf("checksum file = %q, want %q", checksumRec.Body.String(), expected)
+	}
+	if digest, ok := cfg.digest("amd64/linux/oc.tar.zst"); !ok || digest.size != int64(archiveRec.Body.Len()) {
+		t.Errorf("expected the digest of the archive to be recorded, got %+v", digest)
+	}
+
+	// the checksum file is evicted along with the archive
+	cfg.ArchiveCacheSize = 1
+	getArchive(t, handler, "amd64/linux/oc.zip")
+	if _, err := os.Stat(filepath.Join(cfg.TempDir, "amd64/linux/oc.tar.zst.sha256")); !os.IsNotExist(err) {
+		t.Error("expected the checksum file to be evicted with its archive")
+	}
+}
+
+func TestArchiveConcurrentRequests(t *testing.T) {
+	cfg, _ := newArchivesTestConfig(t)
+	server := httptest.NewServer(cfg.Handler())
+	defer server.Close()
+
+	bodies := make([][]byte, 10)
+	var wg sync.WaitGroup
+	for i := range bodies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Get(server.URL + "/amd64/linux/oc.zip")
+			if err != nil {
+				t.Errorf("request failed: %v", err)
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("expected 200, got %d", resp.StatusCode)
+			}
+			bodies[i], _ = io.ReadAll(resp.Body)
+		}()
+	}
+	wg.Wait()
+
+	for i, body := range bodies {
+		if !bytes.Equal(body, bodies[0]) {
+			t.Errorf("response %d differs from the first one", i)
+		}
+	}
+	// the archive is generated once and accounted for once
+	if cfg.archivesSize != int64(len(bodies[0])) {
+		t.Errorf("archivesSize = %d, want %d", cfg.archivesSize, len(bodies[0]))
+	}
+}
+
+func TestArchiveGenerationFailure(t *testing.T) {
+	cfg, binaryPath := newArchivesTestConfig(t)
+	handler := cfg.Handler()
+
+	if err := os.Rename(binaryPath, binaryPath+".moved"); err != nil {
+		t.Fatal(err)
+	}
+	rec := getArchive(t, handler, "amd64/linux/oc.tar.gz")
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 for a missing artifact, got %d", rec.Code)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.TempDir, "amd64/linux/oc.tar.gz")); !os.IsNotExist(err) {
+		t.Error("failed archives must not be cached")
+	}
+
+	// failures are not cached either
+	if err := os.Rename(binaryPath+".moved", binaryPath); err != nil {
+		t.Fatal(err)
+	}
+	rec = getArchive(t, handler, "amd64/linux/oc.tar.gz")
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 once the artifact is back, got %d", rec.Code)
+	}
+}
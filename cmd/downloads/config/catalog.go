@@ -0,0 +1,156 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	klog "k8s.io/klog/v2"
+)
+
+const catalogFileName = "catalog.json"
+
+// filterKeys are the query parameters the listings can be filtered by
+var filterKeys = []string{"tool", "os", "arch"}
+
+// Catalog lists the artifacts grouped by tool, it is served as catalog.json.
+type Catalog struct {
+	Tools []CatalogTool `json:"tools"`
+}
+
+// CatalogTool describes a tool and all artifacts it is available as.
+type CatalogTool struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	LicenseURL  string             `json:"licenseURL,omitempty"`
+	Artifacts   []ManifestArtifact `json:"artifacts"`
+}
+
+// artifactFilter selects artifacts by tool, operating system and architecture. An empty
+// set matches everything.
+type artifactFilter map[string]map[string]bool
+
+// newArtifactFilter reads the filter from the query. Every parameter can be repeated or
+// contain several comma separated values, e.g. ?tool=oc,kubectl&os=linux.
+func newArtifactFilter(query url.Values) artifactFilter {
+	filter := artifactFilter{}
+	for _, key := range filterKeys {
+		for _, values := range query[key] {
+			for _, value := range strings.Split(values, ",") {
+				if value = strings.TrimSpace(value); value == "" {
+					continue
+				}
+				if filter[key] == nil {
+					filter[key] = map[string]bool{}
+				}
+				filter[key][value] = true
+			}
+		}
+	}
+	return filter
+}
+
+func isFiltered(query url.Values) bool {
+	for _, key := range filterKeys {
+		if query.Has(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f artifactFilter) matches(tool, operatingSystem, arch string) bool {
+	for key, value := range map[string]string{"tool": tool, "os": operatingSystem, "arch": arch} {
+		if len(f[key]) > 0 && !f[key][value] {
+			return false
+		}
+	}
+	return true
+}
+
+// listItems returns the matching artifacts and the licenses of their tools.
+func (f artifactFilter) listItems(items []ListItemLink) []ListItemLink {
+	tools := map[string]bool{}
+	for _, item := range items {
+		if item.Type == Binary && f.matches(item.Tool, item.OperatingSystem, item.Arch) {
+			tools[item.Tool] = true
+		}
+	}
+
+	filtered := []ListItemLink{}
+	for _, item := range items {
+		if item.Type == License && tools[item.Tool] || item.Type == Binary && f.matches(item.Tool, item.OperatingSystem, item.Arch) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// groupListItems groups the links by tool, in the order the tools are first configured.
+func groupListItems(items []ListItemLink) []ListGroup {
+	groups := []ListGroup{}
+	index := map[string]int{}
+	for _, item := range items {
+		i, ok := index[item.Tool]
+		if !ok {
+			i = len(groups)
+			index[item.Tool] = i
+			groups = append(groups, ListGroup{Tool: item.Tool})
+		}
+		if groups[i].Description == "" {
+			groups[i].Description = item.Description
+		}
+		groups[i].Items = append(groups[i].Items, item)
+	}
+	return groups
+}
+
+// buildCatalog groups the manifest by tool and applies the filter.
+func (c *DownloadsServerConfig) buildCatalog(filter artifactFilter) *Catalog {
+	licenses := map[string]string{}
+	for _, item := range c.listing {
+		if item.Type == License {
+			licenses[item.Tool] = item.URL
+		}
+	}
+
+	catalog := &Catalog{Tools: []CatalogTool{}}
+	index := map[string]int{}
+	for _, artifact := range c.buildManifest().Artifacts {
+		if !filter.matches(artifact.Tool, artifact.OperatingSystem, artifact.Arch) {
+			continue
+		}
+		i, ok := index[artifact.Tool]
+		if !ok {
+			i = len(catalog.Tools)
+			index[artifact.Tool] = i
+			catalog.Tools = append(catalog.Tools, CatalogTool{
+				Name:       artifact.Tool,
+				LicenseURL: licenses[artifact.Tool],
+				Artifacts:  []ManifestArtifact{},
+			})
+		}
+		if catalog.Tools[i].Description == "" {
+			catalog.Tools[i].Description = artifact.Description
+		}
+		catalog.Tools[i].Artifacts = append(catalog.Tools[i].Artifacts, artifact)
+	}
+	return catalog
+}
+
+func (c *DownloadsServerConfig) serveCatalog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(c.buildCatalog(newArtifactFilter(r.URL.Query()))); err != nil {
+		klog.Errorf("Failed to write catalog: %v", err)
+	}
+}
+
+// serveFilteredListing renders the root HTML page with the matching artifacts only.
+func (c *DownloadsServerConfig) serveFilteredListing(w http.ResponseWriter, r *http.Request) {
+	items := newArtifactFilter(r.URL.Query()).listItems(c.listing)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := c.TemplateHTML.Execute(w, HtmlPageData{Groups: groupListItems(items)}); err != nil {
+		klog.Errorf("Failed to render filtered listing: %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newCatalogTestConfig(t *testing.T) *DownloadsServerConfig {
+	t.Helper()
+	srcDir := t.TempDir()
+	cfg := newTestConfig(t, []ArtifactSpec{
+		{Tool: "oc", Arch: "amd64", OperatingSystem: "linux", Path: filepath.Join(srcDir, "oc")},
+		{Tool: "oc", Arch: "amd64", OperatingSystem: "windows", Path: filepath.Join(srcDir, "oc.exe")},
+		{Tool: "kubectl", Arch: "amd64", OperatingSystem: "linux", Path: filepath.Join(srcDir, "kubectl"), Version: "1.33", Description: "Kubernetes CLI", LicensePath: filepath.Join(srcDir, "LICENSE")},
+		{Tool: "oc", Arch: "arm64", OperatingSystem: "linux", Path: filepath.Join(srcDir, "4.19", "oc"), Version: "4.19"},
+	})
+	if err := cfg.setupArtifactsDirectory(); err != nil {
+		t.Fatalf("setupArtifactsDirectory() error: %v", err)
+	}
+	cfg.PublishIndexesInBackground()
+	waitForIndexes(t, cfg)
+	return cfg
+}
+
+func TestNewArtifactFilter(t *testing.T) {
+	filter := newArtifactFilter(url.Values{"tool": {"oc,kubectl"}, "os": {"linux", "mac"}, "unknown": {"x"}})
+	tests := []struct {
+		tool, os, arch string
+		expected       bool
+	}{
+		{"oc", "linux", "amd64", true},
+		{"kubectl", "mac", "arm64", true},
+		{"helm", "linux", "amd64", false},
+		{"oc", "windows", "amd64", false},
+	}
+	for _, tt := range tests {
+		if got := filter.matches(tt.tool, tt.os, tt.arch); got != tt.expected {
+			t.Errorf("matches(%q, %q, %q) = %v, want %v", tt.tool, tt.os, tt.arch, got, tt.expected)
+		}
+	}
+
+	if !newArtifactFilter(url.Values{}).matches("oc", "linux", "amd64") {
+		t.Error("an empty filter should match everything")
+	}
+}
+
+func TestGroupListItems(t *testing.T) {
+	cfg := newCatalogTestConfig(t)
+
+	groups := groupListItems(cfg.listing)
+	if len(groups) != 2 || groups[0].Tool != "oc" || groups[1].Tool != "kubectl" {
+		t.Fatalf("expected oc and kubectl groups, got %+v", groups)
+	}
+	if len(groups[0].Items) != 4 || groups[0].Items[0].Type != License || groups[0].Items[0].URL != "oc-license" {
+		t.Errorf("expected the oc license and 3 binaries, got %+v", groups[0].Items)
+	}
+	if groups[1].Description != "Kubernetes CLI" {
+		t.Errorf("expected the kubectl description, got %q", groups[1].Description)
+	}
+	if groups[1].Items[0].URL != "kubectl-license" {
+		t.Errorf("expected the kubectl license, got %+v", groups[1].Items[0])
+	}
+	if groups[1].Items[1].URL != "amd64/linux/1.33/kubectl" {
+		t.Errorf("expected versioned artifacts in a subdirectory, got %q", groups[1].Items[1].URL)
+	}
+}
+
+func getCatalog(t *testing.T, handler http.Handler, query string) Catalog {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/catalog.json"+query, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	catalog := Catalog{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &catalog); err != nil {
+		t.Fatalf("failed to parse catalog: %v", err)
+	}
+	return catalog
+}
+
+func TestHandler_Catalog(t *testing.T) {
+	handler := newCatalogTestConfig(t).Handler()
+
+	catalog := getCatalog(t, handler, "")
+	if len(catalog.Tools) != 2 || len(catalog.Tools[0].Artifacts) != 3 || len(catalog.Tools[1].Artifacts) != 1 {
+		t.Fatalf("unexpected catalog: %+v", catalog)
+	}
+
+	catalog = getCatalog(t, handler, "?tool=kubectl")
+	if len(catalog.Tools) != 1 {
+		t.Fatalf("expected only kubectl, got %+v", catalog.Tools)
+	}
+	kubectl := catalog.Tools[0]
+	if kubectl.Name != "kubectl" || kubectl.Description != "Kubernetes CLI" || kubectl.LicenseURL != "kubectl-license" {
+		t.Errorf("unexpected kubectl entry: %+v", kubectl)
+	}
+	if kubectl.Artifacts[0].Version != "1.33" || kubectl.Artifacts[0].SHA256 == "" {
+		t.Errorf("expected the version and digest of kubectl, got %+v", kubectl.Artifacts[0])
+	}
+
+	catalog = getCatalog(t, handler, "?os=linux&arch=amd64")
+	if len(catalog.Tools) != 2 || len(catalog.Tools[0].Artifacts) != 1 || catalog.Tools[0].Artifacts[0].URL != "amd64/linux/oc" {
+		t.Errorf("expected the amd64 linux artifacts only, got %+v", catalog.Tools)
+	}
+
+	catalog = getCatalog(t, handler, "?tool=helm")
+	if len(catalog.Tools) != 0 {
+		t.Errorf("expected no tools, got %+v", catalog.Tools)
+	}
+}
+
+func TestHandler_FilteredListing(t *testing.T) {
+	handler := newCatalogTestConfig(t).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "<h2>oc</h2>") || !strings.Contains(body, "<h2>kubectl</h2>") {
+		t.Errorf("expected a group per tool, got %s", body)
+	}
+	if !strings.Contains(body, `oc 4.19 (arm64 linux)`) {
+		t.Errorf("expected the version in the label, got %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?tool=kubectl", nil))
+	body = rec.Body.String()
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+	if !strings.Contains(body, `href="amd64/linux/1.33/kubectl"`) || !strings.Contains(body, `href="kubectl-license"`) {
+		t.Errorf("expected kubectl and its license, got %s", body)
+	}
+	if strings.Contains(body, "<h2>oc</h2>") || strings.Contains(body, "oc-license") {
+		t.Errorf("expected oc to be filtered out, got %s", body)
+	}
+}
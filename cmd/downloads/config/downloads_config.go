@@ -3,15 +3,19 @@ package config
 import (
 	"archive/tar"
 	"archive/zip"
+	"compress/gzip"
+	"crypto"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
 	"text/template"
 
+	"github.com/klauspost/compress/zstd"
 	"gopkg.in/yaml.v3"
 
 	klog "k8s.io/klog/v2"
@@ -20,7 +24,14 @@ import (
 // HtmlPageData holds data passed to the HTML template
 type HtmlPageData struct {
 	SubdirectoriesPathToRoot string
-	Items                    []ListItemLink
+	Groups                   []ListGroup
+}
+
+// ListGroup holds the links to all artifacts of a tool
+type ListGroup struct {
+	Tool        string
+	Description string
+	Items       []ListItemLink
 }
 
 type LinkType string
@@ -33,11 +44,18 @@ const (
 // ListItemLink holds the data for a link in the HTML page
 type ListItemLink struct {
 	// Type can be "link" or "license" to differentiate between links to artifacts and the license
-	Type   LinkType
-	URL    string
-	Name   string
-	TarURL string
-	ZipURL string
+	Type            LinkType
+	Tool            string
+	Version         string
+	Description     string
+	Arch            string
+	OperatingSystem string
+	URL             string
+	Name            string
+	TarURL          string
+	TarGzURL        string
+	TarZstURL       string
+	ZipURL          string
 }
 
 // ArtifactsConfig holds the the contents of an artifacts configuration file
@@ -47,9 +65,18 @@ type ArtifactsConfig struct {
 
 // ArtifactSpec holds the specification for an artifact
 type ArtifactSpec struct {
+	// Tool is the name of the CLI, it defaults to oc
+	Tool            string `yaml:"tool"`
 	Arch            string `yaml:"arch"`
 	OperatingSystem string `yaml:"operatingSystem"`
 	Path            string `yaml:"path"`
+	// Version is optional. Versioned artifacts are served from a subdirectory named after
+	// the version, so that several versions of a tool can be served side by side.
+	Version     string `yaml:"version"`
+	Description string `yaml:"description"`
+	// LicensePath is the path of the license of the tool. The first license configured
+	// for a tool is used, oc defaults to the OpenShift license.
+	LicensePath string `yaml:"licensePath"`
 }
 
 // ArtifactsConfig holds the configuration for the artifacts server
@@ -58,15 +85,42 @@ type DownloadsServerConfig struct {
 	Spec         []ArtifactSpec
 	TempDir      string
 	TemplateHTML *template.Template
-	// archivesReady is closed once all background archive creation goroutines complete.
-	archivesReady chan struct{}
+	// Signer creates detached signatures for all artifacts when set.
+	Signer crypto.Signer
+	// ArchiveCacheSize is the disk budget in bytes for archives generated on demand. The
+	// least recently used archives are evicted once it is exceeded, 0 means unlimited.
+	ArchiveCacheSize int64
+	// indexesReady is closed once the digests of all artifacts are known and the
+	// checksums, signatures and manifest have been written.
+	indexesReady chan struct{}
+
+	digestsLock sync.RWMutex
+	// digests holds the digests of all artifacts and indexes, keyed by their path relative to TempDir
+	digests map[string]fileDigest
+
+	// archiveSources maps the path of every archive relative to TempDir to the artifact it contains
+	archiveSources map[string]archiveSource
+	archivesLock   sync.Mutex
+	// archives holds the archives that are cached or being generated
+	archives map[string]*cachedArchive
+	// archivesSize is the total size of all cached archives in bytes
+	archivesSize int64
+
+	// specsFilePath is the path of the artifacts configuration file, it is reloaded when it changes
+	specsFilePath string
+	// listing holds the links to all artifacts and licenses as shown on the root HTML page
+	listing []ListItemLink
 }
 
 const indexFileName = "index.html"
 const pathToOCLicense = "/usr/share/openshift/LICENSE"
-const ocLicenseFile = "oc-license"
+const licenseFileSuffix = "-license"
+const defaultTool = "oc"
 const defaultArtifactsDir = "/tmp/artifacts"
 
+// archiveFormats lists the archives available for every artifact
+var archiveFormats = []string{".tar", ".tar.gz", ".tar.zst", ".zip"}
+
 // template used to generate the HTML file with links to artifacts
 const templateStringHTML = `<!DOCTYPE html>
 <html lang="en">
@@ -76,17 +130,20 @@ const templateStringHTML = `<!DOCTYPE html>
 </head>
 <body>
 	{{ if .SubdirectoriesPathToRoot }} <p>Directory listings are disabled. See <a href="/private/{{ .SubdirectoriesPathToRoot }}">here</a> for available content.</p> {{ end }}
-	{{ if .Items }}
+	{{ range .Groups }}
+	<h2>{{ .Tool }}</h2>
+	{{ if .Description }} <p>{{ .Description }}</p> {{ end }}
 	<ul>
 		{{ range .Items }}
             {{ if eq .Type "binary" }}
-                <li><a href="{{ .URL }}">oc ({{ .Name }})</a> (<a href="{{ .TarURL }}">tar</a> <a href="{{ .ZipURL }}">zip</a>)</li>
+                <li><a href="{{ .URL }}">{{ .Tool }}{{ if .Version }} {{ .Version }}{{ end }} ({{ .Name }})</a> (<a href="{{ .TarURL }}">tar</a> <a href="{{ .TarGzURL }}">tar.gz</a> <a href="{{ .TarZstURL }}">tar.zst</a> <a href="{{ .ZipURL }}">zip</a>)</li>
             {{ else if eq .Type "license" }}
                 <li><a href="{{ .URL }}">license</a></li>
             {{ end }}
         {{ end }}
 	</ul>
 	{{ end }}
+	{{ if not .SubdirectoriesPathToRoot }} <p>Checksums: <a href="sha256sum.txt">sha256sum.txt</a>, index: <a href="manifest.json">manifest.json</a>, catalog: <a href="catalog.json">catalog.json</a></p> {{ end }}
 </body>
 </html>`
 
@@ -110,9 +167,33 @@ func loadArtifactsSpec(path string) ([]ArtifactSpec, error) {
 		return nil, fmt.Errorf("there are no artifacts to serve")
 	}
 
+	relPaths := map[string]bool{}
+	for i := range specs.Artifacts {
+		spec := &specs.Artifacts[i]
+		if spec.Tool == "" {
+			spec.Tool = defaultTool
+		}
+		if !isPathElement(spec.Tool) {
+			return nil, fmt.Errorf("invalid tool name %q", spec.Tool)
+		}
+		if spec.Version != "" && !isPathElement(spec.Version) {
+			return nil, fmt.Errorf("invalid version %q of %s", spec.Version, spec.Tool)
+		}
+		relPath, _ := artifactRelPaths(*spec)
+		if relPaths[relPath] {
+			return nil, fmt.Errorf("more than one artifact is served as %s", relPath)
+		}
+		relPaths[relPath] = true
+	}
+
 	return specs.Artifacts, nil
 }
 
+// isPathElement reports whether the name can be used as a single element of a path
+func isPathElement(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
 // NewDownloadsServerConfig creates a new ArtifactsConfig object
 func NewDownloadsServerConfig(port int, specsFilePath string) (*DownloadsServerConfig, error) {
 	tempDir := defaultArtifactsDir
@@ -136,10 +217,11 @@ func NewDownloadsServerConfig(port int, specsFilePath string) (*DownloadsServerC
 	}
 
 	artifactsConfig := DownloadsServerConfig{
-		Port:         fmt.Sprintf("%d", port),
-		Spec:         specs,
-		TempDir:      tempDir,
-		TemplateHTML: templateHTML,
+		Port:          fmt.Sprintf("%d", port),
+		Spec:          specs,
+		TempDir:       tempDir,
+		TemplateHTML:  templateHTML,
+		specsFilePath: specsFilePath,
 	}
 	err = artifactsConfig.setupArtifactsDirectory()
 	if err != nil {
@@ -240,32 +322,70 @@ func configureArchivePath(pathToTargetFile string) string {
 	return pathToTargetFile
 }
 
-// create archive for the target binary
-func createArchive(pathToTargetFile string, format string) error {
-	// create archive in the same directory as the target binary
-	pathToArchive := configureArchivePath(pathToTargetFile) + format
+// artifactRelPaths returns the path of the artifact relative to the artifacts directory and the
+// path of its archives without the format extension.
+func artifactRelPaths(spec ArtifactSpec) (string, string) {
+	relPath := filepath.Join(spec.Arch, spec.OperatingSystem, spec.Version, filepath.Base(spec.Path))
+	return relPath, configureArchivePath(relPath)
+}
 
-	file, err := os.Create(pathToArchive)
-	if err != nil {
-		return err
+// writeArchive writes an archive of the given format containing the target binary
+func writeArchive(output io.Writer, pathToTargetFile string, format string) error {
+	var compressor io.WriteCloser
+	switch format {
+	case ".tar", ".zip":
+	case ".tar.gz":
+		compressor = gzip.NewWriter(output)
+	case ".tar.zst":
+		zw, err := zstd.NewWriter(output)
+		if err != nil {
+			return err
+		}
+		compressor = zw
+	default:
+		return fmt.Errorf("unsupported archive type")
+	}
+	if compressor != nil {
+		output = compressor
 	}
 
-	defer file.Close()
-	if format == ".tar" {
-		archiveWriter := tar.NewWriter(file)
-		addFileToTar(archiveWriter, pathToTargetFile)
-		defer archiveWriter.Close()
-	} else if format == ".zip" {
-		archiveWriter := zip.NewWriter(file)
-		addFileToZip(archiveWriter, pathToTargetFile)
-		defer archiveWriter.Close()
+	var archiveWriter io.Closer
+	var err error
+	if format == ".zip" {
+		zw := zip.NewWriter(output)
+		archiveWriter = zw
+		err = addFileToZip(zw, pathToTargetFile)
 	} else {
-		return fmt.Errorf("unsupported archive type")
+		tw := tar.NewWriter(output)
+		archiveWriter = tw
+		err = addFileToTar(tw, pathToTargetFile)
+	}
+	if err != nil {
+		archiveWriter.Close()
+		if compressor != nil {
+			compressor.Close()
+		}
+		return err
+	}
+	// closing writes the archive trailer and flushes the compressor
+	if err := archiveWriter.Close(); err != nil {
+		return err
+	}
+	if compressor != nil {
+		return compressor.Close()
 	}
-
 	return nil
 }
 
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
 // createHTMLFile applies HTML template with the given data to create an HTML file at the given path
 func (artifactsConfig *DownloadsServerConfig) createHTMLFile(path string, data HtmlPageData) error {
 	file, err := os.Create(path)
@@ -303,7 +423,6 @@ func (downloadsConfig *DownloadsServerConfig) handleDirCreation(dirPath string)
 		filepath.Join(dirPath, indexFileName),
 		HtmlPageData{
 			SubdirectoriesPathToRoot: downloadsConfig.TempDir,
-			Items:                    []ListItemLink{},
 		},
 	)
 	if err != nil {
@@ -315,17 +434,19 @@ func (downloadsConfig *DownloadsServerConfig) handleDirCreation(dirPath string)
 
 // generateDirFileContents generates the content of the root HTML file and creates directories, files and archives for artifacts
 func (downloadsConfig *DownloadsServerConfig) generateDirFileContents() ([]ListItemLink, error) {
-	content := []ListItemLink{
-		{
-			Type: License,
-			URL:  ocLicenseFile,
-		},
-	}
+	content := []ListItemLink{}
+	licenses := map[string]bool{}
 
+	downloadsConfig.archiveSources = map[string]archiveSource{}
 	// create subdirectories for the artifacts
-	for _, spec := range downloadsConfig.Spec {
+	for i := range downloadsConfig.Spec {
+		spec := &downloadsConfig.Spec[i]
+		if spec.Tool == "" {
+			spec.Tool = defaultTool
+		}
 		basename := filepath.Base(spec.Path)
-		artifactPath := filepath.Join(downloadsConfig.TempDir, spec.Arch, spec.OperatingSystem, basename)
+		relPath, relArchivePath := artifactRelPaths(*spec)
+		artifactPath := filepath.Join(downloadsConfig.TempDir, relPath)
 		archDir := filepath.Join(downloadsConfig.TempDir, spec.Arch)
 		osDir := filepath.Join(downloadsConfig.TempDir, spec.Arch, spec.OperatingSystem)
 
@@ -339,67 +460,133 @@ func (downloadsConfig *DownloadsServerConfig) generateDirFileContents() ([]ListI
 		if err != nil {
 			return nil, err
 		}
+		// create subdirectory for the version
+		if spec.Version != "" {
+			err = downloadsConfig.handleDirCreation(filepath.Join(osDir, spec.Version))
+			if err != nil {
+				return nil, err
+			}
+		}
 
 		err = os.Symlink(spec.Path, artifactPath)
 		if err != nil {
 			return nil, err
 		}
 
-		relPath := filepath.Join(spec.Arch, spec.OperatingSystem, basename)
-		relArchivePath := configureArchivePath(relPath)
+		// link the license of every tool once, next to the root HTML file
+		licensePath := spec.LicensePath
+		if licensePath == "" && spec.Tool == defaultTool {
+			licensePath = pathToOCLicense
+		}
+		if licensePath != "" && !licenses[spec.Tool] {
+			licenses[spec.Tool] = true
+			licenseFile := spec.Tool + licenseFileSuffix
+			if err := os.Symlink(licensePath, filepath.Join(downloadsConfig.TempDir, licenseFile)); err != nil {
+				return nil, err
+			}
+			content = append(content, ListItemLink{
+				Type: License,
+				Tool: spec.Tool,
+				URL:  licenseFile,
+			})
+		}
+
+		for _, format := range archiveFormats {
+			downloadsConfig.archiveSources[filepath.ToSlash(relArchivePath+format)] = archiveSource{
+				artifactPath: artifactPath,
+				format:       format,
+			}
+		}
 		content = append(content, ListItemLink{
-			Type:   Binary,
-			URL:    relPath,
-			Name:   displayName(spec.Arch, spec.OperatingSystem, basename),
-			TarURL: fmt.Sprintf("%s.tar", relArchivePath),
-			ZipURL: fmt.Sprintf("%s.zip", relArchivePath),
+			Type:            Binary,
+			Tool:            spec.Tool,
+			Version:         spec.Version,
+			Description:     spec.Description,
+			Arch:            spec.Arch,
+			OperatingSystem: spec.OperatingSystem,
+			URL:             relPath,
+			Name:            displayName(spec.Arch, spec.OperatingSystem, basename),
+			TarURL:          fmt.Sprintf("%s.tar", relArchivePath),
+			TarGzURL:        fmt.Sprintf("%s.tar.gz", relArchivePath),
+			TarZstURL:       fmt.Sprintf("%s.tar.zst", relArchivePath),
+			ZipURL:          fmt.Sprintf("%s.zip", relArchivePath),
 		})
 	}
 
 	return content, nil
 }
 
-// CreateArchivesInBackground spawns goroutines to create tar and zip archives
-// for every artifact concurrently. It closes archivesReady when all archives
-// have been written so that Handler() can unblock waiting requests.
-func (c *DownloadsServerConfig) CreateArchivesInBackground() {
-	c.archivesReady = make(chan struct{})
+// PublishIndexesInBackground computes the digests of all artifacts concurrently.
+// Once they are known, the checksums, signatures and manifest are published and
+// indexesReady is closed so that Handler() can unblock waiting requests. Archives
+// are not created here, they are generated on demand by Handler().
+func (c *DownloadsServerConfig) PublishIndexesInBackground() {
+	c.indexesReady = make(chan struct{})
 	var wg sync.WaitGroup
 
 	for _, spec := range c.Spec {
-		basename := filepath.Base(spec.Path)
-		artifactPath := filepath.Join(c.TempDir, spec.Arch, spec.OperatingSystem, basename)
+		relPath, _ := artifactRelPaths(spec)
+		artifactPath := filepath.Join(c.TempDir, relPath)
 
-		wg.Add(2)
-		go func(p string) {
+		wg.Add(1)
+		go func() {
 			defer wg.Done()
-			if err := createArchive(p, ".tar"); err != nil {
-				klog.Errorf("Failed to create tar archive for %s: %v", p, err)
+			digest, err := digestFile(artifactPath)
+			if err != nil {
+				klog.Errorf("Failed to compute digest of %s: %v", artifactPath, err)
+				return
 			}
-		}(artifactPath)
-		go func(p string) {
-			defer wg.Done()
-			if err := createArchive(p, ".zip"); err != nil {
-				klog.Errorf("Failed to create zip archive for %s: %v", p, err)
-			}
-		}(artifactPath)
+			c.recordDigest(relPath, digest)
+		}()
 	}
 
 	go func() {
 		wg.Wait()
-		close(c.archivesReady)
-		klog.Info("All archives created successfully")
+		if err := c.publishIndexes(); err != nil {
+			klog.Errorf("Failed to publish checksums and manifest: %v", err)
+		}
+		close(c.indexesReady)
+		klog.Info("Checksums and manifest published successfully")
 	}()
 }
 
-// Handler returns an http.Handler that serves files from TempDir. Requests for
-// .tar or .zip archives block until background archive creation is complete.
+// waitsForIndexes reports whether a request has to wait for the checksums, signatures and manifest.
+func waitsForIndexes(urlPath string) bool {
+	switch filepath.Base(urlPath) {
+	case manifestFileName, checksumsFileName:
+		return true
+	}
+	return filepath.Ext(urlPath) == signatureExtension
+}
+
+// Handler returns an http.Handler that serves files from TempDir. Archives, their checksum
+// files and signatures are generated on the first request for them, requests for checksums,
+// signatures, the manifest and the catalog block until they have been published. The
+// root HTML page and the catalog can be filtered by tool, os and arch. Files with a known
+// digest are served with it as ETag, range and conditional requests are handled by
+// http.FileServer.
 func (c *DownloadsServerConfig) Handler() http.Handler {
 	fs := http.FileServer(http.Dir(c.TempDir))
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ext := filepath.Ext(r.URL.Path)
-		if ext == ".tar" || ext == ".zip" {
-			<-c.archivesReady
+		relPath := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if archivePath, ok := c.archivePath(relPath); ok {
+			c.serveArchive(w, r, fs, relPath, archivePath)
+			return
+		}
+		switch {
+		case relPath == catalogFileName:
+			<-c.indexesReady
+			c.serveCatalog(w, r)
+			return
+		case (relPath == "" || relPath == indexFileName) && isFiltered(r.URL.Query()):
+			c.serveFilteredListing(w, r)
+			return
+		}
+		if waitsForIndexes(relPath) {
+			<-c.indexesReady
+		}
+		if etag := c.etag(relPath); etag != "" {
+			w.Header().Set("ETag", etag)
 		}
 		fs.ServeHTTP(w, r)
 	})
@@ -407,9 +594,6 @@ func (c *DownloadsServerConfig) Handler() http.Handler {
 
 // setupArtifactsDirectory creates the root HTML file and directories, files and archives for artifacts
 func (artifactsConfig *DownloadsServerConfig) setupArtifactsDirectory() error {
-	// symlink file in the temporary directory that points to the openshift license
-	os.Symlink(pathToOCLicense, filepath.Join(artifactsConfig.TempDir, ocLicenseFile))
-
 	// generates content of the root html file and creates directories, files and archives for artifacts
 	content, err := artifactsConfig.generateDirFileContents()
 	if err != nil {
@@ -419,11 +603,12 @@ func (artifactsConfig *DownloadsServerConfig) setupArtifactsDirectory() error {
 	// create the root html file
 	err = artifactsConfig.createHTMLFile(filepath.Join(artifactsConfig.TempDir, indexFileName), HtmlPageData{
 		SubdirectoriesPathToRoot: "",
-		Items:                    content,
+		Groups:                   groupListItems(content),
 	})
 	if err != nil {
 		return err
 	}
+	artifactsConfig.listing = content
 
 	return nil
 }
@@ -3,15 +3,20 @@ package config
 import (
 	"archive/tar"
 	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"text/template"
 	"time"
+
+	"github.com/klauspost/compress/zstd"
 )
 
 func TestDisplayName(t *testing.T) {
@@ -53,6 +58,78 @@ func TestConfigureArchivePath(t *testing.T) {
 	}
 }
 
+func TestLoadArtifactsSpec(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		expected    []ArtifactSpec
+		expectedErr bool
+	}{
+		{
+			name:     "specs without a tool are oc",
+			config:   "defaultArtifactsConfig:\n  - arch: amd64\n    operatingSystem: linux\n    path: /usr/bin/oc\n",
+			expected: []ArtifactSpec{{Tool: "oc", Arch: "amd64", OperatingSystem: "linux", Path: "/usr/bin/oc"}},
+		},
+		{
+			name: "tools and versions",
+			config: `defaultArtifactsConfig:
+  - tool: kubectl
+    arch: amd64
+    operatingSystem: linux
+    path: /usr/bin/kubectl
+    version: "1.33"
+    description: Kubernetes CLI
+    licensePath: /usr/share/kubectl/LICENSE
+  - arch: amd64
+    operatingSystem: linux
+    path: /usr/bin/oc-4.19
+    version: "4.19"
+`,
+			expected: []ArtifactSpec{
+				{Tool: "kubectl", Arch: "amd64", OperatingSystem: "linux", Path: "/usr/bin/kubectl", Version: "1.33", Description: "Kubernetes CLI", LicensePath: "/usr/share/kubectl/LICENSE"},
+				{Tool: "oc", Arch: "amd64", OperatingSystem: "linux", Path: "/usr/bin/oc-4.19", Version: "4.19"},
+			},
+		},
+		{
+			name:        "duplicate artifacts",
+			config:      "defaultArtifactsConfig:\n  - arch: amd64\n    operatingSystem: linux\n    path: /a/oc\n  - arch: amd64\n    operatingSystem: linux\n    path: /b/oc\n",
+			expectedErr: true,
+		},
+		{
+			name:        "invalid version",
+			config:      "defaultArtifactsConfig:\n  - arch: amd64\n    operatingSystem: linux\n    path: /a/oc\n    version: ../4.19\n",
+			expectedErr: true,
+		},
+		{
+			name:        "no artifacts",
+			config:      "defaultArtifactsConfig: []\n",
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specFile := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(specFile, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			specs, err := loadArtifactsSpec(specFile)
+			if tt.expectedErr {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", specs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadArtifactsSpec() error: %v", err)
+			}
+			if !reflect.DeepEqual(specs, tt.expected) {
+				t.Errorf("loadArtifactsSpec() = %+v, want %+v", specs, tt.expected)
+			}
+		})
+	}
+}
+
 func newTestConfig(t *testing.T, specs []ArtifactSpec) *DownloadsServerConfig {
 	t.Helper()
 	tempDir := t.TempDir()
@@ -96,7 +173,7 @@ func TestGenerateDirFileContents_RelativeURLs(t *testing.T) {
 		if item.Type == License {
 			continue
 		}
-		for _, u := range []string{item.URL, item.TarURL, item.ZipURL} {
+		for _, u := range []string{item.URL, item.TarURL, item.TarGzURL, item.TarZstURL, item.ZipURL} {
 			if strings.Contains(u, cfg.TempDir) {
 				t.Errorf("URL %q contains TempDir %q; expected relative path", u, cfg.TempDir)
 			}
@@ -124,84 +201,103 @@ func TestGenerateDirFileContents_RelativeURLs(t *testing.T) {
 	if win.ZipURL != "amd64/windows/oc.zip" {
 		t.Errorf("expected ZipURL %q, got %q", "amd64/windows/oc.zip", win.ZipURL)
 	}
-}
-
-func TestCreateArchivesInBackground(t *testing.T) {
-	srcDir := t.TempDir()
-	specs := []ArtifactSpec{
-		{Arch: "amd64", OperatingSystem: "linux", Path: filepath.Join(srcDir, "oc")},
-		{Arch: "arm64", OperatingSystem: "mac", Path: filepath.Join(srcDir, "oc-mac")},
+	if win.TarZstURL != "amd64/windows/oc.tar.zst" {
+		t.Errorf("expected TarZstURL %q, got %q", "amd64/windows/oc.tar.zst", win.TarZstURL)
 	}
-	cfg := newTestConfig(t, specs)
 
-	if _, err := cfg.generateDirFileContents(); err != nil {
-		t.Fatalf("generateDirFileContents() error: %v", err)
+	if source, ok := cfg.archiveSources["amd64/windows/oc.tar.gz"]; !ok || source.format != ".tar.gz" {
+		t.Errorf("expected amd64/windows/oc.tar.gz to be generated as .tar.gz, got %+v", source)
 	}
-
-	cfg.CreateArchivesInBackground()
-
-	select {
-	case <-cfg.archivesReady:
-	case <-time.After(10 * time.Second):
-		t.Fatal("timed out waiting for archives to complete")
+	if _, err := os.Stat(filepath.Join(cfg.TempDir, "amd64/windows/oc.tar.gz")); !os.IsNotExist(err) {
+		t.Errorf("archives should only be generated on demand")
 	}
+}
 
-	for _, spec := range specs {
-		basename := filepath.Base(spec.Path)
-		base := filepath.Join(cfg.TempDir, spec.Arch, spec.OperatingSystem, basename)
+// readArchiveEntry returns the name and content of the single entry of an archive
+func readArchiveEntry(t *testing.T, archive []byte, format string) (string, string) {
+	t.Helper()
+	if format == ".zip" {
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			t.Fatalf("failed to open zip: %v", err)
+		}
+		if len(zr.File) != 1 {
+			t.Fatalf("expected 1 zip entry, got %d", len(zr.File))
+		}
+		f, err := zr.File[0].Open()
+		if err != nil {
+			t.Fatalf("failed to open zip entry: %v", err)
+		}
+		defer f.Close()
+		content, _ := io.ReadAll(f)
+		return zr.File[0].Name, string(content)
+	}
 
-		tarPath := base + ".tar"
-		if _, err := os.Stat(tarPath); err != nil {
-			t.Errorf("tar archive not found at %s: %v", tarPath, err)
-			continue
+	var r io.Reader = bytes.NewReader(archive)
+	switch format {
+	case ".tar.gz":
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			t.Fatalf("failed to open gzip: %v", err)
 		}
-		f, _ := os.Open(tarPath)
-		tr := tar.NewReader(f)
-		hdr, err := tr.Next()
-		f.Close()
+		r = gr
+	case ".tar.zst":
+		zr, err := zstd.NewReader(r)
 		if err != nil {
-			t.Errorf("failed to read tar %s: %v", tarPath, err)
-		} else if hdr.Name != basename {
-			t.Errorf("tar entry name = %q, want %q", hdr.Name, basename)
+			t.Fatalf("failed to open zstd: %v", err)
 		}
+		defer zr.Close()
+		r = zr
+	}
+	tr := tar.NewReader(r)
+	hdr, err := tr.Next()
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", format, err)
+	}
+	content, _ := io.ReadAll(tr)
+	return hdr.Name, string(content)
+}
+
+func TestWriteArchive(t *testing.T) {
+	binaryPath := filepath.Join(t.TempDir(), "oc")
+	if err := os.WriteFile(binaryPath, []byte("fake-binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
 
-		zipPath := base + ".zip"
-		if _, err := os.Stat(zipPath); err != nil {
-			t.Errorf("zip archive not found at %s: %v", zipPath, err)
+	for _, format := range archiveFormats {
+		buf := &bytes.Buffer{}
+		if err := writeArchive(buf, binaryPath, format); err != nil {
+			t.Errorf("writeArchive(%s) error: %v", format, err)
 			continue
 		}
-		zr, err := zip.OpenReader(zipPath)
-		if err != nil {
-			t.Errorf("failed to open zip %s: %v", zipPath, err)
-		} else {
-			if len(zr.File) != 1 || zr.File[0].Name != basename {
-				t.Errorf("zip entry name = %q, want %q", zr.File[0].Name, basename)
-			}
-			zr.Close()
+		name, content := readArchiveEntry(t, buf.Bytes(), format)
+		if name != "oc" || content != "fake-binary" {
+			t.Errorf("%s entry = %q with %q, want %q with %q", format, name, content, "oc", "fake-binary")
 		}
 	}
+
+	if err := writeArchive(io.Discard, binaryPath, ".rar"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
 }
 
-func TestHandler_BlocksUntilArchivesReady(t *testing.T) {
+func TestHandler_BlocksUntilIndexesReady(t *testing.T) {
 	tmpDir := t.TempDir()
 	if err := os.WriteFile(filepath.Join(tmpDir, "index.html"), []byte("<html>ok</html>"), 0644); err != nil {
 		t.Fatal(err)
 	}
-	if err := os.MkdirAll(filepath.Join(tmpDir, "amd64", "linux"), 0755); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.WriteFile(filepath.Join(tmpDir, "amd64", "linux", "oc.tar"), []byte("archive"), 0644); err != nil {
+	if err := os.WriteFile(filepath.Join(tmpDir, manifestFileName), []byte("{}"), 0644); err != nil {
 		t.Fatal(err)
 	}
 
 	cfg := &DownloadsServerConfig{
-		TempDir:       tmpDir,
-		archivesReady: make(chan struct{}),
+		TempDir:      tmpDir,
+		indexesReady: make(chan struct{}),
 	}
 
 	handler := cfg.Handler()
 
-	t.Run("non-archive requests are served immediately", func(t *testing.T) {
+	t.Run("non-index requests are served immediately", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
 		rec := httptest.NewRecorder()
 		handler.ServeHTTP(rec, req)
@@ -210,10 +306,10 @@ func TestHandler_BlocksUntilArchivesReady(t *testing.T) {
 		}
 	})
 
-	t.Run("archive request blocks then succeeds", func(t *testing.T) {
+	t.Run("manifest request blocks then succeeds", func(t *testing.T) {
 		done := make(chan int, 1)
 		go func() {
-			req := httptest.NewRequest(http.MethodGet, "/amd64/linux/oc.tar", nil)
+			req := httptest.NewRequest(http.MethodGet, "/manifest.json", nil)
 			rec := httptest.NewRecorder()
 			handler.ServeHTTP(rec, req)
 			done <- rec.Code
@@ -221,19 +317,19 @@ func TestHandler_BlocksUntilArchivesReady(t *testing.T) {
 
 		select {
 		case <-done:
-			t.Fatal("archive request returned before archivesReady was closed")
+			t.Fatal("manifest request returned before indexesReady was closed")
 		case <-time.After(100 * time.Millisecond):
 		}
 
-		close(cfg.archivesReady)
+		close(cfg.indexesReady)
 
 		select {
 		case code := <-done:
 			if code != http.StatusOK {
-				t.Errorf("expected 200 for oc.tar after ready, got %d", code)
+				t.Errorf("expected 200 for manifest.json after ready, got %d", code)
 			}
 		case <-time.After(5 * time.Second):
-			t.Fatal("archive request did not complete after archivesReady was closed")
+			t.Fatal("manifest request did not complete after indexesReady was closed")
 		}
 	})
 }
@@ -341,32 +437,47 @@ func TestNewDownloadsServerConfig_CleansOldRandomTempDirs(t *testing.T) {
 	}
 }
 
-func TestHandler_ServesArchiveImmediatelyWhenReady(t *testing.T) {
-	tmpDir := t.TempDir()
-	if err := os.MkdirAll(filepath.Join(tmpDir, "amd64", "linux"), 0755); err != nil {
-		t.Fatal(err)
-	}
-	archiveContent := []byte("archive-data")
-	if err := os.WriteFile(filepath.Join(tmpDir, "amd64", "linux", "oc.tar"), archiveContent, 0644); err != nil {
-		t.Fatal(err)
+func TestHandler_GeneratesArchivesOnDemand(t *testing.T) {
+	srcDir := t.TempDir()
+	cfg := newTestConfig(t, []ArtifactSpec{{Arch: "amd64", OperatingSystem: "linux", Path: filepath.Join(srcDir, "oc")}})
+	if _, err := cfg.generateDirFileContents(); err != nil {
+		t.Fatalf("generateDirFileContents() error: %v", err)
 	}
+	// archives do not wait for the indexes
+	cfg.indexesReady = make(chan struct{})
+	handler := cfg.Handler()
+	archivePath := filepath.Join(cfg.TempDir, "amd64", "linux", "oc.tar.gz")
 
-	ready := make(chan struct{})
-	close(ready)
-	cfg := &DownloadsServerConfig{
-		TempDir:       tmpDir,
-		archivesReady: ready,
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/amd64/linux/oc.tar.gz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	streamed := rec.Body.Bytes()
+	if name, content := readArchiveEntry(t, streamed, ".tar.gz"); name != "oc" || content != "fake-binary" {
+		t.Errorf("unexpected archive entry %q with %q", name, content)
 	}
 
-	req := httptest.NewRequest(http.MethodGet, "/amd64/linux/oc.tar", nil)
-	rec := httptest.NewRecorder()
-	cfg.Handler().ServeHTTP(rec, req)
+	cached, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatalf("archive was not cached: %v", err)
+	}
+	if !bytes.Equal(cached, streamed) {
+		t.Error("cached archive differs from the streamed one")
+	}
 
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/amd64/linux/oc.tar.gz", nil))
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected 200, got %d", rec.Code)
 	}
-	body, _ := io.ReadAll(rec.Result().Body)
-	if string(body) != string(archiveContent) {
-		t.Errorf("expected body %q, got %q", archiveContent, body)
+	if !bytes.Equal(rec.Body.Bytes(), cached) {
+		t.Error("expected the cached archive to be served")
+	}
+	if rec.Header().Get("ETag") == "" {
+		t.Error("expected cached archives to be served with an ETag")
+	}
+	if _, err := os.Stat(filepath.Join(cfg.TempDir, "amd64", "linux", "oc.zip")); !os.IsNotExist(err) {
+		t.Error("only requested archives should be generated")
 	}
 }
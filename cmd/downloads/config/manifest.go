@@ -0,0 +1,290 @@
+package config
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const manifestFileName = "manifest.json"
+const checksumsFileName = "sha256sum.txt"
+const signatureExtension = ".sig"
+
+// archiveChecksumExtension is the extension of the checksum file written next to every
+// generated archive, in the format of sha256sum.txt.
+const archiveChecksumExtension = ".sha256"
+
+// Manifest is the machine-readable index of all artifacts served, published as manifest.json.
+type Manifest struct {
+	Artifacts []ManifestArtifact `json:"artifacts"`
+}
+
+// ManifestArtifact describes a binary and the archives it is available in.
+type ManifestArtifact struct {
+	Name            string `json:"name"`
+	Tool            string `json:"tool"`
+	Description     string `json:"description,omitempty"`
+	Arch            string `json:"arch"`
+	OperatingSystem string `json:"operatingSystem"`
+	Version         string `json:"version,omitempty"`
+	ManifestFile
+	Archives []ManifestFile `json:"archives"`
+}
+
+// ManifestFile describes a single file, all URLs are relative to the manifest. Archives
+// are generated on demand, so their size and digest are not known in advance. Instead,
+// they refer to a checksum file that is written when the archive is generated.
+type ManifestFile struct {
+	URL          string `json:"url"`
+	Format       string `json:"format,omitempty"`
+	Size         int64  `json:"size,omitempty"`
+	SHA256       string `json:"sha256,omitempty"`
+	ChecksumURL  string `json:"checksumURL,omitempty"`
+	SignatureURL string `json:"signatureURL,omitempty"`
+}
+
+// fileDigest holds the size and SHA-256 digest of a served file
+type fileDigest struct {
+	size   int64
+	sha256 []byte
+}
+
+// LoadSigningKey reads a PEM encoded RSA or ECDSA private key used to create detached
+// signatures. Signatures are created over the SHA-256 digest of each file, so they can
+// be verified with e.g. `openssl dgst -sha256 -verify key.pub -signature oc.sig oc`.
+func LoadSigningKey(path string) (crypto.Signer, error) {
+	keyPEM, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s does not contain PEM data", path)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T, only RSA and ECDSA keys are supported", key)
+	}
+}
+
+// digestFile computes the size and SHA-256 digest of the file, following symlinks.
+func digestFile(path string) (fileDigest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return fileDigest{}, err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, file)
+	if err != nil {
+		return fileDigest{}, err
+	}
+	return fileDigest{size: size, sha256: hasher.Sum(nil)}, nil
+}
+
+func (c *DownloadsServerConfig) recordDigest(relPath string, digest fileDigest) {
+	c.digestsLock.Lock()
+	defer c.digestsLock.Unlock()
+	if c.digests == nil {
+		c.digests = map[string]fileDigest{}
+	}
+	c.digests[filepath.ToSlash(relPath)] = digest
+}
+
+func (c *DownloadsServerConfig) digest(relPath string) (fileDigest, bool) {
+	c.digestsLock.RLock()
+	defer c.digestsLock.RUnlock()
+	digest, ok := c.digests[relPath]
+	return digest, ok
+}
+
+// etag returns the strong ETag of a file, which is its digest, or an empty string if it is not known (yet).
+func (c *DownloadsServerConfig) etag(relPath string) string {
+	digest, ok := c.digest(relPath)
+	if !ok {
+		return ""
+	}
+	return `"` + hex.EncodeToString(digest.sha256) + `"`
+}
+
+// sign writes a detached signature of the file with the given digest next to it.
+func (c *DownloadsServerConfig) sign(relPath string, digest []byte) error {
+	signature, err := c.Signer.Sign(rand.Reader, digest, crypto.SHA256)
+	if err != nil {
+		return fmt.Errorf("failed to sign %s: %w", relPath, err)
+	}
+	return os.WriteFile(filepath.Join(c.TempDir, relPath+signatureExtension), signature, 0644)
+}
+
+// writeArchiveChecksum writes the checksum file of a generated archive next to it.
+func (c *DownloadsServerConfig) writeArchiveChecksum(relPath string, digest fileDigest) error {
+	content := fmt.Sprintf("%s  %s\n", hex.EncodeToString(digest.sha256), filepath.Base(relPath))
+	return os.WriteFile(filepath.Join(c.TempDir, relPath+archiveChecksumExtension), []byte(content), 0644)
+}
+
+// writeFile writes a generated file, records its digest and signs it when a signing key is configured.
+func (c *DownloadsServerConfig) writeFile(relPath string, content []byte) error {
+	if err := os.WriteFile(filepath.Join(c.TempDir, relPath), content, 0644); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(content)
+	c.recordDigest(relPath, fileDigest{size: int64(len(content)), sha256: sum[:]})
+	if c.Signer != nil {
+		return c.sign(relPath, sum[:])
+	}
+	return nil
+}
+
+// writeChecksums writes a sha256sum.txt file into every directory containing artifacts and
+// one into the root directory covering all of them. The files can be checked with `sha256sum -c`.
+// Archives are covered by their own checksum files since they are generated on demand.
+func (c *DownloadsServerConfig) writeChecksums() error {
+	c.digestsLock.RLock()
+	relPaths := make([]string, 0, len(c.digests))
+	for relPath := range c.digests {
+		if _, ok := c.archiveSources[relPath]; ok {
+			continue
+		}
+		relPaths = append(relPaths, relPath)
+	}
+	c.digestsLock.RUnlock()
+	sort.Strings(relPaths)
+
+	checksums := map[string]*bytes.Buffer{}
+	add := func(dir, name string, digest fileDigest) {
+		if checksums[dir] == nil {
+			checksums[dir] = &bytes.Buffer{}
+		}
+		fmt.Fprintf(checksums[dir], "%s  %s\n", hex.EncodeToString(digest.sha256), name)
+	}
+	for _, relPath := range relPaths {
+		digest, _ := c.digest(relPath)
+		add(".", relPath, digest)
+		if dir := filepath.Dir(relPath); dir != "." {
+			add(dir, filepath.Base(relPath), digest)
+		}
+	}
+
+	for dir, content := range checksums {
+		if err := c.writeFile(filepath.Join(dir, checksumsFileName), content.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildManifest lists every artifact with the digest that was computed for it and the
+// archives it is available in. Artifacts that could not be read are left out.
+func (c *DownloadsServerConfig) buildManifest() *Manifest {
+	manifest := &Manifest{Artifacts: []ManifestArtifact{}}
+	for _, spec := range c.Spec {
+		relPath, relArchivePath := artifactRelPaths(spec)
+		binary, ok := c.manifestFile(relPath)
+		if !ok {
+			continue
+		}
+
+		artifact := ManifestArtifact{
+			Name:            displayName(spec.Arch, spec.OperatingSystem, filepath.Base(spec.Path)),
+			Tool:            spec.Tool,
+			Description:     spec.Description,
+			Arch:            spec.Arch,
+			OperatingSystem: spec.OperatingSystem,
+			Version:         spec.Version,
+			ManifestFile:    binary,
+			Archives:        []ManifestFile{},
+		}
+		for _, format := range archiveFormats {
+			archive := ManifestFile{
+				URL:    filepath.ToSlash(relArchivePath + format),
+				Format: strings.TrimPrefix(format, "."),
+			}
+			archive.ChecksumURL = archive.URL + archiveChecksumExtension
+			if c.Signer != nil {
+				archive.SignatureURL = archive.URL + signatureExtension
+			}
+			artifact.Archives = append(artifact.Archives, archive)
+		}
+		manifest.Artifacts = append(manifest.Artifacts, artifact)
+	}
+	return manifest
+}
+
+func (c *DownloadsServerConfig) manifestFile(relPath string) (ManifestFile, bool) {
+	relPath = filepath.ToSlash(relPath)
+	digest, ok := c.digest(relPath)
+	if !ok {
+		return ManifestFile{}, false
+	}
+	file := ManifestFile{
+		URL:    relPath,
+		Size:   digest.size,
+		SHA256: hex.EncodeToString(digest.sha256),
+	}
+	if c.Signer != nil {
+		file.SignatureURL = relPath + signatureExtension
+	}
+	return file, true
+}
+
+// publishIndexes writes the checksum files and manifest.json once all digests are known.
+func (c *DownloadsServerConfig) publishIndexes() error {
+	if c.Signer != nil {
+		c.digestsLock.RLock()
+		digests := make(map[string]fileDigest, len(c.digests))
+		for relPath, digest := range c.digests {
+			// archives are signed when they are generated
+			if _, ok := c.archiveSources[relPath]; !ok {
+				digests[relPath] = digest
+			}
+		}
+		c.digestsLock.RUnlock()
+
+		for relPath, digest := range digests {
+			if err := c.sign(relPath, digest.sha256); err != nil {
+				return err
+			}
+		}
+	}
+
+	manifest, err := json.MarshalIndent(c.buildManifest(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := c.writeChecksums(); err != nil {
+		return err
+	}
+	return c.writeFile(manifestFileName, manifest)
+}
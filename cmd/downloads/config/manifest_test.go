@@ -0,0 +1,235 @@
+package config
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupTestArtifacts(t *testing.T, specs []ArtifactSpec) *DownloadsServerConfig {
+	t.Helper()
+	cfg := newTestConfig(t, specs)
+	if _, err := cfg.generateDirFileContents(); err != nil {
+		t.Fatalf("generateDirFileContents() error: %v", err)
+	}
+	return cfg
+}
+
+func waitForIndexes(t *testing.T, cfg *DownloadsServerConfig) {
+	t.Helper()
+	select {
+	case <-cfg.indexesReady:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for indexes to be published")
+	}
+}
+
+func sha256Hex(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestManifest(t *testing.T) {
+	srcDir := t.TempDir()
+	specs := []ArtifactSpec{
+		{Arch: "amd64", OperatingSystem: "linux", Path: filepath.Join(srcDir, "oc"), Version: "4.20.0"},
+		{Arch: "amd64", OperatingSystem: "windows", Path: filepath.Join(srcDir, "oc.exe"), Version: "4.20.0"},
+	}
+	cfg := setupTestArtifacts(t, specs)
+	cfg.PublishIndexesInBackground()
+	waitForIndexes(t, cfg)
+
+	content, err := os.ReadFile(filepath.Join(cfg.TempDir, manifestFileName))
+	if err != nil {
+		t.Fatalf("manifest.json not created: %v", err)
+	}
+	manifest := Manifest{}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		t.Fatalf("failed to parse manifest.json: %v", err)
+	}
+	if len(manifest.Artifacts) != 2 {
+		t.Fatalf("expected 2 artifacts in manifest, got %d", len(manifest.Artifacts))
+	}
+
+	win := manifest.Artifacts[1]
+	if win.Tool != "oc" || win.Arch != "amd64" || win.OperatingSystem != "windows" || win.Version != "4.20.0" || win.Name != "amd64 windows" {
+		t.Errorf("unexpected artifact metadata: %+v", win)
+	}
+	if win.URL != "amd64/windows/4.20.0/oc.exe" {
+		t.Errorf("expected URL %q, got %q", "amd64/windows/4.20.0/oc.exe", win.URL)
+	}
+	if win.Size != int64(len("fake-binary")) {
+		t.Errorf("expected size %d, got %d", len("fake-binary"), win.Size)
+	}
+	if win.SignatureURL != "" {
+		t.Errorf("expected no signature without a signing key, got %q", win.SignatureURL)
+	}
+
+	if got := sha256Hex(t, filepath.Join(cfg.TempDir, win.URL)); got != win.SHA256 {
+		t.Errorf("digest of %s = %s, manifest says %s", win.URL, got, win.SHA256)
+	}
+
+	if len(win.Archives) != len(archiveFormats) {
+		t.Fatalf("expected %d archives, got %+v", len(archiveFormats), win.Archives)
+	}
+	gz := win.Archives[1]
+	if gz.URL != "amd64/windows/4.20.0/oc.tar.gz" || gz.Format != "tar.gz" {
+		t.Errorf("unexpected tar.gz archive: %+v", gz)
+	}
+	if gz.SHA256 != "" || gz.Size != 0 {
+		t.Errorf("archives are generated on demand and should not have a digest: %+v", gz)
+	}
+	if gz.ChecksumURL != "amd64/windows/4.20.0/oc.tar.gz.sha256" {
+		t.Errorf("expected the tar.gz archive to refer to its checksum file, got %q", gz.ChecksumURL)
+	}
+}
+
+func TestChecksums(t *testing.T) {
+	srcDir := t.TempDir()
+	specs := []ArtifactSpec{
+		{Arch: "amd64", OperatingSystem: "linux", Path: filepath.Join(srcDir, "oc")},
+		{Arch: "arm64", OperatingSystem: "mac", Path: filepath.Join(srcDir, "oc-mac")},
+	}
+	cfg := setupTestArtifacts(t, specs)
+	cfg.PublishIndexesInBackground()
+	waitForIndexes(t, cfg)
+
+	for dir, expectedFiles := range map[string][]string{
+		".":           {"amd64/linux/oc", "arm64/mac/oc-mac"},
+		"amd64/linux": {"oc"},
+	} {
+		file, err := os.Open(filepath.Join(cfg.TempDir, dir, checksumsFileName))
+		if err != nil {
+			t.Fatalf("sha256sum.txt not created in %s: %v", dir, err)
+		}
+		names := []string{}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			sum, name, ok := strings.Cut(scanner.Text(), "  ")
+			if !ok {
+				t.Errorf("invalid checksum line %q", scanner.Text())
+				continue
+			}
+			if name == manifestFileName {
+				// the manifest is written after the checksums
+				continue
+			}
+			names = append(names, name)
+			if got := sha256Hex(t, filepath.Join(cfg.TempDir, dir, name)); got != sum {
+				t.Errorf("checksum of %s in %s = %s, want %s", name, dir, sum, got)
+			}
+		}
+		file.Close()
+
+		if strings.Join(names, ",") != strings.Join(expectedFiles, ",") {
+			t.Errorf("checksums in %s cover %v, want %v", dir, names, expectedFiles)
+		}
+	}
+}
+
+func TestSignatures(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "signing.key")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	srcDir := t.TempDir()
+	cfg := setupTestArtifacts(t, []ArtifactSpec{{Arch: "amd64", OperatingSystem: "linux", Path: filepath.Join(srcDir, "oc")}})
+	cfg.Signer, err = LoadSigningKey(keyFile)
+	if err != nil {
+		t.Fatalf("LoadSigningKey() error: %v", err)
+	}
+	cfg.PublishIndexesInBackground()
+	waitForIndexes(t, cfg)
+
+	// archive signatures are created when the archive is generated
+	rec := httptest.NewRecorder()
+	cfg.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/amd64/linux/oc.tar.zst.sig", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for an archive signature, got %d", rec.Code)
+	}
+
+	for _, relPath := range []string{"amd64/linux/oc", "amd64/linux/oc.tar.zst", manifestFileName, checksumsFileName} {
+		content, err := os.ReadFile(filepath.Join(cfg.TempDir, relPath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature, err := os.ReadFile(filepath.Join(cfg.TempDir, relPath+signatureExtension))
+		if err != nil {
+			t.Errorf("signature of %s not created: %v", relPath, err)
+			continue
+		}
+		sum := sha256.Sum256(content)
+		if !ecdsa.VerifyASN1(&key.PublicKey, sum[:], signature) {
+			t.Errorf("signature of %s does not verify", relPath)
+		}
+	}
+
+	if _, err := LoadSigningKey(filepath.Join(t.TempDir(), "missing.key")); err == nil {
+		t.Error("expected an error for a missing signing key")
+	}
+}
+
+func TestHandler_ETagAndRange(t *testing.T) {
+	srcDir := t.TempDir()
+	cfg := setupTestArtifacts(t, []ArtifactSpec{{Arch: "amd64", OperatingSystem: "linux", Path: filepath.Join(srcDir, "oc")}})
+	cfg.PublishIndexesInBackground()
+	waitForIndexes(t, cfg)
+	handler := cfg.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/amd64/linux/oc", nil))
+	etag := rec.Header().Get("ETag")
+	if expected := `"` + sha256Hex(t, filepath.Join(cfg.TempDir, "amd64/linux/oc")) + `"`; etag != expected {
+		t.Errorf("expected ETag %s, got %s", expected, etag)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/amd64/linux/oc", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for a matching ETag, got %d", rec.Code)
+	}
+
+	// a range request for an archive that is not cached yet generates it first
+	req = httptest.NewRequest(http.MethodGet, "/amd64/linux/oc.tar", nil)
+	req.Header.Set("Range", "bytes=0-9")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPartialContent {
+		t.Errorf("expected 206 for a range request, got %d", rec.Code)
+	}
+	if rec.Body.Len() != 10 {
+		t.Errorf("expected 10 bytes, got %d", rec.Body.Len())
+	}
+	if rec.Header().Get("ETag") == "" {
+		t.Error("expected archives to be served with an ETag")
+	}
+}
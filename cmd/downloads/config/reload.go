@@ -0,0 +1,102 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/openshift/console/pkg/reload"
+	klog "k8s.io/klog/v2"
+)
+
+// staleArtifactsDirRemovalDelay gives requests that are still served from a replaced
+// artifacts directory time to complete before it is removed.
+var staleArtifactsDirRemovalDelay = time.Minute
+
+// ReloadingHandler serves the downloads server configuration and replaces it whenever
+// the artifacts configuration file changes.
+type ReloadingHandler struct {
+	current atomic.Pointer[servedConfig]
+}
+
+type servedConfig struct {
+	config  *DownloadsServerConfig
+	handler http.Handler
+}
+
+// NewReloadingHandler serves the given configuration until it is reloaded.
+func NewReloadingHandler(c *DownloadsServerConfig) *ReloadingHandler {
+	h := &ReloadingHandler{}
+	h.current.Store(&servedConfig{config: c, handler: c.Handler()})
+	return h
+}
+
+func (h *ReloadingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.current.Load().handler.ServeHTTP(w, r)
+}
+
+// Config returns the configuration that is currently served.
+func (h *ReloadingHandler) Config() *DownloadsServerConfig {
+	return h.current.Load().config
+}
+
+// Run reloads the configuration whenever the artifacts configuration file changes until
+// the context is cancelled. An invalid configuration is logged and the previous one is
+// served until the file is fixed.
+func (h *ReloadingHandler) Run(ctx context.Context) error {
+	return reload.WatchFiles(ctx, h.reload, h.Config().specsFilePath)
+}
+
+func (h *ReloadingHandler) reload() {
+	previous := h.Config()
+	next, err := previous.reloaded()
+	if err != nil {
+		klog.Errorf("Failed to reload artifacts configuration, keeping the previous one: %v", err)
+		return
+	}
+	next.PublishIndexesInBackground()
+	h.current.Store(&servedConfig{config: next, handler: next.Handler()})
+	klog.Infof("Reloaded artifacts configuration, serving %d artifacts from %s", len(next.Spec), next.TempDir)
+
+	time.AfterFunc(staleArtifactsDirRemovalDelay, func() {
+		if err := os.RemoveAll(previous.TempDir); err != nil {
+			klog.Errorf("Failed to remove artifacts directory %s: %v", previous.TempDir, err)
+		}
+	})
+}
+
+// reloaded loads the artifacts configuration file again and sets up a new artifacts
+// directory next to the current one for it. Archives are not carried over.
+func (c *DownloadsServerConfig) reloaded() (*DownloadsServerConfig, error) {
+	specs, err := loadArtifactsSpec(c.specsFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	tempDir, err := os.MkdirTemp(filepath.Dir(c.TempDir), filepath.Base(defaultArtifactsDir)+"-")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(tempDir, 0755); err != nil {
+		os.RemoveAll(tempDir)
+		return nil, err
+	}
+
+	next := &DownloadsServerConfig{
+		Port:             c.Port,
+		Spec:             specs,
+		TempDir:          tempDir,
+		TemplateHTML:     c.TemplateHTML,
+		Signer:           c.Signer,
+		ArchiveCacheSize: c.ArchiveCacheSize,
+		specsFilePath:    c.specsFilePath,
+	}
+	if err := next.setupArtifactsDirectory(); err != nil {
+		os.RemoveAll(tempDir)
+		return nil, err
+	}
+	return next, nil
+}
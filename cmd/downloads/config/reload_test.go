@@ -0,0 +1,86 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReloadingHandler(t *testing.T) {
+	oldDelay := staleArtifactsDirRemovalDelay
+	staleArtifactsDirRemovalDelay = 0
+	defer func() { staleArtifactsDirRemovalDelay = oldDelay }()
+
+	srcDir := t.TempDir()
+	for _, name := range []string{"oc", "kubectl"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte("fake-binary"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	specFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeSpecs := func(content string) {
+		if err := os.WriteFile(specFile, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeSpecs("defaultArtifactsConfig:\n  - arch: amd64\n    operatingSystem: linux\n    path: " + filepath.Join(srcDir, "oc") + "\n")
+
+	cfg := newTestConfig(t, nil)
+	cfg.specsFilePath = specFile
+	var err error
+	if cfg.Spec, err = loadArtifactsSpec(specFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.setupArtifactsDirectory(); err != nil {
+		t.Fatal(err)
+	}
+	cfg.PublishIndexesInBackground()
+	handler := NewReloadingHandler(cfg)
+
+	get := func(path string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+	if code := get("/amd64/linux/kubectl"); code != http.StatusNotFound {
+		t.Errorf("expected 404 before the reload, got %d", code)
+	}
+
+	writeSpecs("defaultArtifactsConfig:\n  - arch: amd64\n    operatingSystem: linux\n    path: " + filepath.Join(srcDir, "oc") +
+		"\n  - tool: kubectl\n    arch: amd64\n    operatingSystem: linux\n    path: " + filepath.Join(srcDir, "kubectl") + "\n")
+	handler.reload()
+
+	next := handler.Config()
+	if next == cfg || len(next.Spec) != 2 || !strings.HasPrefix(filepath.Base(next.TempDir), "artifacts-") {
+		t.Fatalf("expected a new configuration in a new directory, got %+v", next)
+	}
+	if code := get("/amd64/linux/kubectl"); code != http.StatusOK {
+		t.Errorf("expected 200 after the reload, got %d", code)
+	}
+	if code := get("/amd64/linux/oc.tar.gz"); code != http.StatusOK {
+		t.Errorf("expected archives to be served after the reload, got %d", code)
+	}
+
+	// the previous directory is removed once requests had time to complete
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(cfg.TempDir); os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the previous artifacts directory was not removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// invalid configurations are not applied
+	writeSpecs("defaultArtifactsConfig: []\n")
+	handler.reload()
+	if handler.Config() != next {
+		t.Error("expected the previous configuration to be kept")
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 
@@ -18,6 +19,8 @@ func main() {
 
 	fPort := fs.Int("port", 8080, "Port number used to start the downloads server.")
 	fPathToArtifactsFileConfig := fs.String("config-path", "/opt/downloads/defaultArtifactsConfig.yaml", "Path to the configuration file of available 'oc' artifacts.")
+	fArchiveCacheSize := fs.Int64("archive-cache-size", 2<<30, "Maximum disk space in bytes used for archives generated on demand. The least recently used archives are evicted when it is exceeded, 0 means unlimited.")
+	fSigningKey := fs.String("signing-key", "", "Path to a PEM encoded RSA or ECDSA private key. When set, detached signatures are published for all artifacts.")
 
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		klog.Fatalf("Failed to parse flags: %v", err)
@@ -28,7 +31,20 @@ func main() {
 		klog.Fatalf("Failed to configure downloads server config: %v", err)
 		os.Exit(1)
 	}
-	downloadsServerConfig.CreateArchivesInBackground()
+	downloadsServerConfig.ArchiveCacheSize = *fArchiveCacheSize
+	if *fSigningKey != "" {
+		downloadsServerConfig.Signer, err = config.LoadSigningKey(*fSigningKey)
+		if err != nil {
+			klog.Fatalf("Failed to load signing key: %v", err)
+			os.Exit(1)
+		}
+	}
+	downloadsServerConfig.PublishIndexesInBackground()
+
+	handler := config.NewReloadingHandler(downloadsServerConfig)
+	if err := handler.Run(context.Background()); err != nil {
+		klog.Errorf("Failed to watch %s, changes will not be reloaded: %v", *fPathToArtifactsFileConfig, err)
+	}
 
 	// Listen for incoming connections
 	klog.Infof("Server started. Listening on http://0.0.0.0:%s", downloadsServerConfig.Port)
@@ -36,7 +52,7 @@ func main() {
 	// Serve the files and listen for incoming connections
 	downlsrv := &http.Server{
 		Addr:    fmt.Sprintf("0.0.0.0:%s", downloadsServerConfig.Port),
-		Handler: downloadsServerConfig.Handler(),
+		Handler: handler,
 	}
 	klog.Fatal(downlsrv.ListenAndServe())
 
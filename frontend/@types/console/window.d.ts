@@ -5,6 +5,8 @@ declare interface Window {
     alertManagerBaseURL: string;
     alertmanagerUserWorkloadBaseURL: string;
     authDisabled: boolean;
+    /** Whether each optional backend, like thanos or gitops, was reachable when the page loaded */
+    backendsAvailable?: { [backend: string]: boolean };
     basePath: string;
     branding: string;
     consoleVersion: string;
@@ -25,6 +27,8 @@ declare interface Window {
     prometheusTenancyBaseURL: string;
     quickStarts: string;
     releaseVersion: string;
+    /** Changes with any other flag, see /api/console/server-flags */
+    serverFlagsVersion?: string;
     inactivityTimeout: number;
     statuspageID: string;
     GOARCH: string;
@@ -1,8 +1,14 @@
 import { compact, uniq } from 'lodash';
 import { getURLSearchParams } from '@console/internal/components/utils/link';
 
+// Plugins whose service the bridge found to be unavailable are not loaded.
+const isPluginServiceAvailable = (pluginName: string) =>
+  window.SERVER_FLAGS.backendsAvailable?.[`plugins/${pluginName}`] !== false;
+
 const getEnabledDynamicPluginNames = () => {
-  const allPluginNames = window.SERVER_FLAGS.consolePlugins;
+  const allPluginNames = (window.SERVER_FLAGS.consolePlugins ?? []).filter(
+    isPluginServiceAvailable,
+  );
   const disabledPlugins = getURLSearchParams()['disable-plugins'];
 
   if (disabledPlugins === '') {
@@ -92,7 +92,7 @@ export const validateStatus = async (
   if (response.status === 403) {
     return response.json().then((json) => {
       throw new HttpError(
-        unescapeGoUnicode(json.message || 'Access denied due to cluster policy.'),
+        unescapeGoUnicode(json.message || json.error || 'Access denied due to cluster policy.'),
         response.status,
         response,
         json,
@@ -35,7 +35,9 @@ export const defaults = _.mapValues(FLAGS, (flag) => {
       return !window.SERVER_FLAGS.authDisabled;
     case FLAGS.MONITORING:
       return (
-        !!window.SERVER_FLAGS.prometheusBaseURL && !!window.SERVER_FLAGS.prometheusTenancyBaseURL
+        !!window.SERVER_FLAGS.prometheusBaseURL &&
+        !!window.SERVER_FLAGS.prometheusTenancyBaseURL &&
+        window.SERVER_FLAGS.backendsAvailable?.thanos !== false
       );
     case FLAGS.DEVCONSOLE_PROXY:
       return true;
@@ -0,0 +1,76 @@
+'use strict';
+
+/**
+ * Writes the .br, .zst and .gz siblings of the compressible files of the build output, which
+ * the bridge serves instead of compressing the files on every request.
+ *
+ * Zstandard siblings need a Node.js version with `zlib.zstdCompressSync`; without it the
+ * bridge compresses with zstd on the fly.
+ *
+ * Usage: node ./scripts/compress-assets.js [dir], dir defaults to public/dist.
+ */
+
+const fs = require('fs');
+const path = require('path');
+const zlib = require('zlib');
+
+const COMPRESSIBLE = /\.(css|html|js|json|map|mjs|svg|ttf|txt|wasm|xml)$/;
+// the bridge does not compress smaller files either
+const MIN_SIZE = 1024;
+
+const encoders = [
+  {
+    extension: '.br',
+    compress: (content) =>
+      zlib.brotliCompressSync(content, {
+        params: {
+          [zlib.constants.BROTLI_PARAM_QUALITY]: zlib.constants.BROTLI_MAX_QUALITY,
+          [zlib.constants.BROTLI_PARAM_SIZE_HINT]: content.length,
+        },
+      }),
+  },
+  zlib.zstdCompressSync && {
+    extension: '.zst',
+    compress: (content) =>
+      zlib.zstdCompressSync(content, {
+        params: { [zlib.constants.ZSTD_c_compressionLevel]: 19 },
+      }),
+  },
+  {
+    extension: '.gz',
+    compress: (content) => zlib.gzipSync(content, { level: zlib.constants.Z_BEST_COMPRESSION }),
+  },
+].filter(Boolean);
+
+/**
+ * @param {string} dir A directory of the build output.
+ * @returns {string[]} The compressible files of the directory and its subdirectories.
+ */
+const compressibleFiles = (dir) =>
+  fs.readdirSync(dir, { withFileTypes: true }).flatMap((entry) => {
+    const file = path.join(dir, entry.name);
+    if (entry.isDirectory()) {
+      return compressibleFiles(file);
+    }
+    return entry.isFile() && COMPRESSIBLE.test(entry.name) ? [file] : [];
+  });
+
+const dir = path.resolve(__dirname, '..', process.argv[2] || 'public/dist');
+let written = 0;
+compressibleFiles(dir).forEach((file) => {
+  const content = fs.readFileSync(file);
+  if (content.length < MIN_SIZE) {
+    return;
+  }
+  encoders.forEach(({ extension, compress }) => {
+    const compressed = compress(content);
+    // the bridge serves the file itself if a sibling is missing
+    if (compressed.length < content.length) {
+      fs.writeFileSync(file + extension, compressed);
+      written++;
+    }
+  });
+});
+
+// eslint-disable-next-line no-console
+console.log(`Wrote ${written} precompressed files to ${dir}`);
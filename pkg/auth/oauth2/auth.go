@@ -26,16 +26,17 @@ import (
 )
 
 const (
-	stateCookieName   = "login-state"
-	errorOAuth        = "oauth_error"
-	stateLength       = 32 // hex-encoded 16 random bytes
-	errorLoginState   = "login_state_error"
-	errorCookie       = "cookie_error"
-	errorInternal     = "internal_error"
-	errorMissingCode  = "missing_code"
-	errorMissingState = "missing_state"
-	errorInvalidCode  = "invalid_code"
-	errorInvalidState = "invalid_state"
+	stateCookieName    = "login-state"
+	providerCookieName = "login-provider"
+	errorOAuth         = "oauth_error"
+	stateLength        = 32 // hex-encoded 16 random bytes
+	errorLoginState    = "login_state_error"
+	errorCookie        = "cookie_error"
+	errorInternal      = "internal_error"
+	errorMissingCode   = "missing_code"
+	errorMissingState  = "missing_state"
+	errorInvalidCode   = "invalid_code"
+	errorInvalidState  = "invalid_state"
 )
 
 var (
@@ -70,6 +71,11 @@ type OAuth2Authenticator struct {
 	// allowedRedirectHosts maps host (or host:port) strings that are
 	// allowed for dynamic OAuth redirect_uri selection.
 	allowedRedirectHosts map[string]bool
+
+	// providerName identifies the authenticator among several identity
+	// providers. It is remembered during the login flow so that the callback
+	// is handled by the provider that started it.
+	providerName string
 }
 
 // loginMethod is used to handle OAuth2 responses and associate bearer tokens
@@ -204,17 +210,7 @@ func NewOAuth2Authenticator(ctx context.Context, config *Config) (*OAuth2Authent
 	}
 
 	a := newUnstartedAuthenticator(c)
-
-	authConfig := &oidcConfig{
-		getClient:              a.clientFunc,
-		issuerURL:              c.IssuerURL,
-		logoutRedirectOverride: c.LogoutRedirectOverride,
-		clientID:               c.ClientID,
-		consoleBaseAddress:     c.ConsoleBaseAddress,
-		cookiePath:             c.CookiePath,
-		secureCookies:          c.SecureCookies,
-		constructOAuth2Config:  a.oauth2ConfigConstructor,
-	}
+	authConfig := c.oidcConfig(a)
 
 	var tokenHandler loginMethod
 	switch c.AuthSource {
@@ -252,6 +248,19 @@ func NewOAuth2Authenticator(ctx context.Context, config *Config) (*OAuth2Authent
 	return a, nil
 }
 
+func (c *completedConfig) oidcConfig(a *OAuth2Authenticator) *oidcConfig {
+	return &oidcConfig{
+		getClient:              a.clientFunc,
+		issuerURL:              c.IssuerURL,
+		logoutRedirectOverride: c.LogoutRedirectOverride,
+		clientID:               c.ClientID,
+		consoleBaseAddress:     c.ConsoleBaseAddress,
+		cookiePath:             c.CookiePath,
+		secureCookies:          c.SecureCookies,
+		constructOAuth2Config:  a.oauth2ConfigConstructor,
+	}
+}
+
 func (a *OAuth2Authenticator) oauth2ConfigConstructor(endpointConfig oauth2.Endpoint) *oauth2.Config {
 	// rebuild non-pointer struct each time to prevent any mutation
 	scopesCopy := make([]string, len(a.scopes))
@@ -325,6 +334,12 @@ func (a *OAuth2Authenticator) LoginFunc(w http.ResponseWriter, r *http.Request)
 		MaxAge:   300,
 	}
 	http.SetCookie(w, &cookie)
+	if a.providerName != "" {
+		providerCookie := cookie
+		providerCookie.Name = providerCookieName + "-" + state[:8]
+		providerCookie.Value = a.providerName
+		http.SetCookie(w, &providerCookie)
+	}
 	http.Redirect(w, r, a.oauth2ConfigForHost(r.Host).AuthCodeURL(state), http.StatusSeeOther)
 }
 
@@ -0,0 +1,240 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/console/pkg/auth"
+	"github.com/openshift/console/pkg/auth/sessions"
+)
+
+const providerQueryParam = "provider"
+
+// ProviderConfig describes one of several OIDC identity providers users can
+// choose from on login. Fields left empty fall back to the shared Config.
+type ProviderConfig struct {
+	// Name identifies the provider in the login hint, e.g. /auth/login?provider=<name>.
+	Name        string
+	DisplayName string
+
+	IssuerURL              string
+	IssuerCA               string
+	ClientID               string
+	ClientSecret           string
+	Scope                  []string
+	LogoutRedirectOverride string
+}
+
+type identityProvider struct {
+	*OAuth2Authenticator
+
+	name        string
+	displayName string
+	issuerURL   string
+}
+
+// MultiProviderAuthenticator lets users log in with any of several OIDC issuers.
+// All providers share one session store which remembers the issuer of every
+// session, so that refreshing and logging out go to the provider the user
+// logged in with.
+type MultiProviderAuthenticator struct {
+	providers  []*identityProvider
+	byName     map[string]*identityProvider
+	byIssuer   map[string]*identityProvider
+	sessions   *sessions.CombinedSessionStore
+	chooserTpl *template.Template
+}
+
+type chooserEntry struct {
+	Name        string
+	DisplayName string
+}
+
+// NewMultiProviderAuthenticator initializes an authenticator for each of the providers. The
+// first provider is the default one, it handles requests that cannot be attributed to any issuer.
+func NewMultiProviderAuthenticator(ctx context.Context, config *Config, providers []ProviderConfig) (*MultiProviderAuthenticator, error) {
+	if config.AuthSource != AuthSourceOIDC {
+		return nil, fmt.Errorf("multiple identity providers are only supported for OIDC")
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("at least one identity provider is required")
+	}
+
+	cookiePath := config.CookiePath
+	if cookiePath == "" {
+		cookiePath = "/"
+	}
+	sessionStore := sessions.NewSessionStore(
+		config.CookieAuthenticationKey,
+		config.CookieEncryptionKey,
+		config.SecureCookies,
+		cookiePath,
+	)
+
+	m := &MultiProviderAuthenticator{
+		byName:     make(map[string]*identityProvider, len(providers)),
+		byIssuer:   make(map[string]*identityProvider, len(providers)),
+		sessions:   sessionStore,
+		chooserTpl: template.Must(template.New("chooser").Parse(providerChooserTemplate)),
+	}
+
+	for _, p := range providers {
+		if p.Name == "" || p.IssuerURL == "" {
+			return nil, fmt.Errorf("identity providers must have a name and an issuer URL")
+		}
+		if _, ok := m.byName[p.Name]; ok {
+			return nil, fmt.Errorf("duplicate identity provider name %q", p.Name)
+		}
+		if _, ok := m.byIssuer[p.IssuerURL]; ok {
+			return nil, fmt.Errorf("duplicate identity provider issuer %q", p.IssuerURL)
+		}
+
+		providerConfig := *config
+		providerConfig.IssuerURL = p.IssuerURL
+		providerConfig.IssuerCA = p.IssuerCA
+		providerConfig.ClientID = p.ClientID
+		providerConfig.ClientSecret = p.ClientSecret
+		if len(p.Scope) > 0 {
+			providerConfig.Scope = p.Scope
+		}
+		if p.LogoutRedirectOverride != "" {
+			providerConfig.LogoutRedirectOverride = p.LogoutRedirectOverride
+		}
+
+		c, err := providerConfig.Complete()
+		if err != nil {
+			return nil, fmt.Errorf("identity provider %q: %w", p.Name, err)
+		}
+
+		a := newUnstartedAuthenticator(c)
+		a.providerName = p.Name
+		a.loginMethod, err = newOIDCAuth(ctx, sessionStore, c.oidcConfig(a), a.metrics)
+		if err != nil {
+			return nil, fmt.Errorf("identity provider %q: %w", p.Name, err)
+		}
+
+		displayName := p.DisplayName
+		if displayName == "" {
+			displayName = p.Name
+		}
+		provider := &identityProvider{
+			OAuth2Authenticator: a,
+			name:                p.Name,
+			displayName:         displayName,
+			issuerURL:           p.IssuerURL,
+		}
+		m.providers = append(m.providers, provider)
+		m.byName[p.Name] = provider
+		m.byIssuer[p.IssuerURL] = provider
+	}
+
+	return m, nil
+}
+
+// sessionProvider returns the provider that issued the session of the request.
+func (m *MultiProviderAuthenticator) sessionProvider(r *http.Request) *identityProvider {
+	if provider, ok := m.byIssuer[m.sessions.GetCookieIssuer(r)]; ok {
+		return provider
+	}
+	return m.providers[0]
+}
+
+func (m *MultiProviderAuthenticator) Authenticate(w http.ResponseWriter, r *http.Request) (*auth.User, error) {
+	return m.sessionProvider(r).Authenticate(w, r)
+}
+
+// LoginFunc starts the login flow with the provider named by the "provider" query
+// parameter. Without the hint, users are asked to choose a provider.
+func (m *MultiProviderAuthenticator) LoginFunc(w http.ResponseWriter, r *http.Request) {
+	hint := r.URL.Query().Get(providerQueryParam)
+	if hint == "" && len(m.providers) == 1 {
+		hint = m.providers[0].name
+	}
+
+	if hint == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		entries := make([]chooserEntry, 0, len(m.providers))
+		for _, provider := range m.providers {
+			entries = append(entries, chooserEntry{Name: provider.name, DisplayName: provider.displayName})
+		}
+		if err := m.chooserTpl.Execute(w, entries); err != nil {
+			klog.Errorf("failed to render identity provider chooser: %v", err)
+		}
+		return
+	}
+
+	provider, ok := m.byName[hint]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown identity provider %q", hint), http.StatusBadRequest)
+		return
+	}
+	provider.LoginFunc(w, r)
+}
+
+// LogoutFunc logs the user out of the provider that issued the session.
+func (m *MultiProviderAuthenticator) LogoutFunc(w http.ResponseWriter, r *http.Request) {
+	m.sessionProvider(r).LogoutFunc(w, r)
+}
+
+// CallbackFunc dispatches the callback to the provider that started the login flow.
+func (m *MultiProviderAuthenticator) CallbackFunc(fn func(loginInfo sessions.LoginJSON, successURL string, w http.ResponseWriter)) func(w http.ResponseWriter, r *http.Request) {
+	callbacks := make(map[string]http.HandlerFunc, len(m.providers))
+	for _, provider := range m.providers {
+		callbacks[provider.name] = provider.CallbackFunc(fn)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		callback := callbacks[m.providers[0].name]
+
+		if state := r.URL.Query().Get("state"); len(state) == stateLength {
+			cookieName := providerCookieName + "-" + state[:8]
+			if cookie, err := r.Cookie(cookieName); err == nil {
+				if providerCallback, ok := callbacks[cookie.Value]; ok {
+					callback = providerCallback
+				} else {
+					klog.Warningf("login flow was started by an unknown identity provider %q", cookie.Value)
+				}
+				http.SetCookie(w, &http.Cookie{Name: cookieName, Path: "/auth", MaxAge: -1})
+			}
+		}
+
+		callback(w, r)
+	}
+}
+
+func (m *MultiProviderAuthenticator) GetOCLoginCommand() string {
+	return m.providers[0].GetOCLoginCommand()
+}
+
+// LogoutRedirectURL returns the logout URL of the default provider. Logging out
+// returns the redirect URL of the provider that issued the session.
+func (m *MultiProviderAuthenticator) LogoutRedirectURL() string {
+	return m.providers[0].LogoutRedirectURL()
+}
+
+func (m *MultiProviderAuthenticator) GetSpecialURLs() auth.SpecialAuthURLs {
+	return auth.SpecialAuthURLs{}
+}
+
+func (m *MultiProviderAuthenticator) IsStatic() bool { return false }
+
+const providerChooserTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Log in</title>
+</head>
+<body>
+<h1>Log in with</h1>
+<ul>
+{{- range . }}
+<li><a href="?provider={{ .Name | urlquery }}">{{ .DisplayName }}</a></li>
+{{- end }}
+</ul>
+</body>
+</html>
+`
@@ -0,0 +1,158 @@
+package oauth2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"k8s.io/client-go/rest"
+
+	"github.com/openshift/console/pkg/auth"
+)
+
+func newTestMultiProviderAuthenticator(t *testing.T, providers ...ProviderConfig) *MultiProviderAuthenticator {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	m, err := NewMultiProviderAuthenticator(ctx, &Config{
+		AuthSource:              AuthSourceOIDC,
+		RedirectURL:             "https://console.example.com/auth/callback",
+		ErrorURL:                "/auth/error",
+		SuccessURL:              "/",
+		CookiePath:              "/",
+		SecureCookies:           true,
+		CookieEncryptionKey:     []byte(randomString(32)),
+		CookieAuthenticationKey: []byte(randomString(64)),
+		K8sConfig:               &rest.Config{},
+		Metrics:                 auth.NewMetrics(defaultRestClientConfig),
+	}, providers)
+	require.NoError(t, err)
+	return m
+}
+
+func TestMultiProviderAuthenticator_LoginFunc(t *testing.T) {
+	_, ssoURL, closeSSO := startMockProvider(t)
+	defer closeSSO()
+	_, partnerURL, closePartner := startMockProvider(t)
+	defer closePartner()
+
+	m := newTestMultiProviderAuthenticator(t,
+		ProviderConfig{Name: "sso", DisplayName: "Corporate SSO", IssuerURL: ssoURL.String(), ClientID: testClientID, ClientSecret: testClientSecret},
+		ProviderConfig{Name: "partner", IssuerURL: partnerURL.String(), ClientID: testClientID, ClientSecret: testClientSecret},
+	)
+
+	t.Run("provider chooser", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		m.LoginFunc(rr, httptest.NewRequest(http.MethodGet, "/auth/login", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		body := rr.Body.String()
+		require.Contains(t, body, `href="?provider=sso"`)
+		require.Contains(t, body, "Corporate SSO")
+		require.Contains(t, body, `href="?provider=partner"`)
+	})
+
+	t.Run("provider hint", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		m.LoginFunc(rr, httptest.NewRequest(http.MethodGet, "/auth/login?provider=partner", nil))
+
+		require.Equal(t, http.StatusSeeOther, rr.Code)
+		location, err := url.Parse(rr.Header().Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, partnerURL.Host, location.Host)
+
+		var providerCookie *http.Cookie
+		for _, c := range rr.Result().Cookies() {
+			if strings.HasPrefix(c.Name, providerCookieName+"-") {
+				providerCookie = c
+			}
+		}
+		require.NotNil(t, providerCookie, "the provider that started the login flow must be remembered")
+		require.Equal(t, providerCookieName+"-"+location.Query().Get("state")[:8], providerCookie.Name)
+		require.Equal(t, "partner", providerCookie.Value)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		m.LoginFunc(rr, httptest.NewRequest(http.MethodGet, "/auth/login?provider=unknown", nil))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestMultiProviderAuthenticator_Authenticate(t *testing.T) {
+	_, ssoURL, closeSSO := startMockProvider(t)
+	defer closeSSO()
+	partner, partnerURL, closePartner := startMockProvider(t)
+	defer closePartner()
+
+	m := newTestMultiProviderAuthenticator(t,
+		ProviderConfig{Name: "sso", IssuerURL: ssoURL.String(), ClientID: testClientID, ClientSecret: testClientSecret},
+		ProviderConfig{Name: "partner", IssuerURL: partnerURL.String(), ClientID: testClientID, ClientSecret: testClientSecret},
+	)
+
+	token := addIDToken(
+		&oauth2.Token{RefreshToken: testValidRefreshToken},
+		partner.signPayload(`{"sub":"partner-user","exp":`+strconv.FormatInt(time.Now().Add(5*time.Minute).Unix(), 10)+`}`),
+	)
+	loginWriter := httptest.NewRecorder()
+	_, err := m.byName["partner"].login(loginWriter, httptest.NewRequest(http.MethodGet, "/auth/callback", nil), token)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/kubernetes/api", nil)
+	for _, c := range loginWriter.Result().Cookies() {
+		req.AddCookie(c)
+	}
+
+	user, err := m.Authenticate(httptest.NewRecorder(), req)
+	require.NoError(t, err)
+	require.Equal(t, "partner-user", user.ID)
+
+	_, err = m.byName["sso"].Authenticate(httptest.NewRecorder(), req)
+	require.Error(t, err, "sessions must only be accepted by the provider that issued them")
+}
+
+func TestNewMultiProviderAuthenticatorValidation(t *testing.T) {
+	_, issuerURL, closePort := startMockProvider(t)
+	defer closePort()
+
+	tests := []struct {
+		name      string
+		providers []ProviderConfig
+	}{
+		{
+			name: "no providers",
+		},
+		{
+			name:      "missing name",
+			providers: []ProviderConfig{{IssuerURL: issuerURL.String(), ClientID: testClientID}},
+		},
+		{
+			name: "duplicate name",
+			providers: []ProviderConfig{
+				{Name: "sso", IssuerURL: issuerURL.String(), ClientID: testClientID},
+				{Name: "sso", IssuerURL: "https://other.example.com", ClientID: testClientID},
+			},
+		},
+		{
+			name: "duplicate issuer",
+			providers: []ProviderConfig{
+				{Name: "sso", IssuerURL: issuerURL.String(), ClientID: testClientID},
+				{Name: "partner", IssuerURL: issuerURL.String(), ClientID: testClientID},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMultiProviderAuthenticator(context.Background(), &Config{AuthSource: AuthSourceOIDC}, tt.providers)
+			require.Error(t, err)
+		})
+	}
+}
@@ -192,6 +192,10 @@ func (o *oidcAuth) getLoginState(w http.ResponseWriter, r *http.Request) (*sessi
 
 		return nil, fmt.Errorf("a session was not found on server or is expired")
 	}
+
+	if ls.Issuer() != "" && ls.Issuer() != o.issuerURL {
+		return nil, fmt.Errorf("the session was issued by %q and not by %q", ls.Issuer(), o.issuerURL)
+	}
 	return ls, nil
 }
 
@@ -202,9 +206,10 @@ func (o *oidcAuth) Authenticate(w http.ResponseWriter, r *http.Request) (*auth.U
 	}
 
 	return &auth.User{
-		ID:       ls.UserID(),
-		Username: ls.Username(),
-		Token:    ls.AccessToken(),
+		ID:        ls.UserID(),
+		Username:  ls.Username(),
+		Token:     ls.AccessToken(),
+		ExpiresAt: ls.ExpiresAt(),
 	}, nil
 }
 
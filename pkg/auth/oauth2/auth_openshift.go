@@ -276,7 +276,8 @@ func (o *openShiftAuth) Authenticate(w http.ResponseWriter, r *http.Request) (*a
 	}
 
 	return &auth.User{
-		Token: ls.AccessToken(),
+		Token:     ls.AccessToken(),
+		ExpiresAt: ls.ExpiresAt(),
 	}, nil
 }
 
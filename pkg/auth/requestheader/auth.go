@@ -0,0 +1,193 @@
+package requestheader
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"golang.org/x/oauth2"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/console/pkg/auth"
+	"github.com/openshift/console/pkg/auth/sessions"
+)
+
+var (
+	DefaultUsernameHeaders = []string{"X-Remote-User"}
+	DefaultGroupHeaders    = []string{"X-Remote-Group"}
+)
+
+type Config struct {
+	// ClientCAFile is the PEM bundle used to verify the client certificate
+	// presented by the authenticating proxy.
+	ClientCAFile string
+	// AllowedNames restricts the common names of accepted client certificates.
+	// When empty, any certificate signed by the client CA is accepted.
+	AllowedNames []string
+	// UsernameHeaders are checked in order, the first non-empty value is the username.
+	UsernameHeaders []string
+	// GroupHeaders are all read, every value becomes one group.
+	GroupHeaders []string
+
+	// TokenSource provides the console service account token used to
+	// impersonate authenticated users against the API server.
+	TokenSource oauth2.TokenSource
+
+	SuccessURL             string
+	LogoutRedirectOverride string
+}
+
+// RequestHeaderAuthenticator trusts user identities passed in request headers
+// by an authenticating front proxy. The headers are only honored on connections
+// that present a client certificate signed by the configured CA.
+type RequestHeaderAuthenticator struct {
+	verifyOptions   x509.VerifyOptions
+	allowedNames    sets.Set[string]
+	usernameHeaders []string
+	groupHeaders    []string
+	tokenSource     oauth2.TokenSource
+
+	successURL             string
+	logoutRedirectOverride string
+}
+
+func NewRequestHeaderAuthenticator(c *Config) (*RequestHeaderAuthenticator, error) {
+	if c.TokenSource == nil {
+		return nil, fmt.Errorf("a service account token source is required for request header authentication")
+	}
+
+	caPEM, err := os.ReadFile(c.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request header client CA file: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("file %s contained no CA data", c.ClientCAFile)
+	}
+
+	usernameHeaders := c.UsernameHeaders
+	if len(usernameHeaders) == 0 {
+		usernameHeaders = DefaultUsernameHeaders
+	}
+	groupHeaders := c.GroupHeaders
+	if len(groupHeaders) == 0 {
+		groupHeaders = DefaultGroupHeaders
+	}
+
+	successURL := "/"
+	if c.SuccessURL != "" {
+		successURL = c.SuccessURL
+	}
+
+	return &RequestHeaderAuthenticator{
+		verifyOptions: x509.VerifyOptions{
+			Roots:     roots,
+			KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		},
+		allowedNames:           sets.New(c.AllowedNames...),
+		usernameHeaders:        usernameHeaders,
+		groupHeaders:           groupHeaders,
+		tokenSource:            c.TokenSource,
+		successURL:             successURL,
+		logoutRedirectOverride: c.LogoutRedirectOverride,
+	}, nil
+}
+
+// verifyClientCert checks that the request came through a connection that
+// presented a certificate of the authenticating proxy.
+func (a *RequestHeaderAuthenticator) verifyClientCert(req *http.Request) error {
+	if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+		return fmt.Errorf("request did not present a client certificate")
+	}
+
+	opts := a.verifyOptions
+	opts.Intermediates = x509.NewCertPool()
+	for _, intermediate := range req.TLS.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(intermediate)
+	}
+
+	leaf := req.TLS.PeerCertificates[0]
+	if _, err := leaf.Verify(opts); err != nil {
+		return fmt.Errorf("failed to verify client certificate: %w", err)
+	}
+
+	if a.allowedNames.Len() > 0 && !a.allowedNames.Has(leaf.Subject.CommonName) {
+		return fmt.Errorf("client certificate common name %q is not allowed", leaf.Subject.CommonName)
+	}
+
+	return nil
+}
+
+func (a *RequestHeaderAuthenticator) Authenticate(w http.ResponseWriter, req *http.Request) (*auth.User, error) {
+	if err := a.verifyClientCert(req); err != nil {
+		return nil, err
+	}
+
+	var username string
+	for _, header := range a.usernameHeaders {
+		if username = req.Header.Get(header); username != "" {
+			break
+		}
+	}
+	if username == "" {
+		return nil, fmt.Errorf("no username found in request headers %v", a.usernameHeaders)
+	}
+
+	var groups []string
+	for _, header := range a.groupHeaders {
+		groups = append(groups, req.Header.Values(header)...)
+	}
+
+	// The identity headers were consumed here, don't leak them to proxied backends.
+	for _, header := range append(a.usernameHeaders, a.groupHeaders...) {
+		req.Header.Del(header)
+	}
+
+	token, err := a.tokenSource.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service account token: %w", err)
+	}
+
+	return &auth.User{
+		ID:          username,
+		Username:    username,
+		Token:       token.AccessToken,
+		Groups:      groups,
+		Impersonate: true,
+	}, nil
+}
+
+// LoginFunc redirects authenticated users to the console. Logging in is handled
+// by the front proxy, so there is nothing to do for anyone else.
+func (a *RequestHeaderAuthenticator) LoginFunc(w http.ResponseWriter, req *http.Request) {
+	if _, err := a.Authenticate(w, req); err != nil {
+		klog.V(4).Infof("request header authentication failed: %v", err)
+		http.Error(w, "authentication is handled by the front proxy", http.StatusUnauthorized)
+		return
+	}
+	http.Redirect(w, req, a.successURL, http.StatusSeeOther)
+}
+
+func (a *RequestHeaderAuthenticator) LogoutFunc(w http.ResponseWriter, req *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *RequestHeaderAuthenticator) CallbackFunc(fn func(loginInfo sessions.LoginJSON, successURL string, w http.ResponseWriter)) func(w http.ResponseWriter, req *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) { w.WriteHeader(http.StatusNoContent) }
+}
+
+func (a *RequestHeaderAuthenticator) GetOCLoginCommand() string {
+	return ""
+}
+
+func (a *RequestHeaderAuthenticator) LogoutRedirectURL() string {
+	return a.logoutRedirectOverride
+}
+
+func (a *RequestHeaderAuthenticator) GetSpecialURLs() auth.SpecialAuthURLs {
+	return auth.SpecialAuthURLs{}
+}
+
+func (a *RequestHeaderAuthenticator) IsStatic() bool { return false }
@@ -0,0 +1,181 @@
+package requestheader
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) writePEM(t *testing.T) string {
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return caFile
+}
+
+func (ca *testCA) issueClientCert(t *testing.T, commonName string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestAuthenticate(t *testing.T) {
+	trustedCA := newTestCA(t)
+	untrustedCA := newTestCA(t)
+
+	tests := []struct {
+		name           string
+		allowedNames   []string
+		clientCert     *x509.Certificate
+		headers        http.Header
+		expectErr      bool
+		expectUsername string
+		expectGroups   []string
+	}{
+		{
+			name:           "trusted proxy with user and groups",
+			clientCert:     trustedCA.issueClientCert(t, "front-proxy"),
+			headers:        http.Header{"X-Remote-User": {"alice"}, "X-Remote-Group": {"dev", "ops"}},
+			expectUsername: "alice",
+			expectGroups:   []string{"dev", "ops"},
+		},
+		{
+			name:           "allowed common name",
+			allowedNames:   []string{"front-proxy"},
+			clientCert:     trustedCA.issueClientCert(t, "front-proxy"),
+			headers:        http.Header{"X-Remote-User": {"alice"}},
+			expectUsername: "alice",
+		},
+		{
+			name:         "common name not allowed",
+			allowedNames: []string{"front-proxy"},
+			clientCert:   trustedCA.issueClientCert(t, "someone-else"),
+			headers:      http.Header{"X-Remote-User": {"alice"}},
+			expectErr:    true,
+		},
+		{
+			name:       "certificate signed by another CA",
+			clientCert: untrustedCA.issueClientCert(t, "front-proxy"),
+			headers:    http.Header{"X-Remote-User": {"alice"}},
+			expectErr:  true,
+		},
+		{
+			name:      "no client certificate",
+			headers:   http.Header{"X-Remote-User": {"alice"}},
+			expectErr: true,
+		},
+		{
+			name:       "missing username",
+			clientCert: trustedCA.issueClientCert(t, "front-proxy"),
+			headers:    http.Header{"X-Remote-Group": {"dev"}},
+			expectErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator, err := NewRequestHeaderAuthenticator(&Config{
+				ClientCAFile: trustedCA.writePEM(t),
+				AllowedNames: tt.allowedNames,
+				TokenSource:  oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "sa-token"}),
+			})
+			if err != nil {
+				t.Fatalf("failed to create authenticator: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/kubernetes/api/v1/pods", nil)
+			req.Header = tt.headers
+			req.TLS = &tls.ConnectionState{}
+			if tt.clientCert != nil {
+				req.TLS.PeerCertificates = []*x509.Certificate{tt.clientCert}
+			}
+
+			user, err := authenticator.Authenticate(httptest.NewRecorder(), req)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got user %+v", user)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if user.Username != tt.expectUsername {
+				t.Errorf("expected username %q, got %q", tt.expectUsername, user.Username)
+			}
+			if !reflect.DeepEqual(user.Groups, tt.expectGroups) {
+				t.Errorf("expected groups %v, got %v", tt.expectGroups, user.Groups)
+			}
+			if user.Token != "sa-token" || !user.Impersonate {
+				t.Errorf("expected impersonation with the service account token, got %+v", user)
+			}
+			if req.Header.Get("X-Remote-User") != "" || req.Header.Get("X-Remote-Group") != "" {
+				t.Errorf("identity headers must be removed from the request")
+			}
+		})
+	}
+}
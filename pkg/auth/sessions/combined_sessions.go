@@ -81,6 +81,7 @@ func (cs *CombinedSessionStore) AddSession(w http.ResponseWriter, r *http.Reques
 	clientSession.sessionToken.Values["session-token"] = ls.sessionToken
 	// Store only the small reference ID in the cookie, not the full refresh token
 	clientSession.refreshToken.Values["refresh-token-id"] = ls.refreshTokenID
+	setCookieIssuer(clientSession, ls)
 
 	return ls, clientSession.save(r, w)
 }
@@ -151,6 +152,24 @@ func (cs *CombinedSessionStore) GetCookieRefreshToken(r *http.Request) string {
 	return ""
 }
 
+// GetCookieIssuer returns the issuer of the session referenced by the request cookies.
+// The issuer is kept next to the refresh token reference so that a session can be
+// routed back to its identity provider even after the server-side state is gone.
+func (cs *CombinedSessionStore) GetCookieIssuer(r *http.Request) string {
+	clientSession, _ := cs.clientStore.Get(r, openshiftRefreshTokenCookieName)
+	issuer, _ := clientSession.Values["issuer"].(string)
+	return issuer
+}
+
+// setCookieIssuer records the issuer of the login state in the refresh token cookie.
+func setCookieIssuer(clientSession *session, ls *LoginState) {
+	if ls.issuer != "" {
+		clientSession.refreshToken.Values["issuer"] = ls.issuer
+	} else {
+		delete(clientSession.refreshToken.Values, "issuer")
+	}
+}
+
 func (cs *CombinedSessionStore) UpdateCookieRefreshToken(w http.ResponseWriter, r *http.Request, refreshToken string) error {
 	// Generate a new ID for the refresh token
 	newID := RandomString(32)
@@ -219,6 +238,7 @@ func (cs *CombinedSessionStore) UpdateTokens(w http.ResponseWriter, r *http.Requ
 	if oldRefreshToken != "" {
 		cs.serverStore.byRefreshToken[oldRefreshToken] = loginState
 	}
+	setCookieIssuer(clientSession, loginState)
 	return loginState, clientSession.save(r, w)
 }
 
@@ -827,3 +827,36 @@ func TestCombinedSessionStore_UpdateTokens_CleansUpOldPodCookies(t *testing.T) {
 	require.Equal(t, 2, expiredCookies, "Both old pod cookies should be expired")
 	require.True(t, newSessionCookie, "New session cookie should be created")
 }
+
+func TestCombinedSessionStore_Issuer(t *testing.T) {
+	encryptionKey := []byte(randomString(32))
+	authnKey := []byte(randomString(64))
+	cs := NewSessionStore(authnKey, encryptionKey, true, "/")
+
+	claims := `{"sub":"user-id-0","iss":"https://sso.example.com"}`
+	token := addIDToken(&oauth2.Token{RefreshToken: "refresh-token"}, createTestIDToken(claims))
+
+	addWriter := httptest.NewRecorder()
+	addReq := httptest.NewRequest(http.MethodGet, "/", nil)
+	ls, err := cs.AddSession(addWriter, addReq, newTestVerifier(claims), token)
+	require.NoError(t, err)
+	require.Equal(t, "https://sso.example.com", ls.Issuer())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range addWriter.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	require.Equal(t, "https://sso.example.com", cs.GetCookieIssuer(req))
+
+	got, err := cs.GetSession(httptest.NewRecorder(), req)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	require.Equal(t, "https://sso.example.com", got.Issuer())
+
+	otherClaims := `{"sub":"user-id-0","iss":"https://partner.example.com"}`
+	otherToken := addIDToken(&oauth2.Token{RefreshToken: "other-refresh-token"}, createTestIDToken(otherClaims))
+	_, err = cs.UpdateTokens(httptest.NewRecorder(), req, newTestVerifier(otherClaims), otherToken)
+	require.Error(t, err, "a session must not be refreshed with tokens of another issuer")
+
+	require.Empty(t, cs.GetCookieIssuer(httptest.NewRequest(http.MethodGet, "/", nil)))
+}
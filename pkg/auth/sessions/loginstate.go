@@ -22,6 +22,7 @@ type IDTokenVerifier func(context.Context, string) (*oidc.IDToken, error)
 type LoginState struct {
 	// IMPORTANT: if adding any ref type, change the DeepCopy() implementation
 	userID         string
+	issuer         string
 	name           string
 	email          string
 	exp            time.Time
@@ -41,6 +42,7 @@ type LoginJSON struct {
 }
 
 type interestingClaims struct {
+	Issuer  string   `json:"iss"`
 	Subject string   `json:"sub"`
 	Expiry  jsonTime `json:"exp"`
 	Email   string   `json:"email"`
@@ -88,6 +90,7 @@ func newLoginState(tokenVerifier IDTokenVerifier, token *oauth2.Token) (*LoginSt
 		rawToken:     rawIDToken,
 		refreshToken: token.RefreshToken,
 		userID:       tokenClaims.Subject,
+		issuer:       tokenClaims.Issuer,
 		email:        tokenClaims.Email,
 		name:         tokenClaims.Name,
 	}
@@ -100,6 +103,12 @@ func (ls *LoginState) UserID() string {
 	return ls.userID
 }
 
+// Issuer returns the OIDC issuer that authenticated the user. It is empty for
+// sessions created from opaque access tokens.
+func (ls *LoginState) Issuer() string {
+	return ls.issuer
+}
+
 func (ls *LoginState) Username() string {
 	return ls.name
 }
@@ -127,6 +136,10 @@ func (ls *LoginState) UpdateTokens(verifier IDTokenVerifier, tokenResponse *oaut
 		return fmt.Errorf("the new token's subject does not match the old one")
 	}
 
+	if ls.issuer != "" && tokenClaims.Issuer != ls.issuer {
+		return fmt.Errorf("the new token's issuer does not match the old one")
+	}
+
 	ls.rawToken = rawIDToken
 	ls.refreshToken = tokenResponse.RefreshToken
 	ls.updateExpiry(tokenClaims.Expiry)
@@ -169,6 +182,10 @@ func (ls *LoginState) RefreshTokenID() string {
 	return ls.refreshTokenID
 }
 
+func (ls *LoginState) ExpiresAt() time.Time {
+	return ls.exp
+}
+
 func (ls *LoginState) IsExpired() bool {
 	return ls.now().After(ls.exp)
 }
@@ -0,0 +1,277 @@
+package tokenexchange
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/console/pkg/serverconfig"
+	oscrypto "github.com/openshift/library-go/pkg/crypto"
+)
+
+const (
+	// GrantType is the OAuth 2.0 Token Exchange grant type, see RFC 8693 section 2.1.
+	GrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeIDToken     = "urn:ietf:params:oauth:token-type:id_token"
+
+	// expirySkew makes sure a cached token is not handed out right before it expires.
+	expirySkew = 30 * time.Second
+	// maxCachedTokens bounds the cache, expired tokens are dropped once it is reached.
+	maxCachedTokens = 4096
+)
+
+type Config struct {
+	// TokenURL is the token endpoint of the security token service.
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+
+	// Audience is the logical name of the backend the exchanged token is meant for.
+	Audience string
+	Scopes   []string
+	// SubjectTokenType describes the user token, it defaults to TokenTypeIDToken
+	// since that is what the console holds for OIDC sessions.
+	SubjectTokenType string
+
+	HTTPClient *http.Client
+}
+
+type cachedToken struct {
+	accessToken string
+	expiry      time.Time
+}
+
+// Exchanger swaps user tokens for tokens scoped to a single audience using
+// RFC 8693 token exchange. Exchanged tokens are cached until they expire.
+type Exchanger struct {
+	config Config
+	client *http.Client
+	now    func() time.Time
+
+	cacheLock sync.Mutex
+	cache     map[string]*cachedToken
+
+	// exchanges makes sure a token is only exchanged once when several
+	// requests of the same user arrive at the same time
+	exchanges singleflight.Group
+}
+
+func NewExchanger(c *Config) (*Exchanger, error) {
+	if c.TokenURL == "" {
+		return nil, fmt.Errorf("token exchange requires a token URL")
+	}
+	if _, err := url.Parse(c.TokenURL); err != nil {
+		return nil, fmt.Errorf("invalid token exchange URL: %w", err)
+	}
+	if c.Audience == "" {
+		return nil, fmt.Errorf("token exchange requires an audience")
+	}
+
+	config := *c
+	if config.SubjectTokenType == "" {
+		config.SubjectTokenType = TokenTypeIDToken
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &Exchanger{
+		config: config,
+		client: client,
+		now:    time.Now,
+		cache:  make(map[string]*cachedToken),
+	}, nil
+}
+
+// NewExchangerFromConfig creates an exchanger for one of the token exchange stanzas of the console config.
+func NewExchangerFromConfig(c *serverconfig.TokenExchange) (*Exchanger, error) {
+	config := &Config{
+		TokenURL:         c.TokenURL,
+		ClientID:         c.ClientID,
+		Audience:         c.Audience,
+		Scopes:           c.Scopes,
+		SubjectTokenType: c.SubjectTokenType,
+	}
+
+	if c.ClientSecretFile != "" {
+		secret, err := os.ReadFile(c.ClientSecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token exchange client secret file: %w", err)
+		}
+		config.ClientSecret = strings.TrimSpace(string(secret))
+	}
+
+	if c.CAFile != "" {
+		caPEM, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token exchange CA file: %w", err)
+		}
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("file %s contained no CA data", c.CAFile)
+		}
+		config.HTTPClient = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: oscrypto.SecureTLSConfig(&tls.Config{RootCAs: rootCAs}),
+			},
+			Timeout: 10 * time.Second,
+		}
+	}
+
+	return NewExchanger(config)
+}
+
+// ExchangeToken returns a token for the configured audience on behalf of the
+// owner of the subject token.
+func (e *Exchanger) ExchangeToken(ctx context.Context, subjectToken string) (string, error) {
+	if subjectToken == "" {
+		return "", fmt.Errorf("no token to exchange")
+	}
+
+	sum := sha256.Sum256([]byte(subjectToken))
+	key := hex.EncodeToString(sum[:])
+
+	if token := e.cachedToken(key); token != "" {
+		return token, nil
+	}
+
+	token, err, _ := e.exchanges.Do(key, func() (interface{}, error) {
+		if token := e.cachedToken(key); token != "" {
+			return token, nil
+		}
+		// the exchange is shared with the other waiting requests, it must not
+		// fail because the request that started it went away
+		token, err := e.exchange(context.WithoutCancel(ctx), subjectToken)
+		if err != nil {
+			return "", err
+		}
+		e.storeToken(key, token)
+		return token.accessToken, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token.(string), nil
+}
+
+func (e *Exchanger) cachedToken(key string) string {
+	e.cacheLock.Lock()
+	defer e.cacheLock.Unlock()
+
+	token, ok := e.cache[key]
+	if !ok {
+		return ""
+	}
+	if !e.now().Before(token.expiry) {
+		delete(e.cache, key)
+		return ""
+	}
+	return token.accessToken
+}
+
+func (e *Exchanger) storeToken(key string, token *cachedToken) {
+	if token.expiry.IsZero() {
+		// without a known lifetime the token cannot be safely reused
+		return
+	}
+
+	e.cacheLock.Lock()
+	defer e.cacheLock.Unlock()
+
+	if len(e.cache) >= maxCachedTokens {
+		now := e.now()
+		for k, t := range e.cache {
+			if !now.Before(t.expiry) {
+				delete(e.cache, k)
+			}
+		}
+		if len(e.cache) >= maxCachedTokens {
+			klog.V(4).Infof("token exchange cache for audience %q is full", e.config.Audience)
+			return
+		}
+	}
+	e.cache[key] = token
+}
+
+type tokenResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int64  `json:"expires_in"`
+
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (e *Exchanger) exchange(ctx context.Context, subjectToken string) (*cachedToken, error) {
+	form := url.Values{
+		"grant_type":           {GrantType},
+		"subject_token":        {subjectToken},
+		"subject_token_type":   {e.config.SubjectTokenType},
+		"requested_token_type": {TokenTypeAccessToken},
+		"audience":             {e.config.Audience},
+	}
+	if len(e.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(e.config.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token exchange request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if e.config.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(e.config.ClientID), url.QueryEscape(e.config.ClientSecret))
+	}
+
+	requestTime := e.now()
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token exchange response: %w", err)
+	}
+
+	var tokenResp tokenResponse
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return nil, fmt.Errorf("failed to parse token exchange response (status %d): %w", resp.StatusCode, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token exchange for audience %q failed with status %d: %s %s", e.config.Audience, resp.StatusCode, tokenResp.Error, tokenResp.ErrorDescription)
+	}
+	if tokenResp.AccessToken == "" {
+		return nil, fmt.Errorf("token exchange response did not contain an access token")
+	}
+
+	token := &cachedToken{accessToken: tokenResp.AccessToken}
+	if tokenResp.ExpiresIn > 0 {
+		token.expiry = requestTime.Add(time.Duration(tokenResp.ExpiresIn)*time.Second - expirySkew)
+	}
+	return token, nil
+}
@@ -0,0 +1,175 @@
+package tokenexchange
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSTS struct {
+	t        *testing.T
+	requests atomic.Int32
+	// tokens maps subject tokens to the exchanged token
+	tokens    map[string]string
+	expiresIn int64
+	// release holds back the responses until it is closed
+	release chan struct{}
+}
+
+func (s *fakeSTS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	assert.NoError(s.t, r.ParseForm())
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	assert.True(s.t, ok, "client credentials must be sent")
+	assert.Equal(s.t, "console", clientID)
+	assert.Equal(s.t, "secret", clientSecret)
+
+	assert.Equal(s.t, GrantType, r.Form.Get("grant_type"))
+	assert.Equal(s.t, TokenTypeIDToken, r.Form.Get("subject_token_type"))
+	assert.Equal(s.t, TokenTypeAccessToken, r.Form.Get("requested_token_type"))
+	assert.Equal(s.t, "thanos", r.Form.Get("audience"))
+	assert.Equal(s.t, "metrics:read", r.Form.Get("scope"))
+
+	w.Header().Set("Content-Type", "application/json")
+	token, ok := s.tokens[r.Form.Get("subject_token")]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token":      token,
+		"issued_token_type": TokenTypeAccessToken,
+		"token_type":        "Bearer",
+		"expires_in":        s.expiresIn,
+	})
+}
+
+func newTestExchanger(t *testing.T, sts *fakeSTS) *Exchanger {
+	server := httptest.NewServer(sts)
+	t.Cleanup(server.Close)
+
+	e, err := NewExchanger(&Config{
+		TokenURL:     server.URL,
+		ClientID:     "console",
+		ClientSecret: "secret",
+		Audience:     "thanos",
+		Scopes:       []string{"metrics:read"},
+	})
+	require.NoError(t, err)
+	return e
+}
+
+func TestExchangeToken(t *testing.T) {
+	sts := &fakeSTS{t: t, tokens: map[string]string{"user-token": "thanos-token"}, expiresIn: 300}
+	e := newTestExchanger(t, sts)
+
+	token, err := e.ExchangeToken(context.Background(), "user-token")
+	require.NoError(t, err)
+	require.Equal(t, "thanos-token", token)
+
+	token, err = e.ExchangeToken(context.Background(), "user-token")
+	require.NoError(t, err)
+	require.Equal(t, "thanos-token", token)
+	require.EqualValues(t, 1, sts.requests.Load(), "exchanged tokens must be cached")
+
+	_, err = e.ExchangeToken(context.Background(), "unknown-token")
+	require.Error(t, err)
+
+	_, err = e.ExchangeToken(context.Background(), "")
+	require.Error(t, err)
+}
+
+func TestExchangeTokenExpiry(t *testing.T) {
+	sts := &fakeSTS{t: t, tokens: map[string]string{"user-token": "thanos-token"}, expiresIn: 300}
+	e := newTestExchanger(t, sts)
+
+	now := time.Now()
+	e.now = func() time.Time { return now }
+
+	_, err := e.ExchangeToken(context.Background(), "user-token")
+	require.NoError(t, err)
+
+	now = now.Add(300*time.Second - expirySkew - time.Second)
+	_, err = e.ExchangeToken(context.Background(), "user-token")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, sts.requests.Load())
+
+	now = now.Add(2 * time.Second)
+	_, err = e.ExchangeToken(context.Background(), "user-token")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, sts.requests.Load(), "tokens must be exchanged again once they are about to expire")
+}
+
+func TestExchangeTokenWithoutExpiry(t *testing.T) {
+	sts := &fakeSTS{t: t, tokens: map[string]string{"user-token": "thanos-token"}}
+	e := newTestExchanger(t, sts)
+
+	for i := 0; i < 2; i++ {
+		_, err := e.ExchangeToken(context.Background(), "user-token")
+		require.NoError(t, err)
+	}
+	require.EqualValues(t, 2, sts.requests.Load(), "tokens without a known lifetime must not be cached")
+}
+
+func TestExchangeTokenConcurrently(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		subjectToken string
+		expectError  bool
+	}{
+		{name: "successful exchange", subjectToken: "user-token"},
+		{name: "failed exchange", subjectToken: "unknown-token", expectError: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sts := &fakeSTS{t: t, tokens: map[string]string{"user-token": "thanos-token"}, expiresIn: 300, release: make(chan struct{})}
+			e := newTestExchanger(t, sts)
+
+			var wg sync.WaitGroup
+			errs := make(chan error, 10)
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := e.ExchangeToken(context.Background(), tt.subjectToken)
+					errs <- err
+				}()
+			}
+			require.Eventually(t, func() bool { return sts.requests.Load() > 0 }, 5*time.Second, 10*time.Millisecond)
+			// give the other requests the time to join the running exchange
+			time.Sleep(100 * time.Millisecond)
+			close(sts.release)
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				if tt.expectError {
+					require.Error(t, err)
+				} else {
+					require.NoError(t, err)
+				}
+			}
+			require.EqualValues(t, 1, sts.requests.Load(), "concurrent requests must share a single exchange")
+		})
+	}
+}
+
+func TestNewExchanger(t *testing.T) {
+	_, err := NewExchanger(&Config{Audience: "thanos"})
+	require.Error(t, err, "a token URL is required")
+
+	_, err = NewExchanger(&Config{TokenURL: "https://sts.example.com/token"})
+	require.Error(t, err, "an audience is required")
+}
@@ -2,6 +2,9 @@ package auth
 
 import (
 	"net/http"
+	"time"
+
+	"k8s.io/client-go/rest"
 
 	"github.com/openshift/console/pkg/auth/sessions"
 )
@@ -31,4 +34,25 @@ type User struct {
 	ID       string
 	Username string
 	Token    string
+	// Groups the user belongs to. Only populated by authenticators that
+	// impersonate the user instead of forwarding a token of their own.
+	Groups []string
+	// Impersonate is set when Token belongs to the console service account and
+	// Kubernetes requests need impersonation headers to run as Username.
+	Impersonate bool
+	// ExpiresAt is when the session of the user expires, zero if the authenticator does not know.
+	ExpiresAt time.Time
+}
+
+// ImpersonationConfig returns the impersonation settings Kubernetes clients
+// need to act on behalf of the user. It is empty when the user's own token
+// is used.
+func (u *User) ImpersonationConfig() rest.ImpersonationConfig {
+	if !u.Impersonate {
+		return rest.ImpersonationConfig{}
+	}
+	return rest.ImpersonationConfig{
+		UserName: u.Username,
+		Groups:   u.Groups,
+	}
 }
@@ -15,19 +15,26 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+// CatalogNotifier is told when the items of a catalog were updated or removed from the cache
+type CatalogNotifier interface {
+	CatalogRefreshed(catalog string, removed bool)
+}
+
 // ClusterCatalogReconciler reconciles ClusterCatalog resources
 type ClusterCatalogReconciler struct {
 	client.Client
 	Scheme         *runtime.Scheme
 	catalogService olm.CatalogServiceInterface
+	notifier       CatalogNotifier
 }
 
-// NewClusterCatalogReconciler creates a new ClusterCatalogReconciler
-func NewClusterCatalogReconciler(mgr ctrl.Manager, cs olm.CatalogServiceInterface) *ClusterCatalogReconciler {
+// NewClusterCatalogReconciler creates a new ClusterCatalogReconciler, notifier may be nil
+func NewClusterCatalogReconciler(mgr ctrl.Manager, cs olm.CatalogServiceInterface, notifier CatalogNotifier) *ClusterCatalogReconciler {
 	return &ClusterCatalogReconciler{
 		Client:         mgr.GetClient(),
 		Scheme:         mgr.GetScheme(),
 		catalogService: cs,
+		notifier:       notifier,
 	}
 }
 
@@ -44,6 +51,7 @@ func (r *ClusterCatalogReconciler) Reconcile(ctx context.Context, req reconcile.
 			// The ClusterCatalog has been deleted, delete its CatalogItems from the cache
 			klog.V(4).Infof("Removing CatalogItems for ClusterCatalog %s from cache", req.Name)
 			r.catalogService.RemoveCatalog(req.Name)
+			r.notify(req.Name, true)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
@@ -74,9 +82,16 @@ func (r *ClusterCatalogReconciler) Reconcile(ctx context.Context, req reconcile.
 		}, nil
 	}
 
+	r.notify(req.Name, false)
 	return ctrl.Result{}, nil
 }
 
+func (r *ClusterCatalogReconciler) notify(catalog string, removed bool) {
+	if r.notifier != nil {
+		r.notifier.CatalogRefreshed(catalog, removed)
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager
 func (r *ClusterCatalogReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	utilruntime.Must(ocv1.AddToScheme(mgr.GetScheme()))
@@ -45,6 +45,19 @@ func (m *mockCatalogService) GetCatalogItems() ([]olm.ConsoleCatalogItem, error)
 	return nil, nil
 }
 
+type mockCatalogNotifier struct {
+	refreshed []string
+	removed   []string
+}
+
+func (m *mockCatalogNotifier) CatalogRefreshed(catalog string, removed bool) {
+	if removed {
+		m.removed = append(m.removed, catalog)
+	} else {
+		m.refreshed = append(m.refreshed, catalog)
+	}
+}
+
 func createTestReconciler(objects ...client.Object) (*ClusterCatalogReconciler, *mockCatalogService) {
 	scheme := runtime.NewScheme()
 	_ = ocv1.AddToScheme(scheme)
@@ -191,3 +204,41 @@ func TestReconcileUpdateCatalogError(t *testing.T) {
 	}, result)
 	assert.True(t, mockService.updateCatalogCalled)
 }
+
+func TestReconcileNotifiesCatalogRefreshed(t *testing.T) {
+	clusterCatalog := &ocv1.ClusterCatalog{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testCatalogName,
+		},
+		Status: ocv1.ClusterCatalogStatus{
+			URLs: &ocv1.ClusterCatalogURLs{
+				Base: "https://example.com/catalog",
+			},
+		},
+	}
+
+	reconciler, mockService := createTestReconciler(clusterCatalog)
+	notifier := &mockCatalogNotifier{}
+	reconciler.notifier = notifier
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name: testCatalogName,
+		},
+	}
+
+	_, err := reconciler.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{testCatalogName}, notifier.refreshed)
+
+	// a failed update is retried, the browsers are told once it succeeds
+	mockService.updateError = errors.New("mock update failed")
+	_, err = reconciler.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{testCatalogName}, notifier.refreshed)
+
+	require.NoError(t, reconciler.Delete(context.Background(), clusterCatalog))
+	_, err = reconciler.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{testCatalogName}, notifier.removed)
+}
@@ -0,0 +1,237 @@
+package csp
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/console/pkg/serverutils"
+)
+
+const (
+	consoleCSPReportsTotalMetric    = "console_csp_reports_total"
+	consoleCSPViolationsTotalMetric = "console_csp_violations_total"
+
+	// maxReportSize bounds the body of a report request
+	maxReportSize = 64 * 1024
+	// maxViolations is the number of distinct violations kept for the violations list
+	maxViolations = 256
+	// duplicateInterval is the interval in which a repeated violation is counted once
+	duplicateInterval = time.Minute
+
+	ResultAccepted    = "accepted"
+	ResultDuplicate   = "duplicate"
+	ResultInvalid     = "invalid"
+	ResultRateLimited = "rate_limited"
+)
+
+// pluginAssetsPath is the path the assets of a console plugin are served from.
+const pluginAssetsPath = "/api/plugins/"
+
+// RecordedViolation is a distinct violation with the number of times it was reported.
+type RecordedViolation struct {
+	Violation
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	// lastCounted is when the violation was last counted in the metrics
+	lastCounted time.Time
+}
+
+// Collector collects the CSP violations browsers report for the console and its plugins.
+type Collector struct {
+	mu         sync.Mutex
+	plugins    map[string]bool
+	violations map[Violation]*RecordedViolation
+	limiter    *rate.Limiter
+	now        func() time.Time
+
+	reportsTotal    *prometheus.CounterVec
+	violationsTotal *prometheus.CounterVec
+}
+
+// NewCollector creates a collector that attributes violations to the given plugins. The
+// reports are not authenticated, so at most reportsPerSecond are accepted.
+func NewCollector(plugins []string, reportsPerSecond float64) *Collector {
+	c := &Collector{
+		plugins:    map[string]bool{},
+		violations: map[Violation]*RecordedViolation{},
+		limiter:    rate.NewLimiter(rate.Limit(reportsPerSecond), int(reportsPerSecond*10)+1),
+		now:        time.Now,
+	}
+	for _, plugin := range plugins {
+		c.plugins[plugin] = true
+	}
+	c.reportsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: consoleCSPReportsTotalMetric,
+		Help: "Number of CSP violation reports received, by result.",
+	}, []string{"result"})
+	c.violationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: consoleCSPViolationsTotalMetric,
+		Help: "Number of CSP violations, by plugin, directive and disposition. Repeated violations are counted once a minute.",
+	}, []string{"plugin", "directive", "disposition"})
+	return c
+}
+
+func (c *Collector) GetCollectors() []prometheus.Collector {
+	return []prometheus.Collector{c.reportsTotal, c.violationsTotal}
+}
+
+// HandleReport accepts the reports of the report-uri and report-to directives.
+func (c *Collector) HandleReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		serverutils.SendResponse(w, http.StatusMethodNotAllowed, serverutils.ApiError{Err: "Invalid method: only POST is allowed"})
+		return
+	}
+	if !c.limiter.Allow() {
+		c.reportsTotal.WithLabelValues(ResultRateLimited).Inc()
+		serverutils.SendResponse(w, http.StatusTooManyRequests, serverutils.ApiError{Err: "Too many CSP reports"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxReportSize+1))
+	if err == nil && len(body) > maxReportSize {
+		serverutils.SendResponse(w, http.StatusRequestEntityTooLarge, serverutils.ApiError{Err: "CSP report is too large"})
+		c.reportsTotal.WithLabelValues(ResultInvalid).Inc()
+		return
+	}
+	var violations []Violation
+	if err == nil {
+		violations, err = parseReports(r.Header.Get("Content-Type"), body)
+	}
+	if err != nil {
+		klog.V(4).Infof("Invalid CSP report: %v", err)
+		c.reportsTotal.WithLabelValues(ResultInvalid).Inc()
+		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: err.Error()})
+		return
+	}
+
+	for _, violation := range violations {
+		c.record(violation)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// record adds a violation to the list and counts it, unless it was counted in the last minute.
+func (c *Collector) record(violation Violation) {
+	violation.normalize()
+	violation.Plugin = c.pluginOf(violation)
+	now := c.now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	recorded, ok := c.violations[violation]
+	if !ok {
+		if len(c.violations) >= maxViolations {
+			c.evictOldest()
+		}
+		recorded = &RecordedViolation{Violation: violation, FirstSeen: now}
+		c.violations[violation] = recorded
+	}
+	recorded.Count++
+	recorded.LastSeen = now
+
+	if ok && now.Sub(recorded.lastCounted) < duplicateInterval {
+		c.reportsTotal.WithLabelValues(ResultDuplicate).Inc()
+		return
+	}
+	recorded.lastCounted = now
+	c.reportsTotal.WithLabelValues(ResultAccepted).Inc()
+	c.violationsTotal.WithLabelValues(violation.Plugin, violation.EffectiveDirective, violation.Disposition).Inc()
+}
+
+func (c *Collector) evictOldest() {
+	var oldest *RecordedViolation
+	for _, recorded := range c.violations {
+		if oldest == nil || recorded.LastSeen.Before(oldest.LastSeen) {
+			oldest = recorded
+		}
+	}
+	if oldest != nil {
+		delete(c.violations, oldest.Violation)
+	}
+}
+
+// pluginOf attributes a violation to the enabled plugin whose assets caused it, or whose
+// assets were blocked.
+func (c *Collector) pluginOf(violation Violation) string {
+	for _, rawURL := range []string{violation.SourceFile, violation.BlockedURL} {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			continue
+		}
+		_, rest, found := strings.Cut(u.Path, pluginAssetsPath)
+		if !found {
+			continue
+		}
+		plugin, _, _ := strings.Cut(rest, "/")
+		if c.plugins[plugin] {
+			return plugin
+		}
+	}
+	return ""
+}
+
+// ViolationsResponse is the list of recent violations.
+type ViolationsResponse struct {
+	// Total is the number of reports of the listed violations
+	Total      int                 `json:"total"`
+	Violations []RecordedViolation `json:"violations"`
+}
+
+// Violations returns the recent violations, most recent first, filtered by plugin and
+// directive if they are not empty. Use "console" to only list violations of the console.
+func (c *Collector) Violations(plugin, directive string, since time.Time) ViolationsResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	response := ViolationsResponse{Violations: []RecordedViolation{}}
+	for _, recorded := range c.violations {
+		if plugin != "" && !(recorded.Plugin == plugin || (plugin == "console" && recorded.Plugin == "")) {
+			continue
+		}
+		if directive != "" && recorded.EffectiveDirective != directive {
+			continue
+		}
+		if recorded.LastSeen.Before(since) {
+			continue
+		}
+		response.Total += recorded.Count
+		response.Violations = append(response.Violations, *recorded)
+	}
+	sort.Slice(response.Violations, func(i, j int) bool {
+		return response.Violations[i].LastSeen.After(response.Violations[j].LastSeen)
+	})
+	return response
+}
+
+// HandleViolations lists the recent violations. The plugin and directive parameters filter
+// the violations, and the since parameter is a duration like 1h.
+func (c *Collector) HandleViolations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		serverutils.SendResponse(w, http.StatusMethodNotAllowed, serverutils.ApiError{Err: "Invalid method: only GET is allowed"})
+		return
+	}
+	query := r.URL.Query()
+	var since time.Time
+	if value := query.Get("since"); value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration < 0 {
+			serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: "Invalid since parameter: " + strconv.Quote(value)})
+			return
+		}
+		since = c.now().Add(-duration)
+	}
+	serverutils.SendResponse(w, http.StatusOK, c.Violations(query.Get("plugin"), query.Get("directive"), since))
+}
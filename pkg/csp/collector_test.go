@@ -0,0 +1,196 @@
+package csp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/console/pkg/metrics"
+)
+
+const reportURIReport = `{
+  "csp-report": {
+    "document-uri": "https://console.example.com/k8s/ns/default/pods?token=secret",
+    "referrer": "",
+    "violated-directive": "script-src-elem",
+    "effective-directive": "script-src-elem",
+    "original-policy": "script-src 'self'",
+    "disposition": "report",
+    "blocked-uri": "https://cdn.example.com/lib.js",
+    "line-number": 12,
+    "source-file": "https://console.example.com/api/plugins/monitoring-plugin/plugin-entry.js",
+    "status-code": 200
+  }
+}`
+
+const reportingAPIReports = `[
+  {
+    "type": "csp-violation",
+    "age": 10,
+    "url": "https://console.example.com/",
+    "user_agent": "Mozilla/5.0",
+    "body": {
+      "documentURL": "https://console.example.com/",
+      "blockedURL": "inline",
+      "effectiveDirective": "style-src-attr",
+      "originalPolicy": "style-src 'self'",
+      "disposition": "enforce",
+      "sourceFile": "https://console.example.com/static/main.js",
+      "lineNumber": 1
+    }
+  },
+  {
+    "type": "deprecation",
+    "age": 10,
+    "url": "https://console.example.com/",
+    "body": {"id": "unload"}
+  },
+  {
+    "type": "csp-violation",
+    "age": 10,
+    "url": "https://console.example.com/",
+    "body": {
+      "documentURL": "https://console.example.com/",
+      "blockedURL": "https://console.example.com/api/plugins/unknown-plugin/plugin-entry.js",
+      "effectiveDirective": "made-up-directive",
+      "disposition": "report"
+    }
+  }
+]`
+
+func postReport(c *Collector, contentType, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/csp-report", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	c.HandleReport(w, r)
+	return w
+}
+
+func TestHandleReport(t *testing.T) {
+	c := NewCollector([]string{"monitoring-plugin"}, 100)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	w := postReport(c, "application/csp-report", reportURIReport)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = postReport(c, "application/reports+json", reportingAPIReports)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	violations := c.Violations("", "", time.Time{})
+	require.Len(t, violations.Violations, 3)
+	assert.Equal(t, 3, violations.Total)
+
+	monitoring := c.Violations("monitoring-plugin", "", time.Time{})
+	require.Len(t, monitoring.Violations, 1)
+	assert.Equal(t, Violation{
+		Plugin:             "monitoring-plugin",
+		DocumentURL:        "https://console.example.com/k8s/ns/default/pods",
+		BlockedURL:         "https://cdn.example.com/lib.js",
+		SourceFile:         "https://console.example.com/api/plugins/monitoring-plugin/plugin-entry.js",
+		LineNumber:         12,
+		EffectiveDirective: "script-src-elem",
+		Disposition:        "report",
+	}, monitoring.Violations[0].Violation)
+
+	// violations of plugins that are not enabled are attributed to the console
+	console := c.Violations("console", "", time.Time{})
+	require.Len(t, console.Violations, 2)
+	assert.Equal(t, 1, len(c.Violations("", "other", time.Time{}).Violations))
+	assert.Equal(t, "enforce", c.Violations("", "style-src-attr", time.Time{}).Violations[0].Disposition)
+
+	assert.Equal(t, metrics.RemoveComments(`
+	console_csp_violations_total{directive="other",disposition="report",plugin=""} 1
+	console_csp_violations_total{directive="script-src-elem",disposition="report",plugin="monitoring-plugin"} 1
+	console_csp_violations_total{directive="style-src-attr",disposition="enforce",plugin=""} 1
+	`), metrics.RemoveComments(metrics.FormatMetrics(c.violationsTotal)))
+}
+
+func TestHandleReportDuplicates(t *testing.T) {
+	c := NewCollector(nil, 100)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	postReport(c, "application/csp-report", reportURIReport)
+	now = now.Add(10 * time.Second)
+	postReport(c, "application/csp-report", reportURIReport)
+
+	violations := c.Violations("", "", time.Time{})
+	require.Len(t, violations.Violations, 1)
+	assert.Equal(t, 2, violations.Violations[0].Count)
+	assert.Equal(t, metrics.RemoveComments(`
+	console_csp_reports_total{result="accepted"} 1
+	console_csp_reports_total{result="duplicate"} 1
+	console_csp_violations_total{directive="script-src-elem",disposition="report",plugin=""} 1
+	`), metrics.RemoveComments(metrics.FormatMetrics(c.GetCollectors()...)))
+
+	// the violation is counted again once the duplicate interval passed
+	now = now.Add(duplicateInterval)
+	postReport(c, "application/csp-report", reportURIReport)
+	assert.Equal(t, metrics.RemoveComments(`
+	console_csp_violations_total{directive="script-src-elem",disposition="report",plugin=""} 2
+	`), metrics.RemoveComments(metrics.FormatMetrics(c.violationsTotal)))
+
+	assert.Empty(t, c.Violations("", "", now.Add(time.Second)).Violations)
+}
+
+func TestHandleReportLimits(t *testing.T) {
+	c := NewCollector(nil, 0.001)
+
+	w := postReport(c, "text/plain", "{}")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postReport(c, "application/csp-report", reportURIReport)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, metrics.RemoveComments(`
+	console_csp_reports_total{result="invalid"} 1
+	console_csp_reports_total{result="rate_limited"} 1
+	`), metrics.RemoveComments(metrics.FormatMetrics(c.reportsTotal)))
+	assert.Empty(t, c.Violations("", "", time.Time{}).Violations)
+
+	c = NewCollector(nil, 100)
+	w = postReport(c, "application/csp-report", strings.Repeat(" ", maxReportSize+1))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	w = httptest.NewRecorder()
+	c.HandleReport(w, httptest.NewRequest(http.MethodGet, "/api/csp-report", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestViolationsAreBounded(t *testing.T) {
+	c := NewCollector(nil, 100)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	for i := 0; i <= maxViolations; i++ {
+		now = now.Add(time.Second)
+		c.record(Violation{DocumentURL: "https://console.example.com/", BlockedURL: "https://example.com/" + strings.Repeat("a", i), EffectiveDirective: "img-src"})
+	}
+	violations := c.Violations("", "", time.Time{}).Violations
+	assert.Len(t, violations, maxViolations)
+	// the oldest violation was evicted
+	assert.Equal(t, "https://example.com/"+strings.Repeat("a", maxViolations), violations[0].BlockedURL)
+	assert.Equal(t, "https://example.com/a", violations[len(violations)-1].BlockedURL)
+}
+
+func TestHandleViolations(t *testing.T) {
+	c := NewCollector([]string{"monitoring-plugin"}, 100)
+	postReport(c, "application/csp-report", reportURIReport)
+
+	w := httptest.NewRecorder()
+	c.HandleViolations(w, httptest.NewRequest(http.MethodGet, "/api/console/csp-violations?plugin=monitoring-plugin&since=1h", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var response ViolationsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Total)
+	require.Len(t, response.Violations, 1)
+	assert.Equal(t, "monitoring-plugin", response.Violations[0].Plugin)
+
+	w = httptest.NewRecorder()
+	c.HandleViolations(w, httptest.NewRequest(http.MethodGet, "/api/console/csp-violations?since=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,171 @@
+package csp
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+const (
+	// reportURIContentType is sent by browsers for the report-uri directive
+	reportURIContentType = "application/csp-report"
+	// reportingAPIContentType is sent by browsers for the report-to directive
+	reportingAPIContentType = "application/reports+json"
+
+	// maxURLLength bounds the URLs kept of a violation
+	maxURLLength = 512
+)
+
+// Violation is a Content Security Policy violation reported by a browser.
+type Violation struct {
+	// Plugin is the console plugin the violation is attributed to, empty for the console itself
+	Plugin             string `json:"plugin,omitempty"`
+	DocumentURL        string `json:"documentURL"`
+	BlockedURL         string `json:"blockedURL"`
+	SourceFile         string `json:"sourceFile,omitempty"`
+	LineNumber         int    `json:"lineNumber,omitempty"`
+	ColumnNumber       int    `json:"columnNumber,omitempty"`
+	EffectiveDirective string `json:"effectiveDirective"`
+	// Disposition is "report" for the report-only policy and "enforce" for the enforced one
+	Disposition string `json:"disposition"`
+}
+
+// reportURIBody is the body of a report-uri report.
+type reportURIBody struct {
+	Report struct {
+		DocumentURI        string `json:"document-uri"`
+		BlockedURI         string `json:"blocked-uri"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+		ColumnNumber       int    `json:"column-number"`
+	} `json:"csp-report"`
+}
+
+// reportingAPIReport is a report of a Reporting API batch.
+type reportingAPIReport struct {
+	Type string `json:"type"`
+	Body struct {
+		DocumentURL        string `json:"documentURL"`
+		BlockedURL         string `json:"blockedURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"sourceFile"`
+		LineNumber         int    `json:"lineNumber"`
+		ColumnNumber       int    `json:"columnNumber"`
+	} `json:"body"`
+}
+
+// parseReports parses the violations of a report-uri report or of a Reporting API batch.
+// Reports of the Reporting API that are not CSP violations are skipped.
+func parseReports(contentType string, body []byte) ([]Violation, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content type %q", contentType)
+	}
+
+	switch mediaType {
+	case reportURIContentType, "application/json":
+		var report reportURIBody
+		if err := json.Unmarshal(body, &report); err != nil {
+			return nil, fmt.Errorf("failed to parse CSP report: %v", err)
+		}
+		r := report.Report
+		directive := r.EffectiveDirective
+		if directive == "" {
+			// older browsers only send the violated directive, with its sources
+			directive, _, _ = strings.Cut(r.ViolatedDirective, " ")
+		}
+		return []Violation{{
+			DocumentURL:        r.DocumentURI,
+			BlockedURL:         r.BlockedURI,
+			SourceFile:         r.SourceFile,
+			LineNumber:         r.LineNumber,
+			ColumnNumber:       r.ColumnNumber,
+			EffectiveDirective: directive,
+			Disposition:        r.Disposition,
+		}}, nil
+	case reportingAPIContentType:
+		var reports []reportingAPIReport
+		if err := json.Unmarshal(body, &reports); err != nil {
+			return nil, fmt.Errorf("failed to parse reports: %v", err)
+		}
+		var violations []Violation
+		for _, report := range reports {
+			if report.Type != "csp-violation" {
+				continue
+			}
+			b := report.Body
+			violations = append(violations, Violation{
+				DocumentURL:        b.DocumentURL,
+				BlockedURL:         b.BlockedURL,
+				SourceFile:         b.SourceFile,
+				LineNumber:         b.LineNumber,
+				ColumnNumber:       b.ColumnNumber,
+				EffectiveDirective: b.EffectiveDirective,
+				Disposition:        b.Disposition,
+			})
+		}
+		return violations, nil
+	}
+	return nil, fmt.Errorf("unsupported content type %q", mediaType)
+}
+
+// normalize drops the query and fragment of the URLs of a violation, which can hold tokens,
+// and bounds the values that are used as metric labels.
+func (v *Violation) normalize() {
+	v.DocumentURL = sanitizeURL(v.DocumentURL)
+	v.BlockedURL = sanitizeURL(v.BlockedURL)
+	v.SourceFile = sanitizeURL(v.SourceFile)
+	v.EffectiveDirective = sanitizeDirective(v.EffectiveDirective)
+	if v.Disposition != "enforce" {
+		v.Disposition = "report"
+	}
+}
+
+func sanitizeURL(rawURL string) string {
+	// blocked-uri can be a keyword like "inline" or "eval" instead of a URL
+	if u, err := url.Parse(rawURL); err == nil && u.Scheme != "" {
+		u.RawQuery = ""
+		u.Fragment = ""
+		u.User = nil
+		rawURL = u.String()
+	}
+	if len(rawURL) > maxURLLength {
+		rawURL = rawURL[:maxURLLength]
+	}
+	return rawURL
+}
+
+// directives are the fetch and document directives the console policy can report on. Any
+// other reported directive is recorded as "other" to bound the metric labels.
+var directives = map[string]bool{
+	"base-uri":        true,
+	"connect-src":     true,
+	"default-src":     true,
+	"font-src":        true,
+	"frame-ancestors": true,
+	"frame-src":       true,
+	"img-src":         true,
+	"manifest-src":    true,
+	"media-src":       true,
+	"object-src":      true,
+	"script-src":      true,
+	"script-src-attr": true,
+	"script-src-elem": true,
+	"style-src":       true,
+	"style-src-attr":  true,
+	"style-src-elem":  true,
+	"worker-src":      true,
+}
+
+func sanitizeDirective(directive string) string {
+	if !directives[directive] {
+		return "other"
+	}
+	return directive
+}
@@ -2,7 +2,6 @@ package artifacthub
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,6 +9,7 @@ import (
 
 	"github.com/openshift/console/pkg/auth"
 	"github.com/openshift/console/pkg/devconsole/common"
+	"github.com/openshift/console/pkg/serverutils"
 )
 
 const (
@@ -49,9 +49,8 @@ func makeHTTPRequest(ctx context.Context, url string) (common.DevConsoleCommonRe
 
 func GetTaskYAMLFromGithub(r *http.Request, user *auth.User) (common.DevConsoleCommonResponse, error) {
 	var request TaskYAMLRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
-	if err != nil {
-		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to parse request: %v", err)
+	if err := serverutils.DecodeRequest(r, &request); err != nil {
+		return common.DevConsoleCommonResponse{}, err
 	}
 
 	GITHUB_TASK_YAML_URL := fmt.Sprintf("%s/%s",
@@ -63,9 +62,8 @@ func GetTaskYAMLFromGithub(r *http.Request, user *auth.User) (common.DevConsoleC
 
 func GetTaskDetails(r *http.Request, user *auth.User) (common.DevConsoleCommonResponse, error) {
 	var request TaskDetailsRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
-	if err != nil {
-		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to parse request: %v", err)
+	if err := serverutils.DecodeRequest(r, &request); err != nil {
+		return common.DevConsoleCommonResponse{}, err
 	}
 
 	ARTIFACTHUB_TASKS_DETAILS_URL := fmt.Sprintf("%s/packages/tekton-task/%s/%s/%s",
@@ -79,9 +77,8 @@ func GetTaskDetails(r *http.Request, user *auth.User) (common.DevConsoleCommonRe
 
 func SearchTasks(r *http.Request, user *auth.User) (common.DevConsoleCommonResponse, error) {
 	var request SearchRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
-	if err != nil {
-		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to parse request: %v", err)
+	if err := serverutils.DecodeRequest(r, &request); err != nil {
+		return common.DevConsoleCommonResponse{}, err
 	}
 
 	ARTIFACTHUB_TASKS_SEARCH_URL := ARTIFACTHUB_API_BASE_URL + "/packages/search?offset=0&limit=60&facets=false&kind=7&deprecated=false&sort=relevance"
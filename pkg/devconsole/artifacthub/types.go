@@ -24,11 +24,11 @@ type SearchRequest struct {
 }
 
 type TaskYAMLRequest struct {
-	YamlPath string `json:"yamlPath"`
+	YamlPath string `json:"yamlPath" validate:"required"`
 }
 
 type TaskDetailsRequest struct {
-	RepoName string `json:"repoName"`
-	Name     string `json:"name"`
-	Version  string `json:"version"`
+	RepoName string `json:"repoName" validate:"required"`
+	Name     string `json:"name" validate:"required"`
+	Version  string `json:"version" validate:"required"`
 }
@@ -22,3 +22,28 @@ func (e *ValidationError) Error() string {
 func (e *ValidationError) Unwrap() error {
 	return e.Err
 }
+
+// UpstreamError is returned when a backend service responds with an error status.
+type UpstreamError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("upstream service responded with status %d: %s", e.StatusCode, e.Body)
+}
+
+// ResponseStatusCode is the status code reported to the client. Authorization failures
+// of the backend are passed on, anything else is a bad gateway.
+func (e *UpstreamError) ResponseStatusCode() int {
+	if e.StatusCode == http.StatusForbidden || e.StatusCode == http.StatusNotFound {
+		return e.StatusCode
+	}
+	return http.StatusBadGateway
+}
+
+// Retryable reports whether the backend failed in a way that the same request may succeed
+// later, like when it is overloaded.
+func (e *UpstreamError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusServiceUnavailable || e.StatusCode == http.StatusGatewayTimeout
+}
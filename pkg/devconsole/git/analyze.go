@@ -0,0 +1,121 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/openshift/console/pkg/devconsole/common"
+	"github.com/openshift/console/pkg/serverutils"
+	"k8s.io/apimachinery/pkg/util/cache"
+)
+
+const (
+	// analysisCacheSize is the maximum number of cached analyses
+	analysisCacheSize = 256
+	// analyzeTimeout bounds all the requests of an analysis
+	analyzeTimeout = 30 * time.Second
+)
+
+var (
+	// analysisCacheTTL is short enough that pushes to a branch are picked up while the user
+	// is still in the import form.
+	analysisCacheTTL = 5 * time.Minute
+
+	analysisCache = cache.NewLRUExpireCache(analysisCacheSize)
+)
+
+// Analyze detects how the context dir of a public repository can be imported.
+func Analyze(r *http.Request) (*AnalyzeResponse, error) {
+	var request AnalyzeRequest
+	if err := serverutils.DecodeRequest(r, &request); err != nil {
+		return nil, &common.ValidationError{Err: err}
+	}
+	repoURL, err := validateURL(strings.TrimSuffix(request.RepoURL, "/"))
+	if err != nil {
+		return nil, &common.ValidationError{Err: fmt.Errorf("invalid repoURL: %v", err)}
+	}
+	gitType, err := detectGitType(request.GitType, repoURL.Hostname())
+	if err != nil {
+		return nil, &common.ValidationError{Err: err}
+	}
+	if gitType != GitTypeGit {
+		if owner, name := repoOwnerAndName(repoURL); owner == "" || name == "" {
+			return nil, &common.ValidationError{Err: fmt.Errorf("repoURL must contain an owner and a repository name")}
+		}
+	}
+	if strings.HasPrefix(request.Ref, "-") || strings.ContainsAny(request.Ref, "?#% \\") {
+		return nil, &common.ValidationError{Err: fmt.Errorf("invalid ref %q", request.Ref)}
+	}
+	contextDir := strings.TrimPrefix(path.Clean("/"+request.ContextDir), "/")
+
+	key := strings.Join([]string{gitType, repoURL.String(), request.Ref, contextDir}, "\x00")
+	if cached, ok := analysisCache.Get(key); ok {
+		return cached.(*AnalyzeResponse), nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), analyzeTimeout)
+	defer cancel()
+
+	ref := request.Ref
+	var source repositorySource
+	switch gitType {
+	case GitTypeGitHub:
+		source = &githubSource{repoURL: repoURL, ref: ref}
+	case GitTypeGitLab:
+		source = &gitlabSource{repoURL: repoURL, ref: ref}
+	case GitTypeBitbucket:
+		bitbucket := &bitbucketSource{repoURL: repoURL, ref: ref}
+		if err := bitbucket.resolveRef(ctx); err != nil {
+			return nil, err
+		}
+		source, ref = bitbucket, bitbucket.ref
+	default:
+		release, err := acquireCloneSlot(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		if source, ref, err = newGitSource(ctx, repoURL.String(), ref); err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := analyzeSource(ctx, source, contextDir)
+	if err != nil {
+		return nil, err
+	}
+	response.GitType = gitType
+	response.Ref = ref
+	analysisCache.Add(key, response, analysisCacheTTL)
+	return response, nil
+}
+
+// detectGitType returns the given git type, or the type of the well-known hosts.
+// Bitbucket Server has no source API like Bitbucket Cloud and is read with git.
+func detectGitType(gitType, host string) (string, error) {
+	switch gitType {
+	case GitTypeGitHub, GitTypeGitLab, GitTypeGit:
+		return gitType, nil
+	case GitTypeBitbucket:
+		if host != "bitbucket.org" {
+			return GitTypeGit, nil
+		}
+		return gitType, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unknown gitType %q", gitType)
+	}
+	switch host {
+	case "github.com":
+		return GitTypeGitHub, nil
+	case "gitlab.com":
+		return GitTypeGitLab, nil
+	case "bitbucket.org":
+		return GitTypeBitbucket, nil
+	}
+	return GitTypeGit, nil
+}
@@ -0,0 +1,382 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/pktline"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/openshift/console/pkg/devconsole/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/cache"
+)
+
+// mapSource is a repository with the files of a map.
+type mapSource map[string]string
+
+func (s mapSource) list(_ context.Context, dir string) ([]entry, error) {
+	seen := map[string]bool{}
+	var entries []entry
+	for filePath := range s {
+		rest := filePath
+		if dir != "" {
+			var ok bool
+			if rest, ok = strings.CutPrefix(filePath, dir+"/"); !ok {
+				continue
+			}
+		}
+		name, _, isDir := strings.Cut(rest, "/")
+		if !seen[name] {
+			seen[name] = true
+			entries = append(entries, entry{name: name, dir: isDir})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	return entries, nil
+}
+
+func (s mapSource) read(_ context.Context, filePath string) ([]byte, error) {
+	content, ok := s[filePath]
+	if !ok {
+		return nil, nil
+	}
+	return []byte(content), nil
+}
+
+func TestAnalyzeSource(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      mapSource
+		contextDir string
+		expected   AnalyzeResponse
+	}{
+		{
+			name: "node.js with react and express",
+			files: mapSource{
+				"package.json": `{"dependencies":{"react":"^18.0.0","express":"^4.0.0"}}`,
+				"index.html":   "<html></html>",
+			},
+			expected: AnalyzeResponse{
+				Strategy:      StrategyBuilderImage,
+				BuilderImages: []string{"nodejs", "nginx"},
+				Languages: []Language{
+					{Name: "nodejs", Frameworks: []string{"react", "express"}, Files: []string{"package.json"}},
+					{Name: "html", Frameworks: []string{}, Files: []string{"index.html"}},
+				},
+				TektonPipelines: []string{},
+			},
+		},
+		{
+			name: "dockerfile in a context dir",
+			files: mapSource{
+				"backend/Containerfile":    "FROM scratch",
+				"backend/pom.xml":          "<artifactId>quarkus-bom</artifactId><groupId>io.quarkus</groupId>",
+				"frontend/package.json":    "{}",
+				".tekton/push.yaml":        "kind: PipelineRun",
+				".tekton/pull-request.yml": "kind: PipelineRun",
+				".tekton/README.md":        "",
+			},
+			contextDir: "backend",
+			expected: AnalyzeResponse{
+				ContextDir:    "backend",
+				Strategy:      StrategyDocker,
+				Dockerfile:    "backend/Containerfile",
+				BuilderImages: []string{"java"},
+				Languages: []Language{
+					{Name: "java", Frameworks: []string{"quarkus"}, Files: []string{"pom.xml"}},
+				},
+				TektonPipelines: []string{".tekton/pull-request.yml", ".tekton/push.yaml"},
+			},
+		},
+		{
+			name: "devfile takes precedence over a dockerfile",
+			files: mapSource{
+				"Dockerfile":   "FROM scratch",
+				"devfile.yaml": "schemaVersion: 2.2.0",
+				"go.mod":       "module example.com/app",
+				"app.csproj":   "",
+				"Chart.yaml":   "name: app\nversion: 1.2.3\n",
+				"README.md":    "",
+			},
+			expected: AnalyzeResponse{
+				Strategy:      StrategyDevfile,
+				Dockerfile:    "Dockerfile",
+				Devfile:       "devfile.yaml",
+				BuilderImages: []string{"golang", "dotnet"},
+				Languages: []Language{
+					{Name: "golang", Frameworks: []string{}, Files: []string{"go.mod"}},
+					{Name: "dotnet", Frameworks: []string{}, Files: []string{"app.csproj"}},
+				},
+				HelmChart:       &HelmChart{Path: "Chart.yaml", Name: "app", Version: "1.2.3"},
+				TektonPipelines: []string{},
+			},
+		},
+		{
+			name: "serverless function",
+			files: mapSource{
+				"func.yaml":        "specVersion: 0.36.0\nruntime: python\n",
+				"Dockerfile":       "FROM scratch",
+				"requirements.txt": "Flask==3.0.0",
+			},
+			expected: AnalyzeResponse{
+				Strategy:      StrategyServerlessFunction,
+				Dockerfile:    "Dockerfile",
+				Function:      &Function{Path: "func.yaml", Runtime: "python"},
+				BuilderImages: []string{"python"},
+				Languages: []Language{
+					{Name: "python", Frameworks: []string{"flask"}, Files: []string{"requirements.txt"}},
+				},
+				TektonPipelines: []string{},
+			},
+		},
+		{
+			name:  "nothing recognized",
+			files: mapSource{"README.md": "# app"},
+			expected: AnalyzeResponse{
+				BuilderImages:   []string{},
+				Languages:       []Language{},
+				TektonPipelines: []string{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := analyzeSource(context.Background(), tt.files, tt.contextDir)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, *response)
+		})
+	}
+}
+
+func TestAnalyzeSourceMissingContextDir(t *testing.T) {
+	_, err := analyzeSource(context.Background(), mapSource{"package.json": "{}"}, "missing")
+	var upstreamErr *common.UpstreamError
+	require.ErrorAs(t, err, &upstreamErr)
+	assert.Equal(t, http.StatusNotFound, upstreamErr.StatusCode)
+}
+
+// allowTestServer lets the analysis reach the local test servers.
+func allowTestServer(t *testing.T, ts *httptest.Server) {
+	originalClient, originalValidateURL, originalCache := client, validateURL, analysisCache
+	client = ts.Client()
+	validateURL = url.Parse
+	analysisCache = cache.NewLRUExpireCache(analysisCacheSize)
+	t.Cleanup(func() {
+		client, validateURL, analysisCache = originalClient, originalValidateURL, originalCache
+	})
+}
+
+func analyzeRequest(body string) (*AnalyzeResponse, error) {
+	request := httptest.NewRequest(http.MethodPost, "/api/dev-console/git/analyze", strings.NewReader(body))
+	return Analyze(request)
+}
+
+func TestAnalyzeGitHub(t *testing.T) {
+	var requests []string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path+"?"+r.URL.RawQuery+" "+r.Header.Get("Accept"))
+		switch r.URL.Path {
+		case "/api/v3/repos/owner/repo/contents/app":
+			io.WriteString(w, `[{"name":"package.json","type":"file"},{"name":"src","type":"dir"}]`)
+		case "/api/v3/repos/owner/repo/contents/app/package.json":
+			io.WriteString(w, `{"dependencies":{"next":"14.0.0","react":"18.0.0"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	allowTestServer(t, ts)
+
+	response, err := analyzeRequest(`{"repoURL":"` + ts.URL + `/owner/repo.git","ref":"main","contextDir":"/app/","gitType":"github"}`)
+	require.NoError(t, err)
+	assert.Equal(t, GitTypeGitHub, response.GitType)
+	assert.Equal(t, "main", response.Ref)
+	assert.Equal(t, "app", response.ContextDir)
+	assert.Equal(t, StrategyBuilderImage, response.Strategy)
+	assert.Equal(t, []Language{{Name: "nodejs", Frameworks: []string{"nextjs", "react"}, Files: []string{"package.json"}}}, response.Languages)
+	assert.Equal(t, []string{
+		"/api/v3/repos/owner/repo/contents/app?ref=main application/json",
+		"/api/v3/repos/owner/repo/contents/app/package.json?ref=main application/vnd.github.raw",
+		"/api/v3/repos/owner/repo/contents/.tekton?ref=main application/json",
+	}, requests)
+
+	// the analysis is cached
+	_, err = analyzeRequest(`{"repoURL":"` + ts.URL + `/owner/repo.git","ref":"main","contextDir":"app","gitType":"github"}`)
+	require.NoError(t, err)
+	assert.Len(t, requests, 3)
+}
+
+// newGitServer serves a repository with the smart HTTP protocol of git. The server of
+// go-git supports neither shallow nor partial clones.
+func newGitServer(t *testing.T, files map[string]string) *httptest.Server {
+	storage := memory.NewStorage()
+	worktreeFS := memfs.New()
+	repository, err := gogit.Init(storage, worktreeFS)
+	require.NoError(t, err)
+	worktree, err := repository.Worktree()
+	require.NoError(t, err)
+	for filePath, content := range files {
+		require.NoError(t, worktreeFS.MkdirAll(path.Dir(filePath), 0755))
+		file, err := worktreeFS.Create(filePath)
+		require.NoError(t, err)
+		_, err = file.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+		_, err = worktree.Add(filePath)
+		require.NoError(t, err)
+	}
+	_, err = worktree.Commit("initial commit", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+
+	loader := server.MapLoader{}
+	gitServer := server.NewServer(loader)
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint, err := transport.NewEndpoint("https://" + r.Host + "/repo.git")
+		require.NoError(t, err)
+		loader[endpoint.String()] = storage
+
+		session, err := gitServer.NewUploadPackSession(endpoint, nil)
+		require.NoError(t, err)
+		switch r.URL.Path {
+		case "/repo.git/info/refs":
+			refs, err := session.AdvertisedReferencesContext(r.Context())
+			require.NoError(t, err)
+			w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+			encoder := pktline.NewEncoder(w)
+			require.NoError(t, encoder.EncodeString("# service=git-upload-pack\n"))
+			require.NoError(t, encoder.Flush())
+			require.NoError(t, refs.Encode(w))
+		case "/repo.git/git-upload-pack":
+			request := packp.NewUploadPackRequest()
+			require.NoError(t, request.UploadRequest.Decode(r.Body))
+			response, err := session.UploadPack(r.Context(), request)
+			require.NoError(t, err)
+			w.Header().Set("Content-Type", "application/x-git-upload-pack-result")
+			var body bytes.Buffer
+			require.NoError(t, response.Encode(&body))
+			w.Write(body.Bytes())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestAnalyzeGit(t *testing.T) {
+	ts := newGitServer(t, map[string]string{
+		"Gemfile":           "gem 'rails', '~> 7.1'",
+		"config.ru":         "run Rails.application",
+		".devfile.yaml":     "schemaVersion: 2.2.0",
+		".tekton/push.yaml": "kind: PipelineRun",
+		"docs/index.html":   "<html></html>",
+	})
+	allowTestServer(t, ts)
+
+	response, err := analyzeRequest(`{"repoURL":"` + ts.URL + `/repo.git"}`)
+	require.NoError(t, err)
+	assert.Equal(t, GitTypeGit, response.GitType)
+	assert.Equal(t, "HEAD", response.Ref)
+	assert.Equal(t, StrategyDevfile, response.Strategy)
+	assert.Equal(t, ".devfile.yaml", response.Devfile)
+	assert.Equal(t, []Language{{Name: "ruby", Frameworks: []string{"rails"}, Files: []string{"Gemfile", "config.ru"}}}, response.Languages)
+	assert.Equal(t, []string{".tekton/push.yaml"}, response.TektonPipelines)
+
+	response, err = analyzeRequest(`{"repoURL":"` + ts.URL + `/repo.git","ref":"master","contextDir":"docs"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "master", response.Ref)
+	assert.Equal(t, []string{"nginx"}, response.BuilderImages)
+
+	_, err = analyzeRequest(`{"repoURL":"` + ts.URL + `/repo.git","ref":"missing"}`)
+	var validationErr *common.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+
+	_, err = analyzeRequest(`{"repoURL":"` + ts.URL + `/repo.git","contextDir":"missing"}`)
+	var upstreamErr *common.UpstreamError
+	require.ErrorAs(t, err, &upstreamErr)
+	assert.Equal(t, http.StatusNotFound, upstreamErr.StatusCode)
+}
+
+func TestAcquireCloneSlot(t *testing.T) {
+	var releases []func()
+	for i := 0; i < cap(cloneSlots); i++ {
+		release, err := acquireCloneSlot(t.Context())
+		require.NoError(t, err)
+		releases = append(releases, release)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	_, err := acquireCloneSlot(ctx)
+	var upstreamErr *common.UpstreamError
+	require.ErrorAs(t, err, &upstreamErr)
+	assert.Equal(t, http.StatusServiceUnavailable, upstreamErr.StatusCode)
+
+	releases[0]()
+	release, err := acquireCloneSlot(t.Context())
+	require.NoError(t, err)
+	release()
+	for _, release := range releases[1:] {
+		release()
+	}
+}
+
+func TestAnalyzeValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "invalid JSON", body: `{`},
+		{name: "http URL", body: `{"repoURL":"http://github.com/owner/repo"}`},
+		{name: "internal host", body: `{"repoURL":"https://kubernetes.default.svc/owner/repo"}`},
+		{name: "missing repository name", body: `{"repoURL":"https://github.com/owner"}`},
+		{name: "unknown git type", body: `{"repoURL":"https://example.com/owner/repo","gitType":"svn"}`},
+		{name: "invalid ref", body: `{"repoURL":"https://github.com/owner/repo","ref":"main?x=1"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := analyzeRequest(tt.body)
+			var validationErr *common.ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+		})
+	}
+}
+
+func TestDetectGitType(t *testing.T) {
+	tests := []struct {
+		gitType  string
+		host     string
+		expected string
+	}{
+		{host: "github.com", expected: GitTypeGitHub},
+		{host: "gitlab.com", expected: GitTypeGitLab},
+		{host: "bitbucket.org", expected: GitTypeBitbucket},
+		{host: "git.example.com", expected: GitTypeGit},
+		{gitType: GitTypeGitLab, host: "git.example.com", expected: GitTypeGitLab},
+		{gitType: GitTypeBitbucket, host: "bitbucket.example.com", expected: GitTypeGit},
+		{gitType: GitTypeGit, host: "github.com", expected: GitTypeGit},
+	}
+	for _, tt := range tests {
+		gitType, err := detectGitType(tt.gitType, tt.host)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, gitType, "%s on %s", tt.gitType, tt.host)
+	}
+}
@@ -0,0 +1,269 @@
+package git
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/openshift/console/pkg/devconsole/common"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	StrategyServerlessFunction = "serverless-function"
+	StrategyDevfile            = "devfile"
+	StrategyDocker             = "docker"
+	StrategyBuilderImage       = "builder-image"
+
+	tektonDir = ".tekton"
+)
+
+var (
+	dockerfileNames = []string{"Dockerfile", "Containerfile"}
+	devfileNames    = []string{"devfile.yaml", ".devfile.yaml", "devfile.yml", ".devfile.yml"}
+)
+
+// framework is detected by a string in the file a language was detected by.
+type framework struct {
+	name    string
+	file    string
+	pattern string
+}
+
+// languageRule detects a language by the names of files in the context dir. The rules are
+// ordered by priority, e.g. a Node.js application usually contains HTML files as well.
+type languageRule struct {
+	name         string
+	builderImage string
+	files        []string
+	suffixes     []string
+	frameworks   []framework
+}
+
+var languageRules = []languageRule{
+	{
+		name:         "nodejs",
+		builderImage: "nodejs",
+		files:        []string{"package.json"},
+		frameworks: []framework{
+			{"nextjs", "package.json", `"next"`},
+			{"nuxt", "package.json", `"nuxt"`},
+			{"angular", "package.json", `"@angular/core"`},
+			{"react", "package.json", `"react"`},
+			{"vue", "package.json", `"vue"`},
+			{"nestjs", "package.json", `"@nestjs/core"`},
+			{"express", "package.json", `"express"`},
+		},
+	},
+	{
+		name:         "java",
+		builderImage: "java",
+		files:        []string{"pom.xml", "build.gradle", "build.gradle.kts"},
+		frameworks: []framework{
+			{"quarkus", "pom.xml", "io.quarkus"},
+			{"quarkus", "build.gradle", "io.quarkus"},
+			{"spring-boot", "pom.xml", "spring-boot"},
+			{"spring-boot", "build.gradle", "org.springframework.boot"},
+		},
+	},
+	{
+		name:         "golang",
+		builderImage: "golang",
+		files:        []string{"go.mod", "main.go"},
+	},
+	{
+		name:         "python",
+		builderImage: "python",
+		files:        []string{"requirements.txt", "Pipfile", "pyproject.toml", "setup.py", "app.py"},
+		frameworks: []framework{
+			{"django", "requirements.txt", "django"},
+			{"flask", "requirements.txt", "flask"},
+			{"fastapi", "requirements.txt", "fastapi"},
+			{"django", "pyproject.toml", "django"},
+			{"flask", "pyproject.toml", "flask"},
+			{"fastapi", "pyproject.toml", "fastapi"},
+		},
+	},
+	{
+		name:         "ruby",
+		builderImage: "ruby",
+		files:        []string{"Gemfile", "Rakefile", "config.ru"},
+		frameworks: []framework{
+			{"rails", "Gemfile", "rails"},
+		},
+	},
+	{
+		name:         "php",
+		builderImage: "php",
+		files:        []string{"composer.json", "index.php"},
+		frameworks: []framework{
+			{"laravel", "composer.json", "laravel/framework"},
+			{"symfony", "composer.json", "symfony/framework-bundle"},
+		},
+	},
+	{
+		name:         "dotnet",
+		builderImage: "dotnet",
+		suffixes:     []string{".csproj", ".fsproj", ".sln"},
+	},
+	{
+		name:         "perl",
+		builderImage: "perl",
+		files:        []string{"cpanfile", "index.pl"},
+	},
+	{
+		name:         "html",
+		builderImage: "nginx",
+		files:        []string{"index.html"},
+	},
+}
+
+// analyzeSource inspects the context dir of a repository and the pipelines in its
+// .tekton directory.
+func analyzeSource(ctx context.Context, source repositorySource, contextDir string) (*AnalyzeResponse, error) {
+	entries, err := source.list(ctx, contextDir)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		return nil, &common.UpstreamError{StatusCode: http.StatusNotFound, Body: "repository or context dir not found"}
+	}
+	files := map[string]bool{}
+	for _, entry := range entries {
+		if !entry.dir {
+			files[entry.name] = true
+		}
+	}
+
+	response := &AnalyzeResponse{
+		ContextDir:      contextDir,
+		BuilderImages:   []string{},
+		Languages:       []Language{},
+		TektonPipelines: []string{},
+	}
+	contents := map[string][]byte{}
+	read := func(name string) ([]byte, error) {
+		if content, ok := contents[name]; ok {
+			return content, nil
+		}
+		content, err := source.read(ctx, path.Join(contextDir, name))
+		contents[name] = content
+		return content, err
+	}
+
+	if name := firstFile(files, dockerfileNames); name != "" {
+		response.Dockerfile = path.Join(contextDir, name)
+	}
+	if name := firstFile(files, devfileNames); name != "" {
+		response.Devfile = path.Join(contextDir, name)
+	}
+	if files["func.yaml"] {
+		response.Function = &Function{Path: path.Join(contextDir, "func.yaml")}
+		content, err := read("func.yaml")
+		if err != nil {
+			return nil, err
+		}
+		var function struct {
+			Runtime string `json:"runtime"`
+		}
+		if yaml.Unmarshal(content, &function) == nil {
+			response.Function.Runtime = function.Runtime
+		}
+	}
+	if files["Chart.yaml"] {
+		response.HelmChart = &HelmChart{Path: path.Join(contextDir, "Chart.yaml")}
+		content, err := read("Chart.yaml")
+		if err != nil {
+			return nil, err
+		}
+		var chart struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		}
+		if yaml.Unmarshal(content, &chart) == nil {
+			response.HelmChart.Name = chart.Name
+			response.HelmChart.Version = chart.Version
+		}
+	}
+
+	for _, rule := range languageRules {
+		language, err := rule.detect(files, read)
+		if err != nil {
+			return nil, err
+		}
+		if language != nil {
+			response.Languages = append(response.Languages, *language)
+			if !slices.Contains(response.BuilderImages, rule.builderImage) {
+				response.BuilderImages = append(response.BuilderImages, rule.builderImage)
+			}
+		}
+	}
+
+	// Pipelines as Code reads the pipelines from the root of the repository
+	tektonEntries, err := source.list(ctx, tektonDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range tektonEntries {
+		if !entry.dir && (strings.HasSuffix(entry.name, ".yaml") || strings.HasSuffix(entry.name, ".yml")) {
+			response.TektonPipelines = append(response.TektonPipelines, path.Join(tektonDir, entry.name))
+		}
+	}
+
+	switch {
+	case response.Function != nil:
+		response.Strategy = StrategyServerlessFunction
+	case response.Devfile != "":
+		response.Strategy = StrategyDevfile
+	case response.Dockerfile != "":
+		response.Strategy = StrategyDocker
+	case len(response.BuilderImages) > 0:
+		response.Strategy = StrategyBuilderImage
+	}
+	return response, nil
+}
+
+func firstFile(files map[string]bool, names []string) string {
+	for _, name := range names {
+		if files[name] {
+			return name
+		}
+	}
+	return ""
+}
+
+func (rule *languageRule) detect(files map[string]bool, read func(string) ([]byte, error)) (*Language, error) {
+	language := &Language{Name: rule.name, Frameworks: []string{}, Files: []string{}}
+	for _, name := range rule.files {
+		if files[name] {
+			language.Files = append(language.Files, name)
+		}
+	}
+	for _, suffix := range rule.suffixes {
+		for name := range files {
+			if strings.HasSuffix(name, suffix) {
+				language.Files = append(language.Files, name)
+			}
+		}
+	}
+	if len(language.Files) == 0 {
+		return nil, nil
+	}
+	slices.Sort(language.Files)
+
+	for _, framework := range rule.frameworks {
+		if !files[framework.file] || slices.Contains(language.Frameworks, framework.name) {
+			continue
+		}
+		content, err := read(framework.file)
+		if err != nil {
+			return nil, err
+		}
+		if strings.Contains(strings.ToLower(string(content)), strings.ToLower(framework.pattern)) {
+			language.Frameworks = append(language.Frameworks, framework.name)
+		}
+	}
+	return language, nil
+}
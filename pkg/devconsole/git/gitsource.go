@@ -0,0 +1,179 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp/capability"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/openshift/console/pkg/devconsole/common"
+)
+
+// maxPackSize bounds the packfile fetched from a git server. Servers that support partial
+// clones only send the trees and small files of a single commit.
+var maxPackSize int64 = 64 * 1024 * 1024
+
+var errPackTooLarge = errors.New("repository is too large to be analyzed")
+
+// cloneSlots bounds the repositories that are held in memory at the same time, to four
+// times maxPackSize.
+var cloneSlots = make(chan struct{}, 4)
+
+// acquireCloneSlot waits for a clone slot until the context is done. The returned function
+// releases the slot, once the fetched repository is no longer used.
+func acquireCloneSlot(ctx context.Context) (func(), error) {
+	select {
+	case cloneSlots <- struct{}{}:
+		return func() { <-cloneSlots }, nil
+	case <-ctx.Done():
+		return nil, &common.UpstreamError{StatusCode: http.StatusServiceUnavailable, Body: "too many repositories are being analyzed, try again later"}
+	}
+}
+
+// gitSource reads a repository with the smart HTTP protocol of git. The tree of the ref is
+// fetched into memory once.
+type gitSource struct {
+	tree *object.Tree
+}
+
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		return 0, errPackTooLarge
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+func newGitSource(ctx context.Context, repoURL string, ref string) (*gitSource, string, error) {
+	endpoint, err := transport.NewEndpoint(repoURL)
+	if err != nil {
+		return nil, "", &common.ValidationError{Err: fmt.Errorf("invalid repoURL: %v", err)}
+	}
+	session, err := githttp.NewClient(client).NewUploadPackSession(endpoint, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer session.Close()
+
+	refs, err := session.AdvertisedReferencesContext(ctx)
+	if err != nil {
+		if errors.Is(err, transport.ErrRepositoryNotFound) || errors.Is(err, transport.ErrAuthenticationRequired) {
+			return nil, "", &common.UpstreamError{StatusCode: http.StatusNotFound, Body: err.Error()}
+		}
+		return nil, "", fmt.Errorf("failed to list the references of the repository: %v", err)
+	}
+	hash, resolvedRef, err := resolveAdvertisedRef(refs, ref)
+	if err != nil {
+		return nil, "", err
+	}
+
+	request := packp.NewUploadPackRequestFromCapabilities(refs.Capabilities)
+	// the packfile is read directly, without progress messages
+	request.Capabilities.Delete(capability.Sideband64k)
+	request.Capabilities.Delete(capability.Sideband)
+	request.Wants = []plumbing.Hash{hash}
+	if refs.Capabilities.Supports(capability.Shallow) {
+		request.Capabilities.Set(capability.Shallow)
+		request.Depth = packp.DepthCommits(1)
+	}
+	if refs.Capabilities.Supports(capability.Filter) {
+		request.Capabilities.Set(capability.Filter)
+		request.Filter = packp.FilterBlobLimit(maxFileSize, "")
+	}
+
+	response, err := session.UploadPack(ctx, request)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch the repository: %v", err)
+	}
+	defer response.Close()
+
+	storage := memory.NewStorage()
+	if err := packfile.UpdateObjectStorage(storage, &limitedReader{r: response, remaining: maxPackSize}); err != nil {
+		if errors.Is(err, errPackTooLarge) {
+			return nil, "", &common.ValidationError{Err: err}
+		}
+		return nil, "", fmt.Errorf("failed to read the repository: %v", err)
+	}
+
+	commit, err := object.GetCommit(storage, hash)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read commit %s: %v", hash, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read the tree of commit %s: %v", hash, err)
+	}
+	return &gitSource{tree: tree}, resolvedRef, nil
+}
+
+// resolveAdvertisedRef finds a branch, tag or commit, or HEAD if the ref is empty.
+func resolveAdvertisedRef(refs *packp.AdvRefs, ref string) (plumbing.Hash, string, error) {
+	if ref == "" {
+		if refs.Head == nil {
+			return plumbing.ZeroHash, "", &common.ValidationError{Err: fmt.Errorf("the repository has no HEAD, a ref is required")}
+		}
+		return *refs.Head, "HEAD", nil
+	}
+	if plumbing.IsHash(ref) {
+		// servers like GitHub allow fetching any commit
+		return plumbing.NewHash(ref), ref, nil
+	}
+	for _, name := range []string{ref, "refs/heads/" + ref, "refs/tags/" + ref} {
+		// annotated tags are peeled to their commit
+		if hash, ok := refs.Peeled[name]; ok {
+			return hash, ref, nil
+		}
+		if hash, ok := refs.References[name]; ok {
+			return hash, ref, nil
+		}
+	}
+	return plumbing.ZeroHash, "", &common.ValidationError{Err: fmt.Errorf("ref %q not found", ref)}
+}
+
+func (s *gitSource) list(_ context.Context, dir string) ([]entry, error) {
+	tree := s.tree
+	if dir != "" {
+		var err error
+		if tree, err = s.tree.Tree(dir); err != nil {
+			return nil, nil
+		}
+	}
+	entries := make([]entry, 0, len(tree.Entries))
+	for _, treeEntry := range tree.Entries {
+		entries = append(entries, entry{name: treeEntry.Name, dir: !treeEntry.Mode.IsFile()})
+	}
+	return entries, nil
+}
+
+func (s *gitSource) read(_ context.Context, filePath string) ([]byte, error) {
+	// files that are filtered out by the server are missing
+	file, err := s.tree.File(path.Clean(filePath))
+	if err != nil || file.Size > maxFileSize {
+		return nil, nil
+	}
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, nil
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,264 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/openshift/console/pkg/devconsole/common"
+	"github.com/openshift/console/pkg/devconsole/webhooks"
+)
+
+const (
+	GitTypeGitHub    = "github"
+	GitTypeGitLab    = "gitlab"
+	GitTypeBitbucket = "bitbucket"
+	GitTypeGit       = "git"
+
+	// maxFileSize bounds the files read to detect frameworks, larger files are ignored
+	maxFileSize = 512 * 1024
+)
+
+var (
+	client      = webhooks.NewSafeHTTPClient()
+	validateURL = webhooks.ValidateHostURL
+
+	// bitbucketAPIURL is the API of Bitbucket Cloud, Bitbucket Server is analyzed with git
+	bitbucketAPIURL = "https://api.bitbucket.org/2.0"
+)
+
+// entry is a file or directory of a repository.
+type entry struct {
+	name string
+	dir  bool
+}
+
+// repositorySource reads the files of a repository at a ref.
+type repositorySource interface {
+	// list returns the entries of a directory, nil if it does not exist
+	list(ctx context.Context, dir string) ([]entry, error)
+	// read returns the content of a file, nil if it does not exist or is too large
+	read(ctx context.Context, path string) ([]byte, error)
+}
+
+// get sends a GET request and returns the body of the response, or nil if it was not found.
+func get(ctx context.Context, requestURL *url.URL, accept string) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	request.Header.Set("Accept", accept)
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case response.StatusCode != http.StatusOK:
+		return nil, &common.UpstreamError{StatusCode: response.StatusCode, Body: string(body)}
+	case len(body) > maxFileSize:
+		return nil, nil
+	}
+	return body, nil
+}
+
+func getJSON(ctx context.Context, requestURL *url.URL, result interface{}) (bool, error) {
+	body, err := get(ctx, requestURL, "application/json")
+	if err != nil || body == nil {
+		return false, err
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return false, fmt.Errorf("failed to parse response: %v", err)
+	}
+	return true, nil
+}
+
+// withQuery returns the URL with the query parameters that are not empty.
+func withQuery(u *url.URL, params ...string) *url.URL {
+	query := u.Query()
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i+1] != "" {
+			query.Set(params[i], params[i+1])
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u
+}
+
+// githubSource reads a repository with the contents API of GitHub or GitHub Enterprise.
+type githubSource struct {
+	repoURL *url.URL
+	ref     string
+}
+
+func (s *githubSource) apiURL(elem ...string) *url.URL {
+	apiURL := &url.URL{Scheme: "https", Host: s.repoURL.Host, Path: "/api/v3"}
+	if s.repoURL.Host == "github.com" {
+		apiURL = &url.URL{Scheme: "https", Host: "api.github.com"}
+	}
+	owner, name := repoOwnerAndName(s.repoURL)
+	return apiURL.JoinPath(append([]string{"repos", owner, name, "contents"}, elem...)...)
+}
+
+func (s *githubSource) list(ctx context.Context, dir string) ([]entry, error) {
+	var contents []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+	body, err := get(ctx, withQuery(s.apiURL(dir), "ref", s.ref), "application/json")
+	if err != nil || body == nil {
+		return nil, err
+	}
+	// the contents of a file are an object rather than a list
+	if err := json.Unmarshal(body, &contents); err != nil {
+		return nil, nil
+	}
+	entries := make([]entry, 0, len(contents))
+	for _, content := range contents {
+		entries = append(entries, entry{name: content.Name, dir: content.Type == "dir"})
+	}
+	return entries, nil
+}
+
+func (s *githubSource) read(ctx context.Context, path string) ([]byte, error) {
+	return get(ctx, withQuery(s.apiURL(path), "ref", s.ref), "application/vnd.github.raw")
+}
+
+// gitlabSource reads a repository with the repository API of GitLab.
+type gitlabSource struct {
+	repoURL *url.URL
+	ref     string
+}
+
+func (s *gitlabSource) apiURL(elem ...string) *url.URL {
+	// the path of the project and the path of a file are single elements of the URL
+	apiURL := &url.URL{Scheme: "https", Host: s.repoURL.Host}
+	project := strings.Trim(strings.TrimSuffix(s.repoURL.Path, ".git"), "/")
+	rawPath := "/api/v4/projects/" + url.PathEscape(project) + "/repository"
+	for _, e := range elem {
+		rawPath += "/" + url.PathEscape(e)
+	}
+	apiURL.Path, _ = url.PathUnescape(rawPath)
+	apiURL.RawPath = rawPath
+	return apiURL
+}
+
+func (s *gitlabSource) list(ctx context.Context, dir string) ([]entry, error) {
+	var tree []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+	// a missing directory is an empty tree, only the root of a repository cannot be empty
+	found, err := getJSON(ctx, withQuery(s.apiURL("tree"), "path", dir, "ref", s.ref, "per_page", "100"), &tree)
+	if err != nil || !found || (len(tree) == 0 && dir != "") {
+		return nil, err
+	}
+	entries := make([]entry, 0, len(tree))
+	for _, item := range tree {
+		entries = append(entries, entry{name: item.Name, dir: item.Type == "tree"})
+	}
+	return entries, nil
+}
+
+func (s *gitlabSource) read(ctx context.Context, path string) ([]byte, error) {
+	fileURL := s.apiURL("files", path)
+	fileURL.Path += "/raw"
+	fileURL.RawPath += "/raw"
+	return get(ctx, withQuery(fileURL, "ref", s.ref), "*/*")
+}
+
+// bitbucketSource reads a repository with the source API of Bitbucket Cloud.
+type bitbucketSource struct {
+	repoURL *url.URL
+	ref     string
+}
+
+func (s *bitbucketSource) apiURL(elem ...string) (*url.URL, error) {
+	apiURL, err := url.Parse(bitbucketAPIURL)
+	if err != nil {
+		return nil, err
+	}
+	owner, name := repoOwnerAndName(s.repoURL)
+	return apiURL.JoinPath(append([]string{"repositories", owner, name}, elem...)...), nil
+}
+
+// resolveRef looks up the main branch, since the source API requires a ref.
+func (s *bitbucketSource) resolveRef(ctx context.Context) error {
+	if s.ref != "" {
+		return nil
+	}
+	repoURL, err := s.apiURL()
+	if err != nil {
+		return err
+	}
+	var repository struct {
+		MainBranch struct {
+			Name string `json:"name"`
+		} `json:"mainbranch"`
+	}
+	found, err := getJSON(ctx, repoURL, &repository)
+	if err != nil {
+		return err
+	}
+	if !found || repository.MainBranch.Name == "" {
+		return &common.UpstreamError{StatusCode: http.StatusNotFound, Body: "repository not found"}
+	}
+	s.ref = repository.MainBranch.Name
+	return nil
+}
+
+func (s *bitbucketSource) list(ctx context.Context, dir string) ([]entry, error) {
+	if err := s.resolveRef(ctx); err != nil {
+		return nil, err
+	}
+	srcURL, err := s.apiURL("src", s.ref, dir)
+	if err != nil {
+		return nil, err
+	}
+	// directories are listed if the path ends with a slash
+	srcURL.Path += "/"
+	var src struct {
+		Values []struct {
+			Path string `json:"path"`
+			Type string `json:"type"`
+		} `json:"values"`
+	}
+	found, err := getJSON(ctx, withQuery(srcURL, "pagelen", "100"), &src)
+	if err != nil || !found {
+		return nil, err
+	}
+	entries := make([]entry, 0, len(src.Values))
+	for _, value := range src.Values {
+		entries = append(entries, entry{name: value.Path[strings.LastIndex(value.Path, "/")+1:], dir: value.Type == "commit_directory"})
+	}
+	return entries, nil
+}
+
+func (s *bitbucketSource) read(ctx context.Context, path string) ([]byte, error) {
+	if err := s.resolveRef(ctx); err != nil {
+		return nil, err
+	}
+	srcURL, err := s.apiURL("src", s.ref, path)
+	if err != nil {
+		return nil, err
+	}
+	return get(ctx, srcURL, "*/*")
+}
+
+// repoOwnerAndName splits the path of a repository URL like /owner/name.git.
+func repoOwnerAndName(repoURL *url.URL) (string, string) {
+	owner, name, _ := strings.Cut(strings.Trim(strings.TrimSuffix(repoURL.Path, ".git"), "/"), "/")
+	return owner, name
+}
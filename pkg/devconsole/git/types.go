@@ -0,0 +1,47 @@
+package git
+
+type AnalyzeRequest struct {
+	RepoURL string `json:"repoURL" validate:"required"`
+	// Ref is a branch, tag or commit, the default branch if empty
+	Ref        string `json:"ref"`
+	ContextDir string `json:"contextDir"`
+	// GitType is github, gitlab, bitbucket or git. It is detected from the host of the
+	// URL if empty, self-hosted GitHub and GitLab servers have to be given explicitly.
+	GitType string `json:"gitType" validate:"oneof=github|gitlab|bitbucket|git"`
+}
+
+// AnalyzeResponse describes what was found in the context dir of a repository.
+type AnalyzeResponse struct {
+	GitType    string `json:"gitType"`
+	Ref        string `json:"ref,omitempty"`
+	ContextDir string `json:"contextDir"`
+	// Strategy is the recommended import strategy: serverless-function, devfile, docker,
+	// builder-image or empty if nothing was recognized
+	Strategy string `json:"strategy"`
+	// BuilderImages are the recommended builder images, the best match first
+	BuilderImages   []string   `json:"builderImages"`
+	Languages       []Language `json:"languages"`
+	Dockerfile      string     `json:"dockerfile,omitempty"`
+	Devfile         string     `json:"devfile,omitempty"`
+	Function        *Function  `json:"function,omitempty"`
+	HelmChart       *HelmChart `json:"helmChart,omitempty"`
+	TektonPipelines []string   `json:"tektonPipelines"`
+}
+
+type Language struct {
+	Name       string   `json:"name"`
+	Frameworks []string `json:"frameworks"`
+	// Files are the files the language was detected by
+	Files []string `json:"files"`
+}
+
+type Function struct {
+	Path    string `json:"path"`
+	Runtime string `json:"runtime,omitempty"`
+}
+
+type HelmChart struct {
+	Path    string `json:"path"`
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+}
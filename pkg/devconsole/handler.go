@@ -8,94 +8,251 @@ import (
 	"github.com/openshift/console/pkg/auth"
 	"github.com/openshift/console/pkg/devconsole/artifacthub"
 	"github.com/openshift/console/pkg/devconsole/common"
+	"github.com/openshift/console/pkg/devconsole/git"
 	tektonresults "github.com/openshift/console/pkg/devconsole/tekton-results"
 	"github.com/openshift/console/pkg/devconsole/webhooks"
 	"github.com/openshift/console/pkg/serverutils"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
 )
 
 type handlerFunc func(r *http.Request, user *auth.User, dynamicClient *dynamic.DynamicClient, k8sMode string, proxyHeaderDenyList []string) (interface{}, error)
 
+// streamHandlerFunc writes the response itself, for GET requests and responses that are too
+// large to buffer
+type streamHandlerFunc func(w http.ResponseWriter, r *http.Request, user *auth.User, dynamicClient *dynamic.DynamicClient, k8sMode string)
+
 func handleRequest(w http.ResponseWriter, r *http.Request, user *auth.User, dynamicClient *dynamic.DynamicClient, k8sMode string, proxyHeaderDenyList []string, handler handlerFunc) {
 	response, err := handler(r, user, dynamicClient, k8sMode, proxyHeaderDenyList)
 	if err != nil {
+		var requestErr *serverutils.RequestError
+		if errors.As(err, &requestErr) {
+			serverutils.SendRequestError(w, r, err)
+			return
+		}
 		var validationErr *common.ValidationError
 		if errors.As(err, &validationErr) {
-			serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: err.Error()})
+			serverutils.SendError(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: err.Error()})
+		var upstreamErr *common.UpstreamError
+		if errors.As(err, &upstreamErr) {
+			serverutils.SendAPIError(w, r, upstreamErr.ResponseStatusCode(), serverutils.ApiError{Err: err.Error(), Retryable: upstreamErr.Retryable()})
+			return
+		}
+		serverutils.SendUpstreamError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 	serverutils.SendResponse(w, http.StatusOK, response)
 }
 
-func Handler(user *auth.User, w http.ResponseWriter, r *http.Request, dynamicClient *dynamic.DynamicClient, k8sMode string, proxyHeaderDenyList []string) {
-	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-	if len(path) != 2 {
-		serverutils.SendResponse(w, http.StatusNotFound, serverutils.ApiError{Err: "Invalid URL"})
-		return
-	}
+// route is a POST route of the dev console, with the operation that documents it.
+type route struct {
+	handler   handlerFunc
+	operation serverutils.Operation
+}
+
+// getRoute is a GET route of the dev console, with the operation that documents it.
+type getRoute struct {
+	handler   streamHandlerFunc
+	operation serverutils.Operation
+}
 
-	// Define routes
-	routes := map[string]map[string]handlerFunc{
+// postRoutes are the routes of the dev console that take a JSON request and respond with JSON.
+func postRoutes(anonClientConfig *rest.Config) map[string]map[string]route {
+	return map[string]map[string]route{
 		"artifacthub": {
 			// POST /api/dev-console/artifacthub/search
-			"search": func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string, _ []string) (interface{}, error) {
-				return artifacthub.SearchTasks(r, user)
+			"search": {
+				operation: serverutils.Operation{Summary: "Search the tasks of Artifact Hub", Request: artifacthub.SearchRequest{}, Response: common.DevConsoleCommonResponse{}},
+				handler: func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string, _ []string) (interface{}, error) {
+					return artifacthub.SearchTasks(r, user)
+				},
 			},
 			// POST /api/dev-console/artifacthub/get
-			"get": func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string, _ []string) (interface{}, error) {
-				return artifacthub.GetTaskDetails(r, user)
+			"get": {
+				operation: serverutils.Operation{Summary: "Get the details of an Artifact Hub task", Request: artifacthub.TaskDetailsRequest{}, Response: common.DevConsoleCommonResponse{}},
+				handler: func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string, _ []string) (interface{}, error) {
+					return artifacthub.GetTaskDetails(r, user)
+				},
 			},
 			// POST /api/dev-console/artifacthub/yaml
-			"yaml": func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string, _ []string) (interface{}, error) {
-				return artifacthub.GetTaskYAMLFromGithub(r, user)
+			"yaml": {
+				operation: serverutils.Operation{Summary: "Get the YAML of an Artifact Hub task", Request: artifacthub.TaskYAMLRequest{}, Response: common.DevConsoleCommonResponse{}},
+				handler: func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string, _ []string) (interface{}, error) {
+					return artifacthub.GetTaskYAMLFromGithub(r, user)
+				},
+			},
+		},
+		"git": {
+			// POST /api/dev-console/git/analyze
+			"analyze": {
+				operation: serverutils.Operation{Summary: "Detect the build tools of a git repository", Request: git.AnalyzeRequest{}, Response: git.AnalyzeResponse{}},
+				handler: func(r *http.Request, _ *auth.User, _ *dynamic.DynamicClient, _ string, _ []string) (interface{}, error) {
+					return git.Analyze(r)
+				},
 			},
 		},
 		"tekton-results": {
 			// POST /api/dev-console/tekton-results/get
-			"get": func(r *http.Request, user *auth.User, dynamicClient *dynamic.DynamicClient, k8sMode string, _ []string) (interface{}, error) {
-				return tektonresults.GetTektonResults(r, user, dynamicClient, k8sMode)
+			"get": {
+				operation: serverutils.Operation{Summary: "List TektonResults records", Request: tektonresults.TektonResultsRequest{}, Response: common.DevConsoleCommonResponse{}},
+				handler: func(r *http.Request, user *auth.User, dynamicClient *dynamic.DynamicClient, k8sMode string, _ []string) (interface{}, error) {
+					return tektonresults.GetTektonResults(r, user, dynamicClient, k8sMode)
+				},
 			},
 			// POST /api/dev-console/tekton-results/logs
-			"logs": func(r *http.Request, user *auth.User, dynamicClient *dynamic.DynamicClient, k8sMode string, _ []string) (interface{}, error) {
-				return tektonresults.GetTaskRunLog(r, user, dynamicClient, k8sMode)
+			"logs": {
+				operation: serverutils.Operation{Summary: "Get the log of a TaskRun from TektonResults", Request: tektonresults.TaskRunLogRequest{}, Response: common.DevConsoleCommonResponse{}},
+				handler: func(r *http.Request, user *auth.User, dynamicClient *dynamic.DynamicClient, k8sMode string, _ []string) (interface{}, error) {
+					return tektonresults.GetTaskRunLog(r, user, dynamicClient, k8sMode)
+				},
 			},
 			// POST /api/dev-console/tekton-results/summary
-			"summary": func(r *http.Request, user *auth.User, dynamicClient *dynamic.DynamicClient, k8sMode string, _ []string) (interface{}, error) {
-				return tektonresults.GetResultsSummary(r, user, dynamicClient, k8sMode)
+			"summary": {
+				operation: serverutils.Operation{Summary: "Summarize TektonResults records", Request: tektonresults.SummaryRequest{}, Response: common.DevConsoleCommonResponse{}},
+				handler: func(r *http.Request, user *auth.User, dynamicClient *dynamic.DynamicClient, k8sMode string, _ []string) (interface{}, error) {
+					return tektonresults.GetResultsSummary(r, user, dynamicClient, k8sMode)
+				},
+			},
+			// POST /api/dev-console/tekton-results/records
+			"records": {
+				operation: serverutils.Operation{Summary: "List TektonResults records with their data decoded", Request: tektonresults.RecordsRequest{}, Response: tektonresults.RecordList{}},
+				handler: func(r *http.Request, user *auth.User, dynamicClient *dynamic.DynamicClient, k8sMode string, _ []string) (interface{}, error) {
+					return tektonresults.GetRecords(r, user, dynamicClient, k8sMode)
+				},
 			},
 		},
 		"webhooks": {
 			// POST /api/dev-console/webhooks/github
-			"github": func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string,
-				proxyHeaderDenyList []string) (interface{}, error) {
-
-				return webhooks.CreateGithubWebhook(r, user, proxyHeaderDenyList)
+			"github": {
+				operation: serverutils.Operation{Summary: "Create a GitHub webhook, use webhooks/create instead", Deprecated: true, Request: webhooks.GithubWebhookRequest{}, Response: common.DevConsoleCommonResponse{}},
+				handler: func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string, proxyHeaderDenyList []string) (interface{}, error) {
+					return webhooks.CreateGithubWebhook(r, user, proxyHeaderDenyList)
+				},
 			},
 			// POST /api/dev-console/webhooks/gitlab
-			"gitlab": func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string, proxyHeaderDenyList []string) (interface{}, error) {
-				return webhooks.CreateGitlabWebhook(r, user, proxyHeaderDenyList)
+			"gitlab": {
+				operation: serverutils.Operation{Summary: "Create a GitLab webhook, use webhooks/create instead", Deprecated: true, Request: webhooks.GitlabWebhookRequest{}, Response: common.DevConsoleCommonResponse{}},
+				handler: func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string, proxyHeaderDenyList []string) (interface{}, error) {
+					return webhooks.CreateGitlabWebhook(r, user, proxyHeaderDenyList)
+				},
 			},
 			// POST /api/dev-console/webhooks/bitbucket
-			"bitbucket": func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string, proxyHeaderDenyList []string) (interface{}, error) {
-				return webhooks.CreateBitbucketWebhook(r, user, proxyHeaderDenyList)
+			"bitbucket": {
+				operation: serverutils.Operation{Summary: "Create a Bitbucket webhook, use webhooks/create instead", Deprecated: true, Request: webhooks.BitbucketWebhookRequest{}, Response: common.DevConsoleCommonResponse{}},
+				handler: func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string, proxyHeaderDenyList []string) (interface{}, error) {
+					return webhooks.CreateBitbucketWebhook(r, user, proxyHeaderDenyList)
+				},
+			},
+			// POST /api/dev-console/webhooks/create
+			"create": {
+				operation: serverutils.Operation{Summary: "Create a webhook of a git repository", Request: webhooks.WebhookRequest{}, Response: webhooks.Webhook{}},
+				handler: func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string, _ []string) (interface{}, error) {
+					return webhooks.CreateWebhook(r, webhooks.NewUserSecretGetter(anonClientConfig, user))
+				},
+			},
+			// POST /api/dev-console/webhooks/list
+			"list": {
+				operation: serverutils.Operation{Summary: "List the webhooks of a git repository", Request: webhooks.WebhookRequest{}, Response: []webhooks.Webhook{}},
+				handler: func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string, _ []string) (interface{}, error) {
+					return webhooks.ListWebhooks(r, webhooks.NewUserSecretGetter(anonClientConfig, user))
+				},
+			},
+			// POST /api/dev-console/webhooks/update
+			"update": {
+				operation: serverutils.Operation{Summary: "Update a webhook of a git repository", Request: webhooks.WebhookRequest{}, Response: webhooks.Webhook{}},
+				handler: func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string, _ []string) (interface{}, error) {
+					return webhooks.UpdateWebhook(r, webhooks.NewUserSecretGetter(anonClientConfig, user))
+				},
+			},
+			// POST /api/dev-console/webhooks/delete
+			"delete": {
+				operation: serverutils.Operation{Summary: "Delete a webhook of a git repository", Request: webhooks.WebhookRequest{}, Response: struct{}{}},
+				handler: func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string, _ []string) (interface{}, error) {
+					return struct{}{}, webhooks.DeleteWebhook(r, webhooks.NewUserSecretGetter(anonClientConfig, user))
+				},
+			},
+			// POST /api/dev-console/webhooks/test
+			"test": {
+				operation: serverutils.Operation{Summary: "Send a test event to a webhook of a git repository", Request: webhooks.WebhookRequest{}, Response: struct{}{}},
+				handler: func(r *http.Request, user *auth.User, _ *dynamic.DynamicClient, _ string, _ []string) (interface{}, error) {
+					return struct{}{}, webhooks.TestWebhook(r, webhooks.NewUserSecretGetter(anonClientConfig, user))
+				},
 			},
 		},
 	}
+}
+
+// getRoutes are the routes of the dev console that write the response themselves.
+var getRoutes = map[string]map[string]getRoute{
+	"tekton-results": {
+		// GET /api/dev-console/tekton-results/log-stream?taskRunPath=<path>&follow=true
+		"log-stream": {
+			operation: serverutils.Operation{Summary: "Stream the log of a TaskRun from TektonResults", Query: []serverutils.Parameter{
+				{Name: "taskRunPath", Description: "The path of the log under the parents of TektonResults, starting with the namespace", Required: true},
+				{Name: "follow", Description: "Keep streaming the log while it grows"},
+			}, ResponseContentType: "text/plain"},
+			handler: tektonresults.StreamTaskRunLog,
+		},
+		// GET /api/dev-console/tekton-results/status?namespace=<namespace>
+		"status": {
+			operation: serverutils.Operation{Summary: "Get the status of TektonResults for a namespace", Query: []serverutils.Parameter{
+				{Name: "namespace", Description: "The namespace of the PipelineRuns"},
+			}, Response: tektonresults.Status{}},
+			handler: tektonresults.GetStatus,
+		},
+	},
+}
+
+// Operations returns the operations of the dev console routes by their path under
+// /api/dev-console/, for the OpenAPI document of the bridge.
+func Operations() map[string]serverutils.Operation {
+	operations := map[string]serverutils.Operation{}
+	for group, routes := range postRoutes(nil) {
+		for name, route := range routes {
+			route.operation.Method = http.MethodPost
+			operations[group+"/"+name] = route.operation
+		}
+	}
+	for group, routes := range getRoutes {
+		for name, route := range routes {
+			route.operation.Method = http.MethodGet
+			operations[group+"/"+name] = route.operation
+		}
+	}
+	return operations
+}
+
+func Handler(user *auth.User, w http.ResponseWriter, r *http.Request, dynamicClient *dynamic.DynamicClient, anonClientConfig *rest.Config, k8sMode string, proxyHeaderDenyList []string) {
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(path) != 2 {
+		serverutils.SendError(w, r, http.StatusNotFound, "Invalid URL")
+		return
+	}
+
+	if routes, ok := getRoutes[path[0]]; ok {
+		if route, ok := routes[path[1]]; ok {
+			if r.Method != http.MethodGet {
+				serverutils.SendError(w, r, http.StatusMethodNotAllowed, "Invalid method: only GET is allowed")
+				return
+			}
+			route.handler(w, r, user, dynamicClient, k8sMode)
+			return
+		}
+	}
 
 	// Check for valid route and method
-	if methodHandlers, ok := routes[path[0]]; ok {
-		if handler, ok := methodHandlers[path[1]]; ok {
+	if routes, ok := postRoutes(anonClientConfig)[path[0]]; ok {
+		if route, ok := routes[path[1]]; ok {
 			if r.Method != http.MethodPost {
-				serverutils.SendResponse(w, http.StatusMethodNotAllowed, serverutils.ApiError{Err: "Invalid method: only POST is allowed"})
+				serverutils.SendError(w, r, http.StatusMethodNotAllowed, "Invalid method: only POST is allowed")
 				return
 			}
-			handleRequest(w, r, user, dynamicClient, k8sMode, proxyHeaderDenyList, handler)
+			handleRequest(w, r, user, dynamicClient, k8sMode, proxyHeaderDenyList, route.handler)
 			return
 		}
 	}
 
-	serverutils.SendResponse(w, http.StatusNotFound, serverutils.ApiError{Err: "Invalid URL"})
+	serverutils.SendError(w, r, http.StatusNotFound, "Invalid URL")
 }
@@ -0,0 +1,51 @@
+package devconsole
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/console/pkg/auth"
+)
+
+func TestOperations(t *testing.T) {
+	operations := Operations()
+	registered := 0
+	for group, routes := range postRoutes(nil) {
+		for name := range routes {
+			registered++
+			op, ok := operations[group+"/"+name]
+			if assert.True(t, ok, "expected %s/%s to be documented", group, name) {
+				assert.Equal(t, http.MethodPost, op.Method)
+				assert.NotEmpty(t, op.Summary, "expected %s/%s to have a summary", group, name)
+				assert.NotNil(t, op.Request, "expected %s/%s to document its request", group, name)
+			}
+		}
+	}
+	for group, routes := range getRoutes {
+		for name := range routes {
+			registered++
+			op, ok := operations[group+"/"+name]
+			if assert.True(t, ok, "expected %s/%s to be documented", group, name) {
+				assert.Equal(t, http.MethodGet, op.Method)
+				assert.NotEmpty(t, op.Summary, "expected %s/%s to have a summary", group, name)
+			}
+		}
+	}
+	assert.Len(t, operations, registered)
+	assert.Contains(t, operations, "tekton-results/log-stream")
+	assert.Contains(t, operations, "tekton-results/status")
+
+	// the documented method is the one the handler serves the route for
+	for route, op := range operations {
+		method := http.MethodGet
+		if op.Method == http.MethodGet {
+			method = http.MethodPost
+		}
+		rr := httptest.NewRecorder()
+		Handler(&auth.User{}, rr, httptest.NewRequest(method, "/"+route, nil), nil, nil, "", nil)
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code, "expected %s %s to be rejected", method, route)
+	}
+}
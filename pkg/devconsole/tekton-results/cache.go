@@ -0,0 +1,58 @@
+package tektonresults
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/openshift/console/pkg/auth"
+	"github.com/openshift/console/pkg/devconsole/common"
+	"k8s.io/apimachinery/pkg/util/cache"
+)
+
+const (
+	// responseCacheSize is the maximum number of cached responses
+	responseCacheSize = 500
+	// maxCachedResponseSize keeps large responses out of the cache
+	maxCachedResponseSize = 1 << 20
+)
+
+var (
+	// responseCacheTTL is short, the cache only absorbs the bursts of identical requests
+	// caused by several components of a page listing the same records.
+	responseCacheTTL = 10 * time.Second
+
+	responseCache = cache.NewLRUExpireCache(responseCacheSize)
+)
+
+// responseCacheKey identifies a response of TektonResults to a user. Responses are never
+// shared between users, since TektonResults authorizes every request.
+func responseCacheKey(url string, user *auth.User) string {
+	hash := sha256.New()
+	for _, s := range append([]string{url, user.Token, user.Username}, user.Groups...) {
+		hash.Write([]byte(s))
+		hash.Write([]byte{0})
+	}
+	if user.Impersonate {
+		hash.Write([]byte("impersonate"))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// cachedHTTPRequest is makeHTTPRequest with a short-lived per-user cache for successful responses.
+func cachedHTTPRequest(ctx context.Context, url string, user *auth.User, k8sMode string) (common.DevConsoleCommonResponse, error) {
+	key := responseCacheKey(url, user)
+	if cached, ok := responseCache.Get(key); ok {
+		return cached.(common.DevConsoleCommonResponse), nil
+	}
+
+	response, err := makeHTTPRequest(ctx, url, user, k8sMode)
+	if err != nil {
+		return response, err
+	}
+	if response.StatusCode == 200 && len(response.Body) <= maxCachedResponseSize {
+		responseCache.Add(key, response, responseCacheTTL)
+	}
+	return response, nil
+}
@@ -0,0 +1,324 @@
+package tektonresults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// namespacesAnnotation restricts a TektonResult instance to a comma separated list of
+	// namespaces. Instances without it serve all other namespaces.
+	namespacesAnnotation = "console.openshift.io/tekton-results-namespaces"
+	// defaultInstanceName is the name of the instance created from the TektonConfig
+	defaultInstanceName = "result"
+	apiServiceName      = "tekton-results-api-service"
+	defaultServerPort   = "8080"
+)
+
+var (
+	// hostDiscoveryTimeout bounds how long a request waits for the initial list of instances
+	hostDiscoveryTimeout = 10 * time.Second
+
+	errNotInstalled = errors.New("TektonResults is not installed")
+
+	discoveryLock sync.Mutex
+	discovery     *hostDiscovery
+)
+
+// resultsInstance is a TektonResult resource of the Tekton operator.
+type resultsInstance struct {
+	Name            string
+	TargetNamespace string
+	// Host is the in-cluster address of the API service
+	Host string
+	// Namespaces served by the instance, all namespaces if empty
+	Namespaces []string
+	AuthMode   string
+	Version    string
+}
+
+// hostDiscovery watches the TektonResult resources, so that the API service does not have
+// to be looked up on every request. The operator updates the TektonResult whenever the
+// results section of the TektonConfig changes.
+//
+// The console service account needs list and watch on tektonresults.operator.tekton.dev
+// for the watch. Without them, the default instance is looked up with a get on every
+// request, like before instances could be restricted to namespaces.
+type hostDiscovery struct {
+	client    dynamic.Interface
+	informer  cache.SharedIndexInformer
+	stop      context.CancelFunc
+	instances atomic.Pointer[[]resultsInstance]
+	// getOnly is set when listing the instances is forbidden
+	getOnly     atomic.Bool
+	getOnlyOnce sync.Once
+	// settled is closed once the instances have been listed or listing them failed
+	settled     chan struct{}
+	settledOnce sync.Once
+	synced      atomic.Bool
+	listErr     atomic.Pointer[error]
+
+	// routeHosts caches the route of the API service by namespace for off-cluster mode
+	routeHostsLock sync.Mutex
+	routeHosts     map[string]string
+}
+
+func newHostDiscovery(ctx context.Context, client dynamic.Interface) *hostDiscovery {
+	resourceClient := client.Resource(*TektonResultsResource)
+	listWatch := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			return resourceClient.List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			return resourceClient.Watch(ctx, options)
+		},
+	}
+
+	ctx, stop := context.WithCancel(ctx)
+	d := &hostDiscovery{
+		client:     client,
+		stop:       stop,
+		informer:   cache.NewSharedIndexInformer(cache.ToListWatcherWithWatchListSemantics(listWatch, client), &unstructured.Unstructured{}, 0, cache.Indexers{}),
+		settled:    make(chan struct{}),
+		routeHosts: map[string]string{},
+	}
+	d.instances.Store(&[]resultsInstance{})
+	d.informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		if apierrors.IsForbidden(err) {
+			d.fallBackToGet(err)
+			return
+		}
+		if !apierrors.IsNotFound(err) {
+			klog.Errorf("failed to list and watch TektonResults: %v", err)
+		}
+		d.listErr.Store(&err)
+		d.settle()
+	})
+	d.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { d.update() },
+		UpdateFunc: func(interface{}, interface{}) { d.update() },
+		DeleteFunc: func(interface{}) { d.update() },
+	})
+
+	go d.informer.Run(ctx.Done())
+	go func() {
+		if cache.WaitForCacheSync(ctx.Done(), d.informer.HasSynced) {
+			d.update()
+			d.synced.Store(true)
+			d.settle()
+		}
+	}()
+	return d
+}
+
+// getHostDiscovery returns the discovery shared by all requests, which is started with
+// the client of the first request.
+func getHostDiscovery(dynamicClient dynamic.Interface) (*hostDiscovery, error) {
+	discoveryLock.Lock()
+	defer discoveryLock.Unlock()
+	if discovery == nil {
+		if dynamicClient == nil {
+			return nil, fmt.Errorf("no client to discover TektonResults")
+		}
+		discovery = newHostDiscovery(context.Background(), dynamicClient)
+	}
+	return discovery, nil
+}
+
+func (d *hostDiscovery) settle() {
+	d.settledOnce.Do(func() { close(d.settled) })
+}
+
+// fallBackToGet stops the watch and gets the default instance on every request instead.
+func (d *hostDiscovery) fallBackToGet(err error) {
+	d.getOnlyOnce.Do(func() {
+		klog.Warningf("Not allowed to list and watch TektonResults, only the %q instance is used. Grant list and watch on tektonresults.operator.tekton.dev to the console to discover all instances: %v", defaultInstanceName, err)
+		d.getOnly.Store(true)
+		d.stop()
+		d.settle()
+	})
+}
+
+// getInstance gets the default instance.
+func (d *hostDiscovery) getInstance(ctx context.Context) (*resultsInstance, error) {
+	object, err := d.client.Resource(*TektonResultsResource).Get(ctx, defaultInstanceName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, errNotInstalled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TektonResults: %v", err)
+	}
+	instance := parseResultsInstance(object)
+	return &instance, nil
+}
+
+// update recomputes the instances from the informer cache.
+func (d *hostDiscovery) update() {
+	objects := d.informer.GetStore().List()
+	instances := make([]resultsInstance, 0, len(objects))
+	for _, object := range objects {
+		instances = append(instances, parseResultsInstance(object.(*unstructured.Unstructured)))
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].Name < instances[j].Name
+	})
+	d.instances.Store(&instances)
+
+	// the route is looked up again, in case the target namespace changed
+	d.routeHostsLock.Lock()
+	clear(d.routeHosts)
+	d.routeHostsLock.Unlock()
+}
+
+func parseResultsInstance(object *unstructured.Unstructured) resultsInstance {
+	instance := resultsInstance{
+		Name:     object.GetName(),
+		AuthMode: "token",
+	}
+
+	targetNamespace, _, _ := unstructured.NestedString(object.Object, "spec", "targetNamespace")
+	instance.TargetNamespace = targetNamespace
+	if instance.TargetNamespace == "" {
+		instance.TargetNamespace = "openshift-pipelines"
+	}
+
+	// the port is an integer in recent versions of the operator
+	serverPort := defaultServerPort
+	if port, found, _ := unstructured.NestedFieldNoCopy(object.Object, "spec", "server_port"); found && port != nil {
+		serverPort = fmt.Sprint(port)
+	}
+	if tlsHostname, _, _ := unstructured.NestedString(object.Object, "spec", "tls_hostname_override"); tlsHostname != "" {
+		instance.Host = fmt.Sprintf("%s:%s", tlsHostname, serverPort)
+	} else {
+		instance.Host = fmt.Sprintf("%s.%s.svc.cluster.local:%s", apiServiceName, instance.TargetNamespace, serverPort)
+	}
+
+	if authDisabled, _, _ := unstructured.NestedBool(object.Object, "spec", "auth_disable"); authDisabled {
+		instance.AuthMode = "disabled"
+	} else if impersonate, _, _ := unstructured.NestedBool(object.Object, "spec", "auth_impersonate"); impersonate {
+		instance.AuthMode = "impersonation"
+	}
+	instance.Version, _, _ = unstructured.NestedString(object.Object, "status", "version")
+
+	for _, namespace := range strings.Split(object.GetAnnotations()[namespacesAnnotation], ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			instance.Namespaces = append(instance.Namespaces, namespace)
+		}
+	}
+	return instance
+}
+
+func (d *hostDiscovery) waitForSync(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, hostDiscoveryTimeout)
+	defer cancel()
+
+	select {
+	case <-d.settled:
+	case <-ctx.Done():
+		return fmt.Errorf("timed out discovering TektonResults")
+	}
+	if d.synced.Load() || d.getOnly.Load() {
+		return nil
+	}
+	if err := d.listErr.Load(); err != nil {
+		if apierrors.IsNotFound(*err) {
+			return errNotInstalled
+		}
+		return fmt.Errorf("failed to discover TektonResults: %v", *err)
+	}
+	return nil
+}
+
+// instanceFor selects the instance serving the namespace. An instance that lists the
+// namespace is preferred over the default instances.
+func (d *hostDiscovery) instanceFor(ctx context.Context, namespace string) (*resultsInstance, error) {
+	if err := d.waitForSync(ctx); err != nil {
+		return nil, err
+	}
+	if d.getOnly.Load() {
+		return d.getInstance(ctx)
+	}
+
+	var fallback *resultsInstance
+	for _, instance := range *d.instances.Load() {
+		if namespace != "" && slices.Contains(instance.Namespaces, namespace) {
+			return &instance, nil
+		}
+		if len(instance.Namespaces) == 0 && (fallback == nil || instance.Name == defaultInstanceName) {
+			fallback = &instance
+		}
+	}
+	if fallback == nil {
+		if len(*d.instances.Load()) > 0 {
+			return nil, fmt.Errorf("no TektonResults instance serves namespace %q", namespace)
+		}
+		return nil, errNotInstalled
+	}
+	return fallback, nil
+}
+
+// routeHost returns the host of the route exposing the API service of the instance.
+func (d *hostDiscovery) routeHost(ctx context.Context, instance *resultsInstance) (string, error) {
+	d.routeHostsLock.Lock()
+	host, ok := d.routeHosts[instance.TargetNamespace]
+	d.routeHostsLock.Unlock()
+	if ok {
+		return host, nil
+	}
+
+	route, err := d.client.Resource(*TektonResultsAPIRoute).Namespace(instance.TargetNamespace).Get(ctx, apiServiceName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	host, found, err := unstructured.NestedString(route.Object, "spec", "host")
+	if err != nil || !found {
+		return "", fmt.Errorf("route %s/%s has no host", instance.TargetNamespace, apiServiceName)
+	}
+
+	d.routeHostsLock.Lock()
+	d.routeHosts[instance.TargetNamespace] = host
+	d.routeHostsLock.Unlock()
+	return host, nil
+}
+
+// resolveInstance returns the instance serving the namespace and the host its API is reached at.
+func resolveInstance(ctx context.Context, dynamicClient dynamic.Interface, k8sMode string, namespace string) (*resultsInstance, string, error) {
+	d, err := getHostDiscovery(dynamicClient)
+	if err != nil {
+		return nil, "", err
+	}
+	instance, err := d.instanceFor(ctx, namespace)
+	if err != nil {
+		return nil, "", err
+	}
+	if k8sMode == "off-cluster" {
+		host, err := d.routeHost(ctx, instance)
+		return instance, host, err
+	}
+	return instance, instance.Host, nil
+}
+
+func getTRHost(ctx context.Context, dynamicClient *dynamic.DynamicClient, k8sMode string, namespace string) (string, error) {
+	var client dynamic.Interface
+	if dynamicClient != nil {
+		client = dynamicClient
+	}
+	_, host, err := resolveInstance(ctx, client, k8sMode, namespace)
+	return host, err
+}
@@ -0,0 +1,296 @@
+package tektonresults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift/console/pkg/auth"
+	"github.com/openshift/console/pkg/devconsole/common"
+	"github.com/openshift/console/pkg/serverutils"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+var (
+	// logFollowInterval is how often a followed log is polled for new content
+	logFollowInterval = 2 * time.Second
+	// logFollowIdleTimeout stops following a log that has not grown for a while, e.g.
+	// because the TaskRun has finished
+	logFollowIdleTimeout = 5 * time.Minute
+
+	// forwardedLogHeaders are copied from the TektonResults response
+	forwardedLogHeaders = []string{"Content-Type", "Content-Length", "Content-Range", "Accept-Ranges", "Etag", "Last-Modified"}
+
+	errInvalidRange       = errors.New("invalid range")
+	errUnsatisfiableRange = errors.New("unsatisfiable range")
+)
+
+// flushWriter flushes every write, so that log lines reach the client as they arrive.
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.flusher.Flush()
+	return n, err
+}
+
+func newFlushWriter(w http.ResponseWriter) io.Writer {
+	if flusher, ok := w.(http.Flusher); ok {
+		return &flushWriter{w: w, flusher: flusher}
+	}
+	return w
+}
+
+func validateTaskRunPath(taskRunPath string) error {
+	if taskRunPath == "" {
+		return fmt.Errorf("taskRunPath is required")
+	}
+	if path.IsAbs(taskRunPath) || path.Clean(taskRunPath) != taskRunPath || strings.Contains(taskRunPath, "..") || strings.ContainsAny(taskRunPath, "?#") {
+		return fmt.Errorf("invalid taskRunPath %q", taskRunPath)
+	}
+	return nil
+}
+
+// StreamTaskRunLog passes the log of a TaskRun through without buffering it. A Range
+// header is forwarded to TektonResults, or applied here if TektonResults ignores it.
+// With follow=true the log is polled for new content until the client disconnects
+// or the log stops growing.
+func StreamTaskRunLog(w http.ResponseWriter, r *http.Request, user *auth.User, dynamicClient *dynamic.DynamicClient, k8sMode string) {
+	taskRunPath := r.URL.Query().Get("taskRunPath")
+	if err := validateTaskRunPath(taskRunPath); err != nil {
+		serverutils.SendError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	namespace, _, _ := strings.Cut(taskRunPath, "/")
+	TEKTON_RESULTS_HOST, err := getTRHost(r.Context(), dynamicClient, k8sMode, namespace)
+	if err != nil {
+		serverutils.SendUpstreamError(w, r, http.StatusInternalServerError, fmt.Errorf("failed to get TektonResults host: %w", err))
+		return
+	}
+
+	TASKRUN_LOG_URL := fmt.Sprintf("https://%s/apis/results.tekton.dev/v1alpha2/parents/%s",
+		TEKTON_RESULTS_HOST,
+		taskRunPath,
+	)
+	if r.URL.Query().Get("follow") == "true" {
+		followLog(w, r, TASKRUN_LOG_URL, user, k8sMode)
+		return
+	}
+	proxyLog(w, r, TASKRUN_LOG_URL, user, k8sMode)
+}
+
+func sendLogError(w http.ResponseWriter, r *http.Request, err error) {
+	var upstreamErr *common.UpstreamError
+	if errors.As(err, &upstreamErr) {
+		serverutils.SendAPIError(w, r, upstreamErr.ResponseStatusCode(), serverutils.ApiError{Err: err.Error(), Retryable: upstreamErr.Retryable()})
+		return
+	}
+	serverutils.SendUpstreamError(w, r, http.StatusBadGateway, err)
+}
+
+func copyLogHeaders(w http.ResponseWriter, serviceResponse *http.Response) {
+	for _, header := range forwardedLogHeaders {
+		if value := serviceResponse.Header.Get(header); value != "" {
+			w.Header().Set(header, value)
+		}
+	}
+}
+
+func proxyLog(w http.ResponseWriter, r *http.Request, logURL string, user *auth.User, k8sMode string) {
+	serviceRequest, err := newServiceRequest(r.Context(), logURL, user)
+	if err != nil {
+		sendLogError(w, r, err)
+		return
+	}
+	rangeHeader := r.Header.Get("Range")
+	if rangeHeader != "" {
+		serviceRequest.Header.Set("Range", rangeHeader)
+	}
+
+	serviceResponse, err := doServiceRequest(serviceRequest, k8sMode)
+	if err != nil {
+		sendLogError(w, r, err)
+		return
+	}
+	defer serviceResponse.Body.Close()
+
+	// TektonResults ignored the range, apply it here if the size of the log is known
+	if rangeHeader != "" && serviceResponse.StatusCode == http.StatusOK && serviceResponse.ContentLength >= 0 {
+		size := serviceResponse.ContentLength
+		start, length, err := parseByteRange(rangeHeader, size)
+		switch err {
+		case nil:
+			copyLogHeaders(w, serviceResponse)
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, size))
+			w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+			w.WriteHeader(http.StatusPartialContent)
+			if _, err := io.CopyN(io.Discard, serviceResponse.Body, start); err != nil {
+				return
+			}
+			io.CopyN(newFlushWriter(w), serviceResponse.Body, length)
+			return
+		case errUnsatisfiableRange:
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+			serverutils.SendError(w, r, http.StatusRequestedRangeNotSatisfiable, err.Error())
+			return
+		}
+		// invalid ranges are ignored and the whole log is returned
+	}
+
+	copyLogHeaders(w, serviceResponse)
+	w.WriteHeader(serviceResponse.StatusCode)
+	if _, err := io.Copy(newFlushWriter(w), serviceResponse.Body); err != nil {
+		klog.Errorf("failed to stream TaskRun log: %v", err)
+	}
+}
+
+func followLog(w http.ResponseWriter, r *http.Request, logURL string, user *auth.User, k8sMode string) {
+	var offset int64
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+		// only open ended ranges can be followed
+		start, _, err := parseByteRange(rangeHeader, -1)
+		if err != nil {
+			serverutils.SendError(w, r, http.StatusRequestedRangeNotSatisfiable, "only ranges like bytes=<offset>- can be followed")
+			return
+		}
+		offset = start
+	}
+
+	// the headers are sent with the first part of the log, so that errors can still be reported
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	output := newFlushWriter(w)
+
+	started := false
+	lastGrowth := time.Now()
+	for {
+		written, err := copyLogFrom(r.Context(), output, logURL, offset, user, k8sMode)
+		offset += written
+		if err != nil {
+			if r.Context().Err() != nil {
+				return
+			}
+			if !started && written == 0 {
+				sendLogError(w, r, err)
+				return
+			}
+			klog.Errorf("failed to follow TaskRun log: %v", err)
+			return
+		}
+		if !started {
+			started = true
+			if written == 0 {
+				http.NewResponseController(w).Flush()
+			}
+		}
+
+		if written > 0 {
+			lastGrowth = time.Now()
+		} else if time.Since(lastGrowth) > logFollowIdleTimeout {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(logFollowInterval):
+		}
+	}
+}
+
+// copyLogFrom writes the log after offset to w and returns the number of bytes written.
+// A log that has not been stored yet is treated as empty.
+func copyLogFrom(ctx context.Context, w io.Writer, logURL string, offset int64, user *auth.User, k8sMode string) (int64, error) {
+	serviceRequest, err := newServiceRequest(ctx, logURL, user)
+	if err != nil {
+		return 0, err
+	}
+	if offset > 0 {
+		serviceRequest.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	serviceResponse, err := doServiceRequest(serviceRequest, k8sMode)
+	if err != nil {
+		return 0, err
+	}
+	defer serviceResponse.Body.Close()
+
+	switch serviceResponse.StatusCode {
+	case http.StatusPartialContent:
+		return io.Copy(w, serviceResponse.Body)
+	case http.StatusOK:
+		// the range was ignored, skip the part that has already been sent
+		if _, err := io.CopyN(io.Discard, serviceResponse.Body, offset); err != nil {
+			if err == io.EOF {
+				return 0, nil
+			}
+			return 0, err
+		}
+		return io.Copy(w, serviceResponse.Body)
+	case http.StatusNotFound, http.StatusRequestedRangeNotSatisfiable:
+		return 0, nil
+	default:
+		body, _ := io.ReadAll(io.LimitReader(serviceResponse.Body, 1024))
+		return 0, &common.UpstreamError{StatusCode: serviceResponse.StatusCode, Body: string(body)}
+	}
+}
+
+// parseByteRange parses a single range like bytes=0-99, bytes=100- or bytes=-100. If the
+// size is unknown (-1), only ranges with a start are valid and the length is -1.
+func parseByteRange(rangeHeader string, size int64) (int64, int64, error) {
+	spec, ok := strings.CutPrefix(rangeHeader, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, 0, errInvalidRange
+	}
+	startSpec, endSpec, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return 0, 0, errInvalidRange
+	}
+
+	if startSpec == "" {
+		// suffix range
+		suffix, err := strconv.ParseInt(endSpec, 10, 64)
+		if err != nil || suffix <= 0 || size < 0 {
+			return 0, 0, errInvalidRange
+		}
+		suffix = min(suffix, size)
+		if suffix == 0 {
+			return 0, 0, errUnsatisfiableRange
+		}
+		return size - suffix, suffix, nil
+	}
+
+	start, err := strconv.ParseInt(startSpec, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, errInvalidRange
+	}
+	if size < 0 {
+		if endSpec != "" {
+			return 0, 0, errInvalidRange
+		}
+		return start, -1, nil
+	}
+	if start >= size {
+		return 0, 0, errUnsatisfiableRange
+	}
+	end := size - 1
+	if endSpec != "" {
+		end, err = strconv.ParseInt(endSpec, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, errInvalidRange
+		}
+		end = min(end, size-1)
+	}
+	return start, end - start + 1, nil
+}
@@ -0,0 +1,137 @@
+package tektonresults
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/openshift/console/pkg/auth"
+	"github.com/openshift/console/pkg/devconsole/common"
+	"github.com/openshift/console/pkg/serverutils"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+const (
+	defaultRecordsLimit = 100
+	maxRecordsLimit     = 1000
+	// maxPageSize is the largest page requested from TektonResults
+	maxPageSize = 100
+)
+
+// upstreamRecordList is a page of records as returned by TektonResults, with the
+// record data encoded as base64.
+type upstreamRecordList struct {
+	Records []struct {
+		Name       string `json:"name"`
+		UID        string `json:"uid"`
+		Etag       string `json:"etag"`
+		CreateTime string `json:"createTime"`
+		UpdateTime string `json:"updateTime"`
+		Data       struct {
+			Type  string `json:"type"`
+			Value []byte `json:"value"`
+		} `json:"data"`
+	} `json:"records"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// GetRecords lists the records of a namespace, iterating over the pages of TektonResults
+// until the requested number of records has been collected.
+func GetRecords(r *http.Request, user *auth.User, dynamicClient *dynamic.DynamicClient, k8sMode string) (*RecordList, error) {
+	var request RecordsRequest
+	if err := serverutils.DecodeRequest(r, &request); err != nil {
+		return nil, &common.ValidationError{Err: err}
+	}
+	limit := request.Limit
+	if limit <= 0 {
+		limit = defaultRecordsLimit
+	}
+	limit = min(limit, maxRecordsLimit)
+
+	params, err := url.ParseQuery(request.SearchParams)
+	if err != nil {
+		return nil, &common.ValidationError{Err: fmt.Errorf("error parsing search params: %v", err)}
+	}
+	fixedPageSize := params.Get("page_size") != ""
+
+	TEKTON_RESULTS_HOST, err := getTRHost(r.Context(), dynamicClient, k8sMode, request.SearchNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TektonResults host: %v", err)
+	}
+
+	list := &RecordList{Records: []Record{}}
+	pageToken := request.PageToken
+	for {
+		if pageToken != "" {
+			params.Set("page_token", pageToken)
+		}
+		if !fixedPageSize {
+			params.Set("page_size", strconv.Itoa(min(limit-len(list.Records), maxPageSize)))
+		}
+		RECORDS_URL := fmt.Sprintf("https://%s/apis/results.tekton.dev/v1alpha2/parents/%s/results/-/records?%s",
+			TEKTON_RESULTS_HOST,
+			url.PathEscape(request.SearchNamespace),
+			params.Encode(),
+		)
+
+		response, err := cachedHTTPRequest(r.Context(), RECORDS_URL, user, k8sMode)
+		if err != nil {
+			return nil, err
+		}
+		if response.StatusCode != http.StatusOK {
+			return nil, &common.UpstreamError{StatusCode: response.StatusCode, Body: response.Body}
+		}
+
+		var page upstreamRecordList
+		if err := json.Unmarshal([]byte(response.Body), &page); err != nil {
+			return nil, fmt.Errorf("failed to parse records: %v", err)
+		}
+		for _, upstream := range page.Records {
+			record := Record{
+				Name:       upstream.Name,
+				UID:        upstream.UID,
+				Etag:       upstream.Etag,
+				CreateTime: upstream.CreateTime,
+				UpdateTime: upstream.UpdateTime,
+				Type:       upstream.Data.Type,
+			}
+			if err := decodeRecordData(&record, upstream.Data.Value); err != nil {
+				klog.Errorf("failed to decode TektonResults record %s: %v", upstream.Name, err)
+			}
+			list.Records = append(list.Records, record)
+		}
+
+		pageToken = page.NextPageToken
+		if pageToken == "" || len(list.Records) >= limit {
+			break
+		}
+	}
+	list.NextPageToken = pageToken
+	return list, nil
+}
+
+// decodeRecordData decodes the data of PipelineRun and TaskRun records, any other valid
+// JSON is returned as is.
+func decodeRecordData(record *Record, data []byte) error {
+	switch {
+	case strings.HasSuffix(record.Type, ".PipelineRun"):
+		pipelineRun := &PipelineRun{}
+		if err := json.Unmarshal(data, pipelineRun); err != nil {
+			return err
+		}
+		record.PipelineRun = pipelineRun
+	case strings.HasSuffix(record.Type, ".TaskRun"):
+		taskRun := &TaskRun{}
+		if err := json.Unmarshal(data, taskRun); err != nil {
+			return err
+		}
+		record.TaskRun = taskRun
+	case len(data) > 0 && json.Valid(data):
+		record.Data = data
+	}
+	return nil
+}
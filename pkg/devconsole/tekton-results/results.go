@@ -4,20 +4,20 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/openshift/console/pkg/auth"
 	"github.com/openshift/console/pkg/devconsole/common"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"github.com/openshift/console/pkg/serverutils"
+	"github.com/openshift/console/pkg/tracing"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
-	"k8s.io/klog/v2"
 )
 
 var (
@@ -31,17 +31,22 @@ var (
 		Version:  "v1",
 		Resource: "routes",
 	}
-	cachedTektonResultsHost string = ""
-	tlsCertPath             string = "/var/serving-cert/tls.crt"
+	tlsCertPath string = "/var/serving-cert/tls.crt"
 )
 
-var client *http.Client
+var (
+	clientLock sync.Mutex
+	client     *http.Client
+)
 
+// Client returns the client shared by all requests to TektonResults, it is created on first use.
 func Client(k8sMode string) (*http.Client, error) {
-	serviceTransport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-	}
+	clientLock.Lock()
+	defer clientLock.Unlock()
 	if client == nil {
+		serviceTransport := &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+		}
 		if k8sMode == "off-cluster" {
 			serviceTransport.TLSClientConfig = &tls.Config{
 				InsecureSkipVerify: true,
@@ -64,77 +69,52 @@ func Client(k8sMode string) (*http.Client, error) {
 		}
 
 		client = &http.Client{
-			Transport: serviceTransport,
+			Transport: tracing.Transport(serviceTransport),
 		}
 	}
 	return client, nil
 }
 
-func getTRHost(ctx context.Context, dynamicClient *dynamic.DynamicClient, k8sMode string) (string, error) {
-	if k8sMode == "off-cluster" {
-		route, err := dynamicClient.Resource(*TektonResultsAPIRoute).Namespace("openshift-pipelines").Get(ctx, "tekton-results-api-service", metav1.GetOptions{})
-		if err != nil {
-			return "", err
-		}
-		cachedTektonResultsHost, isHostPresent, err := unstructured.NestedString(route.Object, "spec", "host")
-		if err != nil || !isHostPresent {
-			return "", err
-		}
-		return cachedTektonResultsHost, nil
-	}
-
-	if cachedTektonResultsHost != "" {
-		return cachedTektonResultsHost, nil
-	}
-
-	host, err := dynamicClient.Resource(*TektonResultsResource).Get(ctx, "result", metav1.GetOptions{})
+func newServiceRequest(ctx context.Context, url string, user *auth.User) (*http.Request, error) {
+	serviceRequest, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return "", err
-	}
-	targetNamespace, isTargetNsPresent, err := unstructured.NestedString(host.Object, "spec", "targetNamespace")
-	if err != nil || !isTargetNsPresent {
-		klog.Errorf("spec.targetNamespace not found in TektonResults resource")
-		targetNamespace = ""
-	}
-	serverPort, isPortPresent, err := unstructured.NestedString(host.Object, "spec", "server_port")
-	if err != nil || !isPortPresent {
-		klog.Errorf("spec.server_port not found in TektonResults resource. Using default port 8080")
-		serverPort = "8080"
-	}
-	tlsHostname, isTLSHostnamePresent, err := unstructured.NestedString(host.Object, "spec", "tls_hostname_override")
-	if err != nil || !isTLSHostnamePresent {
-		klog.Errorf("spec.tls_hostname_override not found in TektonResults resource")
-		tlsHostname = ""
+		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
 
-	if tlsHostname != "" {
-		cachedTektonResultsHost = fmt.Sprintf("%s:%s", tlsHostname, serverPort)
-	} else if targetNamespace != "" && serverPort != "" {
-		cachedTektonResultsHost = fmt.Sprintf("tekton-results-api-service.%s.svc.cluster.local:%s", targetNamespace, serverPort)
-	} else {
-		cachedTektonResultsHost = fmt.Sprintf("tekton-results-api-service.openshift-pipelines.svc.cluster.local:%s", serverPort)
+	// Needed for TektonResults API
+	serviceRequest.Header.Set("Authorization", fmt.Sprintf("Bearer %s", user.Token))
+	if user.Impersonate {
+		serviceRequest.Header.Set("Impersonate-User", user.Username)
+		for _, group := range user.Groups {
+			serviceRequest.Header.Add("Impersonate-Group", group)
+		}
 	}
-	return cachedTektonResultsHost, nil
+	return serviceRequest, nil
 }
 
-func makeHTTPRequest(ctx context.Context, url, userToken string, k8sMode string) (common.DevConsoleCommonResponse, error) {
-	serviceRequest, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-
+// doServiceRequest sends the request to TektonResults. The caller has to close the response body.
+func doServiceRequest(serviceRequest *http.Request, k8sMode string) (*http.Response, error) {
+	serviceClient, err := Client(k8sMode)
 	if err != nil {
-		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to create request: %v", err)
+		return nil, err
 	}
 
-	// Needed for TektonResults API
-	serviceRequest.Header.Set("Authorization", fmt.Sprintf("Bearer %s", userToken))
+	serviceResponse, err := serviceClient.Do(serviceRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	return serviceResponse, nil
+}
 
-	serviceClient, err := Client(k8sMode)
+func makeHTTPRequest(ctx context.Context, url string, user *auth.User, k8sMode string) (common.DevConsoleCommonResponse, error) {
+	serviceRequest, err := newServiceRequest(ctx, url, user)
 	if err != nil {
 		return common.DevConsoleCommonResponse{}, err
 	}
 
-	serviceResponse, err := serviceClient.Do(serviceRequest)
+	serviceResponse, err := doServiceRequest(serviceRequest, k8sMode)
 	if err != nil {
-		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to send request: %v", err)
+		return common.DevConsoleCommonResponse{}, err
 	}
 	defer serviceResponse.Body.Close()
 	serviceResponseBody, err := io.ReadAll(serviceResponse.Body)
@@ -151,11 +131,10 @@ func makeHTTPRequest(ctx context.Context, url, userToken string, k8sMode string)
 
 func GetTektonResults(r *http.Request, user *auth.User, dynamicClient *dynamic.DynamicClient, k8sMode string) (common.DevConsoleCommonResponse, error) {
 	var request TektonResultsRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
-	if err != nil {
-		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to parse request: %v", err)
+	if err := serverutils.DecodeRequest(r, &request); err != nil {
+		return common.DevConsoleCommonResponse{}, err
 	}
-	TEKTON_RESULTS_HOST, err := getTRHost(r.Context(), dynamicClient, k8sMode)
+	TEKTON_RESULTS_HOST, err := getTRHost(r.Context(), dynamicClient, k8sMode, request.SearchNamespace)
 	if err != nil {
 		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to get TektonResults host: %v", err)
 	}
@@ -171,16 +150,15 @@ func GetTektonResults(r *http.Request, user *auth.User, dynamicClient *dynamic.D
 		parsedParams.Encode(),
 	)
 
-	return makeHTTPRequest(r.Context(), TEKTON_RESULTS_URL, user.Token, k8sMode)
+	return cachedHTTPRequest(r.Context(), TEKTON_RESULTS_URL, user, k8sMode)
 }
 
 func GetResultsSummary(r *http.Request, user *auth.User, dynamicClient *dynamic.DynamicClient, k8sMode string) (common.DevConsoleCommonResponse, error) {
 	var request SummaryRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
-	if err != nil {
-		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to parse request: %v", err)
+	if err := serverutils.DecodeRequest(r, &request); err != nil {
+		return common.DevConsoleCommonResponse{}, err
 	}
-	TEKTON_RESULTS_HOST, err := getTRHost(r.Context(), dynamicClient, k8sMode)
+	TEKTON_RESULTS_HOST, err := getTRHost(r.Context(), dynamicClient, k8sMode, request.SearchNamespace)
 	if err != nil {
 		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to get TektonResults host: %v", err)
 	}
@@ -196,16 +174,19 @@ func GetResultsSummary(r *http.Request, user *auth.User, dynamicClient *dynamic.
 		parsedParams.Encode(),
 	)
 
-	return makeHTTPRequest(r.Context(), SUMMARY_URL, user.Token, k8sMode)
+	return cachedHTTPRequest(r.Context(), SUMMARY_URL, user, k8sMode)
 }
 
 func GetTaskRunLog(r *http.Request, user *auth.User, dynamicClient *dynamic.DynamicClient, k8sMode string) (common.DevConsoleCommonResponse, error) {
 	var request TaskRunLogRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
-	if err != nil {
-		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to parse request: %v", err)
+	if err := serverutils.DecodeRequest(r, &request); err != nil {
+		return common.DevConsoleCommonResponse{}, err
+	}
+	if err := validateTaskRunPath(request.TaskRunPath); err != nil {
+		return common.DevConsoleCommonResponse{}, &common.ValidationError{Err: err}
 	}
-	TEKTON_RESULTS_HOST, err := getTRHost(r.Context(), dynamicClient, k8sMode)
+	namespace, _, _ := strings.Cut(request.TaskRunPath, "/")
+	TEKTON_RESULTS_HOST, err := getTRHost(r.Context(), dynamicClient, k8sMode, namespace)
 	if err != nil {
 		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to get TektonResults host: %v", err)
 	}
@@ -215,5 +196,5 @@ func GetTaskRunLog(r *http.Request, user *auth.User, dynamicClient *dynamic.Dyna
 		request.TaskRunPath,
 	)
 
-	return makeHTTPRequest(r.Context(), TASKRUN_LOG_URL, user.Token, k8sMode)
+	return makeHTTPRequest(r.Context(), TASKRUN_LOG_URL, user, k8sMode)
 }
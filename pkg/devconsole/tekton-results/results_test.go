@@ -0,0 +1,454 @@
+package tektonresults
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openshift/console/pkg/auth"
+	"github.com/openshift/console/pkg/devconsole/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var testUser = &auth.User{Username: "developer", Token: "developer-token"}
+
+func newResultsInstance(name, host, port string, namespaces ...string) *unstructured.Unstructured {
+	instance := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "operator.tekton.dev/v1alpha1",
+		"kind":       "TektonResult",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec": map[string]interface{}{
+			"targetNamespace":       "openshift-pipelines",
+			"tls_hostname_override": host,
+			"server_port":           port,
+		},
+		"status": map[string]interface{}{"version": "v0.13.0"},
+	}}
+	if len(namespaces) > 0 {
+		instance.SetAnnotations(map[string]string{namespacesAnnotation: strings.Join(namespaces, ",")})
+	}
+	return instance
+}
+
+func newTestDynamicClient(objects ...runtime.Object) *fake.FakeDynamicClient {
+	return fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		*TektonResultsResource: "TektonResultList",
+		*TektonResultsAPIRoute: "RouteList",
+	}, objects...)
+}
+
+// setTestDiscovery replaces the shared discovery with one watching the given client.
+func setTestDiscovery(t *testing.T, client dynamic.Interface) *hostDiscovery {
+	ctx, cancel := context.WithCancel(context.Background())
+	originalDiscovery := discovery
+	discovery = newHostDiscovery(ctx, client)
+	t.Cleanup(func() {
+		cancel()
+		discovery = originalDiscovery
+	})
+	return discovery
+}
+
+func newTestResultsServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	ts := httptest.NewTLSServer(handler)
+	t.Cleanup(ts.Close)
+
+	host, port, err := net.SplitHostPort(ts.Listener.Addr().String())
+	require.NoError(t, err)
+	setTestDiscovery(t, newTestDynamicClient(newResultsInstance(defaultInstanceName, host, port)))
+
+	originalClient, originalCache := client, responseCache
+	client = ts.Client()
+	responseCache = cache.NewLRUExpireCache(responseCacheSize)
+	t.Cleanup(func() {
+		client, responseCache = originalClient, originalCache
+	})
+	return ts
+}
+
+func encodedRecord(name, dataType, value string) string {
+	return fmt.Sprintf(`{"name":"ns/results/r/records/%s","uid":"%s","data":{"type":"%s","value":"%s"}}`,
+		name, name, dataType, base64.StdEncoding.EncodeToString([]byte(value)))
+}
+
+func TestGetRecords(t *testing.T) {
+	var requests []string
+	newTestResultsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		assert.Equal(t, "Bearer developer-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "/apis/results.tekton.dev/v1alpha2/parents/ns/results/-/records", r.URL.Path)
+		switch r.URL.Query().Get("page_token") {
+		case "":
+			fmt.Fprintf(w, `{"records":[%s,%s],"nextPageToken":"page-2"}`,
+				encodedRecord("pr", "tekton.dev/v1.PipelineRun", `{"apiVersion":"tekton.dev/v1","kind":"PipelineRun","metadata":{"name":"build"},"spec":{"pipelineRef":{"name":"build-pipeline"}},"status":{"conditions":[{"type":"Succeeded","status":"True","reason":"Succeeded"}],"childReferences":[{"kind":"TaskRun","name":"build-task","pipelineTaskName":"task"}]}}`),
+				encodedRecord("tr", "tekton.dev/v1.TaskRun", `{"metadata":{"name":"build-task"},"spec":{"taskRef":{"name":"task"}},"status":{"podName":"build-task-pod","results":[{"name":"digest","value":"sha256:abc"}]}}`))
+		case "page-2":
+			fmt.Fprintf(w, `{"records":[%s],"nextPageToken":"page-3"}`,
+				encodedRecord("log", "results.tekton.dev/v1alpha3.Log", `{"kind":"Log","status":{"size":42}}`))
+		default:
+			t.Errorf("unexpected page token %q", r.URL.Query().Get("page_token"))
+		}
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"searchNamespace":"ns","searchParams":"filter=data_type%3D%3D%22tekton.dev%2Fv1.PipelineRun%22","limit":3}`))
+	list, err := GetRecords(request, testUser, nil, "in-cluster")
+	require.NoError(t, err)
+
+	require.Len(t, requests, 2)
+	assert.Contains(t, requests[0], "page_size=3")
+	assert.Contains(t, requests[0], "filter=")
+	assert.Contains(t, requests[1], "page_size=1")
+	assert.Equal(t, "page-3", list.NextPageToken)
+
+	require.Len(t, list.Records, 3)
+	pipelineRun := list.Records[0].PipelineRun
+	require.NotNil(t, pipelineRun)
+	assert.Equal(t, "build", pipelineRun.Name)
+	assert.Equal(t, "build-pipeline", pipelineRun.Spec.PipelineRef.Name)
+	assert.Equal(t, "Succeeded", pipelineRun.Status.Conditions[0].Reason)
+	assert.Equal(t, "build-task", pipelineRun.Status.ChildReferences[0].Name)
+
+	taskRun := list.Records[1].TaskRun
+	require.NotNil(t, taskRun)
+	assert.Equal(t, "build-task-pod", taskRun.Status.PodName)
+	assert.JSONEq(t, `"sha256:abc"`, string(taskRun.Status.Results[0].Value))
+
+	assert.Nil(t, list.Records[2].PipelineRun)
+	assert.JSONEq(t, `{"kind":"Log","status":{"size":42}}`, string(list.Records[2].Data))
+}
+
+func TestGetRecords_Errors(t *testing.T) {
+	newTestResultsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, `{"message":"forbidden"}`)
+	})
+
+	_, err := GetRecords(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`)), testUser, nil, "in-cluster")
+	assert.ErrorContains(t, err, "searchNamespace: Required value")
+
+	_, err = GetRecords(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"searchNamespace":"ns"}`)), testUser, nil, "in-cluster")
+	assert.ErrorContains(t, err, "status 403")
+}
+
+func TestCachedHTTPRequest(t *testing.T) {
+	var hits atomic.Int32
+	ts := newTestResultsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		io.WriteString(w, r.Header.Get("Authorization"))
+	})
+
+	for i := 0; i < 3; i++ {
+		response, err := cachedHTTPRequest(t.Context(), ts.URL+"/summary", testUser, "in-cluster")
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer developer-token", response.Body)
+	}
+	assert.Equal(t, int32(1), hits.Load(), "identical requests of a user should be cached")
+
+	response, err := cachedHTTPRequest(t.Context(), ts.URL+"/summary", &auth.User{Username: "admin", Token: "admin-token"}, "in-cluster")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer admin-token", response.Body, "responses must not be shared between users")
+	assert.Equal(t, int32(2), hits.Load())
+
+	for i := 0; i < 2; i++ {
+		_, err := cachedHTTPRequest(t.Context(), ts.URL+"/error", testUser, "in-cluster")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(4), hits.Load(), "errors should not be cached")
+}
+
+func TestValidateTaskRunPath(t *testing.T) {
+	assert.NoError(t, validateTaskRunPath("ns/results/uid/logs/uid"))
+	for _, invalid := range []string{"", "/ns/results", "ns/../../admin", "ns//results", "ns/results?x=y"} {
+		assert.Error(t, validateTaskRunPath(invalid), invalid)
+	}
+}
+
+func TestGetTaskRunLog_InvalidPath(t *testing.T) {
+	newTestResultsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to TektonResults: %s", r.URL.Path)
+	})
+
+	for _, taskRunPath := range []string{"../admin", "ns/results/../../../namespaces/other/results", "ns/results?filter=x"} {
+		request := httptest.NewRequest(http.MethodPost, "/api/dev-console/tekton-results/logs", strings.NewReader(fmt.Sprintf(`{"taskRunPath":%q}`, taskRunPath)))
+		_, err := GetTaskRunLog(request, testUser, nil, "in-cluster")
+		var validationErr *common.ValidationError
+		assert.ErrorAs(t, err, &validationErr, taskRunPath)
+	}
+}
+
+func TestParseByteRange(t *testing.T) {
+	tests := []struct {
+		header        string
+		size          int64
+		start, length int64
+		err           error
+	}{
+		{"bytes=0-9", 100, 0, 10, nil},
+		{"bytes=90-", 100, 90, 10, nil},
+		{"bytes=-5", 100, 95, 5, nil},
+		{"bytes=50-500", 100, 50, 50, nil},
+		{"bytes=100-", 100, 0, 0, errUnsatisfiableRange},
+		{"bytes=0-1,5-6", 100, 0, 0, errInvalidRange},
+		{"items=0-1", 100, 0, 0, errInvalidRange},
+		{"bytes=20-", -1, 20, -1, nil},
+		{"bytes=20-30", -1, 0, 0, errInvalidRange},
+	}
+	for _, tt := range tests {
+		start, length, err := parseByteRange(tt.header, tt.size)
+		assert.Equal(t, tt.err, err, tt.header)
+		if tt.err == nil {
+			assert.Equal(t, tt.start, start, tt.header)
+			assert.Equal(t, tt.length, length, tt.header)
+		}
+	}
+}
+
+func TestStreamTaskRunLog(t *testing.T) {
+	logContent := "line 1\nline 2\nline 3\n"
+	newTestResultsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/apis/results.tekton.dev/v1alpha2/parents/ns/results/r/logs/l", r.URL.Path)
+		// like TektonResults, ranges are ignored
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Length", fmt.Sprint(len(logContent)))
+		io.WriteString(w, logContent)
+	})
+
+	rec := httptest.NewRecorder()
+	StreamTaskRunLog(rec, httptest.NewRequest(http.MethodGet, "/?taskRunPath=ns/results/r/logs/l", nil), testUser, nil, "in-cluster")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, logContent, rec.Body.String())
+
+	request := httptest.NewRequest(http.MethodGet, "/?taskRunPath=ns/results/r/logs/l", nil)
+	request.Header.Set("Range", "bytes=7-")
+	rec = httptest.NewRecorder()
+	StreamTaskRunLog(rec, request, testUser, nil, "in-cluster")
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, "line 2\nline 3\n", rec.Body.String())
+	assert.Equal(t, fmt.Sprintf("bytes 7-%d/%d", len(logContent)-1, len(logContent)), rec.Header().Get("Content-Range"))
+
+	request = httptest.NewRequest(http.MethodGet, "/?taskRunPath=ns/results/r/logs/l", nil)
+	request.Header.Set("Range", "bytes=100-")
+	rec = httptest.NewRecorder()
+	StreamTaskRunLog(rec, request, testUser, nil, "in-cluster")
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, rec.Code)
+
+	rec = httptest.NewRecorder()
+	StreamTaskRunLog(rec, httptest.NewRequest(http.MethodGet, "/?taskRunPath=../admin", nil), testUser, nil, "in-cluster")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestStreamTaskRunLog_Follow(t *testing.T) {
+	originalInterval, originalTimeout := logFollowInterval, logFollowIdleTimeout
+	logFollowInterval, logFollowIdleTimeout = 10*time.Millisecond, 100*time.Millisecond
+	defer func() { logFollowInterval, logFollowIdleTimeout = originalInterval, originalTimeout }()
+
+	var lock sync.Mutex
+	var logContent bytes.Buffer
+	appendLog := func(s string) {
+		lock.Lock()
+		defer lock.Unlock()
+		logContent.WriteString(s)
+	}
+	newTestResultsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		content := logContent.String()
+		lock.Unlock()
+		if content == "" {
+			// the log has not been stored yet
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var offset int
+		fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &offset)
+		if offset > 0 {
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		io.WriteString(w, content[offset:])
+	})
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		appendLog("step 1\n")
+		time.Sleep(30 * time.Millisecond)
+		appendLog("step 2\n")
+	}()
+
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		StreamTaskRunLog(rec, httptest.NewRequest(http.MethodGet, "/?taskRunPath=ns/results/r/logs/l&follow=true", nil), testUser, nil, "in-cluster")
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("following the log did not stop after it stopped growing")
+	}
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "step 1\nstep 2\n", rec.Body.String())
+	assert.True(t, rec.Flushed)
+}
+
+func TestStreamTaskRunLog_FollowError(t *testing.T) {
+	newTestResultsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	rec := httptest.NewRecorder()
+	StreamTaskRunLog(rec, httptest.NewRequest(http.MethodGet, "/?taskRunPath=ns/results/r/logs/l&follow=true", nil), testUser, nil, "in-cluster")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestHostDiscovery(t *testing.T) {
+	client := newTestDynamicClient(
+		newResultsInstance(defaultInstanceName, "results.example.com", "8080"),
+		newResultsInstance("team-a", "team-a.example.com", "8443", "team-a-dev", "team-a-prod"),
+	)
+	d := setTestDiscovery(t, client)
+
+	host, err := getTRHost(t.Context(), nil, "in-cluster", "team-a-prod")
+	require.NoError(t, err)
+	assert.Equal(t, "team-a.example.com:8443", host)
+
+	host, err = getTRHost(t.Context(), nil, "in-cluster", "other")
+	require.NoError(t, err)
+	assert.Equal(t, "results.example.com:8080", host)
+
+	// changes of the instances are picked up without a restart
+	instance := newResultsInstance(defaultInstanceName, "", "50051")
+	instance.Object["spec"].(map[string]interface{})["targetNamespace"] = "tekton-results"
+	_, err = client.Resource(*TektonResultsResource).Update(t.Context(), instance, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		host, err := getTRHost(t.Context(), nil, "in-cluster", "other")
+		return err == nil && host == "tekton-results-api-service.tekton-results.svc.cluster.local:50051"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// off-cluster, the route of the API service is looked up once
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "route.openshift.io/v1",
+		"kind":       "Route",
+		"metadata":   map[string]interface{}{"name": apiServiceName, "namespace": "tekton-results"},
+		"spec":       map[string]interface{}{"host": "results.apps.example.com"},
+	}}
+	_, err = client.Resource(*TektonResultsAPIRoute).Namespace("tekton-results").Create(t.Context(), route, metav1.CreateOptions{})
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		host, err = getTRHost(t.Context(), nil, "off-cluster", "other")
+		require.NoError(t, err)
+		assert.Equal(t, "results.apps.example.com", host)
+	}
+	gets := 0
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "get" && action.GetResource() == *TektonResultsAPIRoute {
+			gets++
+		}
+	}
+	assert.Equal(t, 1, gets)
+	assert.Len(t, *d.instances.Load(), 2)
+}
+
+func TestHostDiscovery_NoDefaultInstance(t *testing.T) {
+	setTestDiscovery(t, newTestDynamicClient(newResultsInstance("team-a", "team-a.example.com", "8443", "team-a-dev")))
+
+	_, err := getTRHost(t.Context(), nil, "in-cluster", "team-b-dev")
+	assert.ErrorContains(t, err, `no TektonResults instance serves namespace "team-b-dev"`)
+}
+
+func TestHostDiscovery_ListForbidden(t *testing.T) {
+	client := newTestDynamicClient(
+		newResultsInstance(defaultInstanceName, "results.example.com", "8080"),
+		newResultsInstance("team-a", "team-a.example.com", "8443", "team-a-dev"),
+	)
+	forbidden := func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(TektonResultsResource.GroupResource(), "", fmt.Errorf("not allowed"))
+	}
+	client.PrependReactor("list", "tektonresults", forbidden)
+	client.PrependWatchReactor("tektonresults", func(k8stesting.Action) (bool, watch.Interface, error) {
+		return true, nil, apierrors.NewForbidden(TektonResultsResource.GroupResource(), "", fmt.Errorf("not allowed"))
+	})
+	d := setTestDiscovery(t, client)
+
+	// without the watch, the default instance serves all namespaces
+	host, err := getTRHost(t.Context(), nil, "in-cluster", "team-a-dev")
+	require.NoError(t, err)
+	assert.Equal(t, "results.example.com:8080", host)
+	assert.True(t, d.getOnly.Load())
+
+	require.NoError(t, client.Resource(*TektonResultsResource).Delete(t.Context(), defaultInstanceName, metav1.DeleteOptions{}))
+	_, err = getTRHost(t.Context(), nil, "in-cluster", "team-a-dev")
+	assert.ErrorIs(t, err, errNotInstalled)
+}
+
+func TestGetStatus(t *testing.T) {
+	newTestResultsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/apis/results.tekton.dev/v1alpha2/parents/forbidden/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		io.WriteString(w, `{"results":[]}`)
+	})
+
+	status := getStatus(t.Context(), nil, testUser, "in-cluster", "ns")
+	assert.True(t, status.Installed)
+	assert.True(t, status.Reachable)
+	assert.True(t, status.Authorized)
+	assert.Equal(t, defaultInstanceName, status.Instance)
+	assert.Equal(t, "results.tekton.dev/v1alpha2", status.APIVersion)
+	assert.Equal(t, "v0.13.0", status.Version)
+	assert.Equal(t, "token", status.AuthMode)
+	assert.Empty(t, status.Error)
+
+	status = getStatus(t.Context(), nil, testUser, "in-cluster", "forbidden")
+	assert.True(t, status.Reachable)
+	assert.False(t, status.Authorized)
+	assert.NotEmpty(t, status.Error)
+
+	rec := httptest.NewRecorder()
+	GetStatus(rec, httptest.NewRequest(http.MethodGet, "/?namespace=Not_A_Namespace", nil), testUser, nil, "in-cluster")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetStatus_NotInstalled(t *testing.T) {
+	client := newTestDynamicClient()
+	// clusters without the Tekton operator do not have the TektonResult resource
+	client.PrependReactor("list", "tektonresults", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(TektonResultsResource.GroupResource(), "")
+	})
+	setTestDiscovery(t, client)
+
+	status := getStatus(t.Context(), nil, testUser, "in-cluster", "ns")
+	assert.Equal(t, &Status{}, status)
+
+	// an instance that cannot be reached
+	setTestDiscovery(t, newTestDynamicClient(newResultsInstance(defaultInstanceName, "127.0.0.1", "1")))
+	status = getStatus(t.Context(), nil, testUser, "off-cluster", "ns")
+	assert.True(t, status.Installed)
+	assert.False(t, status.Reachable)
+	assert.NotEmpty(t, status.Error)
+}
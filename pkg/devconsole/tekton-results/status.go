@@ -0,0 +1,110 @@
+package tektonresults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/openshift/console/pkg/auth"
+	"github.com/openshift/console/pkg/serverutils"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+)
+
+const resultsAPIVersion = "results.tekton.dev/v1alpha2"
+
+// statusProbeTimeout bounds the request made to check that TektonResults is reachable
+var statusProbeTimeout = 5 * time.Second
+
+// Status describes the TektonResults instance serving a namespace, so that the UI can
+// hide what depends on it when it is missing or misconfigured.
+type Status struct {
+	Installed bool `json:"installed"`
+	// Reachable is true if the API of the instance responded
+	Reachable bool `json:"reachable"`
+	// Authorized is true if the user is allowed to list the results of the namespace
+	Authorized bool   `json:"authorized"`
+	Instance   string `json:"instance,omitempty"`
+	Host       string `json:"host,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	Version    string `json:"version,omitempty"`
+	// AuthMode is token, impersonation or disabled
+	AuthMode string `json:"authMode,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// GetStatus reports the state of the TektonResults instance serving the namespace given
+// with the namespace query parameter. Problems of TektonResults are part of the status,
+// the response is only an error if the request is invalid.
+func GetStatus(w http.ResponseWriter, r *http.Request, user *auth.User, dynamicClient *dynamic.DynamicClient, k8sMode string) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace != "" {
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			serverutils.SendError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid namespace %q", namespace))
+			return
+		}
+	}
+
+	var client dynamic.Interface
+	if dynamicClient != nil {
+		client = dynamicClient
+	}
+	serverutils.SendResponse(w, http.StatusOK, getStatus(r.Context(), client, user, k8sMode, namespace))
+}
+
+func getStatus(ctx context.Context, dynamicClient dynamic.Interface, user *auth.User, k8sMode string, namespace string) *Status {
+	status := &Status{}
+	instance, host, err := resolveInstance(ctx, dynamicClient, k8sMode, namespace)
+	if instance != nil {
+		status.Installed = true
+		status.Instance = instance.Name
+		status.Version = instance.Version
+		status.AuthMode = instance.AuthMode
+	}
+	if err != nil {
+		if !errors.Is(err, errNotInstalled) {
+			status.Error = err.Error()
+		}
+		return status
+	}
+	status.Host = host
+
+	// the parent "-" lists the results of all namespaces
+	parent := namespace
+	if parent == "" {
+		parent = "-"
+	}
+	probeURL := fmt.Sprintf("https://%s/apis/results.tekton.dev/v1alpha2/parents/%s/results?page_size=1", host, url.PathEscape(parent))
+	probeCtx, cancel := context.WithTimeout(ctx, statusProbeTimeout)
+	defer cancel()
+
+	serviceRequest, err := newServiceRequest(probeCtx, probeURL, user)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	serviceResponse, err := doServiceRequest(serviceRequest, k8sMode)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	serviceResponse.Body.Close()
+
+	status.Reachable = true
+	switch serviceResponse.StatusCode {
+	case http.StatusOK:
+		status.Authorized = true
+		status.APIVersion = resultsAPIVersion
+	case http.StatusUnauthorized, http.StatusForbidden:
+		status.APIVersion = resultsAPIVersion
+		status.Error = fmt.Sprintf("not allowed to list the results of namespace %q", parent)
+	case http.StatusNotFound:
+		status.Error = fmt.Sprintf("TektonResults does not serve %s", resultsAPIVersion)
+	default:
+		status.Error = fmt.Sprintf("TektonResults responded with status %d", serviceResponse.StatusCode)
+	}
+	return status
+}
@@ -1,15 +1,128 @@
 package tektonresults
 
+import (
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 type TektonResultsRequest struct {
-	SearchNamespace string `json:"searchNamespace"`
+	SearchNamespace string `json:"searchNamespace" validate:"required,dns1123label"`
 	SearchParams    string `json:"searchParams"`
 }
 
 type SummaryRequest struct {
-	SearchNamespace string `json:"searchNamespace"`
+	SearchNamespace string `json:"searchNamespace" validate:"required,dns1123label"`
 	SearchParams    string `json:"searchParams"`
 }
 
 type TaskRunLogRequest struct {
-	TaskRunPath string `json:"taskRunPath"`
+	TaskRunPath string `json:"taskRunPath" validate:"required"`
+}
+
+type RecordsRequest struct {
+	SearchNamespace string `json:"searchNamespace" validate:"required,dns1123label"`
+	SearchParams    string `json:"searchParams"`
+	// Limit is the number of records to collect before returning, pages are
+	// fetched until it is reached or there are no more records.
+	Limit int `json:"limit"`
+	// PageToken continues a previous request
+	PageToken string `json:"pageToken"`
+}
+
+// RecordList is a list of TektonResults records with their data decoded.
+type RecordList struct {
+	Records []Record `json:"records"`
+	// NextPageToken is empty when there are no more records
+	NextPageToken string `json:"nextPageToken,omitempty"`
+}
+
+// Record is a TektonResults record. PipelineRun and TaskRun records are decoded into
+// PipelineRun and TaskRun, the data of other records is returned as is.
+type Record struct {
+	Name        string          `json:"name"`
+	UID         string          `json:"uid"`
+	Etag        string          `json:"etag,omitempty"`
+	CreateTime  string          `json:"createTime,omitempty"`
+	UpdateTime  string          `json:"updateTime,omitempty"`
+	Type        string          `json:"type"`
+	PipelineRun *PipelineRun    `json:"pipelineRun,omitempty"`
+	TaskRun     *TaskRun        `json:"taskRun,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+}
+
+// PipelineRun holds the fields of a Tekton PipelineRun shown by the console.
+type PipelineRun struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              PipelineRunSpec   `json:"spec"`
+	Status            PipelineRunStatus `json:"status"`
+}
+
+type PipelineRunSpec struct {
+	PipelineRef *Ref    `json:"pipelineRef,omitempty"`
+	Params      []Param `json:"params,omitempty"`
+}
+
+type PipelineRunStatus struct {
+	RunStatus       `json:",inline"`
+	ChildReferences []ChildReference `json:"childReferences,omitempty"`
+}
+
+type ChildReference struct {
+	Kind             string `json:"kind,omitempty"`
+	Name             string `json:"name,omitempty"`
+	PipelineTaskName string `json:"pipelineTaskName,omitempty"`
+}
+
+// TaskRun holds the fields of a Tekton TaskRun shown by the console.
+type TaskRun struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              TaskRunSpec   `json:"spec"`
+	Status            TaskRunStatus `json:"status"`
+}
+
+type TaskRunSpec struct {
+	TaskRef            *Ref    `json:"taskRef,omitempty"`
+	Params             []Param `json:"params,omitempty"`
+	ServiceAccountName string  `json:"serviceAccountName,omitempty"`
+}
+
+type TaskRunStatus struct {
+	RunStatus `json:",inline"`
+	PodName   string      `json:"podName,omitempty"`
+	Steps     []StepState `json:"steps,omitempty"`
+}
+
+type StepState struct {
+	Name      string `json:"name,omitempty"`
+	Container string `json:"container,omitempty"`
+}
+
+// RunStatus holds the status fields shared by PipelineRuns and TaskRuns.
+type RunStatus struct {
+	Conditions     []Condition  `json:"conditions,omitempty"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	Results        []Param      `json:"results,omitempty"`
+}
+
+type Condition struct {
+	Type               string       `json:"type"`
+	Status             string       `json:"status"`
+	Reason             string       `json:"reason,omitempty"`
+	Message            string       `json:"message,omitempty"`
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+type Ref struct {
+	Name string `json:"name,omitempty"`
+	Kind string `json:"kind,omitempty"`
+}
+
+// Param is a parameter or result, its value is a string, an array or an object.
+type Param struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value,omitempty"`
 }
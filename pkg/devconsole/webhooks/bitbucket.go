@@ -0,0 +1,208 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+var bitbucketEvents = map[string][]string{
+	EventPush:        {"repo:push"},
+	EventPullRequest: {"pullrequest:created", "pullrequest:updated"},
+}
+
+var bitbucketServerEvents = map[string][]string{
+	EventPush:        {"repo:refs_changed"},
+	EventPullRequest: {"pr:opened", "pr:from_ref_updated"},
+}
+
+// bitbucketHook is a repository webhook of the Bitbucket Cloud API.
+type bitbucketHook struct {
+	UUID                 string   `json:"uuid,omitempty"`
+	Description          string   `json:"description"`
+	URL                  string   `json:"url"`
+	Active               bool     `json:"active"`
+	Events               []string `json:"events"`
+	SkipCertVerification bool     `json:"skip_cert_verification"`
+	Secret               string   `json:"secret,omitempty"`
+}
+
+func newBitbucketHook(hook Webhook) *bitbucketHook {
+	return &bitbucketHook{
+		Description:          "OpenShift Console",
+		URL:                  hook.URL,
+		Active:               hook.Active,
+		Events:               toProviderEvents(hook.Events, bitbucketEvents),
+		SkipCertVerification: hook.InsecureSSL,
+		Secret:               hook.Secret,
+	}
+}
+
+func (h *bitbucketHook) webhook() *Webhook {
+	return &Webhook{
+		ID:          h.UUID,
+		URL:         h.URL,
+		Events:      fromProviderEvents(h.Events, bitbucketEvents),
+		Active:      h.Active,
+		InsecureSSL: h.SkipCertVerification,
+	}
+}
+
+// bitbucketProvider manages the webhooks of Bitbucket Cloud. The owner is the workspace.
+type bitbucketProvider struct {
+	api *apiClient
+}
+
+func (p *bitbucketProvider) hooksURL(repo Repository, elem ...string) *url.URL {
+	return repo.BaseURL.JoinPath(append([]string{"repositories", repo.Owner, repo.Name, "hooks"}, elem...)...)
+}
+
+func (p *bitbucketProvider) CreateWebhook(ctx context.Context, repo Repository, hook Webhook) (*Webhook, error) {
+	created := &bitbucketHook{}
+	if _, err := p.api.do(ctx, http.MethodPost, p.hooksURL(repo), newBitbucketHook(hook), created); err != nil {
+		return nil, err
+	}
+	return created.webhook(), nil
+}
+
+func (p *bitbucketProvider) ListWebhooks(ctx context.Context, repo Repository) ([]Webhook, error) {
+	webhooks := []Webhook{}
+	listURL := p.hooksURL(repo)
+	listURL.RawQuery = url.Values{"pagelen": {"100"}}.Encode()
+	for page := 0; page < maxPages; page++ {
+		var hooks struct {
+			Values []bitbucketHook `json:"values"`
+			Next   string          `json:"next"`
+		}
+		if _, err := p.api.do(ctx, http.MethodGet, listURL, nil, &hooks); err != nil {
+			return nil, err
+		}
+		for _, hook := range hooks.Values {
+			webhooks = append(webhooks, *hook.webhook())
+		}
+
+		next, ok := nextPageURL(repo.BaseURL, hooks.Next)
+		if !ok {
+			break
+		}
+		listURL = next
+	}
+	return webhooks, nil
+}
+
+func (p *bitbucketProvider) UpdateWebhook(ctx context.Context, repo Repository, hook Webhook) (*Webhook, error) {
+	updated := &bitbucketHook{}
+	if _, err := p.api.do(ctx, http.MethodPut, p.hooksURL(repo, hook.ID), newBitbucketHook(hook), updated); err != nil {
+		return nil, err
+	}
+	return updated.webhook(), nil
+}
+
+func (p *bitbucketProvider) DeleteWebhook(ctx context.Context, repo Repository, id string) error {
+	_, err := p.api.do(ctx, http.MethodDelete, p.hooksURL(repo, id), nil, nil)
+	return err
+}
+
+func (p *bitbucketProvider) TestWebhook(ctx context.Context, repo Repository, id string) error {
+	return errNotSupported("Bitbucket Cloud", "test deliveries")
+}
+
+// bitbucketServerHook is a repository webhook of the Bitbucket Server (Data Center) API.
+type bitbucketServerHook struct {
+	ID                      int64             `json:"id,omitempty"`
+	Name                    string            `json:"name"`
+	URL                     string            `json:"url"`
+	Active                  bool              `json:"active"`
+	Events                  []string          `json:"events"`
+	Configuration           map[string]string `json:"configuration,omitempty"`
+	SSLVerificationRequired bool              `json:"sslVerificationRequired"`
+}
+
+func newBitbucketServerHook(hook Webhook) *bitbucketServerHook {
+	serverHook := &bitbucketServerHook{
+		Name:                    "OpenShift Console",
+		URL:                     hook.URL,
+		Active:                  hook.Active,
+		Events:                  toProviderEvents(hook.Events, bitbucketServerEvents),
+		SSLVerificationRequired: !hook.InsecureSSL,
+	}
+	if hook.Secret != "" {
+		serverHook.Configuration = map[string]string{"secret": hook.Secret}
+	}
+	return serverHook
+}
+
+func (h *bitbucketServerHook) webhook() *Webhook {
+	return &Webhook{
+		ID:          strconv.FormatInt(h.ID, 10),
+		URL:         h.URL,
+		Events:      fromProviderEvents(h.Events, bitbucketServerEvents),
+		Active:      h.Active,
+		InsecureSSL: !h.SSLVerificationRequired,
+	}
+}
+
+// bitbucketServerProvider manages the webhooks of Bitbucket Server, whose API is at
+// /rest/api/1.0 of the server. The owner is the key of the project.
+type bitbucketServerProvider struct {
+	api *apiClient
+}
+
+func (p *bitbucketServerProvider) hooksURL(repo Repository, elem ...string) *url.URL {
+	return repo.BaseURL.JoinPath(append([]string{"projects", repo.Owner, "repos", repo.Name, "webhooks"}, elem...)...)
+}
+
+func (p *bitbucketServerProvider) CreateWebhook(ctx context.Context, repo Repository, hook Webhook) (*Webhook, error) {
+	created := &bitbucketServerHook{}
+	if _, err := p.api.do(ctx, http.MethodPost, p.hooksURL(repo), newBitbucketServerHook(hook), created); err != nil {
+		return nil, err
+	}
+	return created.webhook(), nil
+}
+
+func (p *bitbucketServerProvider) ListWebhooks(ctx context.Context, repo Repository) ([]Webhook, error) {
+	webhooks := []Webhook{}
+	start := 0
+	for page := 0; page < maxPages; page++ {
+		listURL := p.hooksURL(repo)
+		listURL.RawQuery = url.Values{"start": {strconv.Itoa(start)}, "limit": {"100"}}.Encode()
+		var hooks struct {
+			Values        []bitbucketServerHook `json:"values"`
+			IsLastPage    bool                  `json:"isLastPage"`
+			NextPageStart int                   `json:"nextPageStart"`
+		}
+		if _, err := p.api.do(ctx, http.MethodGet, listURL, nil, &hooks); err != nil {
+			return nil, err
+		}
+		for _, hook := range hooks.Values {
+			webhooks = append(webhooks, *hook.webhook())
+		}
+
+		if hooks.IsLastPage || hooks.NextPageStart <= start {
+			break
+		}
+		start = hooks.NextPageStart
+	}
+	return webhooks, nil
+}
+
+func (p *bitbucketServerProvider) UpdateWebhook(ctx context.Context, repo Repository, hook Webhook) (*Webhook, error) {
+	updated := &bitbucketServerHook{}
+	if _, err := p.api.do(ctx, http.MethodPut, p.hooksURL(repo, hook.ID), newBitbucketServerHook(hook), updated); err != nil {
+		return nil, err
+	}
+	return updated.webhook(), nil
+}
+
+func (p *bitbucketServerProvider) DeleteWebhook(ctx context.Context, repo Repository, id string) error {
+	_, err := p.api.do(ctx, http.MethodDelete, p.hooksURL(repo, id), nil, nil)
+	return err
+}
+
+func (p *bitbucketServerProvider) TestWebhook(ctx context.Context, repo Repository, id string) error {
+	testURL := p.hooksURL(repo, "test")
+	testURL.RawQuery = url.Values{"webhookId": {id}}.Encode()
+	_, err := p.api.do(ctx, http.MethodPost, testURL, nil, nil)
+	return err
+}
@@ -0,0 +1,89 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+var giteaEvents = map[string][]string{
+	EventPush:        {"push"},
+	EventPullRequest: {"pull_request"},
+}
+
+// giteaHook is a repository webhook of the Gitea API. Certificates are verified according
+// to the configuration of the server, a webhook cannot skip the verification.
+type giteaHook struct {
+	ID     int64             `json:"id,omitempty"`
+	Type   string            `json:"type,omitempty"`
+	Active bool              `json:"active"`
+	Events []string          `json:"events"`
+	Config map[string]string `json:"config"`
+}
+
+func newGiteaHook(hook Webhook) *giteaHook {
+	config := map[string]string{"url": hook.URL, "content_type": "json"}
+	if hook.Secret != "" {
+		config["secret"] = hook.Secret
+	}
+	return &giteaHook{Type: "gitea", Active: hook.Active, Events: toProviderEvents(hook.Events, giteaEvents), Config: config}
+}
+
+func (h *giteaHook) webhook() *Webhook {
+	return &Webhook{
+		ID:     strconv.FormatInt(h.ID, 10),
+		URL:    h.Config["url"],
+		Events: fromProviderEvents(h.Events, giteaEvents),
+		Active: h.Active,
+	}
+}
+
+// giteaProvider manages the webhooks of Gitea, whose API is at /api/v1 of the server.
+type giteaProvider struct {
+	api *apiClient
+}
+
+func (p *giteaProvider) hooksURL(repo Repository, elem ...string) *url.URL {
+	return repo.BaseURL.JoinPath(append([]string{"repos", repo.Owner, repo.Name, "hooks"}, elem...)...)
+}
+
+func (p *giteaProvider) CreateWebhook(ctx context.Context, repo Repository, hook Webhook) (*Webhook, error) {
+	created := &giteaHook{}
+	if _, err := p.api.do(ctx, http.MethodPost, p.hooksURL(repo), newGiteaHook(hook), created); err != nil {
+		return nil, err
+	}
+	return created.webhook(), nil
+}
+
+func (p *giteaProvider) ListWebhooks(ctx context.Context, repo Repository) ([]Webhook, error) {
+	listURL := p.hooksURL(repo)
+	listURL.RawQuery = url.Values{"limit": {"50"}}.Encode()
+	hooks, err := listLinkedPages[giteaHook](ctx, p.api, repo.BaseURL, listURL)
+	if err != nil {
+		return nil, err
+	}
+	webhooks := make([]Webhook, 0, len(hooks))
+	for _, hook := range hooks {
+		webhooks = append(webhooks, *hook.webhook())
+	}
+	return webhooks, nil
+}
+
+func (p *giteaProvider) UpdateWebhook(ctx context.Context, repo Repository, hook Webhook) (*Webhook, error) {
+	updated := &giteaHook{}
+	if _, err := p.api.do(ctx, http.MethodPatch, p.hooksURL(repo, hook.ID), newGiteaHook(hook), updated); err != nil {
+		return nil, err
+	}
+	return updated.webhook(), nil
+}
+
+func (p *giteaProvider) DeleteWebhook(ctx context.Context, repo Repository, id string) error {
+	_, err := p.api.do(ctx, http.MethodDelete, p.hooksURL(repo, id), nil, nil)
+	return err
+}
+
+func (p *giteaProvider) TestWebhook(ctx context.Context, repo Repository, id string) error {
+	_, err := p.api.do(ctx, http.MethodPost, p.hooksURL(repo, id, "tests"), nil, nil)
+	return err
+}
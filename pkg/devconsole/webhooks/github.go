@@ -0,0 +1,100 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+var githubEvents = map[string][]string{
+	EventPush:        {"push"},
+	EventPullRequest: {"pull_request"},
+}
+
+// githubHook is a repository webhook of the GitHub REST API.
+type githubHook struct {
+	ID     int64    `json:"id,omitempty"`
+	Name   string   `json:"name,omitempty"`
+	Active bool     `json:"active"`
+	Events []string `json:"events"`
+	Config struct {
+		URL         string `json:"url"`
+		ContentType string `json:"content_type,omitempty"`
+		InsecureSSL string `json:"insecure_ssl,omitempty"`
+		Secret      string `json:"secret,omitempty"`
+	} `json:"config"`
+}
+
+func newGithubHook(hook Webhook) *githubHook {
+	githubHook := &githubHook{Name: "web", Active: hook.Active, Events: toProviderEvents(hook.Events, githubEvents)}
+	githubHook.Config.URL = hook.URL
+	githubHook.Config.ContentType = "json"
+	githubHook.Config.InsecureSSL = "0"
+	if hook.InsecureSSL {
+		githubHook.Config.InsecureSSL = "1"
+	}
+	githubHook.Config.Secret = hook.Secret
+	return githubHook
+}
+
+func (h *githubHook) webhook() *Webhook {
+	return &Webhook{
+		ID:          strconv.FormatInt(h.ID, 10),
+		URL:         h.Config.URL,
+		Events:      fromProviderEvents(h.Events, githubEvents),
+		Active:      h.Active,
+		InsecureSSL: h.Config.InsecureSSL == "1",
+	}
+}
+
+// githubProvider manages the webhooks of GitHub and GitHub Enterprise, whose API is at
+// /api/v3 of the server.
+type githubProvider struct {
+	api *apiClient
+}
+
+func (p *githubProvider) hooksURL(repo Repository, elem ...string) *url.URL {
+	return repo.BaseURL.JoinPath(append([]string{"repos", repo.Owner, repo.Name, "hooks"}, elem...)...)
+}
+
+func (p *githubProvider) CreateWebhook(ctx context.Context, repo Repository, hook Webhook) (*Webhook, error) {
+	created := &githubHook{}
+	if _, err := p.api.do(ctx, http.MethodPost, p.hooksURL(repo), newGithubHook(hook), created); err != nil {
+		return nil, err
+	}
+	return created.webhook(), nil
+}
+
+func (p *githubProvider) ListWebhooks(ctx context.Context, repo Repository) ([]Webhook, error) {
+	listURL := p.hooksURL(repo)
+	listURL.RawQuery = url.Values{"per_page": {"100"}}.Encode()
+	hooks, err := listLinkedPages[githubHook](ctx, p.api, repo.BaseURL, listURL)
+	if err != nil {
+		return nil, err
+	}
+	webhooks := make([]Webhook, 0, len(hooks))
+	for _, hook := range hooks {
+		webhooks = append(webhooks, *hook.webhook())
+	}
+	return webhooks, nil
+}
+
+func (p *githubProvider) UpdateWebhook(ctx context.Context, repo Repository, hook Webhook) (*Webhook, error) {
+	updated := &githubHook{}
+	if _, err := p.api.do(ctx, http.MethodPatch, p.hooksURL(repo, hook.ID), newGithubHook(hook), updated); err != nil {
+		return nil, err
+	}
+	return updated.webhook(), nil
+}
+
+func (p *githubProvider) DeleteWebhook(ctx context.Context, repo Repository, id string) error {
+	_, err := p.api.do(ctx, http.MethodDelete, p.hooksURL(repo, id), nil, nil)
+	return err
+}
+
+func (p *githubProvider) TestWebhook(ctx context.Context, repo Repository, id string) error {
+	// a ping is delivered regardless of the events of the webhook
+	_, err := p.api.do(ctx, http.MethodPost, p.hooksURL(repo, id, "pings"), nil, nil)
+	return err
+}
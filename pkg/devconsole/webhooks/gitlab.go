@@ -0,0 +1,141 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/openshift/console/pkg/devconsole/common"
+)
+
+var gitlabEvents = map[string][]string{
+	EventPush:        {"push_events"},
+	EventPullRequest: {"merge_requests_events"},
+}
+
+// gitlabEventFlags are the events of a GitLab project hook. They are all sent when a hook
+// is updated, so that events can be turned off.
+var gitlabEventFlags = []string{
+	"push_events",
+	"tag_push_events",
+	"merge_requests_events",
+	"issues_events",
+	"note_events",
+	"pipeline_events",
+	"job_events",
+	"releases_events",
+}
+
+// gitlabHook is a project hook of the GitLab API. The events are boolean fields whose
+// names vary between versions, so the hook is kept as a map.
+type gitlabHook map[string]interface{}
+
+func newGitlabHook(hook Webhook) (gitlabHook, error) {
+	gitlabHook := gitlabHook{
+		"url":                     hook.URL,
+		"enable_ssl_verification": !hook.InsecureSSL,
+	}
+	if hook.Secret != "" {
+		gitlabHook["token"] = hook.Secret
+	}
+	for _, flag := range gitlabEventFlags {
+		gitlabHook[flag] = false
+	}
+	for _, event := range toProviderEvents(hook.Events, gitlabEvents) {
+		if !strings.HasSuffix(event, "_events") {
+			return nil, &common.ValidationError{Err: fmt.Errorf("unknown GitLab event %q", event)}
+		}
+		gitlabHook[event] = true
+	}
+	return gitlabHook, nil
+}
+
+func (h gitlabHook) webhook() *Webhook {
+	var events []string
+	for key, value := range h {
+		if enabled, ok := value.(bool); ok && enabled && strings.HasSuffix(key, "_events") {
+			events = append(events, key)
+		}
+	}
+	sort.Strings(events)
+
+	webhook := &Webhook{
+		Events: fromProviderEvents(events, gitlabEvents),
+		// GitLab disables failing hooks temporarily, but they cannot be deactivated
+		Active: true,
+	}
+	if id, ok := h["id"].(float64); ok {
+		webhook.ID = fmt.Sprintf("%.0f", id)
+	}
+	webhook.URL, _ = h["url"].(string)
+	if verify, ok := h["enable_ssl_verification"].(bool); ok {
+		webhook.InsecureSSL = !verify
+	}
+	return webhook
+}
+
+// gitlabProvider manages the project hooks of GitLab, whose API is at /api/v4 of the
+// server. The owner is the namespace of the project, including subgroups.
+type gitlabProvider struct {
+	api *apiClient
+}
+
+func (p *gitlabProvider) hooksURL(repo Repository, elem ...string) *url.URL {
+	// the path of the project is a single element of the URL
+	project := strings.Trim(repo.Owner+"/"+repo.Name, "/")
+	projectsURL := repo.BaseURL.JoinPath("projects")
+	projectsURL.RawPath = projectsURL.EscapedPath() + "/" + url.PathEscape(project)
+	projectsURL.Path = projectsURL.Path + "/" + project
+	return projectsURL.JoinPath(append([]string{"hooks"}, elem...)...)
+}
+
+func (p *gitlabProvider) CreateWebhook(ctx context.Context, repo Repository, hook Webhook) (*Webhook, error) {
+	body, err := newGitlabHook(hook)
+	if err != nil {
+		return nil, err
+	}
+	created := gitlabHook{}
+	if _, err := p.api.do(ctx, http.MethodPost, p.hooksURL(repo), body, &created); err != nil {
+		return nil, err
+	}
+	return created.webhook(), nil
+}
+
+func (p *gitlabProvider) ListWebhooks(ctx context.Context, repo Repository) ([]Webhook, error) {
+	listURL := p.hooksURL(repo)
+	listURL.RawQuery = url.Values{"per_page": {"100"}}.Encode()
+	hooks, err := listLinkedPages[gitlabHook](ctx, p.api, repo.BaseURL, listURL)
+	if err != nil {
+		return nil, err
+	}
+	webhooks := make([]Webhook, 0, len(hooks))
+	for _, hook := range hooks {
+		webhooks = append(webhooks, *hook.webhook())
+	}
+	return webhooks, nil
+}
+
+func (p *gitlabProvider) UpdateWebhook(ctx context.Context, repo Repository, hook Webhook) (*Webhook, error) {
+	body, err := newGitlabHook(hook)
+	if err != nil {
+		return nil, err
+	}
+	updated := gitlabHook{}
+	if _, err := p.api.do(ctx, http.MethodPut, p.hooksURL(repo, hook.ID), body, &updated); err != nil {
+		return nil, err
+	}
+	return updated.webhook(), nil
+}
+
+func (p *gitlabProvider) DeleteWebhook(ctx context.Context, repo Repository, id string) error {
+	_, err := p.api.do(ctx, http.MethodDelete, p.hooksURL(repo, id), nil, nil)
+	return err
+}
+
+func (p *gitlabProvider) TestWebhook(ctx context.Context, repo Repository, id string) error {
+	_, err := p.api.do(ctx, http.MethodPost, p.hooksURL(repo, id, "test", "push_events"), nil, nil)
+	return err
+}
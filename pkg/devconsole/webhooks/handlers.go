@@ -0,0 +1,192 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/openshift/console/pkg/auth"
+	"github.com/openshift/console/pkg/devconsole/common"
+	"github.com/openshift/console/pkg/serverutils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const defaultTokenSecretKey = "token"
+
+// SecretGetter reads a Secret with the permissions of the user, so that users can only
+// use the tokens they have access to.
+type SecretGetter func(ctx context.Context, namespace, name string) (*corev1.Secret, error)
+
+// NewUserSecretGetter returns a SecretGetter that authenticates as the user.
+func NewUserSecretGetter(anonClientConfig *rest.Config, user *auth.User) SecretGetter {
+	return func(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
+		config := rest.CopyConfig(anonClientConfig)
+		config.BearerToken = user.Token
+		config.Impersonate = user.ImpersonationConfig()
+		client, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+}
+
+// webhookTarget is a validated WebhookRequest.
+type webhookTarget struct {
+	request  WebhookRequest
+	provider GitProvider
+	repo     Repository
+}
+
+func parseWebhookRequest(r *http.Request, secrets SecretGetter) (*webhookTarget, error) {
+	var request WebhookRequest
+	if err := serverutils.DecodeRequest(r, &request); err != nil {
+		return nil, &common.ValidationError{Err: err}
+	}
+	if !slices.Contains(gitProviders, request.Provider) {
+		return nil, &common.ValidationError{Err: fmt.Errorf("unknown git provider %q", request.Provider)}
+	}
+
+	rawBaseURL := request.BaseURL
+	if rawBaseURL == "" {
+		rawBaseURL = defaultBaseURLs[request.Provider]
+	}
+	baseURL, err := ValidateHostURL(rawBaseURL)
+	if err != nil {
+		return nil, &common.ValidationError{Err: fmt.Errorf("invalid baseURL: %v", err)}
+	}
+
+	owner := []string{request.Owner}
+	if request.Provider == ProviderGitLab {
+		// projects of GitLab can be nested in subgroups
+		owner = strings.Split(request.Owner, "/")
+	}
+	for _, element := range owner {
+		if err := validatePathElement("owner", element); err != nil {
+			return nil, &common.ValidationError{Err: err}
+		}
+	}
+	if err := validatePathElement("repoName", request.RepoName); err != nil {
+		return nil, &common.ValidationError{Err: err}
+	}
+
+	token, err := readToken(r.Context(), secrets, request.TokenSecret)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := NewGitProvider(request.Provider, token)
+	if err != nil {
+		return nil, &common.ValidationError{Err: err}
+	}
+
+	return &webhookTarget{
+		request:  request,
+		provider: provider,
+		repo:     Repository{BaseURL: baseURL, Owner: request.Owner, Name: request.RepoName},
+	}, nil
+}
+
+func readToken(ctx context.Context, secrets SecretGetter, ref SecretRef) (string, error) {
+	if ref.Namespace == "" || ref.Name == "" {
+		return "", &common.ValidationError{Err: fmt.Errorf("tokenSecret requires a namespace and a name")}
+	}
+	key := ref.Key
+	if key == "" {
+		key = defaultTokenSecretKey
+	}
+
+	secret, err := secrets(ctx, ref.Namespace, ref.Name)
+	if err != nil {
+		return "", &common.ValidationError{Err: fmt.Errorf("failed to read secret %s/%s: %v", ref.Namespace, ref.Name, err)}
+	}
+	token := strings.TrimSpace(string(secret.Data[key]))
+	if token == "" {
+		return "", &common.ValidationError{Err: fmt.Errorf("secret %s/%s has no key %q", ref.Namespace, ref.Name, key)}
+	}
+	return token, nil
+}
+
+func validateWebhookID(id string) error {
+	if err := validatePathElement("webhook ID", id); err != nil {
+		return &common.ValidationError{Err: err}
+	}
+	return nil
+}
+
+func validateWebhook(hook Webhook) error {
+	// the URL is called by the provider rather than the console, it is often a route of the cluster
+	hookURL, err := url.Parse(hook.URL)
+	if err != nil || (hookURL.Scheme != "https" && hookURL.Scheme != "http") || hookURL.Host == "" {
+		return &common.ValidationError{Err: fmt.Errorf("invalid webhook URL %q", hook.URL)}
+	}
+	if len(hook.Events) == 0 {
+		return &common.ValidationError{Err: fmt.Errorf("webhook requires at least one event")}
+	}
+	return nil
+}
+
+// CreateWebhook creates a webhook with the token of the referenced Secret.
+func CreateWebhook(r *http.Request, secrets SecretGetter) (*Webhook, error) {
+	target, err := parseWebhookRequest(r, secrets)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateWebhook(target.request.Webhook); err != nil {
+		return nil, err
+	}
+	return target.provider.CreateWebhook(r.Context(), target.repo, target.request.Webhook)
+}
+
+// ListWebhooks lists the webhooks of a repository.
+func ListWebhooks(r *http.Request, secrets SecretGetter) ([]Webhook, error) {
+	target, err := parseWebhookRequest(r, secrets)
+	if err != nil {
+		return nil, err
+	}
+	return target.provider.ListWebhooks(r.Context(), target.repo)
+}
+
+// UpdateWebhook replaces the webhook with the ID of the given webhook.
+func UpdateWebhook(r *http.Request, secrets SecretGetter) (*Webhook, error) {
+	target, err := parseWebhookRequest(r, secrets)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateWebhookID(target.request.Webhook.ID); err != nil {
+		return nil, err
+	}
+	if err := validateWebhook(target.request.Webhook); err != nil {
+		return nil, err
+	}
+	return target.provider.UpdateWebhook(r.Context(), target.repo, target.request.Webhook)
+}
+
+// DeleteWebhook deletes the webhook with the given ID.
+func DeleteWebhook(r *http.Request, secrets SecretGetter) error {
+	target, err := parseWebhookRequest(r, secrets)
+	if err != nil {
+		return err
+	}
+	if err := validateWebhookID(target.request.WebhookID); err != nil {
+		return err
+	}
+	return target.provider.DeleteWebhook(r.Context(), target.repo, target.request.WebhookID)
+}
+
+// TestWebhook triggers a test delivery of the webhook with the given ID.
+func TestWebhook(r *http.Request, secrets SecretGetter) error {
+	target, err := parseWebhookRequest(r, secrets)
+	if err != nil {
+		return err
+	}
+	if err := validateWebhookID(target.request.WebhookID); err != nil {
+		return err
+	}
+	return target.provider.TestWebhook(r.Context(), target.repo, target.request.WebhookID)
+}
@@ -0,0 +1,240 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/openshift/console/pkg/devconsole/common"
+)
+
+const (
+	ProviderGitHub          = "github"
+	ProviderGitLab          = "gitlab"
+	ProviderBitbucket       = "bitbucket"
+	ProviderBitbucketServer = "bitbucket-server"
+	ProviderGitea           = "gitea"
+
+	// EventPush and EventPullRequest are translated to the events of each provider.
+	// Other events are passed to the provider as they are.
+	EventPush        = "push"
+	EventPullRequest = "pull_request"
+
+	// maxPages bounds the number of pages followed when listing webhooks
+	maxPages = 10
+)
+
+var gitProviders = []string{ProviderGitHub, ProviderGitLab, ProviderBitbucket, ProviderBitbucketServer, ProviderGitea}
+
+// defaultBaseURLs are the APIs of the hosted services
+var defaultBaseURLs = map[string]string{
+	ProviderGitHub:    "https://api.github.com",
+	ProviderGitLab:    "https://gitlab.com/api/v4",
+	ProviderBitbucket: "https://api.bitbucket.org/2.0",
+}
+
+// Webhook is a webhook of a repository, in a form that is common to all providers.
+type Webhook struct {
+	ID     string   `json:"id,omitempty"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Active bool     `json:"active"`
+	// InsecureSSL disables the verification of the certificate of URL
+	InsecureSSL bool `json:"insecureSSL,omitempty"`
+	// Secret signs the deliveries, providers never return it
+	Secret string `json:"secret,omitempty"`
+}
+
+// Repository identifies a repository at the API of a provider. Owner is the project of
+// GitLab and Bitbucket Server, and the workspace of Bitbucket Cloud.
+type Repository struct {
+	BaseURL *url.URL
+	Owner   string
+	Name    string
+}
+
+// GitProvider manages the webhooks of a repository at a git provider.
+type GitProvider interface {
+	CreateWebhook(ctx context.Context, repo Repository, hook Webhook) (*Webhook, error)
+	ListWebhooks(ctx context.Context, repo Repository) ([]Webhook, error)
+	UpdateWebhook(ctx context.Context, repo Repository, hook Webhook) (*Webhook, error)
+	DeleteWebhook(ctx context.Context, repo Repository, id string) error
+	// TestWebhook asks the provider to deliver a test event to the webhook
+	TestWebhook(ctx context.Context, repo Repository, id string) error
+}
+
+// NewGitProvider returns the provider with the given name, authenticating with the token.
+func NewGitProvider(name string, token string) (GitProvider, error) {
+	switch name {
+	case ProviderGitHub:
+		return &githubProvider{api: &apiClient{authorize: bearerAuth(token)}}, nil
+	case ProviderGitLab:
+		return &gitlabProvider{api: &apiClient{authorize: headerAuth("PRIVATE-TOKEN", token)}}, nil
+	case ProviderBitbucket:
+		return &bitbucketProvider{api: &apiClient{authorize: bearerAuth(token)}}, nil
+	case ProviderBitbucketServer:
+		return &bitbucketServerProvider{api: &apiClient{authorize: bearerAuth(token)}}, nil
+	case ProviderGitea:
+		return &giteaProvider{api: &apiClient{authorize: headerAuth("Authorization", "token "+token)}}, nil
+	}
+	return nil, fmt.Errorf("unknown git provider %q", name)
+}
+
+// errNotSupported is returned for operations a provider has no API for
+func errNotSupported(provider, operation string) error {
+	return &common.ValidationError{Err: fmt.Errorf("%s does not support %s", provider, operation)}
+}
+
+func bearerAuth(token string) func(*http.Request) {
+	return headerAuth("Authorization", "Bearer "+token)
+}
+
+func headerAuth(header, value string) func(*http.Request) {
+	return func(r *http.Request) {
+		r.Header.Set(header, value)
+	}
+}
+
+// toProviderEvents replaces the common events with the events of a provider.
+func toProviderEvents(events []string, providerEvents map[string][]string) []string {
+	translated := []string{}
+	for _, event := range events {
+		names, ok := providerEvents[event]
+		if !ok {
+			names = []string{event}
+		}
+		for _, name := range names {
+			if !slices.Contains(translated, name) {
+				translated = append(translated, name)
+			}
+		}
+	}
+	return translated
+}
+
+// fromProviderEvents reports the events of a provider with the common names where possible.
+func fromProviderEvents(events []string, providerEvents map[string][]string) []string {
+	translated := []string{}
+	remaining := slices.Clone(events)
+	for _, event := range []string{EventPush, EventPullRequest} {
+		matched := false
+		remaining = slices.DeleteFunc(remaining, func(e string) bool {
+			if slices.Contains(providerEvents[event], e) {
+				matched = true
+				return true
+			}
+			return false
+		})
+		if matched {
+			translated = append(translated, event)
+		}
+	}
+	return append(translated, remaining...)
+}
+
+// apiClient sends JSON requests to the API of a provider.
+type apiClient struct {
+	authorize func(*http.Request)
+}
+
+// do sends a request to the URL and decodes the response into result, if it is not nil.
+// Errors of the provider are returned as common.UpstreamError.
+func (c *apiClient) do(ctx context.Context, method string, requestURL *url.URL, body, result interface{}) (http.Header, error) {
+	var requestBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %v", err)
+		}
+		requestBody = bytes.NewReader(bodyBytes)
+	}
+
+	serviceRequest, err := http.NewRequestWithContext(ctx, method, requestURL.String(), requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	serviceRequest.Header.Set("Accept", "application/json")
+	if body != nil {
+		serviceRequest.Header.Set("Content-Type", "application/json")
+	}
+	c.authorize(serviceRequest)
+
+	serviceResponse, err := client.Do(serviceRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	defer serviceResponse.Body.Close()
+
+	responseBody, err := io.ReadAll(io.LimitReader(serviceResponse.Body, maxResponseBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if len(responseBody) > maxResponseBodySize {
+		return nil, fmt.Errorf("response body exceeds maximum allowed size of %d bytes", maxResponseBodySize)
+	}
+	if serviceResponse.StatusCode < 200 || serviceResponse.StatusCode > 299 {
+		return nil, &common.UpstreamError{StatusCode: serviceResponse.StatusCode, Body: string(responseBody)}
+	}
+	if result != nil && len(responseBody) > 0 {
+		if err := json.Unmarshal(responseBody, result); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %v", err)
+		}
+	}
+	return serviceResponse.Header, nil
+}
+
+// nextPageURL validates the URL of the next page returned by a provider. Only pages of
+// the same API are followed.
+func nextPageURL(baseURL *url.URL, next string) (*url.URL, bool) {
+	if next == "" {
+		return nil, false
+	}
+	nextURL, err := baseURL.Parse(next)
+	if err != nil || nextURL.Scheme != baseURL.Scheme || nextURL.Host != baseURL.Host || !strings.HasPrefix(nextURL.Path, baseURL.Path) {
+		return nil, false
+	}
+	return nextURL, true
+}
+
+// linkHeaderNext returns the URL of the next page of a Link header, as used by GitHub,
+// GitLab and Gitea.
+func linkHeaderNext(header http.Header) string {
+	for _, link := range strings.Split(header.Get("Link"), ",") {
+		target, params, ok := strings.Cut(link, ";")
+		if !ok {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if strings.ReplaceAll(strings.TrimSpace(param), `"`, "") == "rel=next" {
+				return strings.Trim(strings.TrimSpace(target), "<>")
+			}
+		}
+	}
+	return ""
+}
+
+// listLinkedPages lists the items of all pages that are linked with a Link header.
+func listLinkedPages[T any](ctx context.Context, api *apiClient, baseURL, listURL *url.URL) ([]T, error) {
+	var items []T
+	for page := 0; page < maxPages; page++ {
+		var pageItems []T
+		header, err := api.do(ctx, http.MethodGet, listURL, nil, &pageItems)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, pageItems...)
+
+		next, ok := nextPageURL(baseURL, linkHeaderNext(header))
+		if !ok {
+			break
+		}
+		listURL = next
+	}
+	return items, nil
+}
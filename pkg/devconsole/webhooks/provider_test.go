@@ -0,0 +1,334 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/openshift/console/pkg/devconsole/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type recordedRequest struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   map[string]interface{}
+}
+
+// newProviderServer stands in for the API of a provider. Responses are looked up by
+// method and escaped path, other requests are answered with 404.
+func newProviderServer(t *testing.T, responses map[string]string) (*httptest.Server, *[]recordedRequest) {
+	var requests []recordedRequest
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorded := recordedRequest{method: r.Method, path: r.URL.EscapedPath(), query: r.URL.Query(), header: r.Header.Clone()}
+		if body, _ := io.ReadAll(r.Body); len(body) > 0 {
+			assert.NoError(t, json.Unmarshal(body, &recorded.body))
+		}
+		requests = append(requests, recorded)
+
+		response, ok := responses[r.Method+" "+r.URL.EscapedPath()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message":"Not Found"}`)
+			return
+		}
+		if strings.HasPrefix(response, "Link:") {
+			link, body, _ := strings.Cut(strings.TrimPrefix(response, "Link:"), "\n")
+			w.Header().Set("Link", strings.ReplaceAll(link, "{{server}}", "https://"+r.Host))
+			response = body
+		}
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(ts.Close)
+
+	originalClient := client
+	client = ts.Client()
+	t.Cleanup(func() { client = originalClient })
+	return ts, &requests
+}
+
+func testRepository(t *testing.T, ts *httptest.Server, apiPath, owner, name string) Repository {
+	baseURL, err := url.Parse(ts.URL + apiPath)
+	require.NoError(t, err)
+	return Repository{BaseURL: baseURL, Owner: owner, Name: name}
+}
+
+var testHook = Webhook{
+	URL:    "https://el-listener.apps.example.com",
+	Events: []string{EventPush, EventPullRequest},
+	Active: true,
+	Secret: "webhook-secret",
+}
+
+func TestGithubProvider(t *testing.T) {
+	hook := `{"id":7,"name":"web","active":true,"events":["push","pull_request"],"config":{"url":"https://el-listener.apps.example.com","content_type":"json","insecure_ssl":"0"}}`
+	ts, requests := newProviderServer(t, map[string]string{
+		"POST /api/v3/repos/owner/repo/hooks":         hook,
+		"GET /api/v3/repos/owner/repo/hooks":          "Link:<{{server}}/api/v3/repos/owner/repo/hooks?page=2>; rel=\"next\"\n[" + hook + "]",
+		"PATCH /api/v3/repos/owner/repo/hooks/7":      hook,
+		"DELETE /api/v3/repos/owner/repo/hooks/7":     "",
+		"POST /api/v3/repos/owner/repo/hooks/7/pings": "",
+	})
+	repo := testRepository(t, ts, "/api/v3", "owner", "repo")
+	provider, err := NewGitProvider(ProviderGitHub, "github-token")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	created, err := provider.CreateWebhook(ctx, repo, testHook)
+	require.NoError(t, err)
+	assert.Equal(t, &Webhook{ID: "7", URL: testHook.URL, Events: []string{EventPush, EventPullRequest}, Active: true}, created)
+	assert.Equal(t, "Bearer github-token", (*requests)[0].header.Get("Authorization"))
+	assert.Equal(t, "web", (*requests)[0].body["name"])
+	assert.Equal(t, map[string]interface{}{"url": testHook.URL, "content_type": "json", "insecure_ssl": "0", "secret": "webhook-secret"}, (*requests)[0].body["config"])
+
+	// the second page is requested, but the stand-in serves the first one again
+	*requests = nil
+	hooks, err := provider.ListWebhooks(ctx, repo)
+	require.NoError(t, err)
+	assert.Len(t, hooks, maxPages)
+	assert.Equal(t, "2", (*requests)[1].query.Get("page"))
+
+	updated := testHook
+	updated.ID = "7"
+	_, err = provider.UpdateWebhook(ctx, repo, updated)
+	require.NoError(t, err)
+	assert.NoError(t, provider.DeleteWebhook(ctx, repo, "7"))
+	assert.NoError(t, provider.TestWebhook(ctx, repo, "7"))
+
+	err = provider.DeleteWebhook(ctx, repo, "8")
+	var upstreamErr *common.UpstreamError
+	require.ErrorAs(t, err, &upstreamErr)
+	assert.Equal(t, http.StatusNotFound, upstreamErr.StatusCode)
+}
+
+func TestGitlabProvider(t *testing.T) {
+	hook := `{"id":7,"url":"https://el-listener.apps.example.com","push_events":true,"merge_requests_events":true,"tag_push_events":false,"enable_ssl_verification":true}`
+	ts, requests := newProviderServer(t, map[string]string{
+		"POST /api/v4/projects/group%2Fsubgroup%2Frepo/hooks":                    hook,
+		"GET /api/v4/projects/group%2Fsubgroup%2Frepo/hooks":                     "[" + hook + "]",
+		"PUT /api/v4/projects/group%2Fsubgroup%2Frepo/hooks/7":                   hook,
+		"DELETE /api/v4/projects/group%2Fsubgroup%2Frepo/hooks/7":                "",
+		"POST /api/v4/projects/group%2Fsubgroup%2Frepo/hooks/7/test/push_events": `{"message":"201 Created"}`,
+	})
+	repo := testRepository(t, ts, "/api/v4", "group/subgroup", "repo")
+	provider, err := NewGitProvider(ProviderGitLab, "gitlab-token")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	created, err := provider.CreateWebhook(ctx, repo, testHook)
+	require.NoError(t, err)
+	assert.Equal(t, &Webhook{ID: "7", URL: testHook.URL, Events: []string{EventPush, EventPullRequest}, Active: true}, created)
+	request := (*requests)[0]
+	assert.Equal(t, "gitlab-token", request.header.Get("PRIVATE-TOKEN"))
+	assert.Equal(t, true, request.body["push_events"])
+	assert.Equal(t, true, request.body["merge_requests_events"])
+	assert.Equal(t, false, request.body["tag_push_events"])
+	assert.Equal(t, "webhook-secret", request.body["token"])
+
+	hooks, err := provider.ListWebhooks(ctx, repo)
+	require.NoError(t, err)
+	assert.Len(t, hooks, 1)
+
+	updated := testHook
+	updated.ID = "7"
+	updated.Events = []string{"tag_push_events"}
+	_, err = provider.UpdateWebhook(ctx, repo, updated)
+	require.NoError(t, err)
+	request = (*requests)[len(*requests)-1]
+	assert.Equal(t, false, request.body["push_events"], "events that are not listed are turned off")
+	assert.Equal(t, true, request.body["tag_push_events"])
+
+	updated.Events = []string{"deploy"}
+	_, err = provider.UpdateWebhook(ctx, repo, updated)
+	var validationErr *common.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+
+	assert.NoError(t, provider.DeleteWebhook(ctx, repo, "7"))
+	assert.NoError(t, provider.TestWebhook(ctx, repo, "7"))
+}
+
+func TestBitbucketProvider(t *testing.T) {
+	hook := `{"uuid":"{b1}","url":"https://el-listener.apps.example.com","active":true,"events":["repo:push","pullrequest:created","pullrequest:updated"],"skip_cert_verification":false}`
+	ts, requests := newProviderServer(t, map[string]string{
+		"POST /2.0/repositories/workspace/repo/hooks":            hook,
+		"GET /2.0/repositories/workspace/repo/hooks":             `{"values":[` + hook + `],"next":"https://bitbucket.example.com/2.0/repositories/workspace/repo/hooks?page=2"}`,
+		"PUT /2.0/repositories/workspace/repo/hooks/%7Bb1%7D":    hook,
+		"DELETE /2.0/repositories/workspace/repo/hooks/%7Bb1%7D": "",
+	})
+	repo := testRepository(t, ts, "/2.0", "workspace", "repo")
+	provider, err := NewGitProvider(ProviderBitbucket, "bitbucket-token")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	created, err := provider.CreateWebhook(ctx, repo, testHook)
+	require.NoError(t, err)
+	assert.Equal(t, &Webhook{ID: "{b1}", URL: testHook.URL, Events: []string{EventPush, EventPullRequest}, Active: true}, created)
+	assert.Equal(t, "Bearer bitbucket-token", (*requests)[0].header.Get("Authorization"))
+	assert.Equal(t, []interface{}{"repo:push", "pullrequest:created", "pullrequest:updated"}, (*requests)[0].body["events"])
+
+	// pages of other hosts are not followed
+	*requests = nil
+	hooks, err := provider.ListWebhooks(ctx, repo)
+	require.NoError(t, err)
+	assert.Len(t, hooks, 1)
+	assert.Len(t, *requests, 1)
+
+	updated := testHook
+	updated.ID = "{b1}"
+	_, err = provider.UpdateWebhook(ctx, repo, updated)
+	require.NoError(t, err)
+	assert.NoError(t, provider.DeleteWebhook(ctx, repo, "{b1}"))
+
+	var validationErr *common.ValidationError
+	assert.ErrorAs(t, provider.TestWebhook(ctx, repo, "{b1}"), &validationErr)
+}
+
+func TestBitbucketServerProvider(t *testing.T) {
+	hook := `{"id":7,"name":"OpenShift Console","url":"https://el-listener.apps.example.com","active":true,"events":["repo:refs_changed","pr:opened","pr:from_ref_updated"],"sslVerificationRequired":true}`
+	ts, requests := newProviderServer(t, map[string]string{
+		"POST /rest/api/1.0/projects/PRJ/repos/repo/webhooks":      hook,
+		"GET /rest/api/1.0/projects/PRJ/repos/repo/webhooks":       `{"values":[` + hook + `],"isLastPage":true}`,
+		"PUT /rest/api/1.0/projects/PRJ/repos/repo/webhooks/7":     hook,
+		"DELETE /rest/api/1.0/projects/PRJ/repos/repo/webhooks/7":  "",
+		"POST /rest/api/1.0/projects/PRJ/repos/repo/webhooks/test": `{"statusCode":200}`,
+	})
+	repo := testRepository(t, ts, "/rest/api/1.0", "PRJ", "repo")
+	provider, err := NewGitProvider(ProviderBitbucketServer, "server-token")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	created, err := provider.CreateWebhook(ctx, repo, testHook)
+	require.NoError(t, err)
+	assert.Equal(t, &Webhook{ID: "7", URL: testHook.URL, Events: []string{EventPush, EventPullRequest}, Active: true}, created)
+	assert.Equal(t, map[string]interface{}{"secret": "webhook-secret"}, (*requests)[0].body["configuration"])
+	assert.Equal(t, true, (*requests)[0].body["sslVerificationRequired"])
+
+	hooks, err := provider.ListWebhooks(ctx, repo)
+	require.NoError(t, err)
+	assert.Len(t, hooks, 1)
+
+	updated := testHook
+	updated.ID = "7"
+	_, err = provider.UpdateWebhook(ctx, repo, updated)
+	require.NoError(t, err)
+	assert.NoError(t, provider.DeleteWebhook(ctx, repo, "7"))
+	assert.NoError(t, provider.TestWebhook(ctx, repo, "7"))
+	assert.Equal(t, "7", (*requests)[len(*requests)-1].query.Get("webhookId"))
+}
+
+func TestGiteaProvider(t *testing.T) {
+	hook := `{"id":7,"type":"gitea","active":true,"events":["push","pull_request"],"config":{"url":"https://el-listener.apps.example.com","content_type":"json"}}`
+	ts, requests := newProviderServer(t, map[string]string{
+		"POST /api/v1/repos/owner/repo/hooks":         hook,
+		"GET /api/v1/repos/owner/repo/hooks":          "[" + hook + "]",
+		"PATCH /api/v1/repos/owner/repo/hooks/7":      hook,
+		"DELETE /api/v1/repos/owner/repo/hooks/7":     "",
+		"POST /api/v1/repos/owner/repo/hooks/7/tests": "",
+	})
+	repo := testRepository(t, ts, "/api/v1", "owner", "repo")
+	provider, err := NewGitProvider(ProviderGitea, "gitea-token")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	created, err := provider.CreateWebhook(ctx, repo, testHook)
+	require.NoError(t, err)
+	assert.Equal(t, &Webhook{ID: "7", URL: testHook.URL, Events: []string{EventPush, EventPullRequest}, Active: true}, created)
+	assert.Equal(t, "token gitea-token", (*requests)[0].header.Get("Authorization"))
+	assert.Equal(t, "gitea", (*requests)[0].body["type"])
+
+	hooks, err := provider.ListWebhooks(ctx, repo)
+	require.NoError(t, err)
+	assert.Len(t, hooks, 1)
+
+	updated := testHook
+	updated.ID = "7"
+	_, err = provider.UpdateWebhook(ctx, repo, updated)
+	require.NoError(t, err)
+	assert.NoError(t, provider.DeleteWebhook(ctx, repo, "7"))
+	assert.NoError(t, provider.TestWebhook(ctx, repo, "7"))
+}
+
+func TestProviderEvents(t *testing.T) {
+	assert.Equal(t, []string{"pr:opened", "pr:from_ref_updated", "repo:refs_changed"},
+		toProviderEvents([]string{EventPullRequest, EventPullRequest, EventPush}, bitbucketServerEvents))
+	assert.Equal(t, []string{EventPush, "issues"}, fromProviderEvents([]string{"issues", "push"}, githubEvents))
+}
+
+func TestCreateWebhook(t *testing.T) {
+	originalResolveIP := resolveIP
+	resolveIP = func(string) ([]net.IP, error) { return []net.IP{net.ParseIP("203.0.113.1")}, nil }
+	defer func() { resolveIP = originalResolveIP }()
+
+	hook := `{"id":7,"active":true,"events":["push"],"config":{"url":"https://el-listener.apps.example.com"}}`
+	ts, requests := newProviderServer(t, map[string]string{
+		"POST /api/v3/repos/owner/repo/hooks": hook,
+	})
+	secrets := func(_ context.Context, namespace, name string) (*corev1.Secret, error) {
+		if namespace != "ns" || name != "git-token" {
+			return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, name)
+		}
+		return &corev1.Secret{Data: map[string][]byte{"token": []byte("secret-token\n"), "other": nil}}, nil
+	}
+	newRequest := func(modify func(*WebhookRequest)) *http.Request {
+		request := WebhookRequest{
+			Provider:    ProviderGitHub,
+			BaseURL:     ts.URL + "/api/v3",
+			Owner:       "owner",
+			RepoName:    "repo",
+			TokenSecret: SecretRef{Namespace: "ns", Name: "git-token"},
+			Webhook:     Webhook{URL: "https://el-listener.apps.example.com", Events: []string{EventPush}, Active: true},
+		}
+		if modify != nil {
+			modify(&request)
+		}
+		body, err := json.Marshal(request)
+		require.NoError(t, err)
+		return httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body)))
+	}
+
+	created, err := CreateWebhook(newRequest(nil), secrets)
+	require.NoError(t, err)
+	assert.Equal(t, "7", created.ID)
+	assert.Equal(t, "Bearer secret-token", (*requests)[0].header.Get("Authorization"))
+
+	tests := []struct {
+		name   string
+		modify func(*WebhookRequest)
+		errMsg string
+	}{
+		{"unknown provider", func(r *WebhookRequest) { r.Provider = "svn" }, `unknown git provider "svn"`},
+		{"base URL required", func(r *WebhookRequest) { r.Provider, r.BaseURL = ProviderGitea, "" }, "invalid baseURL"},
+		{"cluster-internal base URL", func(r *WebhookRequest) { r.BaseURL = "https://gitea.gitea.svc" }, "cluster-internal"},
+		{"owner traversal", func(r *WebhookRequest) { r.Owner = ".." }, "invalid owner"},
+		{"repository with slash", func(r *WebhookRequest) { r.RepoName = "repo/hooks" }, "invalid repoName"},
+		{"missing secret", func(r *WebhookRequest) { r.TokenSecret.Name = "other" }, "failed to read secret ns/other"},
+		{"missing secret key", func(r *WebhookRequest) { r.TokenSecret.Key = "other" }, `has no key "other"`},
+		{"webhook without events", func(r *WebhookRequest) { r.Webhook.Events = nil }, "at least one event"},
+		{"invalid webhook URL", func(r *WebhookRequest) { r.Webhook.URL = "file:///etc/passwd" }, "invalid webhook URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CreateWebhook(newRequest(tt.modify), secrets)
+			var validationErr *common.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+
+	err = DeleteWebhook(newRequest(func(r *WebhookRequest) { r.WebhookID = "../../../user" }), secrets)
+	assert.ErrorContains(t, err, "invalid webhook ID")
+	assert.Len(t, *requests, 1, fmt.Sprintf("invalid requests must not reach the provider: %+v", *requests))
+}
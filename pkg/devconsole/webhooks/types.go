@@ -54,3 +54,27 @@ type BitbucketWebhookRequest struct {
 	RepoName string        `json:"repoName"`
 	Body     BBWebhookBody `json:"body"`
 }
+
+// SecretRef references the key of a Secret that holds the access token of a git provider.
+type SecretRef struct {
+	Namespace string `json:"namespace" validate:"required,dns1123label"`
+	Name      string `json:"name" validate:"required,dns1123subdomain"`
+	// Key defaults to token
+	Key string `json:"key,omitempty"`
+}
+
+// WebhookRequest is the request of the webhook endpoints that are common to all providers.
+type WebhookRequest struct {
+	// Provider is github, gitlab, bitbucket, bitbucket-server or gitea
+	Provider string `json:"provider"`
+	// BaseURL is the root of the REST API of the provider. It defaults to the hosted
+	// service for GitHub, GitLab and Bitbucket.
+	BaseURL     string    `json:"baseURL"`
+	Owner       string    `json:"owner"`
+	RepoName    string    `json:"repoName"`
+	TokenSecret SecretRef `json:"tokenSecret"`
+	// Webhook is created or updated
+	Webhook Webhook `json:"webhook"`
+	// WebhookID is deleted or tested
+	WebhookID string `json:"webhookID"`
+}
@@ -44,7 +44,9 @@ func isInternalHostname(hostname string) bool {
 	return false
 }
 
-func validateHostURL(rawURL string) (*url.URL, error) {
+// ValidateHostURL checks that a URL supplied by a user is an https URL of a public host,
+// so that requests to it cannot reach services of the cluster.
+func ValidateHostURL(rawURL string) (*url.URL, error) {
 	parsed, err := url.Parse(rawURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid URL: %v", err)
@@ -96,7 +98,9 @@ func ssrfDialControl(network, address string, c syscall.RawConn) error {
 	return nil
 }
 
-func newSafeHTTPClient() *http.Client {
+// NewSafeHTTPClient returns a client that refuses to connect to private addresses, even
+// if a validated host resolves to one later.
+func NewSafeHTTPClient() *http.Client {
 	return &http.Client{
 		Timeout: 30 * time.Second,
 		Transport: &http.Transport{
@@ -108,3 +112,15 @@ func newSafeHTTPClient() *http.Client {
 		},
 	}
 }
+
+// validatePathElement checks that a value from the request stays a single element of the
+// path of a provider's API.
+func validatePathElement(name, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s is required", name)
+	}
+	if value == "." || value == ".." || strings.ContainsAny(value, "/\\?#%") {
+		return fmt.Errorf("invalid %s %q", name, value)
+	}
+	return nil
+}
@@ -150,7 +150,7 @@ func TestValidateHostURL(t *testing.T) {
 				return tt.mockIPs, nil
 			}
 
-			result, err := validateHostURL(tt.rawURL)
+			result, err := ValidateHostURL(tt.rawURL)
 			if tt.wantErr {
 				assert.Error(t, err)
 				if tt.errSubstr != "" {
@@ -11,13 +11,14 @@ import (
 
 	"github.com/openshift/console/pkg/auth"
 	"github.com/openshift/console/pkg/devconsole/common"
+	"github.com/openshift/console/pkg/serverutils"
 )
 
 const maxResponseBodySize = 10 * 1024 * 1024 // 10 MB
 
 var webhookHeaderDenyList = []string{"Host"}
 
-var client = newSafeHTTPClient()
+var client = NewSafeHTTPClient()
 
 func makeHTTPRequest(ctx context.Context, url string, headers http.Header, body []byte, proxyHeaderDenyList []string) (common.DevConsoleCommonResponse, error) {
 	serviceRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
@@ -64,11 +65,13 @@ func makeHTTPRequest(ctx context.Context, url string, headers http.Header, body
 	}, nil
 }
 
+// CreateGithubWebhook forwards the headers of the browser, including its credentials.
+//
+// Deprecated: use CreateWebhook, which reads the token from a Secret.
 func CreateGithubWebhook(r *http.Request, user *auth.User, proxyHeaderDenyList []string) (common.DevConsoleCommonResponse, error) {
 	var request GithubWebhookRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
-	if err != nil {
-		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to parse request: %v", err)
+	if err := serverutils.DecodeRequest(r, &request); err != nil {
+		return common.DevConsoleCommonResponse{}, err
 	}
 
 	bodyBytes, err := json.Marshal(request.Body)
@@ -76,7 +79,7 @@ func CreateGithubWebhook(r *http.Request, user *auth.User, proxyHeaderDenyList [
 		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to marshal request body: %v", err)
 	}
 
-	baseURL, err := validateHostURL(request.HostName)
+	baseURL, err := ValidateHostURL(request.HostName)
 	if err != nil {
 		return common.DevConsoleCommonResponse{}, &common.ValidationError{Err: fmt.Errorf("invalid hostName: %v", err)}
 	}
@@ -85,11 +88,13 @@ func CreateGithubWebhook(r *http.Request, user *auth.User, proxyHeaderDenyList [
 	return makeHTTPRequest(r.Context(), webhookURL.String(), request.Headers, bodyBytes, proxyHeaderDenyList)
 }
 
+// CreateGitlabWebhook forwards the headers of the browser, including its credentials.
+//
+// Deprecated: use CreateWebhook, which reads the token from a Secret.
 func CreateGitlabWebhook(r *http.Request, user *auth.User, proxyHeaderDenyList []string) (common.DevConsoleCommonResponse, error) {
 	var request GitlabWebhookRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
-	if err != nil {
-		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to parse request: %v", err)
+	if err := serverutils.DecodeRequest(r, &request); err != nil {
+		return common.DevConsoleCommonResponse{}, err
 	}
 
 	bodyBytes, err := json.Marshal(request.Body)
@@ -97,7 +102,7 @@ func CreateGitlabWebhook(r *http.Request, user *auth.User, proxyHeaderDenyList [
 		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to marshal request body: %v", err)
 	}
 
-	baseURL, err := validateHostURL(request.HostName)
+	baseURL, err := ValidateHostURL(request.HostName)
 	if err != nil {
 		return common.DevConsoleCommonResponse{}, &common.ValidationError{Err: fmt.Errorf("invalid hostName: %v", err)}
 	}
@@ -106,11 +111,13 @@ func CreateGitlabWebhook(r *http.Request, user *auth.User, proxyHeaderDenyList [
 	return makeHTTPRequest(r.Context(), webhookURL.String(), request.Headers, bodyBytes, proxyHeaderDenyList)
 }
 
+// CreateBitbucketWebhook forwards the headers of the browser, including its credentials.
+//
+// Deprecated: use CreateWebhook, which reads the token from a Secret.
 func CreateBitbucketWebhook(r *http.Request, user *auth.User, proxyHeaderDenyList []string) (common.DevConsoleCommonResponse, error) {
 	var request BitbucketWebhookRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
-	if err != nil {
-		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to parse request: %v", err)
+	if err := serverutils.DecodeRequest(r, &request); err != nil {
+		return common.DevConsoleCommonResponse{}, err
 	}
 
 	bodyBytes, err := json.Marshal(request.Body)
@@ -118,7 +125,7 @@ func CreateBitbucketWebhook(r *http.Request, user *auth.User, proxyHeaderDenyLis
 		return common.DevConsoleCommonResponse{}, fmt.Errorf("failed to marshal request body: %v", err)
 	}
 
-	baseURL, err := validateHostURL(request.BaseURL)
+	baseURL, err := ValidateHostURL(request.BaseURL)
 	if err != nil {
 		return common.DevConsoleCommonResponse{}, &common.ValidationError{Err: fmt.Errorf("invalid baseURL: %v", err)}
 	}
@@ -0,0 +1,648 @@
+package devfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	devfilev1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/library/v2/pkg/devfile/generator"
+	"github.com/devfile/library/v2/pkg/devfile/parser"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	buildv1 "github.com/openshift/api/build/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+)
+
+// BuildStrategy is the kind of object that builds the image components of a devfile.
+type BuildStrategy string
+
+const (
+	BuildStrategyBuildConfig BuildStrategy = "BuildConfig"
+	BuildStrategyShipwright  BuildStrategy = "Shipwright"
+	BuildStrategyPipelineRun BuildStrategy = "PipelineRun"
+)
+
+const (
+	// internalRegistry is the service of the OpenShift image registry the builds push to
+	internalRegistry = "image-registry.openshift-image-registry.svc:5000"
+	// imageTriggersAnnotation makes OpenShift roll out workloads when an image stream tag is updated
+	imageTriggersAnnotation = "image.openshift.io/triggers"
+	// dockerImagePortAttribute is the port of the application if the devfile has no endpoints
+	dockerImagePortAttribute = "alpha.dockerimage-port"
+)
+
+var (
+	defaultVolumeSize = resource.MustParse("1Gi")
+
+	// kindOrder is the order objects are created in, so that the objects a workload
+	// references exist before it. Other kinds are created last.
+	kindOrder = []string{
+		"ServiceAccount",
+		"Secret",
+		"ConfigMap",
+		"PersistentVolumeClaim",
+		"ImageStream",
+		"BuildConfig",
+		"Build",
+		"BuildRun",
+		"PipelineRun",
+		"Deployment",
+		"StatefulSet",
+		"DaemonSet",
+		"Job",
+		"CronJob",
+		"Service",
+		"Route",
+		"Ingress",
+	}
+)
+
+// ConvertOptions are the parameters of ConvertDevfile.
+type ConvertOptions struct {
+	// Name is the name of the application and of the objects that are not named by the devfile
+	Name string
+	// Namespace is the namespace the image streams are created in
+	Namespace string
+	// Git is the repository the image components are built from
+	Git GitData
+	// BuildStrategy is the kind of object that builds the images, BuildConfig by default
+	BuildStrategy BuildStrategy
+}
+
+// converter collects the objects of a devfile.
+type converter struct {
+	devfileObj parser.DevfileObj
+	options    ConvertOptions
+	objects    []unstructured.Unstructured
+	// imageStreams maps the image names of the image components to their image streams
+	imageStreams map[string]string
+}
+
+// ConvertDevfile converts the components of the default deploy command of a devfile into
+// the objects of an application, in the order they should be created in:
+//   - every image component is built into an image stream with a BuildConfig, a Shipwright
+//     Build and BuildRun or a Tekton PipelineRun,
+//   - inlined kubernetes and openshift components are used as they are, with a Route for
+//     each of their public endpoints,
+//   - if there are no inlined components, the container components are deployed with a
+//     Deployment, their volumes, a Service and a Route for each public endpoint.
+//
+// Containers that use the image of an image component are updated when it is rebuilt.
+func ConvertDevfile(devfileObj parser.DevfileObj, options ConvertOptions) ([]unstructured.Unstructured, error) {
+	if options.Name == "" {
+		return nil, fmt.Errorf("a name is required to convert a devfile")
+	}
+	if options.Namespace == "" {
+		return nil, fmt.Errorf("a namespace is required to convert a devfile")
+	}
+	switch options.BuildStrategy {
+	case "":
+		options.BuildStrategy = BuildStrategyBuildConfig
+	case BuildStrategyBuildConfig, BuildStrategyShipwright, BuildStrategyPipelineRun:
+	default:
+		return nil, fmt.Errorf("unknown build strategy %q", options.BuildStrategy)
+	}
+
+	deployAssociatedComponents, err := GetDeployComponents(devfileObj)
+	if err != nil {
+		return nil, err
+	}
+	components, err := devfileObj.Data.GetComponents(common.DevfileOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the components from devfile: %w", err)
+	}
+
+	c := &converter{devfileObj: devfileObj, options: options, imageStreams: map[string]string{}}
+	var endpoints []componentEndpoint
+	imageCount := 0
+	deployContainers := true
+	for _, component := range components {
+		if _, ok := deployAssociatedComponents[component.Name]; !ok {
+			continue
+		}
+		switch {
+		case component.Image != nil:
+			if err := c.addImage(component, imageCount); err != nil {
+				return nil, err
+			}
+			imageCount++
+		case component.Kubernetes != nil || component.Openshift != nil:
+			k8sLike := kubernetesLikeComponent(component)
+			if k8sLike.Inlined == "" {
+				// components that are not inlined fall back to the container components
+				continue
+			}
+			if err := c.addKubernetes(component.Name, k8sLike); err != nil {
+				return nil, err
+			}
+			for _, endpoint := range k8sLike.Endpoints {
+				endpoints = append(endpoints, componentEndpoint{component: component.Name, Endpoint: endpoint})
+			}
+			deployContainers = false
+		}
+	}
+
+	// endpoints are exposed once the Services of all components are known
+	for _, endpoint := range endpoints {
+		if err := c.exposeEndpoint(endpoint); err != nil {
+			return nil, err
+		}
+	}
+
+	if deployContainers {
+		if err := c.addContainers(); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range c.objects {
+		if err := c.useImageStreams(&c.objects[i]); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(c.objects, func(i, j int) bool {
+		return kindRank(c.objects[i].GetKind()) < kindRank(c.objects[j].GetKind())
+	})
+	return c.objects, nil
+}
+
+func kindRank(kind string) int {
+	for i, k := range kindOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(kindOrder)
+}
+
+func kubernetesLikeComponent(component devfilev1.Component) devfilev1.K8sLikeComponent {
+	if component.Kubernetes != nil {
+		return component.Kubernetes.K8sLikeComponent
+	}
+	return component.Openshift.K8sLikeComponent
+}
+
+func (c *converter) add(obj runtime.Object) error {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	// drop the empty fields of the typed objects
+	unstructured.RemoveNestedField(content, "status")
+	unstructured.RemoveNestedField(content, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(content, "spec", "template", "metadata", "creationTimestamp")
+	c.objects = append(c.objects, unstructured.Unstructured{Object: content})
+	return nil
+}
+
+func (c *converter) pullSpec(imageStream string) string {
+	return fmt.Sprintf("%s/%s/%s:latest", internalRegistry, c.options.Namespace, imageStream)
+}
+
+// addImage adds the image stream of an image component and the objects that build it. The
+// first image stream is named like the application, the others after their component.
+func (c *converter) addImage(component devfilev1.Component, index int) error {
+	dockerfile := component.Image.Dockerfile
+	if dockerfile == nil || dockerfile.Uri == "" {
+		return fmt.Errorf("image component %s must have a dockerfile uri", component.Name)
+	}
+	if strings.Contains(dockerfile.Uri, "://") {
+		return fmt.Errorf("the dockerfile uri of image component %s must be a path in the repository", component.Name)
+	}
+
+	imageStream := c.options.Name
+	if index > 0 {
+		imageStream = c.options.Name + "-" + component.Name
+	}
+	c.imageStreams[component.Image.ImageName] = imageStream
+
+	imageStreamResource := generator.GetImageStream(generator.ImageStreamParams{
+		TypeMeta:   generator.GetTypeMeta("ImageStream", "image.openshift.io/v1"),
+		ObjectMeta: generator.GetObjectMeta(imageStream, "", nil, nil),
+	})
+	if err := c.add(&imageStreamResource); err != nil {
+		return err
+	}
+
+	contextDir := strings.TrimPrefix(path.Clean(path.Join("/", c.options.Git.Dir, dockerfile.BuildContext)), "/")
+	switch c.options.BuildStrategy {
+	case BuildStrategyShipwright:
+		c.addShipwrightBuild(imageStream, contextDir, dockerfile.Uri)
+	case BuildStrategyPipelineRun:
+		c.addPipelineRun(imageStream, contextDir, dockerfile.Uri)
+	default:
+		buildConfig := generator.GetBuildConfig(generator.BuildConfigParams{
+			TypeMeta:   generator.GetTypeMeta("BuildConfig", "build.openshift.io/v1"),
+			ObjectMeta: generator.GetObjectMeta(imageStream, "", nil, nil),
+			BuildConfigSpecParams: generator.BuildConfigSpecParams{
+				ImageStreamTagName: imageStream,
+				GitRef:             c.options.Git.Ref,
+				GitURL:             c.options.Git.URL,
+				ContextDir:         contextDir,
+				BuildStrategy:      generator.GetDockerBuildStrategy(dockerfile.Uri, nil),
+			},
+		})
+		buildConfig.Spec.Triggers = []buildv1.BuildTriggerPolicy{{Type: buildv1.ConfigChangeBuildTriggerType}}
+		return c.add(buildConfig)
+	}
+	return nil
+}
+
+// addShipwrightBuild builds the image with the buildah strategy of Shipwright.
+func (c *converter) addShipwrightBuild(imageStream, contextDir, dockerfile string) {
+	git := map[string]interface{}{"url": c.options.Git.URL}
+	if c.options.Git.Ref != "" {
+		git["revision"] = c.options.Git.Ref
+	}
+	source := map[string]interface{}{"type": "Git", "git": git}
+	if contextDir != "" {
+		source["contextDir"] = contextDir
+	}
+	c.objects = append(c.objects, unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "shipwright.io/v1beta1",
+		"kind":       "Build",
+		"metadata":   map[string]interface{}{"name": imageStream},
+		"spec": map[string]interface{}{
+			"source":   source,
+			"strategy": map[string]interface{}{"name": "buildah", "kind": "ClusterBuildStrategy"},
+			"paramValues": []interface{}{
+				map[string]interface{}{"name": "dockerfile", "value": dockerfile},
+			},
+			"output": map[string]interface{}{"image": c.pullSpec(imageStream)},
+		},
+	}}, unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "shipwright.io/v1beta1",
+		"kind":       "BuildRun",
+		"metadata":   map[string]interface{}{"generateName": imageStream + "-"},
+		"spec": map[string]interface{}{
+			"build": map[string]interface{}{"name": imageStream},
+		},
+	}})
+}
+
+// addPipelineRun builds the image with the git-clone and buildah tasks of OpenShift Pipelines.
+func (c *converter) addPipelineRun(imageStream, contextDir, dockerfile string) {
+	if contextDir == "" {
+		contextDir = "."
+	}
+	clusterTask := func(name string) map[string]interface{} {
+		return map[string]interface{}{
+			"resolver": "cluster",
+			"params": []interface{}{
+				map[string]interface{}{"name": "kind", "value": "task"},
+				map[string]interface{}{"name": "name", "value": name},
+				map[string]interface{}{"name": "namespace", "value": "openshift-pipelines"},
+			},
+		}
+	}
+	c.objects = append(c.objects, unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "tekton.dev/v1",
+		"kind":       "PipelineRun",
+		"metadata":   map[string]interface{}{"generateName": imageStream + "-build-"},
+		"spec": map[string]interface{}{
+			"pipelineSpec": map[string]interface{}{
+				"workspaces": []interface{}{map[string]interface{}{"name": "source"}},
+				"tasks": []interface{}{
+					map[string]interface{}{
+						"name":    "fetch-repository",
+						"taskRef": clusterTask("git-clone"),
+						"params": []interface{}{
+							map[string]interface{}{"name": "URL", "value": c.options.Git.URL},
+							map[string]interface{}{"name": "REVISION", "value": c.options.Git.Ref},
+						},
+						"workspaces": []interface{}{map[string]interface{}{"name": "output", "workspace": "source"}},
+					},
+					map[string]interface{}{
+						"name":     "build",
+						"runAfter": []interface{}{"fetch-repository"},
+						"taskRef":  clusterTask("buildah"),
+						"params": []interface{}{
+							map[string]interface{}{"name": "IMAGE", "value": c.pullSpec(imageStream)},
+							map[string]interface{}{"name": "DOCKERFILE", "value": "./" + path.Join(contextDir, dockerfile)},
+							map[string]interface{}{"name": "CONTEXT", "value": contextDir},
+						},
+						"workspaces": []interface{}{map[string]interface{}{"name": "source", "workspace": "source"}},
+					},
+				},
+			},
+			"workspaces": []interface{}{
+				map[string]interface{}{
+					"name": "source",
+					"volumeClaimTemplate": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"accessModes": []interface{}{"ReadWriteOnce"},
+							"resources": map[string]interface{}{
+								"requests": map[string]interface{}{"storage": defaultVolumeSize.String()},
+							},
+						},
+					},
+				},
+			},
+		},
+	}})
+}
+
+// componentEndpoint is an endpoint of a kubernetes or openshift component.
+type componentEndpoint struct {
+	devfilev1.Endpoint
+	component string
+}
+
+// addKubernetes adds the objects of an inlined kubernetes or openshift component.
+func (c *converter) addKubernetes(name string, component devfilev1.K8sLikeComponent) error {
+	values, err := parser.ReadKubernetesYaml(parser.YamlSrc{Data: []byte(component.Inlined)}, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read the Kubernetes yaml of component %s: %w", name, err)
+	}
+	for i, value := range values {
+		data, err := yaml.Marshal(value)
+		if err == nil {
+			data, err = yaml.YAMLToJSON(data)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read object %d of component %s: %w", i, name, err)
+		}
+		var obj unstructured.Unstructured
+		if err := obj.UnmarshalJSON(data); err != nil {
+			return fmt.Errorf("failed to read object %d of component %s: %w", i, name, err)
+		}
+		c.objects = append(c.objects, obj)
+	}
+	return nil
+}
+
+// exposeEndpoint checks that a Service of the devfile serves the port of an internal or
+// public endpoint, and adds a Route to the Service for a public endpoint.
+func (c *converter) exposeEndpoint(endpoint componentEndpoint) error {
+	if endpoint.Exposure == devfilev1.NoneEndpointExposure {
+		return nil
+	}
+	service := c.serviceForPort(endpoint.TargetPort)
+	if service == "" {
+		return fmt.Errorf("endpoint %s of component %s must be exposed by a Service on port %d", endpoint.Name, endpoint.component, endpoint.TargetPort)
+	}
+	if endpoint.Exposure == devfilev1.InternalEndpointExposure {
+		return nil
+	}
+	return c.addRoute(endpoint.Endpoint, service)
+}
+
+// serviceForPort returns the name of the first Service that serves or targets a port.
+func (c *converter) serviceForPort(port int) string {
+	for _, obj := range c.objects {
+		if obj.GetKind() != "Service" {
+			continue
+		}
+		var service corev1.Service
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &service); err != nil {
+			continue
+		}
+		for _, servicePort := range service.Spec.Ports {
+			if int(servicePort.Port) == port || servicePort.TargetPort.IntValue() == port {
+				return service.Name
+			}
+		}
+	}
+	return ""
+}
+
+func (c *converter) addRoute(endpoint devfilev1.Endpoint, service string) error {
+	var secure bool
+	if endpoint.Secure != nil {
+		secure = *endpoint.Secure
+	}
+	return c.add(generator.GetRoute(endpoint, generator.RouteParams{
+		TypeMeta:   generator.GetTypeMeta("Route", "route.openshift.io/v1"),
+		ObjectMeta: generator.GetObjectMeta(endpoint.Name, "", nil, nil),
+		RouteSpecParams: generator.RouteSpecParams{
+			ServiceName: service,
+			PortNumber:  intstr.FromInt(endpoint.TargetPort),
+			Path:        endpoint.Path,
+			Secure:      secure,
+		},
+	}))
+}
+
+// addContainers deploys the container components with a Deployment, with claims for their
+// persistent volumes, and exposes their endpoints with a Service and Routes.
+func (c *converter) addContainers() error {
+	name := c.options.Name
+	labels := map[string]string{"app": name}
+
+	podTemplateSpec, err := generator.GetPodTemplateSpec(c.devfileObj, generator.PodTemplateParams{
+		ObjectMeta: generator.GetObjectMeta(name, "", labels, nil),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get the containers from devfile: %w", err)
+	}
+	if len(podTemplateSpec.Spec.Containers) == 0 {
+		return fmt.Errorf("no deployment definition was found in the devfile")
+	}
+
+	volumeComponents, err := c.devfileObj.Data.GetComponents(common.DevfileOptions{
+		ComponentOptions: common.ComponentOptions{ComponentType: devfilev1.VolumeComponentType},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get the volumes from devfile: %w", err)
+	}
+	volumeInfos := map[string]generator.VolumeInfo{}
+	for _, component := range volumeComponents {
+		pvcName := name + "-" + component.Name
+		volumeInfos[component.Name] = generator.VolumeInfo{PVCName: pvcName, VolumeName: component.Name}
+		if component.Volume.Ephemeral != nil && *component.Volume.Ephemeral {
+			continue
+		}
+		size := defaultVolumeSize
+		if component.Volume.Size != "" {
+			if size, err = resource.ParseQuantity(component.Volume.Size); err != nil {
+				return fmt.Errorf("invalid size of volume component %s: %w", component.Name, err)
+			}
+		}
+		if err := c.add(generator.GetPVC(generator.PVCParams{
+			TypeMeta:   generator.GetTypeMeta("PersistentVolumeClaim", "v1"),
+			ObjectMeta: generator.GetObjectMeta(pvcName, "", nil, nil),
+			Quantity:   size,
+		})); err != nil {
+			return err
+		}
+	}
+	volumes, err := generator.GetVolumesAndVolumeMounts(c.devfileObj, generator.VolumeParams{
+		Containers:             podTemplateSpec.Spec.Containers,
+		VolumeNameToVolumeInfo: volumeInfos,
+	}, common.DevfileOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get the volumes from devfile: %w", err)
+	}
+	// the volumes are collected from a map
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Name < volumes[j].Name })
+	for _, container := range podTemplateSpec.Spec.Containers {
+		mounts := container.VolumeMounts
+		sort.SliceStable(mounts, func(i, j int) bool { return mounts[i].Name < mounts[j].Name })
+	}
+	podTemplateSpec.Spec.Volumes = append(podTemplateSpec.Spec.Volumes, volumes...)
+
+	deployment, err := generator.GetDeployment(c.devfileObj, generator.DeploymentParams{
+		TypeMeta:          generator.GetTypeMeta("Deployment", "apps/v1"),
+		ObjectMeta:        generator.GetObjectMeta(name, "", labels, nil),
+		PodTemplateSpec:   podTemplateSpec,
+		PodSelectorLabels: labels,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get the Deployment for the devfile: %w", err)
+	}
+	if err := c.add(deployment); err != nil {
+		return err
+	}
+
+	service, err := generator.GetService(c.devfileObj, generator.ServiceParams{
+		TypeMeta:       generator.GetTypeMeta("Service", "v1"),
+		ObjectMeta:     generator.GetObjectMeta(name, "", labels, nil),
+		SelectorLabels: labels,
+	}, common.DevfileOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get the Service for the devfile: %w", err)
+	}
+
+	var endpoints []devfilev1.Endpoint
+	containerComponents, err := c.devfileObj.Data.GetDevfileContainerComponents(common.DevfileOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get the containers from devfile: %w", err)
+	}
+	for _, component := range containerComponents {
+		endpoints = append(endpoints, component.Container.Endpoints...)
+	}
+
+	if len(endpoints) == 0 {
+		// images without endpoints are exposed on the port of the attribute, if there is one
+		var attributeErr error
+		port := int(c.devfileObj.Data.GetMetadata().Attributes.GetNumber(dockerImagePortAttribute, &attributeErr))
+		if attributeErr != nil || port == 0 {
+			return nil
+		}
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name:       fmt.Sprintf("http-%d", port),
+			Port:       int32(port),
+			TargetPort: intstr.FromInt(port),
+		})
+		endpoints = append(endpoints, devfilev1.Endpoint{Name: name, TargetPort: port})
+	}
+	if len(service.Spec.Ports) == 0 {
+		return nil
+	}
+	if err := c.add(service); err != nil {
+		return err
+	}
+
+	for _, endpoint := range endpoints {
+		if endpoint.Exposure != devfilev1.PublicEndpointExposure && endpoint.Exposure != "" {
+			continue
+		}
+		if err := c.addRoute(endpoint, service.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type imageTrigger struct {
+	From      imageTriggerFrom `json:"from"`
+	FieldPath string           `json:"fieldPath"`
+}
+
+type imageTriggerFrom struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// podSpecPath returns the path of the pod spec of a workload.
+func podSpecPath(obj *unstructured.Unstructured) []string {
+	switch obj.GetKind() {
+	case "Pod":
+		return []string{"spec"}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	}
+	if _, found, _ := unstructured.NestedMap(obj.Object, "spec", "template", "spec"); found {
+		return []string{"spec", "template", "spec"}
+	}
+	return nil
+}
+
+// useImageStreams replaces the images of the image components with their image streams and
+// annotates the workload so that it is rolled out when the images are rebuilt.
+func (c *converter) useImageStreams(obj *unstructured.Unstructured) error {
+	specPath := podSpecPath(obj)
+	if specPath == nil || len(c.imageStreams) == 0 {
+		return nil
+	}
+
+	var triggers []imageTrigger
+	for _, field := range []string{"initContainers", "containers"} {
+		fieldPath := append(append([]string{}, specPath...), field)
+		containers, found, err := unstructured.NestedSlice(obj.Object, fieldPath...)
+		if err != nil || !found {
+			continue
+		}
+		for _, container := range containers {
+			container, ok := container.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			image, _ := container["image"].(string)
+			imageStream, ok := c.imageStreamFor(image)
+			if !ok {
+				continue
+			}
+			container["image"] = c.pullSpec(imageStream)
+			triggers = append(triggers, imageTrigger{
+				From:      imageTriggerFrom{Kind: "ImageStreamTag", Name: imageStream + ":latest", Namespace: c.options.Namespace},
+				FieldPath: fmt.Sprintf("%s[?(@.name==\"%s\")].image", strings.Join(fieldPath, "."), container["name"]),
+			})
+		}
+		if err := unstructured.SetNestedSlice(obj.Object, containers, fieldPath...); err != nil {
+			return err
+		}
+	}
+
+	annotations := obj.GetAnnotations()
+	if len(triggers) == 0 || obj.GetKind() == "Pod" || annotations[imageTriggersAnnotation] != "" {
+		return nil
+	}
+	value, err := json.Marshal(triggers)
+	if err != nil {
+		return err
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[imageTriggersAnnotation] = string(value)
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+// imageStreamFor returns the image stream of the image component that builds an image.
+// Images without a tag match the latest tag.
+func (c *converter) imageStreamFor(image string) (string, bool) {
+	for imageName, imageStream := range c.imageStreams {
+		if image == imageName || withLatestTag(image) == withLatestTag(imageName) {
+			return imageStream, true
+		}
+	}
+	return "", false
+}
+
+func withLatestTag(image string) string {
+	name := image[strings.LastIndex(image, "/")+1:]
+	if strings.ContainsAny(name, ":@") {
+		return image
+	}
+	return image + ":latest"
+}
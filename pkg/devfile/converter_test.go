@@ -0,0 +1,148 @@
+package devfile
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files of the devfile stacks")
+
+// TestConvertDevfileStacks converts the devfiles in testdata/stacks, which follow the stacks
+// of the devfile registry, with every build strategy and compares the objects with the
+// golden files next to them. Run the tests with -update to write the golden files.
+func TestConvertDevfileStacks(t *testing.T) {
+	stacks, err := filepath.Glob("testdata/stacks/*")
+	require.NoError(t, err)
+	require.NotEmpty(t, stacks)
+
+	httpTimeout := 10
+	for _, stack := range stacks {
+		content, err := os.ReadFile(filepath.Join(stack, "devfile.yaml"))
+		require.NoError(t, err)
+		devfileObj, err := parseDevfileWithFallback(context.Background(), content, &httpTimeout, nil)
+		require.NoError(t, err)
+
+		for _, strategy := range []BuildStrategy{BuildStrategyBuildConfig, BuildStrategyShipwright, BuildStrategyPipelineRun} {
+			t.Run(filepath.Base(stack)+"/"+string(strategy), func(t *testing.T) {
+				objects, err := ConvertDevfile(devfileObj, ConvertOptions{
+					Name:          "my-app",
+					Namespace:     "my-project",
+					Git:           GitData{URL: "https://github.com/example/app", Ref: "main", Dir: "/"},
+					BuildStrategy: strategy,
+				})
+				require.NoError(t, err)
+
+				var actual bytes.Buffer
+				for _, obj := range objects {
+					data, err := yaml.Marshal(obj.Object)
+					require.NoError(t, err)
+					actual.WriteString("---\n")
+					actual.Write(data)
+				}
+
+				golden := filepath.Join(stack, strings.ToLower(string(strategy))+".yaml")
+				if *updateGolden {
+					require.NoError(t, os.WriteFile(golden, actual.Bytes(), 0644))
+				}
+				expected, err := os.ReadFile(golden)
+				require.NoError(t, err)
+				assert.Equal(t, string(expected), actual.String())
+			})
+		}
+	}
+}
+
+func TestConvertDevfileErrors(t *testing.T) {
+	httpTimeout := 10
+	devfileObj, err := parseDevfileWithFallback(context.Background(), []byte(validDevfileNoParent), &httpTimeout, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		devfile string
+		options ConvertOptions
+		wantErr string
+	}{
+		{
+			name:    "missing namespace",
+			options: ConvertOptions{Name: "my-app"},
+			wantErr: "a namespace is required to convert a devfile",
+		},
+		{
+			name:    "unknown build strategy",
+			options: ConvertOptions{Name: "my-app", Namespace: "my-project", BuildStrategy: "S2I"},
+			wantErr: `unknown build strategy "S2I"`,
+		},
+		{
+			name: "public endpoint without service",
+			devfile: `
+schemaVersion: 2.2.0
+metadata:
+  name: test
+components:
+  - name: deploy
+    kubernetes:
+      inlined: |
+        kind: Deployment
+        apiVersion: apps/v1
+        metadata:
+          name: test
+      endpoints:
+        - name: http
+          targetPort: 8080
+commands:
+  - id: deploy
+    apply:
+      component: deploy
+      group:
+        kind: deploy
+        isDefault: true
+`,
+			options: ConvertOptions{Name: "my-app", Namespace: "my-project"},
+			wantErr: "endpoint http of component deploy must be exposed by a Service on port 8080",
+		},
+		{
+			name: "image from a remote dockerfile",
+			devfile: `
+schemaVersion: 2.2.0
+metadata:
+  name: test
+components:
+  - name: image
+    image:
+      imageName: test
+      dockerfile:
+        uri: https://example.com/Dockerfile
+commands:
+  - id: deploy
+    apply:
+      component: image
+      group:
+        kind: deploy
+        isDefault: true
+`,
+			options: ConvertOptions{Name: "my-app", Namespace: "my-project"},
+			wantErr: "the dockerfile uri of image component image must be a path in the repository",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := devfileObj
+			if tt.devfile != "" {
+				obj, err = parseDevfileWithFallback(context.Background(), []byte(tt.devfile), &httpTimeout, nil)
+				require.NoError(t, err)
+			}
+			_, err := ConvertDevfile(obj, tt.options)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
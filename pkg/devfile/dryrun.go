@@ -0,0 +1,112 @@
+package devfile
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var secretsResource = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+// ObjectValidation is the result of creating an object of a devfile with dry-run.
+type ObjectValidation struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Name       string   `json:"name"`
+	Error      string   `json:"error,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// DryRunObjects creates the objects in a namespace with dry-run, with the client of the
+// user, and warns about the Secrets the workloads reference that do not exist. Secrets the
+// user is not allowed to read are not checked.
+func DryRunObjects(ctx context.Context, client dynamic.Interface, namespace string, objects []unstructured.Unstructured) []ObjectValidation {
+	secrets := map[string]bool{}
+	for _, obj := range objects {
+		// the Secrets of the devfile are created with the workloads
+		if obj.GetKind() == "Secret" {
+			secrets[obj.GetName()] = true
+		}
+	}
+	secretExists := func(name string) bool {
+		if exists, ok := secrets[name]; ok {
+			return exists
+		}
+		_, err := client.Resource(secretsResource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		secrets[name] = !apierrors.IsNotFound(err)
+		return secrets[name]
+	}
+
+	validations := make([]ObjectValidation, 0, len(objects))
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		validation := ObjectValidation{APIVersion: obj.GetAPIVersion(), Kind: obj.GetKind(), Name: obj.GetName()}
+		if validation.Name == "" {
+			validation.Name = obj.GetGenerateName()
+		}
+
+		gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+		_, err := client.Resource(gvr).Namespace(namespace).Create(ctx, obj.DeepCopy(), metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+		if err != nil {
+			validation.Error = err.Error()
+		}
+		for _, secret := range referencedSecrets(&obj) {
+			if !secretExists(secret) {
+				validation.Warnings = append(validation.Warnings, fmt.Sprintf("Secret %s does not exist in namespace %s", secret, namespace))
+			}
+		}
+		validations = append(validations, validation)
+	}
+	return validations
+}
+
+// referencedSecrets returns the Secrets a workload requires in its environment and volumes.
+func referencedSecrets(obj *unstructured.Unstructured) []string {
+	specPath := podSpecPath(obj)
+	if specPath == nil {
+		return nil
+	}
+	content, found, err := unstructured.NestedMap(obj.Object, specPath...)
+	if err != nil || !found {
+		return nil
+	}
+	var podSpec corev1.PodSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, &podSpec); err != nil {
+		return nil
+	}
+
+	var secrets []string
+	seen := map[string]bool{}
+	add := func(name string, optional *bool) {
+		if name != "" && (optional == nil || !*optional) && !seen[name] {
+			seen[name] = true
+			secrets = append(secrets, name)
+		}
+	}
+	for _, container := range append(podSpec.InitContainers, podSpec.Containers...) {
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				add(env.ValueFrom.SecretKeyRef.Name, env.ValueFrom.SecretKeyRef.Optional)
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil {
+				add(envFrom.SecretRef.Name, envFrom.SecretRef.Optional)
+			}
+		}
+	}
+	for _, volume := range podSpec.Volumes {
+		if volume.Secret != nil {
+			add(volume.Secret.SecretName, volume.Secret.Optional)
+		}
+	}
+	return secrets
+}
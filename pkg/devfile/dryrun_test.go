@@ -0,0 +1,177 @@
+package devfile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/openshift/console/pkg/auth"
+	"github.com/openshift/console/pkg/serverutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newDryRunClient returns a client with the Secret other-secret, which rejects Routes.
+func newDryRunClient() *dynamicfake.FakeDynamicClient {
+	secret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "other-secret", "namespace": "my-project"},
+	}}
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), secret)
+	client.PrependReactor("create", "routes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "route.openshift.io", Resource: "routes"}, "", fmt.Errorf("not allowed"))
+	})
+	return client
+}
+
+func TestDryRunObjects(t *testing.T) {
+	deployment := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "app"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":  "app",
+							"image": "app:latest",
+							"env": []interface{}{
+								map[string]interface{}{"name": "A", "valueFrom": map[string]interface{}{"secretKeyRef": map[string]interface{}{"name": "missing-secret", "key": "a"}}},
+								map[string]interface{}{"name": "B", "valueFrom": map[string]interface{}{"secretKeyRef": map[string]interface{}{"name": "optional-secret", "key": "b", "optional": true}}},
+							},
+							"envFrom": []interface{}{
+								map[string]interface{}{"secretRef": map[string]interface{}{"name": "other-secret"}},
+							},
+						},
+					},
+					"volumes": []interface{}{
+						map[string]interface{}{"name": "tls", "secret": map[string]interface{}{"secretName": "app-tls"}},
+						map[string]interface{}{"name": "certs", "secret": map[string]interface{}{"secretName": "missing-secret"}},
+					},
+				},
+			},
+		},
+	}}
+	secret := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "app-tls"},
+	}}
+	route := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "route.openshift.io/v1",
+		"kind":       "Route",
+		"metadata":   map[string]interface{}{"name": "app"},
+	}}
+	buildRun := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "shipwright.io/v1beta1",
+		"kind":       "BuildRun",
+		"metadata":   map[string]interface{}{"generateName": "app-"},
+	}}
+
+	client := newDryRunClient()
+	validations := DryRunObjects(context.Background(), client, "my-project", []unstructured.Unstructured{secret, deployment, route, buildRun})
+	require.Len(t, validations, 4)
+	assert.Equal(t, ObjectValidation{APIVersion: "v1", Kind: "Secret", Name: "app-tls"}, validations[0])
+	assert.Equal(t, ObjectValidation{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       "app",
+		Warnings:   []string{"Secret missing-secret does not exist in namespace my-project"},
+	}, validations[1])
+	assert.Equal(t, "Route", validations[2].Kind)
+	assert.Contains(t, validations[2].Error, "not allowed")
+	assert.Equal(t, ObjectValidation{APIVersion: "shipwright.io/v1beta1", Kind: "BuildRun", Name: "app-"}, validations[3])
+
+	var dryRuns int
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "create" {
+			assert.Equal(t, "my-project", action.GetNamespace())
+			dryRuns++
+		}
+	}
+	assert.Equal(t, 4, dryRuns)
+}
+
+func TestHandleConvert(t *testing.T) {
+	devfileContent, err := os.ReadFile("testdata/stacks/openshift-quarkus/devfile.yaml")
+	require.NoError(t, err)
+	handler := NewConvertHandler(nil, http.DefaultTransport, "https://kubernetes.default.svc")
+	handler.newClient = func(user *auth.User) (dynamic.Interface, error) {
+		return newDryRunClient(), nil
+	}
+	user := &auth.User{Token: "token"}
+
+	convert := func(form DevfileConvertForm) *httptest.ResponseRecorder {
+		body, err := json.Marshal(form)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		handler.HandleConvert(user, w, httptest.NewRequest(http.MethodPost, "/api/devfile/convert", bytes.NewReader(body)))
+		return w
+	}
+
+	t.Run("converts and validates the devfile", func(t *testing.T) {
+		w := convert(DevfileConvertForm{
+			Name:      "my-app",
+			Namespace: "my-project",
+			Git:       GitData{URL: "https://github.com/example/app", Ref: "main"},
+			Devfile:   DevfileData{DevfileContent: string(devfileContent)},
+			DryRun:    true,
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response DevfileConvertResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		var kinds []string
+		for _, obj := range response.Objects {
+			kinds = append(kinds, obj.GetKind())
+		}
+		assert.Equal(t, []string{"Secret", "ConfigMap", "ImageStream", "BuildConfig", "Deployment", "Service", "Route"}, kinds)
+		require.Len(t, response.Validation, len(kinds))
+		assert.Equal(t, []string{"Secret db-credentials does not exist in namespace my-project"}, response.Validation[4].Warnings)
+		assert.NotEmpty(t, response.Validation[6].Error)
+	})
+
+	t.Run("objects are not validated without dry-run", func(t *testing.T) {
+		w := convert(DevfileConvertForm{
+			Name:          "my-app",
+			Namespace:     "my-project",
+			Devfile:       DevfileData{DevfileContent: string(devfileContent)},
+			BuildStrategy: BuildStrategyShipwright,
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.NotContains(t, w.Body.String(), "validation")
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		w := convert(DevfileConvertForm{Name: "My App", Namespace: "my-project"})
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var apiErr serverutils.ApiError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiErr))
+		require.NotNil(t, apiErr.Details)
+		require.Len(t, apiErr.Details.Causes, 2)
+		assert.Equal(t, "name", apiErr.Details.Causes[0].Field)
+		assert.Equal(t, "devfile.devfileContent", apiErr.Details.Causes[1].Field)
+
+		w = convert(DevfileConvertForm{Name: "my-app", Namespace: "my-project", Devfile: DevfileData{DevfileContent: string(devfileContent)}, BuildStrategy: "S2I"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "unknown build strategy")
+
+		w = httptest.NewRecorder()
+		handler.HandleConvert(user, w, httptest.NewRequest(http.MethodGet, "/api/devfile/convert", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
@@ -1,37 +1,45 @@
 package devfile
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"path"
 	"strings"
 
+	"github.com/openshift/console/pkg/auth"
 	"github.com/openshift/console/pkg/serverutils"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 
 	devfile "github.com/devfile/library/v2/pkg/devfile"
 	"github.com/devfile/library/v2/pkg/devfile/parser"
 )
 
-func DevfileSamplesHandler(w http.ResponseWriter, r *http.Request) {
+// DevfileSamplesHandler returns the samples of the registry given by the registry
+// parameter. Without the parameter, the samples of all configured registries are merged.
+func (registries *Registries) DevfileSamplesHandler(w http.ResponseWriter, r *http.Request) {
 	registry := r.URL.Query().Get("registry")
-	if registry == "" {
+	if registry == "" && len(registries.list()) == 0 {
 		errMsg := "The registry parameter is missing"
 		klog.Error(errMsg)
 		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: errMsg})
 		return
 	}
 
-	sampleIndex, err := GetRegistrySamples(registry)
+	samples, err := registries.Samples(r.Context(), registry)
 	if err != nil {
 		errMsg := fmt.Sprintf("Failed to read from registry %s: %v", registry, err)
+		if registry == "" {
+			errMsg = fmt.Sprintf("Failed to read from the devfile registries: %v", err)
+		}
 		klog.Error(errMsg)
 		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: errMsg})
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(sampleIndex)
+	serverutils.SendResponse(w, http.StatusOK, samples)
 }
 
 // parseDevfileWithFallback attempts to parse the devfile with full parent/plugin
@@ -39,10 +47,14 @@ func DevfileSamplesHandler(w http.ResponseWriter, r *http.Request) {
 // the OCI pull times out), it retries without flattening. The unflattened parse
 // still provides all locally-defined components and commands, which is sufficient
 // for the outer-loop resource generation the console backend performs.
-func parseDevfileWithFallback(devfileContentBytes []byte, httpTimeout *int) (parser.DevfileObj, error) {
+// Parent devfiles are read from the configured registries first.
+func parseDevfileWithFallback(ctx context.Context, devfileContentBytes []byte, httpTimeout *int, registries *Registries) (parser.DevfileObj, error) {
+	devfileContentBytes, devfileUtilsClient := registries.resolveParent(ctx, devfileContentBytes)
 	devfileObj, _, err := devfile.ParseDevfileAndValidate(parser.ParserArgs{
-		Data:        devfileContentBytes,
-		HTTPTimeout: httpTimeout,
+		Data:               devfileContentBytes,
+		HTTPTimeout:        httpTimeout,
+		RegistryURLs:       registries.registryURLs(),
+		DevfileUtilsClient: devfileUtilsClient,
 	})
 	if err == nil {
 		return devfileObj, nil
@@ -63,32 +75,28 @@ func parseDevfileWithFallback(devfileContentBytes []byte, httpTimeout *int) (par
 	return devfileObj, nil
 }
 
-func DevfileHandler(w http.ResponseWriter, r *http.Request) {
-	var (
-		data       DevfileForm
-		devfileObj parser.DevfileObj
-	)
+func parseErrorMessage(err error) string {
+	errMsg := "Failed to parse devfile:"
+	if strings.Contains(err.Error(), "schemaVersion not present in devfile") {
+		return fmt.Sprintf("%s schemaVersion not present in devfile. Only devfile 2.2.0 or above is supported. The devfile needs to have the schemaVersion set in the metadata section with a value of 2.2.0 or above.", errMsg)
+	}
+	return fmt.Sprintf("%s %s", errMsg, err)
+}
 
-	err := json.NewDecoder(r.Body).Decode(&data)
-	if err != nil {
-		errMsg := fmt.Sprintf("Failed to decode response: %v", err)
-		klog.Error(errMsg)
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: errMsg})
+func (registries *Registries) DevfileHandler(w http.ResponseWriter, r *http.Request) {
+	var data DevfileForm
+	if err := serverutils.DecodeRequest(r, &data); err != nil {
+		klog.Errorf("Invalid devfile request: %v", err)
+		serverutils.SendRequestError(w, r, err)
 		return
 	}
 
 	devfileContentBytes := []byte(data.Devfile.DevfileContent)
 	httpTimeout := 10
 
-	devfileObj, err = parseDevfileWithFallback(devfileContentBytes, &httpTimeout)
+	devfileObj, err := parseDevfileWithFallback(r.Context(), devfileContentBytes, &httpTimeout, registries)
 	if err != nil {
-		errMsg := "Failed to parse devfile:"
-		if strings.Contains(err.Error(), "schemaVersion not present in devfile") {
-			errMsg = fmt.Sprintf("%s schemaVersion not present in devfile. Only devfile 2.2.0 or above is supported. The devfile needs to have the schemaVersion set in the metadata section with a value of 2.2.0 or above.", errMsg)
-		} else {
-			errMsg = fmt.Sprintf("%s %s", errMsg, err)
-		}
-
+		errMsg := parseErrorMessage(err)
 		klog.Error(errMsg)
 		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: errMsg})
 		return
@@ -153,3 +161,86 @@ func DevfileHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Write(resp)
 }
+
+// ConvertHandler converts devfiles into the objects of an application and validates them
+// in the namespace of the user.
+type ConvertHandler struct {
+	registries *Registries
+	anonConfig *rest.Config
+	// newClient creates the client of the user that validates the objects
+	newClient func(user *auth.User) (dynamic.Interface, error)
+}
+
+func NewConvertHandler(registries *Registries, anonymousTransport http.RoundTripper, k8sProxiedEndpoint string) *ConvertHandler {
+	h := &ConvertHandler{
+		registries: registries,
+		anonConfig: &rest.Config{
+			Host:      k8sProxiedEndpoint,
+			Transport: anonymousTransport,
+		},
+	}
+	h.newClient = h.userClient
+	return h
+}
+
+func (h *ConvertHandler) userClient(user *auth.User) (dynamic.Interface, error) {
+	config := rest.CopyConfig(h.anonConfig)
+	config.BearerToken = user.Token
+	config.Impersonate = user.ImpersonationConfig()
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating dynamic client: %v", err)
+	}
+	return client, nil
+}
+
+func (h *ConvertHandler) HandleConvert(user *auth.User, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		serverutils.SendResponse(w, http.StatusMethodNotAllowed, serverutils.ApiError{Err: "Invalid method: only POST is allowed"})
+		return
+	}
+
+	var data DevfileConvertForm
+	if err := serverutils.DecodeRequest(r, &data); err != nil {
+		klog.Errorf("Invalid devfile convert request: %v", err)
+		serverutils.SendRequestError(w, r, err)
+		return
+	}
+
+	httpTimeout := 10
+	devfileObj, err := parseDevfileWithFallback(r.Context(), []byte(data.Devfile.DevfileContent), &httpTimeout, h.registries)
+	if err != nil {
+		errMsg := parseErrorMessage(err)
+		klog.Error(errMsg)
+		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: errMsg})
+		return
+	}
+
+	objects, err := ConvertDevfile(devfileObj, ConvertOptions{
+		Name:          data.Name,
+		Namespace:     data.Namespace,
+		Git:           data.Git,
+		BuildStrategy: data.BuildStrategy,
+	})
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to convert the devfile: %v", err)
+		klog.Error(errMsg)
+		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: errMsg})
+		return
+	}
+
+	response := DevfileConvertResponse{Objects: objects}
+	if data.DryRun {
+		client, err := h.newClient(user)
+		if err != nil {
+			errMsg := fmt.Sprintf("Failed to create the client to validate the objects: %v", err)
+			klog.Error(errMsg)
+			serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: errMsg})
+			return
+		}
+		response.Validation = DryRunObjects(r.Context(), client, data.Namespace, objects)
+	}
+	serverutils.SendResponse(w, http.StatusOK, response)
+}
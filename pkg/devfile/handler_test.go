@@ -1,6 +1,7 @@
 package devfile
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -111,7 +112,7 @@ func TestParseDevfileWithFallback(t *testing.T) {
 	httpTimeout := 10
 
 	t.Run("devfile without parent parses on first attempt", func(t *testing.T) {
-		devfileObj, err := parseDevfileWithFallback([]byte(validDevfileNoParent), &httpTimeout)
+		devfileObj, err := parseDevfileWithFallback(context.Background(), []byte(validDevfileNoParent), &httpTimeout, nil)
 		assert.NoError(t, err)
 
 		components, err := GetDeployComponents(devfileObj)
@@ -121,7 +122,7 @@ func TestParseDevfileWithFallback(t *testing.T) {
 	})
 
 	t.Run("devfile with unreachable parent falls back to unflattened parse", func(t *testing.T) {
-		devfileObj, err := parseDevfileWithFallback([]byte(devfileWithBadRegistry), &httpTimeout)
+		devfileObj, err := parseDevfileWithFallback(context.Background(), []byte(devfileWithBadRegistry), &httpTimeout, nil)
 		assert.NoError(t, err, "fallback to unflattened parse should succeed")
 
 		components, err := GetDeployComponents(devfileObj)
@@ -131,7 +132,7 @@ func TestParseDevfileWithFallback(t *testing.T) {
 	})
 
 	t.Run("completely invalid devfile fails both attempts", func(t *testing.T) {
-		_, err := parseDevfileWithFallback([]byte("not valid yaml: ["), &httpTimeout)
+		_, err := parseDevfileWithFallback(context.Background(), []byte("not valid yaml: ["), &httpTimeout, nil)
 		assert.Error(t, err)
 	})
 }
@@ -0,0 +1,324 @@
+package devfile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	parserUtil "github.com/devfile/library/v2/pkg/devfile/parser/util"
+	devfileUtil "github.com/devfile/library/v2/pkg/util"
+	indexSchema "github.com/devfile/registry-support/index/generator/schema"
+	"github.com/openshift/console/pkg/reload"
+	"github.com/openshift/console/pkg/serverconfig"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// sampleCacheSize is the maximum number of cached registry indexes
+	sampleCacheSize = 64
+	// registryTimeout matches the timeout used for the public registries
+	registryTimeout = 10 * time.Second
+	// maxRegistryResponseSize bounds the indexes and devfiles read from a registry
+	maxRegistryResponseSize = 16 * 1024 * 1024
+)
+
+var (
+	// sampleCacheTTL keeps the samples of a registry for a few minutes, registries are
+	// rebuilt rarely and every visit of the import page lists the samples.
+	sampleCacheTTL = 5 * time.Minute
+
+	sampleCache = cache.NewLRUExpireCache(sampleCacheSize)
+)
+
+// Registries are the devfile registries configured by the cluster admin. A nil *Registries
+// only allows the public registries.
+type Registries struct {
+	registries []*registry
+}
+
+type registry struct {
+	serverconfig.DevfileRegistry
+	client   *http.Client
+	caBundle *reload.CABundle
+}
+
+// NewRegistries creates the clients of the configured registries.
+func NewRegistries(configs []serverconfig.DevfileRegistry) (*Registries, error) {
+	r := &Registries{}
+	for _, config := range configs {
+		config.URL = strings.TrimSuffix(config.URL, "/")
+		config.MirrorOf = strings.TrimSuffix(config.MirrorOf, "/")
+		reg := &registry{DevfileRegistry: config}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if config.CAFile != "" {
+			caBundle, err := reload.NewCABundle("devfile-registry-"+config.Name, config.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the CA file of devfile registry %s: %v", config.Name, err)
+			}
+			reg.caBundle = caBundle
+			transport.TLSClientConfig = caBundle.ClientTLSConfig()
+		}
+		reg.client = &http.Client{Transport: transport, Timeout: registryTimeout}
+		r.registries = append(r.registries, reg)
+	}
+	return r, nil
+}
+
+// CABundles returns the CA bundles of the registries, which are reloaded when rotated.
+func (r *Registries) CABundles() []*reload.CABundle {
+	var caBundles []*reload.CABundle
+	for _, reg := range r.list() {
+		if reg.caBundle != nil {
+			caBundles = append(caBundles, reg.caBundle)
+		}
+	}
+	return caBundles
+}
+
+func (r *Registries) list() []*registry {
+	if r == nil {
+		return nil
+	}
+	return r.registries
+}
+
+// lookup finds a configured registry by its name or by a URL it serves, directly or as a mirror.
+func (r *Registries) lookup(nameOrURL string) *registry {
+	for _, reg := range r.list() {
+		if reg.Name == nameOrURL {
+			return reg
+		}
+	}
+	return r.forURL(nameOrURL)
+}
+
+// forURL finds the configured registry that serves a URL, directly or as a mirror.
+func (r *Registries) forURL(rawURL string) *registry {
+	rawURL = strings.TrimSuffix(rawURL, "/")
+	for _, reg := range r.list() {
+		if rawURL == reg.URL || (reg.MirrorOf != "" && rawURL == reg.MirrorOf) {
+			return reg
+		}
+	}
+	return nil
+}
+
+// get reads a path of the registry, with the token of the registry if it has one.
+func (reg *registry) get(ctx context.Context, path string) ([]byte, error) {
+	return reg.getURL(ctx, reg.URL+path)
+}
+
+func (reg *registry) getURL(ctx context.Context, url string) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if reg.TokenFile != "" {
+		token, err := os.ReadFile(reg.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the token of devfile registry %s: %v", reg.Name, err)
+		}
+		request.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	response, err := reg.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxRegistryResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned %s", url, response.Status)
+	}
+	return body, nil
+}
+
+func (reg *registry) samples(ctx context.Context) ([]indexSchema.Schema, error) {
+	body, err := reg.get(ctx, "/index/sample")
+	if err != nil {
+		return nil, err
+	}
+	var samples []indexSchema.Schema
+	if err := json.Unmarshal(body, &samples); err != nil {
+		return nil, fmt.Errorf("failed to parse the samples of devfile registry %s: %v", reg.Name, err)
+	}
+	return samples, nil
+}
+
+// cachedSamples returns the samples of a registry from the cache, or reads and caches them.
+func cachedSamples(key string, fetch func() ([]indexSchema.Schema, error)) ([]indexSchema.Schema, error) {
+	if cached, ok := sampleCache.Get(key); ok {
+		return cached.([]indexSchema.Schema), nil
+	}
+	samples, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	sampleCache.Add(key, samples, sampleCacheTTL)
+	return samples, nil
+}
+
+// Samples returns the samples of a public registry or of a configured registry, given by
+// its name or URL. Public registries with a configured mirror are read from the mirror. If registryParam is empty, the samples of all configured registries
+// are merged, the first registry winning for samples with the same name.
+func (r *Registries) Samples(ctx context.Context, registryParam string) ([]indexSchema.Schema, error) {
+	if registryParam != "" {
+		if reg := r.lookup(registryParam); reg != nil {
+			return cachedSamples(reg.URL, func() ([]indexSchema.Schema, error) { return reg.samples(ctx) })
+		}
+		return cachedSamples(registryParam, func() ([]indexSchema.Schema, error) { return getRegistrySamples(registryParam) })
+	}
+
+	registries := r.list()
+	if len(registries) == 0 {
+		return nil, fmt.Errorf("no devfile registries are configured")
+	}
+	results := make([][]indexSchema.Schema, len(registries))
+	errs := make([]error, len(registries))
+	var wg sync.WaitGroup
+	for i, reg := range registries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = cachedSamples(reg.URL, func() ([]indexSchema.Schema, error) { return reg.samples(ctx) })
+		}()
+	}
+	wg.Wait()
+
+	merged := []indexSchema.Schema{}
+	names := map[string]bool{}
+	var firstErr error
+	failed := 0
+	for i, samples := range results {
+		if errs[i] != nil {
+			klog.Warningf("Failed to read samples from devfile registry %s: %v", registries[i].Name, errs[i])
+			failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("devfile registry %s: %v", registries[i].Name, errs[i])
+			}
+			continue
+		}
+		for _, sample := range samples {
+			if !names[sample.Name] {
+				names[sample.Name] = true
+				merged = append(merged, sample)
+			}
+		}
+	}
+	if failed == len(registries) {
+		return nil, firstErr
+	}
+	return merged, nil
+}
+
+// registryURLs are the URLs the parser looks up parent devfiles without a registryUrl in.
+func (r *Registries) registryURLs() []string {
+	var urls []string
+	for _, reg := range r.list() {
+		urls = append(urls, reg.URL)
+	}
+	return urls
+}
+
+// resolveParent points a parent devfile that is referenced by its id at a configured
+// registry. The parent is then read by its URI with the CA and token of the registry,
+// instead of with the registry client of the devfile library, which can neither be given
+// credentials nor redirected to a mirror. The returned DevfileUtils is nil if the devfile
+// is unchanged.
+func (r *Registries) resolveParent(ctx context.Context, devfileContent []byte) ([]byte, parserUtil.DevfileUtils) {
+	if len(r.list()) == 0 {
+		return devfileContent, nil
+	}
+	var devfile map[string]interface{}
+	if err := yaml.Unmarshal(devfileContent, &devfile); err != nil {
+		// the parser reports the error
+		return devfileContent, nil
+	}
+	parent, _ := devfile["parent"].(map[string]interface{})
+	id, _ := parent["id"].(string)
+	if id == "" {
+		return devfileContent, nil
+	}
+	registryURL, _ := parent["registryUrl"].(string)
+	version, _ := parent["version"].(string)
+
+	candidates := r.list()
+	if registryURL != "" {
+		reg := r.forURL(registryURL)
+		if reg == nil {
+			return devfileContent, nil
+		}
+		candidates = []*registry{reg}
+	}
+
+	stackPath := "/devfiles/" + id
+	if version != "" {
+		stackPath += "/" + version
+	}
+	for _, reg := range candidates {
+		parentContent, err := reg.get(ctx, stackPath)
+		if err != nil {
+			klog.V(4).Infof("Parent devfile %s not found in devfile registry %s: %v", id, reg.Name, err)
+			continue
+		}
+
+		parentURL := reg.URL + stackPath
+		delete(parent, "id")
+		delete(parent, "registryUrl")
+		delete(parent, "version")
+		parent["uri"] = parentURL
+		resolved, err := yaml.Marshal(devfile)
+		if err != nil {
+			return devfileContent, nil
+		}
+		return resolved, &registryDevfileUtils{
+			registries: r,
+			ctx:        ctx,
+			contents:   map[string][]byte{parentURL: parentContent},
+		}
+	}
+	klog.Warningf("Parent devfile %s was not found in the configured devfile registries", id)
+	return devfileContent, nil
+}
+
+// registryDevfileUtils reads the devfiles of the configured registries for the parser.
+type registryDevfileUtils struct {
+	registries *Registries
+	ctx        context.Context
+	// contents are the devfiles that were already read while resolving the parent
+	contents map[string][]byte
+}
+
+func (u *registryDevfileUtils) DownloadInMemory(params devfileUtil.HTTPRequestParams) ([]byte, error) {
+	if content, ok := u.contents[params.URL]; ok {
+		return content, nil
+	}
+	for _, reg := range u.registries.list() {
+		if strings.HasPrefix(params.URL, reg.URL+"/") {
+			return reg.getURL(u.ctx, params.URL)
+		}
+	}
+	return parserUtil.NewDevfileUtilsClient().DownloadInMemory(params)
+}
+
+func (u *registryDevfileUtils) DownloadGitRepoResources(url string, destDir string, token string) error {
+	// the stack resources of a registry are not needed to generate the resources
+	for _, reg := range u.registries.list() {
+		if strings.HasPrefix(url, reg.URL+"/") {
+			return nil
+		}
+	}
+	return parserUtil.NewDevfileUtilsClient().DownloadGitRepoResources(url, destDir, token)
+}
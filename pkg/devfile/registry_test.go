@@ -0,0 +1,246 @@
+package devfile
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/console/pkg/serverconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/cache"
+)
+
+const parentDevfile = `
+schemaVersion: 2.2.0
+metadata:
+  name: nodejs-parent
+components:
+  - name: kubernetes-deploy
+    kubernetes:
+      inlined: |
+        kind: Deployment
+        apiVersion: apps/v1
+        metadata:
+          name: parent-deploy
+        spec:
+          replicas: 1
+          selector:
+            matchLabels:
+              app: test
+          template:
+            metadata:
+              labels:
+                app: test
+            spec:
+              containers:
+                - name: test
+                  image: test:latest
+`
+
+const devfileWithRegistryParent = `
+schemaVersion: 2.2.0
+metadata:
+  name: test
+parent:
+  id: nodejs-parent
+  registryUrl: 'https://registry.devfile.io'
+  version: 1.0.0
+components:
+  - name: image-build
+    image:
+      imageName: test:latest
+      dockerfile:
+        uri: Dockerfile
+        buildContext: .
+commands:
+  - id: build-image
+    apply:
+      component: image-build
+  - id: deployk8s
+    apply:
+      component: kubernetes-deploy
+  - id: deploy
+    composite:
+      commands:
+        - build-image
+        - deployk8s
+      group:
+        kind: deploy
+        isDefault: true
+`
+
+type testRegistry struct {
+	server   *httptest.Server
+	requests []string
+	auth     []string
+}
+
+// newTestRegistry serves the given paths of a devfile registry with TLS. The CA of the
+// server and a token are written to files for the registry config.
+func newTestRegistry(t *testing.T, name string, responses map[string]string) (*testRegistry, serverconfig.DevfileRegistry) {
+	registry := &testRegistry{}
+	registry.server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registry.requests = append(registry.requests, r.URL.Path)
+		registry.auth = append(registry.auth, r.Header.Get("Authorization"))
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(registry.server.Close)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: registry.server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, caPEM, 0600))
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte(name+"-token\n"), 0600))
+
+	return registry, serverconfig.DevfileRegistry{Name: name, URL: registry.server.URL + "/", CAFile: caFile, TokenFile: tokenFile}
+}
+
+func resetSampleCache(t *testing.T) {
+	original := sampleCache
+	sampleCache = cache.NewLRUExpireCache(sampleCacheSize)
+	t.Cleanup(func() { sampleCache = original })
+}
+
+func TestRegistriesSamples(t *testing.T) {
+	resetSampleCache(t)
+	mirror, mirrorConfig := newTestRegistry(t, "mirror", map[string]string{
+		"/index/sample": `[{"name":"nodejs-basic","displayName":"Basic Node.js"},{"name":"go-basic","displayName":"Basic Go"}]`,
+	})
+	internal, internalConfig := newTestRegistry(t, "internal", map[string]string{
+		"/index/sample": `[{"name":"nodejs-basic","displayName":"Internal Node.js"},{"name":"internal-app","displayName":"Internal App"}]`,
+	})
+	_, brokenConfig := newTestRegistry(t, "broken", map[string]string{})
+
+	registries, err := NewRegistries([]serverconfig.DevfileRegistry{mirrorConfig, brokenConfig, internalConfig})
+	require.NoError(t, err)
+	assert.Len(t, registries.CABundles(), 3)
+
+	samples, err := registries.Samples(context.Background(), "")
+	require.NoError(t, err)
+	var names, displayNames []string
+	for _, sample := range samples {
+		names = append(names, sample.Name)
+		displayNames = append(displayNames, sample.DisplayName)
+	}
+	assert.Equal(t, []string{"nodejs-basic", "go-basic", "internal-app"}, names)
+	assert.Equal(t, []string{"Basic Node.js", "Basic Go", "Internal App"}, displayNames)
+	assert.Equal(t, []string{"Bearer mirror-token"}, mirror.auth)
+
+	// a single registry is selected by name or URL and read from the cache
+	samples, err = registries.Samples(context.Background(), "internal")
+	require.NoError(t, err)
+	assert.Len(t, samples, 2)
+	_, err = registries.Samples(context.Background(), internal.server.URL)
+	require.NoError(t, err)
+	assert.Len(t, internal.requests, 1)
+
+	_, err = registries.Samples(context.Background(), "broken")
+	assert.Error(t, err)
+	_, err = registries.Samples(context.Background(), "https://unknown.example.com")
+	assert.EqualError(t, err, "registry https://unknown.example.com is invalid")
+}
+
+func TestRegistriesSamplesFromMirror(t *testing.T) {
+	resetSampleCache(t)
+	mirror, mirrorConfig := newTestRegistry(t, "mirror", map[string]string{
+		"/index/sample": `[{"name":"nodejs-basic","displayName":"Basic Node.js"}]`,
+	})
+	mirrorConfig.MirrorOf = "https://registry.devfile.io/"
+	registries, err := NewRegistries([]serverconfig.DevfileRegistry{mirrorConfig})
+	require.NoError(t, err)
+
+	// the console asks for the samples of the public registry, which is mirrored
+	samples, err := registries.Samples(context.Background(), "https://registry.devfile.io")
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, "Basic Node.js", samples[0].DisplayName)
+	assert.Equal(t, []string{"/index/sample"}, mirror.requests)
+	assert.Equal(t, []string{"Bearer mirror-token"}, mirror.auth)
+
+	// the samples are cached under the URL of the mirror
+	_, ok := sampleCache.Get(mirror.server.URL)
+	assert.True(t, ok)
+	_, err = registries.Samples(context.Background(), mirror.server.URL)
+	require.NoError(t, err)
+	assert.Len(t, mirror.requests, 1)
+}
+
+func TestRegistriesSamplesAllFailing(t *testing.T) {
+	resetSampleCache(t)
+	_, brokenConfig := newTestRegistry(t, "broken", map[string]string{})
+	registries, err := NewRegistries([]serverconfig.DevfileRegistry{brokenConfig})
+	require.NoError(t, err)
+
+	_, err = registries.Samples(context.Background(), "")
+	assert.ErrorContains(t, err, "devfile registry broken")
+
+	var noRegistries *Registries
+	_, err = noRegistries.Samples(context.Background(), "")
+	assert.Error(t, err)
+}
+
+func TestNewRegistriesInvalidCAFile(t *testing.T) {
+	_, err := NewRegistries([]serverconfig.DevfileRegistry{{Name: "mirror", URL: "https://mirror.example.com", CAFile: "/does/not/exist"}})
+	assert.ErrorContains(t, err, "devfile registry mirror")
+}
+
+func TestParseDevfileWithMirroredParent(t *testing.T) {
+	httpTimeout := 10
+	mirror, mirrorConfig := newTestRegistry(t, "mirror", map[string]string{
+		"/devfiles/nodejs-parent/1.0.0": parentDevfile,
+	})
+	mirrorConfig.MirrorOf = "https://registry.devfile.io/"
+	registries, err := NewRegistries([]serverconfig.DevfileRegistry{mirrorConfig})
+	require.NoError(t, err)
+
+	devfileObj, err := parseDevfileWithFallback(context.Background(), []byte(devfileWithRegistryParent), &httpTimeout, registries)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/devfiles/nodejs-parent/1.0.0"}, mirror.requests)
+	assert.Equal(t, []string{"Bearer mirror-token"}, mirror.auth)
+
+	// the kubernetes component of the parent is only present if the parent was flattened
+	components, err := GetDeployComponents(devfileObj)
+	require.NoError(t, err)
+	assert.Contains(t, components, "image-build")
+	assert.Contains(t, components, "kubernetes-deploy")
+}
+
+func TestResolveParent(t *testing.T) {
+	mirror, mirrorConfig := newTestRegistry(t, "mirror", map[string]string{
+		"/devfiles/nodejs-parent": parentDevfile,
+	})
+	registries, err := NewRegistries([]serverconfig.DevfileRegistry{mirrorConfig})
+	require.NoError(t, err)
+
+	t.Run("parent without registry is looked up in the configured registries", func(t *testing.T) {
+		resolved, utils := registries.resolveParent(context.Background(), []byte("schemaVersion: 2.2.0\nparent:\n  id: nodejs-parent\n"))
+		assert.NotNil(t, utils)
+		assert.Contains(t, string(resolved), "uri: "+mirror.server.URL+"/devfiles/nodejs-parent")
+		assert.NotContains(t, string(resolved), "id:")
+	})
+
+	t.Run("parent of a registry that is not mirrored is unchanged", func(t *testing.T) {
+		devfile := []byte(devfileWithRegistryParent)
+		resolved, utils := registries.resolveParent(context.Background(), devfile)
+		assert.Nil(t, utils)
+		assert.Equal(t, devfile, resolved)
+	})
+
+	t.Run("missing parent is unchanged", func(t *testing.T) {
+		devfile := []byte("schemaVersion: 2.2.0\nparent:\n  id: missing\n")
+		resolved, utils := registries.resolveParent(context.Background(), devfile)
+		assert.Nil(t, utils)
+		assert.Equal(t, devfile, resolved)
+	})
+}
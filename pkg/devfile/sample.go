@@ -17,17 +17,21 @@ var testRegistryServer = ""
 // it gets the content of the index (index.json) of the specified registry.
 // This is based on https://github.com/devfile/registry-support/blob/master/registry-library/library/library.go#L61
 func GetRegistrySamples(registry string) ([]byte, error) {
+	devfileIndex, err := getRegistrySamples(registry)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(devfileIndex)
+}
+
+func getRegistrySamples(registry string) ([]indexSchema.Schema, error) {
 	//reduce the http request and response timeouts on the registry to 10s
 	httpTimeout := 10
 	if registry == DEVFILE_REGISTRY_URL || registry == DEVFILE_STAGING_REGISTRY_URL || registry == testRegistryServer && testRegistryServer != "" {
 		// set registryOption with `user=openshift-console` and `client=openshift-console` for registry telemetry tracking
 		registryOption := registryLibrary.RegistryOptions{HTTPTimeout: &httpTimeout, Telemetry: registryLibrary.TelemetryData{User: ODC_TELEMETRY_CLIENT_NAME, Client: ODC_TELEMETRY_CLIENT_NAME}}
 
-		devfileIndex, err := registryLibrary.GetRegistryIndex(registry, registryOption, indexSchema.SampleDevfileType)
-		if err != nil {
-			return nil, err
-		}
-		return json.Marshal(devfileIndex)
+		return registryLibrary.GetRegistryIndex(registry, registryOption, indexSchema.SampleDevfileType)
 	} else {
 		return nil, fmt.Errorf("registry %s is invalid", registry)
 	}
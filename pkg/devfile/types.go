@@ -6,11 +6,12 @@ import (
 	routev1 "github.com/openshift/api/route/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // DevfileForm is the needed data to send to the devfile library
 type DevfileForm struct {
-	Name    string      `json:"name"`
+	Name    string      `json:"name" validate:"required"`
 	Git     GitData     `json:"git"`
 	Devfile DevfileData `json:"devfile"`
 }
@@ -44,7 +45,25 @@ type GitData struct {
 // DevfileData is the devfile-related information
 type DevfileData struct {
 	// DevfileContent is the content of the "devfile.yaml"
-	DevfileContent string `json:"devfileContent"`
+	DevfileContent string `json:"devfileContent" validate:"required"`
 	// DevfilePath is the path to the devfile (including the file name; ie "./my-path/devfile.yaml")
 	DevfilePath string `json:"devfilePath"`
 }
+
+// DevfileConvertForm is the devfile to convert into the objects of an application
+type DevfileConvertForm struct {
+	Name      string      `json:"name" validate:"required,dns1035label"`
+	Namespace string      `json:"namespace" validate:"required,dns1123label"`
+	Git       GitData     `json:"git"`
+	Devfile   DevfileData `json:"devfile"`
+	// BuildStrategy is the kind of object that builds the images, BuildConfig by default
+	BuildStrategy BuildStrategy `json:"buildStrategy"`
+	// DryRun creates the objects with dry-run in the namespace
+	DryRun bool `json:"dryRun"`
+}
+
+// DevfileConvertResponse is the list of objects of a devfile in the order they should be created in
+type DevfileConvertResponse struct {
+	Objects    []unstructured.Unstructured `json:"objects"`
+	Validation []ObjectValidation          `json:"validation,omitempty"`
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/openshift/library-go/pkg/crypto"
 
+	"github.com/openshift/console/pkg/tracing"
 	"github.com/openshift/console/pkg/utils"
 )
 
@@ -119,7 +120,7 @@ func httpClient(tlsConfig *tls.Config, blockPrivateIPs bool) (*http.Client, erro
 	}
 
 	client := &http.Client{
-		Transport: tr,
+		Transport: tracing.Transport(tr),
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			if len(via) >= maxRedirects {
 				return fmt.Errorf("stopped after %d redirects", maxRedirects)
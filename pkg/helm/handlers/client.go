@@ -2,10 +2,14 @@ package handlers
 
 import (
 	"fmt"
+	"net/http"
 
 	"helm.sh/helm/v4/pkg/action"
 	"k8s.io/client-go/dynamic"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/transport"
+
+	"github.com/openshift/console/pkg/auth"
 )
 
 type HandlerClients struct {
@@ -32,3 +36,15 @@ func NewHandlerClients(conf *action.Configuration) (*HandlerClients, error) {
 	}, nil
 
 }
+
+// userTransport wraps the transport with the impersonation headers required for
+// users that are authenticated without a token of their own.
+func userTransport(rt http.RoundTripper, user *auth.User) *http.RoundTripper {
+	if user.Impersonate {
+		rt = transport.NewImpersonatingRoundTripper(transport.ImpersonationConfig{
+			UserName: user.Username,
+			Groups:   user.Groups,
+		}, rt)
+	}
+	return &rt
+}
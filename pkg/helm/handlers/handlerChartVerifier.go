@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -20,7 +19,7 @@ type verifierHandlers struct {
 	ApiServerHost           string
 	Transport               http.RoundTripper
 	getActionConfigurations func(string, string, string, *http.RoundTripper) *action.Configuration
-	newProxy                func(bearerToken string) (chartproxy.Proxy, error)
+	newProxy                func(user *auth.User) (chartproxy.Proxy, error)
 	chartVerifier           func(charturl string, values map[string]interface{}, conf *action.Configuration) (string, error)
 }
 
@@ -32,37 +31,36 @@ func NewVerifierHandler(apiUrl string, transport http.RoundTripper, kubeversionG
 		chartVerifier:           chartverifier.ChartVerifier,
 	}
 
-	h.newProxy = func(bearerToken string) (getter chartproxy.Proxy, err error) {
+	h.newProxy = func(user *auth.User) (getter chartproxy.Proxy, err error) {
 		return chartproxy.New(func() (*rest.Config, error) {
-			return h.restConfig(bearerToken), nil
+			return h.restConfig(user), nil
 		}, kubeversionGetter)
 	}
 
 	return h
 }
-func (h *verifierHandlers) restConfig(bearerToken string) *rest.Config {
+func (h *verifierHandlers) restConfig(user *auth.User) *rest.Config {
 	return &rest.Config{
 		Host:        h.ApiServerHost,
-		BearerToken: bearerToken,
+		BearerToken: user.Token,
+		Impersonate: user.ImpersonationConfig(),
 		Transport:   h.Transport,
 	}
 }
 func (h *verifierHandlers) HandleChartVerifier(user *auth.User, w http.ResponseWriter, r *http.Request) {
 	var req HelmVerifierRequest
-
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: fmt.Sprintf("Failed to parse request: %v", err)})
+	if err := serverutils.DecodeRequest(r, &req); err != nil {
+		serverutils.SendRequestError(w, r, err)
 		return
 	}
 	if !actions.IsValidChartURL(req.ChartUrl) {
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: "invalid chart URL: must be oci:// or http(s)://*.tgz"})
+		serverutils.SendError(w, r, http.StatusBadRequest, "invalid chart URL: must be oci:// or http(s)://*.tgz")
 		return
 	}
-	conf := h.getActionConfigurations(h.ApiServerHost, "default", user.Token, &h.Transport)
+	conf := h.getActionConfigurations(h.ApiServerHost, "default", user.Token, userTransport(h.Transport, user))
 	resp, err := h.chartVerifier(req.ChartUrl, req.Values, conf)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("Failed to verify chart: %v", err)})
+		serverutils.SendUpstreamError(w, r, http.StatusBadGateway, fmt.Errorf("Failed to verify chart: %w", err))
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -38,7 +38,7 @@ func TestHelmHandlers_HandleChartVerifier(t *testing.T) {
 		{
 			name:             "Error occurred",
 			body:             validBody,
-			expectedResponse: `{"error":"Failed to verify chart: Chart path is invalid"}`,
+			expectedResponse: `{"error":"Failed to verify chart: Chart path is invalid","code":"BadGateway"}`,
 			error:            errors.New("Chart path is invalid"),
 			httpStatusCode:   http.StatusBadGateway,
 		},
@@ -74,14 +74,16 @@ func TestHelmHandlers_HandleChartVerifier(t *testing.T) {
 
 func TestHelmHandlers_HandleChartVerifier_RejectsInvalidURLs(t *testing.T) {
 	tests := []struct {
-		name string
-		body string
+		name           string
+		body           string
+		httpStatusCode int
 	}{
-		{"rejects internal IP without tgz", `{"chart_url":"http://172.28.1.76:8849/nacos"}`},
-		{"rejects non-tgz HTTP URL", `{"chart_url":"http://example.com/charts/mychart"}`},
-		{"rejects empty chart_url", `{"chart_url":""}`},
-		{"rejects ftp scheme", `{"chart_url":"ftp://example.com/chart.tgz"}`},
-		{"rejects file scheme", `{"chart_url":"file:///etc/passwd"}`},
+		{"rejects internal IP without tgz", `{"chart_url":"http://172.28.1.76:8849/nacos"}`, http.StatusBadRequest},
+		{"rejects non-tgz HTTP URL", `{"chart_url":"http://example.com/charts/mychart"}`, http.StatusBadRequest},
+		{"rejects empty chart_url", `{"chart_url":""}`, http.StatusUnprocessableEntity},
+		{"rejects ftp scheme", `{"chart_url":"ftp://example.com/chart.tgz"}`, http.StatusUnprocessableEntity},
+		{"rejects file scheme", `{"chart_url":"file:///etc/passwd"}`, http.StatusUnprocessableEntity},
+		{"rejects unknown fields", `{"chart_url":"https://example.com/charts/mychart-1.0.0.tgz","chartUrl":""}`, http.StatusBadRequest},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -92,8 +94,8 @@ func TestHelmHandlers_HandleChartVerifier_RejectsInvalidURLs(t *testing.T) {
 			response := httptest.NewRecorder()
 
 			handlers.HandleChartVerifier(&auth.User{}, response, request)
-			if response.Code != http.StatusBadRequest {
-				t.Errorf("expected status 400 but got %v", response.Code)
+			if response.Code != tt.httpStatusCode {
+				t.Errorf("expected status %v but got %v", tt.httpStatusCode, response.Code)
 			}
 		})
 	}
@@ -221,7 +221,7 @@ func TestHelmHandlers_HandleHelmList(t *testing.T) {
 			name:             "Error occurred at listing releases",
 			error:            errors.New("unknown error occurred"),
 			httpStatusCode:   http.StatusBadGateway,
-			expectedResponse: `{"error":"Failed to list helm releases: unknown error occurred"}`,
+			expectedResponse: `{"error":"Failed to list helm releases: unknown error occurred","code":"BadGateway"}`,
 		},
 		{
 			name:             "Return releases serialized in JSON format",
@@ -263,7 +263,7 @@ func TestHelmHandlers_HandleHelmInstall(t *testing.T) {
 	}{
 		{
 			name:             "Error occurred",
-			expectedResponse: `{"error":"Failed to install helm chart: Chart path is invalid"}`,
+			expectedResponse: `{"error":"Failed to install helm chart: Chart path is invalid","code":"BadGateway"}`,
 			error:            errors.New("Chart path is invalid"),
 			httpStatusCode:   http.StatusBadGateway,
 		},
@@ -308,7 +308,7 @@ func TestHelmHandlers_HandleHelmRenderManifest(t *testing.T) {
 		{
 			name:                "Error occurred",
 			error:               errors.New("Chart path is invalid"),
-			expectedResponse:    `{"error":"Failed to render manifests: Chart path is invalid"}`,
+			expectedResponse:    `{"error":"Failed to render manifests: Chart path is invalid","code":"BadGateway"}`,
 			httpStatusCode:      http.StatusBadGateway,
 			expectedContentType: "application/json",
 		},
@@ -358,7 +358,7 @@ func TestHelmHandlers_HandleGetRelease(t *testing.T) {
 			error:            errors.New("unknown error occurred"),
 			httpStatusCode:   http.StatusBadGateway,
 			releaseName:      "Test",
-			expectedResponse: `{"error":"Failed to find helm release: unknown error occurred"}`,
+			expectedResponse: `{"error":"Failed to find helm release: unknown error occurred","code":"BadGateway"}`,
 		},
 		{
 			name:             "Return the requested release serialized in JSON format",
@@ -401,7 +401,7 @@ func TestHelmHandlers_HandleGetChart(t *testing.T) {
 		{
 			name:                "Error occurred",
 			error:               errors.New("Chart path is invalid"),
-			expectedResponse:    `{"error":"Failed to retrieve chart: Chart path is invalid"}`,
+			expectedResponse:    `{"error":"Failed to retrieve chart: Chart path is invalid","code":"BadRequest"}`,
 			httpStatusCode:      http.StatusBadRequest,
 			expectedContentType: "application/json",
 		},
@@ -453,7 +453,7 @@ func TestHelmHandlers_HandleGetReleaseHistory(t *testing.T) {
 		{
 			name:                "chart release history should error out when there is error from helm",
 			error:               errors.New("Chart path is invalid"),
-			expectedResponse:    `{"error":"Failed to list helm release history: Chart path is invalid"}`,
+			expectedResponse:    `{"error":"Failed to list helm release history: Chart path is invalid","code":"BadGateway"}`,
 			httpStatusCode:      http.StatusBadGateway,
 			expectedContentType: "application/json",
 			releaseName:         "test",
@@ -469,7 +469,7 @@ func TestHelmHandlers_HandleGetReleaseHistory(t *testing.T) {
 		{
 			name:                "NotFound error should be returned if release does not exist",
 			error:               actions.ErrReleaseNotFound,
-			expectedResponse:    `{"error":"Failed to list helm release history: release: not found"}`,
+			expectedResponse:    `{"error":"Failed to list helm release history: release: not found","code":"NotFound"}`,
 			httpStatusCode:      http.StatusNotFound,
 			expectedContentType: "application/json",
 			releaseName:         "test",
@@ -512,7 +512,7 @@ func TestHelmHandlers_HandleHelmUninstallRelease(t *testing.T) {
 		{
 			name:                "Invalid chart uninstall release test",
 			error:               errors.New("Chart path is invalid"),
-			expectedResponse:    `{"error":"Failed to uninstall helm release: Chart path is invalid"}`,
+			expectedResponse:    `{"error":"Failed to uninstall helm release: Chart path is invalid","code":"BadGateway"}`,
 			httpStatusCode:      http.StatusBadGateway,
 			expectedContentType: "application/json",
 			releaseName:         "test",
@@ -530,7 +530,7 @@ func TestHelmHandlers_HandleHelmUninstallRelease(t *testing.T) {
 		{
 			name:                "uninstalling non exist release should return not found",
 			error:               actions.ErrReleaseNotFound,
-			expectedResponse:    `{"error":"Failed to uninstall helm release: release: not found"}`,
+			expectedResponse:    `{"error":"Failed to uninstall helm release: release: not found","code":"NotFound"}`,
 			httpStatusCode:      http.StatusNotFound,
 			expectedContentType: "application/json",
 			releaseName:         "test",
@@ -576,7 +576,7 @@ func TestHelmHandlers_HandleHelmRollbackRelease(t *testing.T) {
 			name:                "Invalid chart rollback release test",
 			error:               errors.New("Chart path is invalid"),
 			body:                `{"name": "test", "namespace":"test", "version":1}`,
-			expectedResponse:    `{"error":"Failed to rollback helm releases: Chart path is invalid"}`,
+			expectedResponse:    `{"error":"Failed to rollback helm releases: Chart path is invalid","code":"BadGateway"}`,
 			httpStatusCode:      http.StatusBadGateway,
 			expectedContentType: "application/json",
 			releaseName:         "test",
@@ -601,11 +601,11 @@ func TestHelmHandlers_HandleHelmRollbackRelease(t *testing.T) {
 		},
 		{
 			name:                "Invalid body in the request should throw an json parsing error",
-			expectedResponse:    `{"error":"Failed to parse request: json: cannot unmarshal string into Go struct field HelmRequest.version of type int"}`,
+			expectedResponse:    `{"error":"Failed to parse request: json: cannot unmarshal string into Go struct field HelmRequest.version of type int","code":"BadRequest"}`,
 			body:                `{"name": "test", "namespace":"test", "version":"abc"}`,
 			expectedContentType: "application/json",
 			error:               errors.New(`{"error":"Failed to parse request: json: cannot unmarshal string into Go struct field HelmRequest.version of type int"}`),
-			httpStatusCode:      http.StatusBadGateway,
+			httpStatusCode:      http.StatusBadRequest,
 			releaseName:         "test",
 			releaseNamespace:    "test",
 		},
@@ -613,7 +613,7 @@ func TestHelmHandlers_HandleHelmRollbackRelease(t *testing.T) {
 			name:                "Non exist release rollback should return revision not found error",
 			error:               actions.ErrReleaseRevisionNotFound,
 			body:                `{"name": "test", "namespace":"test", "version":1}`,
-			expectedResponse:    `{"error":"Failed to rollback helm releases: revision not found for provided release"}`,
+			expectedResponse:    `{"error":"Failed to rollback helm releases: revision not found for provided release","code":"NotFound"}`,
 			httpStatusCode:      http.StatusNotFound,
 			expectedContentType: "application/json",
 			releaseName:         "test",
@@ -660,7 +660,7 @@ func TestHelmHandlers_HandleHelmUpgradeRelease(t *testing.T) {
 		{
 			name:                "Invalid chart path upgrade release test",
 			error:               errors.New("Chart path is invalid"),
-			expectedResponse:    `{"error":"Failed to upgrade helm release: Chart path is invalid"}`,
+			expectedResponse:    `{"error":"Failed to upgrade helm release: Chart path is invalid","code":"BadGateway"}`,
 			httpStatusCode:      http.StatusBadGateway,
 			expectedContentType: "application/json",
 			requestBody:         `{"name":"test", "namespace": "test-namespace", "version": 1}`,
@@ -680,7 +680,7 @@ func TestHelmHandlers_HandleHelmUpgradeRelease(t *testing.T) {
 		},
 		{
 			name:                "Upgrade of non exist release should return no revision found error",
-			expectedResponse:    `{"error":"Failed to rollback helm releases: revision not found for provided release"}`,
+			expectedResponse:    `{"error":"Failed to rollback helm releases: revision not found for provided release","code":"NotFound"}`,
 			release:             &fakeRelease,
 			expectedContentType: "application/json",
 			error:               actions.ErrReleaseRevisionNotFound,
@@ -841,14 +841,14 @@ func TestHelmHandlers_Index(t *testing.T) {
 			name:             "error case should return correct http header",
 			httpStatusCode:   http.StatusInternalServerError,
 			proxyNewError:    errors.New("Fake error"),
-			expectedResponse: `{"error":"Failed to get k8s config: Fake error"}`,
+			expectedResponse: `{"error":"Failed to get k8s config: Fake error","code":"InternalError"}`,
 			onlyCompatible:   true,
 		},
 		{
 			name:             "Report error while retrieving the merged index",
 			httpStatusCode:   http.StatusInternalServerError,
 			indexFileError:   errors.New("Fake error"),
-			expectedResponse: `{"error":"Failed to get index file: Fake error"}`,
+			expectedResponse: `{"error":"Failed to get index file: Fake error","code":"InternalError"}`,
 			onlyCompatible:   true,
 		},
 	}
@@ -864,9 +864,9 @@ func TestHelmHandlers_Index(t *testing.T) {
 				Token: "foo",
 			}
 			handler := helmHandlers{
-				newProxy: func(token string) (proxy chartproxy.Proxy, err error) {
-					if token != user.Token {
-						t.Errorf("Expected token %s but got %s", user.Token, token)
+				newProxy: func(u *auth.User) (proxy chartproxy.Proxy, err error) {
+					if u.Token != user.Token {
+						t.Errorf("Expected token %s but got %s", user.Token, u.Token)
 					}
 					return &fakeProxy{
 						repo:           tt.indexFile,
@@ -913,7 +913,7 @@ func TestHelmHandlers_HandleHelmInstallAsync(t *testing.T) {
 	}{
 		{
 			name:             "Error occurred",
-			expectedResponse: `{"error":"Failed to install helm chart: Chart path is invalid"}`,
+			expectedResponse: `{"error":"Failed to install helm chart: Chart path is invalid","code":"BadGateway"}`,
 			error:            errors.New("Chart path is invalid"),
 			httpStatusCode:   http.StatusBadGateway,
 		},
@@ -961,7 +961,7 @@ func TestHelmHandlers_HandleHelmUpgradeReleaseAsync(t *testing.T) {
 		{
 			name:                "Invalid chart path upgrade release test",
 			error:               errors.New("Chart path is invalid"),
-			expectedResponse:    `{"error":"Failed to upgrade helm release: Chart path is invalid"}`,
+			expectedResponse:    `{"error":"Failed to upgrade helm release: Chart path is invalid","code":"BadGateway"}`,
 			httpStatusCode:      http.StatusBadGateway,
 			expectedContentType: "application/json",
 			requestBody:         `{"name":"test", "namespace": "test-namespace", "version": 1}`,
@@ -981,7 +981,7 @@ func TestHelmHandlers_HandleHelmUpgradeReleaseAsync(t *testing.T) {
 		},
 		{
 			name:                "Upgrade of non exist release should return no revision found error",
-			expectedResponse:    `{"error":"Failed to rollback helm releases: revision not found for provided release"}`,
+			expectedResponse:    `{"error":"Failed to rollback helm releases: revision not found for provided release","code":"NotFound"}`,
 			secret:              &fakeSecret,
 			expectedContentType: "application/json",
 			error:               actions.ErrReleaseRevisionNotFound,
@@ -1027,7 +1027,7 @@ func TestHelmHandlers_HandleHelmUnInstallAsync(t *testing.T) {
 	}{
 		{
 			name:             "Error occurred",
-			expectedResponse: `{"error":"Failed to install helm chart: Chart path is invalid"}`,
+			expectedResponse: `{"error":"Failed to install helm chart: Chart path is invalid","code":"BadGateway"}`,
 			error:            errors.New("Chart path is invalid"),
 			httpStatusCode:   http.StatusBadGateway,
 		},
@@ -1072,13 +1072,13 @@ func TestHelmHandlers_HandleHelmInstallAsyncNoRepo(t *testing.T) {
 		{
 			name:             "Invalid JSON request",
 			requestBody:      `{invalid}`,
-			expectedResponse: `{"error":"Failed to parse request: invalid character 'i' looking for beginning of object key string"}`,
-			httpStatusCode:   http.StatusBadGateway,
+			expectedResponse: `{"error":"Failed to parse request: invalid character 'i' looking for beginning of object key string","code":"BadRequest"}`,
+			httpStatusCode:   http.StatusBadRequest,
 		},
 		{
 			name:             "Error occurred during chart install from URL",
 			requestBody:      `{"name":"test-release","namespace":"default","chart_url":"http://ghcr.io/test/chart","noRepo":true}`,
-			expectedResponse: `{"error":"Failed to install helm chart: Chart path is invalid"}`,
+			expectedResponse: `{"error":"Failed to install helm chart: Chart path is invalid","code":"BadRequest"}`,
 			error:            errors.New("Chart path is invalid"),
 			httpStatusCode:   http.StatusBadRequest,
 		},
@@ -44,9 +44,9 @@ func New(apiUrl string, transport http.RoundTripper, kubeversionGetter version.K
 		getReleaseHistory:       actions.GetReleaseHistory,
 	}
 
-	h.newProxy = func(bearerToken string) (getter chartproxy.Proxy, err error) {
+	h.newProxy = func(user *auth.User) (getter chartproxy.Proxy, err error) {
 		return chartproxy.New(func() (*rest.Config, error) {
-			return h.restConfig(bearerToken), nil
+			return h.restConfig(user), nil
 		}, kubeversionGetter)
 	}
 
@@ -76,35 +76,34 @@ type helmHandlers struct {
 	getChart              func(chartUrl string, conf *action.Configuration, namespace string, client dynamic.Interface, coreClient corev1client.CoreV1Interface, filesCleanup bool, indexEntry string) (*chart.Chart, error)
 	getChartFromURL       func(url string, conf *action.Configuration, namespace string, client dynamic.Interface, coreClient corev1client.CoreV1Interface, filesCleanup bool, basicAuthSecretName string) (*chart.Chart, error)
 	getReleaseHistory     func(releaseName string, conf *action.Configuration) ([]*releasev1.Release, error)
-	newProxy              func(bearerToken string) (chartproxy.Proxy, error)
+	newProxy              func(user *auth.User) (chartproxy.Proxy, error)
 }
 
-func (h *helmHandlers) restConfig(bearerToken string) *rest.Config {
+func (h *helmHandlers) restConfig(user *auth.User) *rest.Config {
 	return &rest.Config{
 		Host:        h.ApiServerHost,
-		BearerToken: bearerToken,
+		BearerToken: user.Token,
+		Impersonate: user.ImpersonationConfig(),
 		Transport:   h.Transport,
 	}
 }
 
 func (h *helmHandlers) HandleHelmRenderManifests(user *auth.User, w http.ResponseWriter, r *http.Request) {
 	var req HelmRequest
-
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := serverutils.DecodeRequest(r, &req); err != nil {
+		serverutils.SendRequestError(w, r, err)
 		return
 	}
 
-	conf := h.getActionConfigurations(h.ApiServerHost, req.Namespace, user.Token, &h.Transport)
+	conf := h.getActionConfigurations(h.ApiServerHost, req.Namespace, user.Token, userTransport(h.Transport, user))
 	handlerClients, err := NewHandlerClients(conf)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: err.Error()})
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 	resp, err := h.renderManifests(req.Name, req.ChartUrl, req.Values, conf, handlerClients.DynamicClient, handlerClients.CoreClient, req.Namespace, req.IndexEntry, false)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("Failed to render manifests: %v", err)})
+		serverutils.SendUpstreamError(w, r, http.StatusBadGateway, fmt.Errorf("Failed to render manifests: %w", err))
 		return
 	}
 
@@ -114,22 +113,20 @@ func (h *helmHandlers) HandleHelmRenderManifests(user *auth.User, w http.Respons
 
 func (h *helmHandlers) HandleHelmInstall(user *auth.User, w http.ResponseWriter, r *http.Request) {
 	var req HelmRequest
-
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("Failed to parse request: %v", err)})
+	if err := serverutils.DecodeRequest(r, &req); err != nil {
+		serverutils.SendRequestError(w, r, err)
 		return
 	}
 
-	conf := h.getActionConfigurations(h.ApiServerHost, req.Namespace, user.Token, &h.Transport)
+	conf := h.getActionConfigurations(h.ApiServerHost, req.Namespace, user.Token, userTransport(h.Transport, user))
 	handlerClients, err := NewHandlerClients(conf)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: err.Error()})
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 	resp, err := h.installChart(req.Namespace, req.Name, req.ChartUrl, req.Values, conf, handlerClients.DynamicClient, handlerClients.CoreClient, true, req.IndexEntry)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("Failed to install helm chart: %v", err)})
+		serverutils.SendUpstreamError(w, r, http.StatusBadGateway, fmt.Errorf("Failed to install helm chart: %w", err))
 		return
 	}
 
@@ -140,10 +137,8 @@ func (h *helmHandlers) HandleHelmInstall(user *auth.User, w http.ResponseWriter,
 
 func (h *helmHandlers) HandleHelmInstallAsync(user *auth.User, w http.ResponseWriter, r *http.Request) {
 	var req HelmRequest
-
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("Failed to parse request: %v", err)})
+	if err := serverutils.DecodeRequest(r, &req); err != nil {
+		serverutils.SendRequestError(w, r, err)
 		return
 	}
 
@@ -152,17 +147,17 @@ func (h *helmHandlers) HandleHelmInstallAsync(user *auth.User, w http.ResponseWr
 		namespace = "default"
 	}
 
-	conf := h.getActionConfigurations(h.ApiServerHost, namespace, user.Token, &h.Transport)
+	conf := h.getActionConfigurations(h.ApiServerHost, namespace, user.Token, userTransport(h.Transport, user))
 	handlerClients, err := NewHandlerClients(conf)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: err.Error()})
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	if req.NoRepo {
 		resp, err := h.installChartFromURL(namespace, req.Name, req.ChartUrl, req.Values, conf, handlerClients.CoreClient, req.ChartVersion, req.BasicAuthSecretName)
 		if err != nil {
-			serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: fmt.Sprintf("Failed to install helm chart: %v", err)})
+			serverutils.SendUpstreamError(w, r, http.StatusBadRequest, fmt.Errorf("Failed to install helm chart: %w", err))
 			return
 		}
 		serverutils.SendResponse(w, http.StatusCreated, resp)
@@ -171,7 +166,7 @@ func (h *helmHandlers) HandleHelmInstallAsync(user *auth.User, w http.ResponseWr
 
 	resp, err := h.installChartAsync(namespace, req.Name, req.ChartUrl, req.Values, conf, handlerClients.DynamicClient, handlerClients.CoreClient, true, req.IndexEntry)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("Failed to install helm chart: %v", err)})
+		serverutils.SendUpstreamError(w, r, http.StatusBadGateway, fmt.Errorf("Failed to install helm chart: %w", err))
 		return
 	}
 	serverutils.SendResponse(w, http.StatusCreated, resp)
@@ -186,15 +181,15 @@ func (h *helmHandlers) HandleHelmList(user *auth.User, w http.ResponseWriter, r
 	if limitInfoParam != "" {
 		limitInfo, err = strconv.ParseBool(limitInfoParam)
 		if err != nil {
-			serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("Failed to parse limitInfo parameter: %v", err)})
+			serverutils.SendError(w, r, http.StatusBadRequest, fmt.Sprintf("Failed to parse limitInfo parameter: %v", err))
 			return
 		}
 	}
 
-	conf := h.getActionConfigurations(h.ApiServerHost, ns, user.Token, &h.Transport)
+	conf := h.getActionConfigurations(h.ApiServerHost, ns, user.Token, userTransport(h.Transport, user))
 	resp, err := h.listReleases(conf, limitInfo)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("Failed to list helm releases: %v", err)})
+		serverutils.SendUpstreamError(w, r, http.StatusBadGateway, fmt.Errorf("Failed to list helm releases: %w", err))
 		return
 	}
 
@@ -209,16 +204,16 @@ func (h *helmHandlers) HandleGetRelease(user *auth.User, w http.ResponseWriter,
 	ns := queryParams.Get("ns")
 	chartName := queryParams.Get("name")
 
-	conf := h.getActionConfigurations(h.ApiServerHost, ns, user.Token, &h.Transport)
+	conf := h.getActionConfigurations(h.ApiServerHost, ns, user.Token, userTransport(h.Transport, user))
 	release, err := h.getRelease(chartName, conf)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("Failed to find helm release: %v", err)})
+		serverutils.SendUpstreamError(w, r, http.StatusBadGateway, fmt.Errorf("Failed to find helm release: %w", err))
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	rawManifest, err := json.Marshal(release)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: fmt.Sprintf("Failed to find helm release: %v", err)})
+		serverutils.SendError(w, r, http.StatusInternalServerError, fmt.Sprintf("Failed to find helm release: %v", err))
 		return
 	}
 	w.Write(rawManifest)
@@ -236,17 +231,17 @@ func (h *helmHandlers) HandleChartGet(user *auth.User, w http.ResponseWriter, r
 		namespace = "default"
 	}
 
-	conf := h.getActionConfigurations(h.ApiServerHost, namespace, user.Token, &h.Transport)
+	conf := h.getActionConfigurations(h.ApiServerHost, namespace, user.Token, userTransport(h.Transport, user))
 	handlerClients, err := NewHandlerClients(conf)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: err.Error()})
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	var resp *chart.Chart
 	if noRepo {
 		if chartUrl == "" {
-			serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: "chart URL is required"})
+			serverutils.SendError(w, r, http.StatusBadRequest, "chart URL is required")
 			return
 		}
 		resp, err = h.getChartFromURL(chartUrl, conf, namespace, handlerClients.DynamicClient, handlerClients.CoreClient, true, basicAuthSecretName)
@@ -254,7 +249,7 @@ func (h *helmHandlers) HandleChartGet(user *auth.User, w http.ResponseWriter, r
 		resp, err = h.getChart(chartUrl, conf, namespace, handlerClients.DynamicClient, handlerClients.CoreClient, true, indexEntry)
 	}
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: fmt.Sprintf("Failed to retrieve chart: %v", err)})
+		serverutils.SendUpstreamError(w, r, http.StatusBadRequest, fmt.Errorf("Failed to retrieve chart: %w", err))
 		return
 	}
 
@@ -266,26 +261,24 @@ func (h *helmHandlers) HandleChartGet(user *auth.User, w http.ResponseWriter, r
 
 func (h *helmHandlers) HandleUpgradeRelease(user *auth.User, w http.ResponseWriter, r *http.Request) {
 	var req HelmRequest
-
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: fmt.Sprintf("Failed to parse request: %v", err)})
+	if err := serverutils.DecodeRequest(r, &req); err != nil {
+		serverutils.SendRequestError(w, r, err)
 		return
 	}
 
-	conf := h.getActionConfigurations(h.ApiServerHost, req.Namespace, user.Token, &h.Transport)
+	conf := h.getActionConfigurations(h.ApiServerHost, req.Namespace, user.Token, userTransport(h.Transport, user))
 	handlerClients, err := NewHandlerClients(conf)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: err.Error()})
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 	resp, err := h.upgradeRelease(req.Namespace, req.Name, req.ChartUrl, req.Values, conf, handlerClients.DynamicClient, handlerClients.CoreClient, false, req.IndexEntry)
 	if err != nil {
 		if err.Error() == actions.ErrReleaseRevisionNotFound.Error() {
-			serverutils.SendResponse(w, http.StatusNotFound, serverutils.ApiError{Err: fmt.Sprintf("Failed to rollback helm releases: %v", err)})
+			serverutils.SendError(w, r, http.StatusNotFound, fmt.Sprintf("Failed to rollback helm releases: %v", err))
 			return
 		}
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("Failed to upgrade helm release: %v", err)})
+		serverutils.SendUpstreamError(w, r, http.StatusBadGateway, fmt.Errorf("Failed to upgrade helm release: %w", err))
 		return
 	}
 
@@ -296,26 +289,24 @@ func (h *helmHandlers) HandleUpgradeRelease(user *auth.User, w http.ResponseWrit
 
 func (h *helmHandlers) HandleUpgradeReleaseAsync(user *auth.User, w http.ResponseWriter, r *http.Request) {
 	var req HelmRequest
-
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: fmt.Sprintf("Failed to parse request: %v", err)})
+	if err := serverutils.DecodeRequest(r, &req); err != nil {
+		serverutils.SendRequestError(w, r, err)
 		return
 	}
 
-	conf := h.getActionConfigurations(h.ApiServerHost, req.Namespace, user.Token, &h.Transport)
+	conf := h.getActionConfigurations(h.ApiServerHost, req.Namespace, user.Token, userTransport(h.Transport, user))
 	handlerClients, err := NewHandlerClients(conf)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: err.Error()})
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 	resp, err := h.upgradeReleaseAsync(req.Namespace, req.Name, req.ChartUrl, req.Values, conf, handlerClients.DynamicClient, handlerClients.CoreClient, false, req.IndexEntry, req.BasicAuthSecretName)
 	if err != nil {
 		if err.Error() == actions.ErrReleaseRevisionNotFound.Error() {
-			serverutils.SendResponse(w, http.StatusNotFound, serverutils.ApiError{Err: fmt.Sprintf("Failed to rollback helm releases: %v", err)})
+			serverutils.SendError(w, r, http.StatusNotFound, fmt.Sprintf("Failed to rollback helm releases: %v", err))
 			return
 		}
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("Failed to upgrade helm release: %v", err)})
+		serverutils.SendUpstreamError(w, r, http.StatusBadGateway, fmt.Errorf("Failed to upgrade helm release: %w", err))
 		return
 	}
 	serverutils.SendResponse(w, http.StatusCreated, resp)
@@ -326,14 +317,14 @@ func (h *helmHandlers) HandleUninstallRelease(user *auth.User, w http.ResponseWr
 	ns := params.Get("ns")
 	rel := params.Get("name")
 
-	conf := h.getActionConfigurations(h.ApiServerHost, ns, user.Token, &h.Transport)
+	conf := h.getActionConfigurations(h.ApiServerHost, ns, user.Token, userTransport(h.Transport, user))
 	resp, err := h.uninstallRelease(rel, conf)
 	if err != nil {
 		if err.Error() == actions.ErrReleaseNotFound.Error() {
-			serverutils.SendResponse(w, http.StatusNotFound, serverutils.ApiError{Err: fmt.Sprintf("Failed to uninstall helm release: %v", err)})
+			serverutils.SendError(w, r, http.StatusNotFound, fmt.Sprintf("Failed to uninstall helm release: %v", err))
 			return
 		}
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("Failed to uninstall helm release: %v", err)})
+		serverutils.SendUpstreamError(w, r, http.StatusBadGateway, fmt.Errorf("Failed to uninstall helm release: %w", err))
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -343,21 +334,19 @@ func (h *helmHandlers) HandleUninstallRelease(user *auth.User, w http.ResponseWr
 
 func (h *helmHandlers) HandleRollbackRelease(user *auth.User, w http.ResponseWriter, r *http.Request) {
 	var req HelmRequest
-
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("Failed to parse request: %v", err)})
+	if err := serverutils.DecodeRequest(r, &req); err != nil {
+		serverutils.SendRequestError(w, r, err)
 		return
 	}
 
-	conf := h.getActionConfigurations(h.ApiServerHost, req.Namespace, user.Token, &h.Transport)
+	conf := h.getActionConfigurations(h.ApiServerHost, req.Namespace, user.Token, userTransport(h.Transport, user))
 	rel, err := h.rollbackRelease(req.Name, req.Version, conf)
 	if err != nil {
 		if err.Error() == actions.ErrReleaseRevisionNotFound.Error() {
-			serverutils.SendResponse(w, http.StatusNotFound, serverutils.ApiError{Err: fmt.Sprintf("Failed to rollback helm releases: %v", err)})
+			serverutils.SendError(w, r, http.StatusNotFound, fmt.Sprintf("Failed to rollback helm releases: %v", err))
 			return
 		}
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("Failed to rollback helm releases: %v", err)})
+		serverutils.SendUpstreamError(w, r, http.StatusBadGateway, fmt.Errorf("Failed to rollback helm releases: %w", err))
 		return
 	}
 
@@ -370,14 +359,14 @@ func (h *helmHandlers) HandleGetReleaseHistory(user *auth.User, w http.ResponseW
 	params := r.URL.Query()
 	name := params.Get("name")
 	ns := params.Get("ns")
-	conf := h.getActionConfigurations(h.ApiServerHost, ns, user.Token, &h.Transport)
+	conf := h.getActionConfigurations(h.ApiServerHost, ns, user.Token, userTransport(h.Transport, user))
 	rels, err := h.getReleaseHistory(name, conf)
 	if err != nil {
 		if err.Error() == actions.ErrReleaseNotFound.Error() {
-			serverutils.SendResponse(w, http.StatusNotFound, serverutils.ApiError{Err: fmt.Sprintf("Failed to list helm release history: %v", err)})
+			serverutils.SendError(w, r, http.StatusNotFound, fmt.Sprintf("Failed to list helm release history: %v", err))
 			return
 		}
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("Failed to list helm release history: %v", err)})
+		serverutils.SendUpstreamError(w, r, http.StatusBadGateway, fmt.Errorf("Failed to list helm release history: %w", err))
 		return
 	}
 	res, _ := json.Marshal(rels)
@@ -387,10 +376,10 @@ func (h *helmHandlers) HandleGetReleaseHistory(user *auth.User, w http.ResponseW
 
 func (h *helmHandlers) HandleIndexFile(user *auth.User, w http.ResponseWriter, r *http.Request) {
 
-	proxy, err := h.newProxy(user.Token)
+	proxy, err := h.newProxy(user)
 
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: fmt.Sprintf("Failed to get k8s config: %v", err)})
+		serverutils.SendError(w, r, http.StatusInternalServerError, fmt.Sprintf("Failed to get k8s config: %v", err))
 		return
 	}
 
@@ -405,7 +394,7 @@ func (h *helmHandlers) HandleIndexFile(user *auth.User, w http.ResponseWriter, r
 		var err error
 		onlyCompatible, err = strconv.ParseBool(onlyCompatibleParam)
 		if err != nil {
-			serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: fmt.Sprintf("Supported value for onlyCompatible query param is true or false, received: %s", onlyCompatibleParam)})
+			serverutils.SendError(w, r, http.StatusBadRequest, fmt.Sprintf("Supported value for onlyCompatible query param is true or false, received: %s", onlyCompatibleParam))
 			return
 		}
 	}
@@ -413,14 +402,14 @@ func (h *helmHandlers) HandleIndexFile(user *auth.User, w http.ResponseWriter, r
 	indexFile, err := proxy.IndexFile(onlyCompatible, r.URL.Query().Get("namespace"))
 
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: fmt.Sprintf("Failed to get index file: %v", err)})
+		serverutils.SendUpstreamError(w, r, http.StatusInternalServerError, fmt.Errorf("Failed to get index file: %w", err))
 		return
 	}
 
 	out, err := yaml.Marshal(indexFile)
 
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: fmt.Sprintf("Failed to deserialize index file to yaml: %v", err)})
+		serverutils.SendError(w, r, http.StatusInternalServerError, fmt.Sprintf("Failed to deserialize index file to yaml: %v", err))
 		return
 	}
 
@@ -432,19 +421,19 @@ func (h *helmHandlers) HandleUninstallReleaseAsync(user *auth.User, w http.Respo
 	ns := params.Get("ns")
 	rel := params.Get("name")
 	version := params.Get("version")
-	conf := h.getActionConfigurations(h.ApiServerHost, ns, user.Token, &h.Transport)
+	conf := h.getActionConfigurations(h.ApiServerHost, ns, user.Token, userTransport(h.Transport, user))
 	handlerClients, err := NewHandlerClients(conf)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: err.Error()})
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 	err = h.uninstallReleaseAsync(rel, ns, version, conf, handlerClients.CoreClient)
 	if err != nil {
 		if err.Error() == actions.ErrReleaseNotFound.Error() {
-			serverutils.SendResponse(w, http.StatusNotFound, serverutils.ApiError{Err: fmt.Sprintf("Failed to uninstall helm release: %v", err)})
+			serverutils.SendError(w, r, http.StatusNotFound, fmt.Sprintf("Failed to uninstall helm release: %v", err))
 			return
 		}
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("Failed to uninstall helm release: %v", err)})
+		serverutils.SendUpstreamError(w, r, http.StatusBadGateway, fmt.Errorf("Failed to uninstall helm release: %w", err))
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -460,19 +449,19 @@ func (h *helmHandlers) HandleURLChartGet(user *auth.User, w http.ResponseWriter,
 	basicAuthSecretName := params.Get("basic_auth_secret_name")
 
 	if chartUrl == "" {
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: "chart URL is required"})
+		serverutils.SendError(w, r, http.StatusBadRequest, "chart URL is required")
 		return
 	}
 
-	conf := h.getActionConfigurations(h.ApiServerHost, "default", user.Token, &h.Transport)
+	conf := h.getActionConfigurations(h.ApiServerHost, "default", user.Token, userTransport(h.Transport, user))
 	handlerClients, err := NewHandlerClients(conf)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: err.Error()})
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 	resp, err := h.getChartFromURL(chartUrl, conf, namespace, handlerClients.DynamicClient, handlerClients.CoreClient, true, basicAuthSecretName)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: fmt.Sprintf("Failed to retrieve chart: %v", err)})
+		serverutils.SendUpstreamError(w, r, http.StatusBadRequest, fmt.Errorf("Failed to retrieve chart: %w", err))
 		return
 	}
 
@@ -1,18 +1,18 @@
 package handlers
 
 type HelmRequest struct {
-	Name                string                 `json:"name"`
-	Namespace           string                 `json:"namespace"`
-	ChartUrl            string                 `json:"chart_url"`
+	Name                string                 `json:"name" validate:"dns1123subdomain"`
+	Namespace           string                 `json:"namespace" validate:"dns1123label"`
+	ChartUrl            string                 `json:"chart_url" validate:"url=http|https|oci"`
 	ChartVersion        string                 `json:"chart_version"` // optional; for OCI/direct URL install, used when chart_url has no tag
 	Values              map[string]interface{} `json:"values"`
 	Version             int                    `json:"version"`
 	IndexEntry          string                 `json:"indexEntry"`
 	NoRepo              bool                   `json:"noRepo"`
-	BasicAuthSecretName string                 `json:"basic_auth_secret_name"` // optional; names a Secret in Namespace with keys username and password for OCI/HTTP chart pull when NoRepo is true.
+	BasicAuthSecretName string                 `json:"basic_auth_secret_name" validate:"dns1123subdomain"` // optional; names a Secret in Namespace with keys username and password for OCI/HTTP chart pull when NoRepo is true.
 }
 
 type HelmVerifierRequest struct {
-	ChartUrl string                 `json:"chart_url"`
+	ChartUrl string                 `json:"chart_url" validate:"required,url=http|https|oci"`
 	Values   map[string]interface{} `json:"values"`
 }
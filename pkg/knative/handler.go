@@ -40,6 +40,7 @@ func NewKnativeHandler(anonymousTransport http.RoundTripper, proxiedK8SEndpoint,
 func (h *KnativeHandler) generateClient(user *auth.User) (dynamic.Interface, error) {
 	config := rest.CopyConfig(h.anonConfig)
 	config.BearerToken = user.Token
+	config.Impersonate = user.ImpersonationConfig()
 
 	client, err := dynamic.NewForConfig(config)
 	if err != nil {
@@ -60,13 +61,13 @@ func (h *KnativeHandler) Handle(user *auth.User, w http.ResponseWriter, r *http.
 	client, err := h.generateClient(user)
 	if err != nil {
 		klog.Errorf("Error creating dynamic client: %v", err)
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: err.Error()})
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	// GET /namespaces/{namespace}/services/{service}/invoke
 	if r.Method == http.MethodGet && len(parts) == 5 && parts[4] == "invoke" {
-		serverutils.SendResponse(w, http.StatusMethodNotAllowed, serverutils.ApiError{Err: "Invalid method: only POST is allowed"})
+		serverutils.SendError(w, r, http.StatusMethodNotAllowed, "Invalid method: only POST is allowed")
 		return
 	}
 
@@ -75,7 +76,7 @@ func (h *KnativeHandler) Handle(user *auth.User, w http.ResponseWriter, r *http.
 		url, err := getServiceEndpoints(client, namespace, service)
 		if err != nil {
 			klog.Errorf("Error Fetching Route URL for Knative Service: %v", err)
-			serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: err.Error()})
+			serverutils.SendUpstreamError(w, r, http.StatusInternalServerError, err)
 			return
 		}
 		serverutils.SendResponse(w, http.StatusOK, json.RawMessage(fmt.Sprintf(`{"url": "%s"}`, url)))
@@ -84,10 +85,15 @@ func (h *KnativeHandler) Handle(user *auth.User, w http.ResponseWriter, r *http.
 
 	// POST /namespaces/{namespace}/services/{service}/invoke
 	if r.Method == http.MethodPost && len(parts) == 5 && parts[4] == "invoke" {
-		response, err := invokeService(client, namespace, service, r)
+		var invokeRequest InvokeServiceRequestBody
+		if err := serverutils.DecodeRequest(r, &invokeRequest); err != nil {
+			serverutils.SendRequestError(w, r, err)
+			return
+		}
+		response, err := invokeService(client, namespace, service, invokeRequest)
 		if err != nil {
 			klog.Errorf("Error During Knative Function Invokation: %v", err)
-			serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: err.Error()})
+			serverutils.SendUpstreamError(w, r, http.StatusBadGateway, err)
 			return
 		}
 		serverutils.SendResponse(w, http.StatusOK, response)
@@ -121,20 +127,11 @@ func getServiceEndpoints(client dynamic.Interface, namespace string, service str
 	return url, nil
 }
 
-func invokeService(client dynamic.Interface, namespace string, service string, r *http.Request) (InvokeServiceResponseBody, error) {
-
-	var invokeRequest InvokeServiceRequestBody
-	err := json.NewDecoder(r.Body).Decode(&invokeRequest)
-	if err != nil {
-		return InvokeServiceResponseBody{}, fmt.Errorf("Failed to parse request: %v", err)
-	}
+func invokeService(client dynamic.Interface, namespace string, service string, invokeRequest InvokeServiceRequestBody) (InvokeServiceResponseBody, error) {
 	endpoint, err := getServiceEndpoints(client, namespace, service)
 	if err != nil {
 		return InvokeServiceResponseBody{}, fmt.Errorf("Error fetching route url for service %s: %v", service, err)
 	}
-	if invokeRequest.Body.InvokeFormat == "" {
-		return InvokeServiceResponseBody{}, fmt.Errorf("Missing invoke format")
-	}
 
 	switch invokeRequest.Body.InvokeFormat {
 	case "http":
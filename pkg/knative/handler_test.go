@@ -1,12 +1,12 @@
 package knative
 
 import (
-	"bytes"
-	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/openshift/console/pkg/auth"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -118,15 +118,7 @@ func TestInvokeService(t *testing.T) {
 
 			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), tt.route)
 
-			requestBytes, err := json.Marshal(tt.requestBody)
-			if err != nil {
-				t.Errorf("Unexpected error: %s", err)
-			}
-
-			req := httptest.NewRequest(http.MethodPost, "/bar", bytes.NewBuffer(requestBytes))
-			req.Header.Set("Content-Type", "application/json")
-
-			actual, err := invokeService(dynamicClient, tt.namespace, tt.svcName, req)
+			actual, err := invokeService(dynamicClient, tt.namespace, tt.svcName, tt.requestBody)
 			if err != nil {
 				t.Errorf("Unexpected error: %s", err)
 			}
@@ -136,3 +128,25 @@ func TestInvokeService(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleInvokeValidation(t *testing.T) {
+	handler := NewKnativeHandler(http.DefaultTransport, "https://127.0.0.1:6443", "/api/console/knative")
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "invalid JSON", body: `{`, expectedStatus: http.StatusBadRequest},
+		{name: "unknown field", body: `{"body":{"invoke-format":"http"},"insecure":true}`, expectedStatus: http.StatusBadRequest},
+		{name: "missing invoke format", body: `{"body":{}}`, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "unsupported invoke format", body: `{"body":{"invoke-format":"grpc"}}`, expectedStatus: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/console/knative/namespaces/default/services/hello/invoke", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			handler.Handle(&auth.User{}, rr, req)
+			assert.Equal(t, tt.expectedStatus, rr.Code, rr.Body.String())
+		})
+	}
+}
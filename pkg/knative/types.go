@@ -51,7 +51,7 @@ type InvokeBody struct {
 	InvokeQuery       map[string][]string `json:"invoke-query,omitempty"`
 	InvokeMessage     string              `json:"invoke-message,omitempty"`
 	InvokeEndpoint    string              `json:"invoke-endpoint,omitempty"`
-	InvokeFormat      string              `json:"invoke-format,omitempty"`
+	InvokeFormat      string              `json:"invoke-format,omitempty" validate:"required,oneof=http|ce"`
 	InvokeContentType string              `json:"invoke-contentType,omitempty"`
 }
 
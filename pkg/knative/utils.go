@@ -15,12 +15,13 @@ func EventSourceFilter(w http.ResponseWriter, r *http.Response) {
 
 	if err := json.NewDecoder(r.Body).Decode(&eventSourceList); err != nil {
 		klog.Errorf("Event Source CRD response deserialization failed: %s", err)
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: err.Error()})
+		serverutils.SendError(w, r.Request, http.StatusInternalServerError, err.Error())
+		return
 	}
 
 	if err := json.NewEncoder(w).Encode(eventSourceList); err != nil {
 		klog.Errorf("Event Source CRD response serialization failed: %s", err)
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: err.Error()})
+		serverutils.SendError(w, r.Request, http.StatusInternalServerError, err.Error())
 	}
 }
 
@@ -30,11 +31,12 @@ func ChannelFilter(w http.ResponseWriter, r *http.Response) {
 
 	if err := json.NewDecoder(r.Body).Decode(&channelList); err != nil {
 		klog.Errorf("Channel CRD response deserialization failed: %s", err)
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: err.Error()})
+		serverutils.SendError(w, r.Request, http.StatusInternalServerError, err.Error())
+		return
 	}
 
 	if err := json.NewEncoder(w).Encode(channelList); err != nil {
 		klog.Errorf("Channel CRD response serialization failed: %s", err)
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: err.Error()})
+		serverutils.SendError(w, r.Request, http.StatusInternalServerError, err.Error())
 	}
 }
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const redactedHeaderValue = "REDACTED"
+
+// alwaysRedactedHeaders hold credentials and are never logged, in addition to the headers
+// of the proxy deny list.
+var alwaysRedactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-CSRFToken"}
+
+type accessLogContextKey struct{}
+
+// accessLogUser is shared with the handlers of a request, so that the authentication
+// middleware can report the user of the request once it is known.
+type accessLogUser struct {
+	username string
+}
+
+// AccessLogEntry is a line of the access log.
+type AccessLogEntry struct {
+	Time       time.Time           `json:"time"`
+	RequestID  string              `json:"requestID"`
+	Route      string              `json:"route"`
+	Method     string              `json:"method"`
+	Path       string              `json:"path"`
+	Status     int                 `json:"status"`
+	Bytes      int64               `json:"bytes"`
+	DurationMS float64             `json:"durationMS"`
+	User       string              `json:"user,omitempty"`
+	RemoteAddr string              `json:"remoteAddr"`
+	UserAgent  string              `json:"userAgent,omitempty"`
+	Headers    map[string][]string `json:"headers,omitempty"`
+}
+
+// AccessLogger writes a JSON line for each request to its output.
+type AccessLogger struct {
+	mu            sync.Mutex
+	encoder       *json.Encoder
+	redactHeaders map[string]bool
+	now           func() time.Time
+}
+
+// NewAccessLogger creates an access logger that redacts the values of the given headers,
+// usually the proxy header deny list, and of the headers that hold credentials.
+func NewAccessLogger(out io.Writer, redactHeaders []string) *AccessLogger {
+	l := &AccessLogger{
+		encoder:       json.NewEncoder(out),
+		redactHeaders: map[string]bool{},
+		now:           time.Now,
+	}
+	for _, header := range append(alwaysRedactedHeaders, redactHeaders...) {
+		l.redactHeaders[http.CanonicalHeaderKey(header)] = true
+	}
+	return l
+}
+
+// WithAccessLog logs the requests of the route. Requests without a request ID get one,
+// which is also returned in the response.
+func WithAccessLog(l *AccessLogger, route string, h http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := setRequestID(w, r)
+
+		// the path and headers are read before the handlers, which can rewrite them
+		entry := AccessLogEntry{
+			RequestID:  requestID,
+			Route:      route,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			RemoteAddr: r.RemoteAddr,
+			UserAgent:  r.UserAgent(),
+			Headers:    l.redact(r.Header),
+		}
+		user := &accessLogUser{}
+		start := l.now()
+		sw := newStatusRecorder(w)
+		h.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), accessLogContextKey{}, user)))
+
+		entry.Time = start
+		entry.Status = sw.status
+		entry.Bytes = sw.bytes
+		entry.DurationMS = float64(l.now().Sub(start).Microseconds()) / 1000
+		entry.User = user.username
+		l.log(entry)
+	}
+}
+
+func (l *AccessLogger) redact(header http.Header) map[string][]string {
+	headers := make(map[string][]string, len(header))
+	for key, values := range header {
+		if l.redactHeaders[http.CanonicalHeaderKey(key)] {
+			headers[key] = []string{redactedHeaderValue}
+			continue
+		}
+		headers[key] = values
+	}
+	return headers
+}
+
+func (l *AccessLogger) log(entry AccessLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.encoder.Encode(entry); err != nil {
+		klog.Errorf("failed to write access log: %v", err)
+	}
+}
+
+// setAccessLogUser reports the user of a request to the access log, if it is enabled.
+func setAccessLogUser(ctx context.Context, username string) {
+	if user, ok := ctx.Value(accessLogContextKey{}).(*accessLogUser); ok {
+		user.username = username
+	}
+}
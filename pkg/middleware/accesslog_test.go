@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithAccessLog(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewAccessLogger(&out, []string{"Cookie", "X-CSRFToken"})
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	logger.now = func() time.Time {
+		now = now.Add(1500 * time.Microsecond)
+		return now
+	}
+	handler := WithAccessLog(logger, "/api/kubernetes/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setAccessLogUser(r.Context(), "kube:admin")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/kubernetes/api/v1/namespaces", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("Cookie", "openshift-session-token=secret")
+	req.Header.Set("X-CSRFToken", "secret")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get(RequestIDHeader); got != "abc-123" {
+		t.Errorf("%s = %q, want abc-123", RequestIDHeader, got)
+	}
+	if bytes.Contains(out.Bytes(), []byte("secret")) {
+		t.Fatalf("access log contains credentials: %s", out.String())
+	}
+	var entry AccessLogEntry
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to parse access log %q: %v", out.String(), err)
+	}
+	if entry.RequestID != "abc-123" || entry.Route != "/api/kubernetes/" || entry.Path != "/api/kubernetes/api/v1/namespaces" {
+		t.Errorf("unexpected request of the access log entry: %+v", entry)
+	}
+	if entry.Status != http.StatusCreated || entry.Bytes != 7 || entry.DurationMS != 1.5 || entry.User != "kube:admin" {
+		t.Errorf("unexpected response of the access log entry: %+v", entry)
+	}
+	for _, header := range []string{"Authorization", "Cookie", "X-Csrftoken"} {
+		if values := entry.Headers[header]; len(values) != 1 || values[0] != redactedHeaderValue {
+			t.Errorf("header %s = %v, want it redacted", header, values)
+		}
+	}
+	if values := entry.Headers["Accept"]; len(values) != 1 || values[0] != "application/json" {
+		t.Errorf("header Accept = %v, want application/json", values)
+	}
+}
+
+func TestWithAccessLogGeneratesRequestID(t *testing.T) {
+	var out bytes.Buffer
+	var forwardedID string
+	handler := WithAccessLog(NewAccessLogger(&out, nil), "/api/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwardedID = r.Header.Get(RequestIDHeader)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/", nil))
+
+	requestID := rr.Header().Get(RequestIDHeader)
+	if requestID == "" || requestID != forwardedID {
+		t.Errorf("request ID %q of the response does not match %q of the request", requestID, forwardedID)
+	}
+	var entry AccessLogEntry
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.RequestID != requestID || entry.Status != http.StatusOK || entry.User != "" {
+		t.Errorf("unexpected access log entry: %+v", entry)
+	}
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/openshift/console/pkg/auth"
+	"github.com/openshift/console/pkg/auth/csrfverifier"
+	"github.com/openshift/console/pkg/auth/static"
+	"github.com/openshift/console/pkg/plugins"
+	"github.com/openshift/console/pkg/proxy"
+)
+
+const serviceAccountToken = "console-sa-token"
+
+var frontProxyUser = auth.User{
+	Username:    "jane",
+	Groups:      []string{"developers"},
+	Token:       serviceAccountToken,
+	Impersonate: true,
+}
+
+func TestKubernetesAuthMiddleware(t *testing.T) {
+	var received http.Header
+	handler := KubernetesAuthMiddleware(static.NewStaticAuthenticator(frontProxyUser), csrfverifier.NewCSRFVerifier(nil, false), func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/kubernetes/api/v1/namespaces", nil)
+	req.Header.Set("Impersonate-User", "system:admin")
+	req.Header.Add("Sec-Websocket-Protocol", "base64url.bearer.authorization.k8s.io.abc, Impersonate-User.c3lzdGVtOmFkbWlu")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := received.Get("Authorization"); got != "Bearer "+serviceAccountToken {
+		t.Errorf("expected the service account token, got %q", got)
+	}
+	if got := received.Get("Impersonate-User"); got != "jane" {
+		t.Errorf("expected to impersonate jane, got %q", got)
+	}
+	if got := received.Values("Impersonate-Group"); len(got) != 1 || got[0] != "developers" {
+		t.Errorf("expected to impersonate the groups of the user, got %v", got)
+	}
+	if got := received.Get("Sec-Websocket-Protocol"); got != "base64url.bearer.authorization.k8s.io.abc" {
+		t.Errorf("expected the impersonation subprotocol to be removed, got %q", got)
+	}
+}
+
+func TestAuthMiddlewareWithoutServiceAccountToken(t *testing.T) {
+	var received http.Header
+	handler := AuthMiddleware(static.NewStaticAuthenticator(frontProxyUser), csrfverifier.NewCSRFVerifier(nil, false), func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/console/user-settings", nil)
+	req.Header.Set("Authorization", "Bearer client-token")
+	req.Header.Set("Impersonate-User", "system:admin")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := received.Get("Authorization"); got != "" {
+		t.Errorf("expected no Authorization header, got %q", got)
+	}
+	if got := received.Get("Impersonate-User"); got != "" {
+		t.Errorf("expected no impersonation, got %q", got)
+	}
+}
+
+func TestPluginProxyNeverSeesServiceAccountToken(t *testing.T) {
+	var backendRequests []*http.Request
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backendRequests = append(backendRequests, r)
+	}))
+	defer backend.Close()
+	backendURL, _ := url.Parse(backend.URL)
+
+	pluginHandler := plugins.NewPluginsProxyServiceHandler("/api/proxy/plugin/my-plugin/backend/", backendURL, nil, true)
+	pluginProxy := proxy.NewProxy(pluginHandler.ProxyConfig)
+	csrfVerifier := csrfverifier.NewCSRFVerifier(nil, false)
+	authenticator := static.NewStaticAuthenticator(frontProxyUser)
+	handlers := map[string]http.Handler{
+		"guarded":   AuthMiddleware(authenticator, csrfVerifier, DenyImpersonatedUsers(pluginProxy.ServeHTTP)),
+		"unguarded": AuthMiddleware(authenticator, csrfVerifier, pluginProxy.ServeHTTP),
+	}
+	for name, handler := range handlers {
+		t.Run(name, func(t *testing.T) {
+			backendRequests = nil
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+			if name == "guarded" && rr.Code != http.StatusForbidden {
+				t.Errorf("expected status 403, got %d", rr.Code)
+			}
+			for _, r := range backendRequests {
+				for key, values := range r.Header {
+					for _, value := range values {
+						if strings.Contains(value, serviceAccountToken) {
+							t.Errorf("the plugin backend received the service account token in %s", key)
+						}
+					}
+					if strings.HasPrefix(key, "Impersonate-") {
+						t.Errorf("the plugin backend received the impersonation header %s", key)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	consoleHTTPRequestsTotalMetric          = "console_http_requests_total"
+	consoleHTTPRequestDurationSecondsMetric = "console_http_request_duration_seconds"
+	consoleHTTPRequestsInFlightMetric       = "console_http_requests_in_flight"
+)
+
+// Metrics records the requests served by the console routes.
+type Metrics struct {
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	inFlight        *prometheus.GaugeVec
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: consoleHTTPRequestsTotalMetric,
+			Help: "Number of HTTP requests served, by route, method and status class.",
+		}, []string{"route", "method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    consoleHTTPRequestDurationSecondsMetric,
+			Help:    "Duration of the HTTP requests served, by route, method and status class.",
+			Buckets: []float64{0.005, 0.025, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"route", "method", "code"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: consoleHTTPRequestsInFlightMetric,
+			Help: "Number of HTTP requests being served, by route.",
+		}, []string{"route"}),
+	}
+}
+
+func (m *Metrics) GetCollectors() []prometheus.Collector {
+	return []prometheus.Collector{m.requestsTotal, m.requestDuration, m.inFlight}
+}
+
+// WithRequestMetrics records the requests of the route, which is the path template the
+// handler is registered for, so that the metric labels stay bounded.
+func WithRequestMetrics(m *Metrics, route string, h http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inFlight := m.inFlight.WithLabelValues(route)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		sw := newStatusRecorder(w)
+		h.ServeHTTP(sw, r)
+
+		method, code := MethodLabel(r.Method), StatusClass(sw.status)
+		m.requestsTotal.WithLabelValues(route, method, code).Inc()
+		m.requestDuration.WithLabelValues(route, method, code).Observe(time.Since(start).Seconds())
+	}
+}
+
+// MethodLabel bounds the values of the method label to the standard HTTP methods.
+func MethodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+// StatusClass returns the class of a status code, like 2xx.
+func StatusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return fmt.Sprintf("%dxx", status/100)
+}
+
+// statusRecorder records the status and the size of a response. Websocket upgrades and
+// streamed responses still work through it since it implements http.Hijacker and
+// http.Flusher.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func newStatusRecorder(w http.ResponseWriter) *statusRecorder {
+	if sw, ok := w.(*statusRecorder); ok {
+		return sw
+	}
+	return &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("the response writer does not support hijacking")
+	}
+	// a hijacked connection is handed over to the handler, the websocket proxies report
+	// their upgrades with 101 Switching Protocols
+	w.status = http.StatusSwitchingProtocols
+	w.wroteHeader = true
+	return h.Hijack()
+}
+
+// Unwrap allows http.ResponseController to reach the underlying response writer.
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openshift/console/pkg/metrics"
+)
+
+func TestWithRequestMetrics(t *testing.T) {
+	m := NewMetrics()
+	handler := WithRequestMetrics(m, "/api/console/version", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	for _, method := range []string{http.MethodGet, http.MethodGet, http.MethodPost, "PURGE"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/api/console/version", nil))
+	}
+
+	expected := metrics.RemoveComments(`
+	console_http_requests_in_flight{route="/api/console/version"} 0
+	console_http_requests_total{code="2xx",method="GET",route="/api/console/version"} 2
+	console_http_requests_total{code="2xx",method="OTHER",route="/api/console/version"} 1
+	console_http_requests_total{code="4xx",method="POST",route="/api/console/version"} 1
+	`)
+	if got := metrics.RemoveComments(metrics.FormatMetrics(m.requestsTotal, m.inFlight)); got != expected {
+		t.Errorf("metrics = %s, want %s", got, expected)
+	}
+	if got := metrics.FormatMetrics(m.requestDuration); !strings.Contains(got, `console_http_request_duration_seconds_count{code="2xx",method="GET",route="/api/console/version"} 2`) {
+		t.Errorf("request durations were not recorded: %s", got)
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	tests := map[int]string{
+		http.StatusSwitchingProtocols: "1xx",
+		http.StatusNoContent:          "2xx",
+		http.StatusFound:              "3xx",
+		http.StatusNotFound:           "4xx",
+		http.StatusBadGateway:         "5xx",
+		0:                             "unknown",
+	}
+	for status, want := range tests {
+		if got := StatusClass(status); got != want {
+			t.Errorf("StatusClass(%d) = %q, want %q", status, got, want)
+		}
+	}
+}
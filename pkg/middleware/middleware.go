@@ -19,7 +19,21 @@ type HandlerWithUser func(*auth.User, http.ResponseWriter, *http.Request)
 
 // Middleware generates a middleware wrapper for request handlers.
 // Responds with 401 for requests with missing/invalid/incomplete token with verified email address.
+//
+// The token of users authenticated by a front proxy belongs to the console service account,
+// it is not set on their requests, see KubernetesAuthMiddleware.
 func AuthMiddleware(authenticator auth.Authenticator, csrfVerifier *csrfverifier.CSRFVerifier, h http.HandlerFunc) http.HandlerFunc {
+	return authMiddleware(authenticator, csrfVerifier, false, h)
+}
+
+// KubernetesAuthMiddleware is AuthMiddleware for the handlers that forward the request to the
+// API server. The requests of users authenticated by a front proxy are sent with the token of
+// the console service account, and impersonate the user.
+func KubernetesAuthMiddleware(authenticator auth.Authenticator, csrfVerifier *csrfverifier.CSRFVerifier, h http.HandlerFunc) http.HandlerFunc {
+	return authMiddleware(authenticator, csrfVerifier, true, h)
+}
+
+func authMiddleware(authenticator auth.Authenticator, csrfVerifier *csrfverifier.CSRFVerifier, kubernetes bool, h http.HandlerFunc) http.HandlerFunc {
 	return csrfVerifier.WithCSRFVerification(
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			user, err := authenticator.Authenticate(w, r)
@@ -28,13 +42,71 @@ func AuthMiddleware(authenticator auth.Authenticator, csrfVerifier *csrfverifier
 				w.WriteHeader(http.StatusUnauthorized)
 				return
 			}
-			r.Header.Set("Authorization", fmt.Sprintf("Bearer %s", user.Token))
+			switch {
+			case !user.Impersonate:
+				r.Header.Set("Authorization", fmt.Sprintf("Bearer %s", user.Token))
+			case kubernetes:
+				r.Header.Set("Authorization", fmt.Sprintf("Bearer %s", user.Token))
+				setImpersonationHeaders(r, user)
+			default:
+				r.Header.Del("Authorization")
+				removeImpersonationHeaders(r)
+			}
+			setAccessLogUser(r.Context(), user.Username)
 			ctx := context.WithValue(r.Context(), auth.UserContextKey, user)
 			h.ServeHTTP(w, r.WithContext(ctx))
 		}),
 	)
 }
 
+// DenyImpersonatedUsers responds with 403 to the users authenticated by a front proxy. It
+// guards the proxies of backends that authorize the token of the user and do not support
+// impersonation, these users have no token of their own to send.
+func DenyImpersonatedUsers(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if user := auth.GetUserFromRequestContext(r); user == nil || user.Impersonate {
+			serverutils.SendError(w, r, http.StatusForbidden, "This endpoint is not available for users authenticated by a front proxy")
+			return
+		}
+		h(w, r)
+	}
+}
+
+// setImpersonationHeaders replaces any impersonation requested by the client with
+// the identity of the authenticated user. The bearer token of such users belongs to
+// the console service account, so client supplied impersonation must never reach
+// the API server.
+func setImpersonationHeaders(r *http.Request, user *auth.User) {
+	removeImpersonationHeaders(r)
+	r.Header.Set("Impersonate-User", user.Username)
+	for _, group := range user.Groups {
+		r.Header.Add("Impersonate-Group", group)
+	}
+}
+
+// removeImpersonationHeaders removes the impersonation requested by the client.
+func removeImpersonationHeaders(r *http.Request) {
+	for key := range r.Header {
+		if strings.HasPrefix(key, "Impersonate-") {
+			r.Header.Del(key)
+		}
+	}
+	r.Header.Del("X-Console-Impersonate-Groups")
+
+	if protocols := r.Header.Values("Sec-Websocket-Protocol"); len(protocols) > 0 {
+		r.Header.Del("Sec-Websocket-Protocol")
+		for _, value := range protocols {
+			for _, protocol := range strings.Split(value, ",") {
+				protocol = strings.TrimSpace(protocol)
+				if strings.HasPrefix(protocol, "Impersonate-User.") || strings.HasPrefix(protocol, "Impersonate-Group.") {
+					continue
+				}
+				r.Header.Add("Sec-Websocket-Protocol", protocol)
+			}
+		}
+	}
+}
+
 func WithBearerTokenReview(tokenReviewer *auth.TokenReviewer, h http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+
+	"github.com/openshift/console/pkg/auth"
+	"github.com/openshift/console/pkg/serverutils"
+)
+
+const (
+	consoleRateLimitedRequestsTotalMetric = "console_rate_limited_requests_total"
+	consoleRateLimitClientsMetric         = "console_rate_limit_clients"
+
+	// rateLimitSweepInterval is how often the buckets of idle clients are dropped
+	rateLimitSweepInterval = time.Minute
+)
+
+// RateLimiterConfig configures the budget of each client of the rate limited routes.
+type RateLimiterConfig struct {
+	// RequestsPerSecond is the rate the budget of a client refills at. Rate limiting is
+	// disabled if it is zero.
+	RequestsPerSecond float64
+	// Burst is the largest budget of a client.
+	Burst int
+	// RouteCosts overrides the costs the routes are registered with. A cost of zero exempts
+	// the route from rate limiting.
+	RouteCosts map[string]int
+}
+
+// RateLimiter limits the requests of each authenticated user, or of each IP address for
+// the routes that do not require authentication, with a token bucket. Each rate limited route
+// takes its cost from the bucket of the client, so that expensive routes use up the budget
+// of a client sooner.
+type RateLimiter struct {
+	limit      rate.Limit
+	burst      int
+	routeCosts map[string]int
+	now        func() time.Time
+
+	mu        sync.Mutex
+	clients   map[string]*rateLimitClient
+	lastSweep time.Time
+
+	limitedRequests *prometheus.CounterVec
+	clientsGauge    prometheus.Gauge
+}
+
+type rateLimitClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func NewRateLimiter(config RateLimiterConfig) *RateLimiter {
+	return &RateLimiter{
+		limit:      rate.Limit(config.RequestsPerSecond),
+		burst:      config.Burst,
+		routeCosts: config.RouteCosts,
+		now:        time.Now,
+		clients:    map[string]*rateLimitClient{},
+		limitedRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: consoleRateLimitedRequestsTotalMetric,
+			Help: "Number of requests rejected by the rate limit, by route and client type (user or ip).",
+		}, []string{"route", "client"}),
+		clientsGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: consoleRateLimitClientsMetric,
+			Help: "Number of clients the rate limit tracks.",
+		}),
+	}
+}
+
+func (l *RateLimiter) GetCollectors() []prometheus.Collector {
+	return []prometheus.Collector{l.limitedRequests, l.clientsGauge}
+}
+
+// WithRateLimit limits the requests of a route. Requests are counted against the user that
+// AuthMiddleware authenticated, or against the IP address of unauthenticated requests, so it
+// has to run after AuthMiddleware on authenticated routes.
+func WithRateLimit(l *RateLimiter, route string, cost int, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if l.allow(w, r, auth.GetUserFromRequestContext(r), route, cost) {
+			h(w, r)
+		}
+	}
+}
+
+// WithUserRateLimit limits the requests of a route that gets the authenticated user.
+func WithUserRateLimit(l *RateLimiter, route string, cost int, h HandlerWithUser) HandlerWithUser {
+	return func(user *auth.User, w http.ResponseWriter, r *http.Request) {
+		if l.allow(w, r, user, route, cost) {
+			h(user, w, r)
+		}
+	}
+}
+
+// Allow takes the cost of a route from the budget of the client of a request, for the routes
+// that only charge the requests that do expensive work, like the requests that miss a cache.
+// It writes the 429 response and returns false if the client is over its budget.
+func (l *RateLimiter) Allow(w http.ResponseWriter, r *http.Request, route string, cost int) bool {
+	return l.allow(w, r, auth.GetUserFromRequestContext(r), route, cost)
+}
+
+// allow takes the cost of the route from the bucket of the client, or rejects the request
+// with 429 Too Many Requests and the time the client has to wait in the Retry-After header.
+func (l *RateLimiter) allow(w http.ResponseWriter, r *http.Request, user *auth.User, route string, cost int) bool {
+	if l == nil || l.limit <= 0 {
+		return true
+	}
+	if override, ok := l.routeCosts[route]; ok {
+		cost = override
+	}
+	if cost <= 0 {
+		return true
+	}
+	// a request must not cost more than the budget, it would never be allowed
+	cost = min(cost, l.burst)
+
+	clientType, key := "user", userKey(user)
+	if key == "" {
+		clientType, key = "ip", "ip:"+remoteIP(r)
+	}
+	now := l.now()
+	reservation := l.limiter(key, now).ReserveN(now, cost)
+	delay := reservation.DelayFrom(now)
+	if delay == 0 {
+		return true
+	}
+	reservation.CancelAt(now)
+
+	l.limitedRequests.WithLabelValues(route, clientType).Inc()
+	retryAfter := int(math.Ceil(delay.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	serverutils.SendResponse(w, http.StatusTooManyRequests, serverutils.ApiError{Err: fmt.Sprintf("Too many requests, retry in %d seconds", retryAfter)})
+	return false
+}
+
+// limiter returns the bucket of a client, and drops the buckets of the clients that have not
+// sent requests since their bucket filled up again.
+func (l *RateLimiter) limiter(key string, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= rateLimitSweepInterval {
+		refill := time.Duration(float64(l.burst) / float64(l.limit) * float64(time.Second))
+		for k, client := range l.clients {
+			if now.Sub(client.lastSeen) > refill {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	client, ok := l.clients[key]
+	if !ok {
+		client = &rateLimitClient{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[key] = client
+	}
+	client.lastSeen = now
+	l.clientsGauge.Set(float64(len(l.clients)))
+	return client.limiter
+}
+
+// userKey identifies the user, by the hash of the token if the authenticator does not know
+// the name of the user.
+func userKey(user *auth.User) string {
+	switch {
+	case user == nil:
+		return ""
+	case user.Username != "":
+		return "user:" + user.Username
+	case user.ID != "":
+		return "id:" + user.ID
+	case user.Token != "":
+		sum := sha256.Sum256([]byte(user.Token))
+		return "token:" + hex.EncodeToString(sum[:16])
+	}
+	return ""
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
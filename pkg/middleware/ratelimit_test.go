@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openshift/console/pkg/auth"
+	"github.com/openshift/console/pkg/metrics"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(RateLimiterConfig{
+		RequestsPerSecond: 1,
+		Burst:             10,
+		RouteCosts:        map[string]int{"/api/helm/template": 0},
+	})
+	l.now = func() time.Time { return now }
+	ok := func(user *auth.User, w http.ResponseWriter, r *http.Request) {}
+	discovery := WithUserRateLimit(l, "/api/api-discovery", 4, ok)
+	template := WithUserRateLimit(l, "/api/helm/template", 10, ok)
+	devfile := WithRateLimit(l, "/api/devfile/", 20, func(w http.ResponseWriter, r *http.Request) {})
+
+	request := func(h HandlerWithUser, user *auth.User) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h(user, rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rr
+	}
+	alice := &auth.User{Username: "alice"}
+	for i := 0; i < 2; i++ {
+		if rr := request(discovery, alice); rr.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want %d", i, rr.Code, http.StatusOK)
+		}
+	}
+	rr := request(discovery, alice)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusTooManyRequests)
+	}
+	// 2 tokens are left, the 2 missing ones take 2 seconds to refill
+	if got := rr.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want %q", got, "2")
+	}
+
+	// the rejected request did not take tokens, and the budget of each user is separate
+	now = now.Add(2 * time.Second)
+	if rr := request(discovery, alice); rr.Code != http.StatusOK {
+		t.Errorf("status after the refill = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if rr := request(discovery, &auth.User{Token: "sha256~token"}); rr.Code != http.StatusOK {
+		t.Errorf("status of another user = %d, want %d", rr.Code, http.StatusOK)
+	}
+	// the configured cost exempts the route
+	for i := 0; i < 5; i++ {
+		if rr := request(template, alice); rr.Code != http.StatusOK {
+			t.Errorf("status of an exempt route = %d, want %d", rr.Code, http.StatusOK)
+		}
+	}
+
+	// unauthenticated requests are limited by IP address, costs are capped by the burst
+	for _, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/devfile/", nil)
+		req.RemoteAddr = "10.0.0.1:40000"
+		devfile(rr, req)
+		if rr.Code != want {
+			t.Errorf("status of an unauthenticated request = %d, want %d", rr.Code, want)
+		}
+	}
+
+	expected := metrics.RemoveComments(`
+	console_rate_limit_clients 3
+	console_rate_limited_requests_total{client="ip",route="/api/devfile/"} 1
+	console_rate_limited_requests_total{client="user",route="/api/api-discovery"} 1
+	`)
+	if got := metrics.RemoveComments(metrics.FormatMetrics(l.GetCollectors()...)); got != expected {
+		t.Errorf("metrics = %s, want %s", got, expected)
+	}
+
+	// the buckets of idle clients are dropped once they are full again
+	now = now.Add(time.Minute)
+	request(discovery, alice)
+	if len(l.clients) != 1 {
+		t.Errorf("clients = %d, want 1", len(l.clients))
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	for _, l := range []*RateLimiter{nil, NewRateLimiter(RateLimiterConfig{})} {
+		h := WithRateLimit(l, "/api/api-discovery", 20, func(w http.ResponseWriter, r *http.Request) {})
+		for i := 0; i < 10; i++ {
+			rr := httptest.NewRecorder()
+			h(rr, httptest.NewRequest(http.MethodGet, "/api/api-discovery", nil))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/openshift/console/pkg/serverutils"
+)
+
+// RequestIDHeader is the header that identifies a request in the access log and in the
+// error responses.
+const RequestIDHeader = serverutils.RequestIDHeader
+
+// WithRequestID gives the requests without a request ID one, which is also returned in the
+// response, so that errors reported by users can be found in the logs.
+func WithRequestID(h http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setRequestID(w, r)
+		h.ServeHTTP(w, r)
+	}
+}
+
+// setRequestID keeps the request ID sent by a client or a router in front of the console,
+// unless it is too long to log, and generates one otherwise.
+func setRequestID(w http.ResponseWriter, r *http.Request) string {
+	requestID := r.Header.Get(RequestIDHeader)
+	if requestID == "" || len(requestID) > 128 {
+		requestID = uuid.NewString()
+		r.Header.Set(RequestIDHeader, requestID)
+	}
+	w.Header().Set(RequestIDHeader, requestID)
+	return requestID
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openshift/console/pkg/serverutils"
+)
+
+func TestWithRequestID(t *testing.T) {
+	handler := WithRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverutils.SendError(w, r, http.StatusNotFound, "not found")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/unknown", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if got := rr.Header().Get(RequestIDHeader); got != "abc-123" {
+		t.Errorf("%s = %q, want abc-123", RequestIDHeader, got)
+	}
+	if !strings.Contains(rr.Body.String(), `"requestID":"abc-123"`) {
+		t.Errorf("expected the request ID in the error, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/unknown", nil))
+	requestID := rr.Header().Get(RequestIDHeader)
+	if requestID == "" {
+		t.Fatalf("expected a request ID to be generated")
+	}
+	if !strings.Contains(rr.Body.String(), `"requestID":"`+requestID+`"`) {
+		t.Errorf("expected the generated request ID in the error, got %s", rr.Body.String())
+	}
+}
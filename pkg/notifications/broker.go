@@ -0,0 +1,163 @@
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types pushed to the browser.
+const (
+	// EventConsoleState is sent first on every connection, with the state /api/check-updates returns
+	EventConsoleState = "console-state"
+	// EventConsoleVersionChanged and EventPluginsChanged are sent on connection when the console
+	// commit or the plugins differ from the ones the browser loaded
+	EventConsoleVersionChanged = "console-version-changed"
+	EventPluginsChanged        = "plugins-changed"
+	// EventCustomizationReloaded is sent when the customization was reloaded from the config file
+	EventCustomizationReloaded = "customization-reloaded"
+	// EventCatalogRefreshed is sent when the items of an OLM catalog were updated or removed
+	EventCatalogRefreshed = "catalog-refreshed"
+	// EventBackendAvailabilityChanged is sent when an optional backend, like Thanos, becomes
+	// available or unavailable
+	EventBackendAvailabilityChanged = "backend-availability-changed"
+	// EventSessionExpiring is sent ahead of the expiry of the session of the connection
+	EventSessionExpiring = "session-expiring"
+	EventHeartbeat       = "heartbeat"
+)
+
+const (
+	// historySize is the number of events kept for browsers that reconnect
+	historySize = 64
+	// subscriberBufferSize is the number of events a slow connection can fall behind before it is
+	// closed. The browser reconnects and gets the missed events from the history.
+	subscriberBufferSize = 16
+)
+
+// Event is a notification pushed to the browser. Events are numbered per console process, the
+// ID is "<epoch>-<sequence>" so that the IDs of a previous process are not mistaken for recent ones.
+type Event struct {
+	ID   string
+	Type string
+	Data []byte
+}
+
+// CatalogRefreshed is the data of a catalog-refreshed event.
+type CatalogRefreshed struct {
+	Catalog string `json:"catalog"`
+	Removed bool   `json:"removed,omitempty"`
+}
+
+// BackendAvailabilityChanged is the data of a backend-availability-changed event.
+type BackendAvailabilityChanged struct {
+	Backend   string `json:"backend"`
+	Available bool   `json:"available"`
+}
+
+// Broker distributes the events of the console to the open notification streams.
+type Broker struct {
+	mu          sync.Mutex
+	epoch       string
+	sequence    uint64
+	history     []Event
+	subscribers map[chan Event]struct{}
+	closed      bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		subscribers: map[chan Event]struct{}{},
+	}
+}
+
+// Publish sends an event with the JSON encoded data to all streams.
+func (b *Broker) Publish(eventType string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.sequence++
+	event := Event{ID: fmt.Sprintf("%s-%d", b.epoch, b.sequence), Type: eventType, Data: encoded}
+	b.history = append(b.history, event)
+	if len(b.history) > historySize {
+		b.history = b.history[len(b.history)-historySize:]
+	}
+	for subscriber := range b.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			// the connection is too slow, close it rather than blocking all the others
+			delete(b.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+	return nil
+}
+
+// CatalogRefreshed publishes a catalog-refreshed event.
+func (b *Broker) CatalogRefreshed(catalog string, removed bool) {
+	_ = b.Publish(EventCatalogRefreshed, CatalogRefreshed{Catalog: catalog, Removed: removed})
+}
+
+// subscribe returns a channel with the events published from now on, and the events that were
+// published after lastEventID if it is an event of this process that is still in the history.
+// The channel is closed when the broker is closed or the subscriber falls behind.
+func (b *Broker) subscribe(lastEventID string) (events chan Event, missed []Event, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, nil, false
+	}
+	events = make(chan Event, subscriberBufferSize)
+	b.subscribers[events] = struct{}{}
+	return events, b.missedEvents(lastEventID), true
+}
+
+func (b *Broker) missedEvents(lastEventID string) []Event {
+	epoch, sequence, found := strings.Cut(lastEventID, "-")
+	if !found || epoch != b.epoch {
+		return nil
+	}
+	last, err := strconv.ParseUint(sequence, 10, 64)
+	if err != nil {
+		return nil
+	}
+	var missed []Event
+	for _, event := range b.history {
+		if seq, _ := strconv.ParseUint(strings.TrimPrefix(event.ID, b.epoch+"-"), 10, 64); seq > last {
+			missed = append(missed, event)
+		}
+	}
+	return missed
+}
+
+func (b *Broker) unsubscribe(events chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[events]; ok {
+		delete(b.subscribers, events)
+		close(events)
+	}
+}
+
+// Close ends all streams, so that the server can shut down. Browsers reconnect to another
+// console pod.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for subscriber := range b.subscribers {
+		delete(b.subscribers, subscriber)
+		close(subscriber)
+	}
+}
@@ -0,0 +1,215 @@
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/console/pkg/auth"
+	"github.com/openshift/console/pkg/serverutils"
+)
+
+const (
+	// heartbeatInterval keeps the stream open through proxies and lets the browser detect a
+	// broken connection
+	heartbeatInterval = 30 * time.Second
+	// reconnectDelay is how long the browser waits before it reconnects to a closed stream
+	reconnectDelay = 5 * time.Second
+	// sessionExpiryWarning is how long before the session expires the session-expiring event is sent
+	sessionExpiryWarning = 5 * time.Minute
+	// maxConnections bounds the open streams of a console process
+	maxConnections = 2000
+)
+
+// State is the state of the console a browser compares with the one it loaded.
+type State struct {
+	ConsoleCommit         string                  `json:"consoleCommit"`
+	Plugins               []string                `json:"plugins"`
+	Capabilities          []operatorv1.Capability `json:"capabilities,omitempty"`
+	ContentSecurityPolicy string                  `json:"contentSecurityPolicy,omitempty"`
+}
+
+// ConsoleVersionChanged is the data of a console-version-changed event.
+type ConsoleVersionChanged struct {
+	Previous string `json:"previous"`
+	Current  string `json:"current"`
+}
+
+// PluginsChanged is the data of a plugins-changed event.
+type PluginsChanged struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// SessionExpiring is the data of a session-expiring event.
+type SessionExpiring struct {
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Heartbeat is the data of a heartbeat event.
+type Heartbeat struct {
+	Time time.Time `json:"time"`
+}
+
+// Handler streams the events of a broker as server-sent events.
+type Handler struct {
+	broker            *Broker
+	state             func() State
+	connections       atomic.Int64
+	heartbeatInterval time.Duration
+	now               func() time.Time
+}
+
+// NewHandler creates a handler whose streams start with the state returned by state.
+func NewHandler(broker *Broker, state func() State) *Handler {
+	return &Handler{
+		broker:            broker,
+		state:             state,
+		heartbeatInterval: heartbeatInterval,
+		now:               time.Now,
+	}
+}
+
+// HandleNotifications streams the console events until the browser disconnects, the session
+// of the user expires or the server shuts down. Browsers that reconnect with a Last-Event-ID
+// header get the events they missed. The consoleCommit and plugins (comma separated) query
+// parameters are the state the browser loaded, changes to it are sent when the stream starts.
+func (h *Handler) HandleNotifications(user *auth.User, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		serverutils.SendResponse(w, http.StatusMethodNotAllowed, serverutils.ApiError{Err: "Invalid method: only GET is allowed"})
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: "Streaming is not supported"})
+		return
+	}
+	if h.connections.Add(1) > maxConnections {
+		h.connections.Add(-1)
+		w.Header().Set("Retry-After", "30")
+		serverutils.SendResponse(w, http.StatusServiceUnavailable, serverutils.ApiError{Err: "Too many notification streams"})
+		return
+	}
+	defer h.connections.Add(-1)
+
+	events, missed, ok := h.broker.subscribe(r.Header.Get("Last-Event-ID"))
+	if !ok {
+		serverutils.SendResponse(w, http.StatusServiceUnavailable, serverutils.ApiError{Err: "The server is shutting down"})
+		return
+	}
+	defer h.broker.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	// prevent proxies like nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay.Milliseconds())
+
+	state := h.state()
+	initial := []Event{newEvent(EventConsoleState, state)}
+	query := r.URL.Query()
+	if commit := query.Get("consoleCommit"); commit != "" && commit != state.ConsoleCommit {
+		initial = append(initial, newEvent(EventConsoleVersionChanged, ConsoleVersionChanged{Previous: commit, Current: state.ConsoleCommit}))
+	}
+	if query.Has("plugins") {
+		if changed, ok := pluginsChanged(query.Get("plugins"), state.Plugins); ok {
+			initial = append(initial, newEvent(EventPluginsChanged, changed))
+		}
+	}
+	for _, event := range append(initial, missed...) {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeatInterval)
+	defer heartbeat.Stop()
+	var expiring, expired <-chan time.Time
+	if !user.ExpiresAt.IsZero() {
+		untilExpiry := user.ExpiresAt.Sub(h.now())
+		expiringTimer := time.NewTimer(max(untilExpiry-sessionExpiryWarning, 0))
+		defer expiringTimer.Stop()
+		expiredTimer := time.NewTimer(max(untilExpiry, 0))
+		defer expiredTimer.Stop()
+		expiring, expired = expiringTimer.C, expiredTimer.C
+	}
+
+	for {
+		var event Event
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			event = e
+		case <-heartbeat.C:
+			event = newEvent(EventHeartbeat, Heartbeat{Time: h.now().UTC()})
+		case <-expiring:
+			event = newEvent(EventSessionExpiring, SessionExpiring{ExpiresAt: user.ExpiresAt.UTC()})
+		case <-expired:
+			// the browser reconnects with its refreshed session, or is told to log in again
+			return
+		}
+		if err := writeEvent(w, event); err != nil {
+			klog.V(4).Infof("notification stream closed: %v", err)
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// newEvent creates an event that is not kept in the history, it has no ID so that the browser
+// keeps the ID of the last event of the broker it received.
+func newEvent(eventType string, data interface{}) Event {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		klog.Errorf("failed to encode %s event: %v", eventType, err)
+		encoded = []byte("{}")
+	}
+	return Event{Type: eventType, Data: encoded}
+}
+
+func writeEvent(w io.Writer, event Event) error {
+	var b strings.Builder
+	if event.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", event.ID)
+	}
+	fmt.Fprintf(&b, "event: %s\ndata: %s\n\n", event.Type, event.Data)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// pluginsChanged compares the plugins a browser loaded with the enabled plugins.
+func pluginsChanged(loaded string, enabled []string) (PluginsChanged, bool) {
+	loadedPlugins := map[string]bool{}
+	for _, plugin := range strings.Split(loaded, ",") {
+		if plugin = strings.TrimSpace(plugin); plugin != "" {
+			loadedPlugins[plugin] = true
+		}
+	}
+	changed := PluginsChanged{Added: []string{}, Removed: []string{}}
+	for _, plugin := range enabled {
+		if !loadedPlugins[plugin] {
+			changed.Added = append(changed.Added, plugin)
+		}
+		delete(loadedPlugins, plugin)
+	}
+	for plugin := range loadedPlugins {
+		changed.Removed = append(changed.Removed, plugin)
+	}
+	sort.Strings(changed.Added)
+	sort.Strings(changed.Removed)
+	return changed, len(changed.Added) > 0 || len(changed.Removed) > 0
+}
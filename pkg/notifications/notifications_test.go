@@ -0,0 +1,227 @@
+package notifications
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/console/pkg/auth"
+)
+
+// stream reads the events of a notification stream.
+type stream struct {
+	t       *testing.T
+	resp    *http.Response
+	scanner *bufio.Scanner
+}
+
+func openStream(t *testing.T, server *httptest.Server, path string, lastEventID string) *stream {
+	req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	return &stream{t: t, resp: resp, scanner: bufio.NewScanner(resp.Body)}
+}
+
+// next returns the next event, skipping the retry field.
+func (s *stream) next() Event {
+	var event Event
+	for s.scanner.Scan() {
+		field, value, _ := strings.Cut(s.scanner.Text(), ": ")
+		switch field {
+		case "id":
+			event.ID = value
+		case "event":
+			event.Type = value
+		case "data":
+			event.Data = []byte(value)
+		case "":
+			if event.Type != "" {
+				return event
+			}
+		}
+	}
+	require.NoError(s.t, s.scanner.Err())
+	s.t.Fatal("the stream ended")
+	return event
+}
+
+func (s *stream) ended() bool {
+	_, err := io.Copy(io.Discard, s.resp.Body)
+	return err == nil
+}
+
+func newTestServer(t *testing.T, broker *Broker, user *auth.User, configure func(*Handler)) *httptest.Server {
+	h := NewHandler(broker, func() State {
+		return State{ConsoleCommit: "new-commit", Plugins: []string{"acm", "mce"}}
+	})
+	if configure != nil {
+		configure(h)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.HandleNotifications(user, w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBrokerMissedEvents(t *testing.T) {
+	broker := NewBroker()
+	for i := 0; i < historySize+2; i++ {
+		require.NoError(t, broker.Publish(EventCustomizationReloaded, struct{}{}))
+	}
+	first := broker.epoch + "-1"
+	last := broker.epoch + "-66"
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		expected    int
+	}{
+		{name: "no last event", lastEventID: "", expected: 0},
+		{name: "last event", lastEventID: last, expected: 0},
+		{name: "event in the history", lastEventID: broker.epoch + "-60", expected: 6},
+		{name: "event that left the history", lastEventID: first, expected: historySize},
+		{name: "event of another process", lastEventID: "abc-60", expected: 0},
+		{name: "invalid event ID", lastEventID: broker.epoch + "-x", expected: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, missed, ok := broker.subscribe(tt.lastEventID)
+			require.True(t, ok)
+			defer broker.unsubscribe(events)
+			assert.Len(t, missed, tt.expected)
+			if len(missed) > 0 {
+				assert.Equal(t, last, missed[len(missed)-1].ID)
+			}
+		})
+	}
+}
+
+func TestBrokerSlowSubscriber(t *testing.T) {
+	broker := NewBroker()
+	events, _, ok := broker.subscribe("")
+	require.True(t, ok)
+	for i := 0; i < subscriberBufferSize+1; i++ {
+		require.NoError(t, broker.Publish(EventCustomizationReloaded, struct{}{}))
+	}
+	received := 0
+	for range events {
+		received++
+	}
+	assert.Equal(t, subscriberBufferSize, received, "the channel is closed once it is full")
+	broker.unsubscribe(events)
+
+	broker.Close()
+	_, _, ok = broker.subscribe("")
+	assert.False(t, ok)
+	assert.NoError(t, broker.Publish(EventCustomizationReloaded, struct{}{}))
+}
+
+func TestHandleNotifications(t *testing.T) {
+	broker := NewBroker()
+	server := newTestServer(t, broker, &auth.User{Username: "kube:admin"}, nil)
+
+	s := openStream(t, server, "/?consoleCommit=old-commit&plugins=acm,logging", "")
+	event := s.next()
+	assert.Equal(t, EventConsoleState, event.Type)
+	assert.Empty(t, event.ID)
+	assert.JSONEq(t, `{"consoleCommit":"new-commit","plugins":["acm","mce"]}`, string(event.Data))
+
+	event = s.next()
+	assert.Equal(t, EventConsoleVersionChanged, event.Type)
+	assert.JSONEq(t, `{"previous":"old-commit","current":"new-commit"}`, string(event.Data))
+
+	event = s.next()
+	assert.Equal(t, EventPluginsChanged, event.Type)
+	assert.JSONEq(t, `{"added":["mce"],"removed":["logging"]}`, string(event.Data))
+
+	broker.CatalogRefreshed("redhat-operators", false)
+	event = s.next()
+	assert.Equal(t, EventCatalogRefreshed, event.Type)
+	assert.Equal(t, broker.epoch+"-1", event.ID)
+	assert.JSONEq(t, `{"catalog":"redhat-operators"}`, string(event.Data))
+
+	// the browser reconnects after it missed an event
+	broker.CatalogRefreshed("community-operators", true)
+	reconnected := openStream(t, server, "/?consoleCommit=new-commit&plugins=mce,acm", broker.epoch+"-1")
+	assert.Equal(t, EventConsoleState, reconnected.next().Type)
+	event = reconnected.next()
+	assert.Equal(t, broker.epoch+"-2", event.ID)
+	assert.JSONEq(t, `{"catalog":"community-operators","removed":true}`, string(event.Data))
+
+	broker.Close()
+	assert.True(t, s.ended())
+	assert.True(t, reconnected.ended())
+}
+
+func TestHandleNotificationsHeartbeat(t *testing.T) {
+	server := newTestServer(t, NewBroker(), &auth.User{}, func(h *Handler) {
+		h.heartbeatInterval = 10 * time.Millisecond
+	})
+
+	s := openStream(t, server, "/", "")
+	assert.Equal(t, EventConsoleState, s.next().Type)
+	event := s.next()
+	assert.Equal(t, EventHeartbeat, event.Type)
+	var heartbeat Heartbeat
+	require.NoError(t, json.Unmarshal(event.Data, &heartbeat))
+	assert.False(t, heartbeat.Time.IsZero())
+}
+
+func TestHandleNotificationsSessionExpiry(t *testing.T) {
+	expiresAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	server := newTestServer(t, NewBroker(), &auth.User{ExpiresAt: expiresAt}, func(h *Handler) {
+		h.now = func() time.Time { return expiresAt.Add(-sessionExpiryWarning - 10*time.Millisecond) }
+	})
+	s := openStream(t, server, "/", "")
+	assert.Equal(t, EventConsoleState, s.next().Type)
+	event := s.next()
+	assert.Equal(t, EventSessionExpiring, event.Type)
+	assert.JSONEq(t, `{"expiresAt":"2026-10-19T12:00:00Z"}`, string(event.Data))
+
+	// the stream of an expired session is closed
+	server = newTestServer(t, NewBroker(), &auth.User{ExpiresAt: time.Now().Add(-time.Second)}, nil)
+	s = openStream(t, server, "/", "")
+	assert.True(t, s.ended())
+}
+
+func TestHandleNotificationsErrors(t *testing.T) {
+	broker := NewBroker()
+	server := newTestServer(t, broker, &auth.User{}, nil)
+
+	resp, err := http.Post(server.URL, "text/plain", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	assert.Equal(t, "GET", resp.Header.Get("Allow"))
+
+	broker.Close()
+	resp, err = http.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestPluginsChanged(t *testing.T) {
+	_, changed := pluginsChanged(" acm, mce ,", []string{"mce", "acm"})
+	assert.False(t, changed)
+
+	plugins, changed := pluginsChanged("", []string{"acm"})
+	assert.True(t, changed)
+	assert.Equal(t, PluginsChanged{Added: []string{"acm"}, Removed: []string{}}, plugins)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/openshift/console/pkg/proxy"
+	"github.com/openshift/console/pkg/tracing"
 	"k8s.io/klog/v2"
 )
 
@@ -23,8 +24,11 @@ type CatalogdClient struct {
 }
 
 func NewCatalogdClient(httpClient *http.Client, proxyConfig *proxy.Config) *CatalogdClient {
+	// the service client is shared, only the requests to catalogd are traced with this copy
+	tracedClient := *httpClient
+	tracedClient.Transport = tracing.Transport(httpClient.Transport)
 	return &CatalogdClient{
-		httpClient:  httpClient,
+		httpClient:  &tracedClient,
 		proxyConfig: proxyConfig,
 	}
 }
@@ -48,7 +52,7 @@ func (c *CatalogdClient) FetchMetas(catalog string, baseURL string, r *http.Requ
 		requestURL = fmt.Sprintf("%s?%s", requestURL, queryParams)
 	}
 
-	req, err := http.NewRequest(r.Method, requestURL, nil)
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, requestURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -47,13 +47,13 @@ func (o *OLMHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (o *OLMHandler) catalogdMetasHandler(w http.ResponseWriter, r *http.Request) {
 	catalogName := r.PathValue("catalogName")
 	if catalogName == "" {
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: "catalog name is required"})
+		serverutils.SendError(w, r, http.StatusBadRequest, "catalog name is required")
 		return
 	}
 
 	resp, err := o.catalogService.GetMetas(catalogName, r)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusInternalServerError, err.Error())
+		serverutils.SendUpstreamError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 	defer resp.Body.Close()
@@ -61,7 +61,8 @@ func (o *OLMHandler) catalogdMetasHandler(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(resp.StatusCode)
 	_, err = io.Copy(w, resp.Body)
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusInternalServerError, err.Error())
+		// the status was sent already
+		klog.Errorf("failed to copy the metas of catalog %s: %v", catalogName, err)
 		return
 	}
 }
@@ -71,7 +72,7 @@ func (o *OLMHandler) catalogItemsHandler(w http.ResponseWriter, r *http.Request)
 	modified, err := serverutils.ModifiedSince(r, lastModified)
 	if err != nil {
 		klog.Error(err)
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: fmt.Sprintf("Invalid If-Modified-Since header: %v", err)})
+		serverutils.SendError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid If-Modified-Since header: %v", err))
 		return
 	}
 	if !modified {
@@ -83,7 +84,7 @@ func (o *OLMHandler) catalogItemsHandler(w http.ResponseWriter, r *http.Request)
 	klog.V(4).Info("catalog items modified, returning 200")
 	items, err := o.catalogService.GetCatalogItems()
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusInternalServerError, err.Error())
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -92,7 +93,8 @@ func (o *OLMHandler) catalogItemsHandler(w http.ResponseWriter, r *http.Request)
 	}
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(items); err != nil {
-		serverutils.SendResponse(w, http.StatusInternalServerError, err.Error())
+		// the status was sent already
+		klog.Errorf("failed to encode the catalog items: %v", err)
 		return
 	}
 }
@@ -104,19 +106,19 @@ func (o *OLMHandler) catalogIconHandler(w http.ResponseWriter, r *http.Request)
 	packageName := r.PathValue("packageName")
 
 	if catalogName == "" || packageName == "" {
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: "catalog name and package name are required"})
+		serverutils.SendError(w, r, http.StatusBadRequest, "catalog name and package name are required")
 		return
 	}
 
 	icon, err := o.catalogService.GetPackageIcon(catalogName, packageName)
 	if err != nil {
 		klog.Errorf("Failed to get icon for %s/%s: %v", catalogName, packageName, err)
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: fmt.Sprintf("Failed to get icon: %v", err)})
+		serverutils.SendError(w, r, http.StatusInternalServerError, fmt.Sprintf("Failed to get icon: %v", err))
 		return
 	}
 
 	if icon == nil {
-		serverutils.SendResponse(w, http.StatusNotFound, serverutils.ApiError{Err: "icon not found"})
+		serverutils.SendError(w, r, http.StatusNotFound, "icon not found")
 		return
 	}
 
@@ -156,30 +158,30 @@ func (o *OLMHandler) catalogIconHandler(w http.ResponseWriter, r *http.Request)
 func (o *OLMHandler) checkPackageManifestHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		w.Header().Set("Allow", "GET")
-		serverutils.SendResponse(w, http.StatusMethodNotAllowed, serverutils.ApiError{Err: "Method unsupported, the only supported methods is GET"})
+		serverutils.SendError(w, r, http.StatusMethodNotAllowed, "Method unsupported, the only supported methods is GET")
 		return
 	}
 
 	operatorName, operatorNamespace, err := o.getOperatorMeta(r)
 	if err != nil {
 		klog.Error(err)
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: fmt.Sprint(err)})
+		serverutils.SendError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	client, _, err := o.getClientWithScheme(r)
 	if err != nil {
 		klog.Error(err)
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: fmt.Sprint(err)})
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	packageManifest := olmv1.PackageManifest{}
 	err = client.Get(context.TODO(), types.NamespacedName{Name: operatorName, Namespace: operatorNamespace}, &packageManifest)
 	if err != nil {
-		errMsg := fmt.Sprintf("Failed to get operator: %v", err)
-		klog.Error(errMsg)
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: errMsg})
+		err = fmt.Errorf("Failed to get operator: %w", err)
+		klog.Error(err)
+		serverutils.SendUpstreamError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -188,7 +190,7 @@ func (o *OLMHandler) checkPackageManifestHandler(w http.ResponseWriter, r *http.
 	if err != nil {
 		errMsg := fmt.Sprintf("Failed to marshal %q operator response: %v", operatorName, err)
 		klog.Error(errMsg)
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: errMsg})
+		serverutils.SendError(w, r, http.StatusInternalServerError, errMsg)
 		return
 	}
 	serverutils.SendResponse(w, http.StatusOK, nil)
@@ -197,21 +199,21 @@ func (o *OLMHandler) checkPackageManifestHandler(w http.ResponseWriter, r *http.
 func (o *OLMHandler) operandsListHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		w.Header().Set("Allow", "GET")
-		serverutils.SendResponse(w, http.StatusMethodNotAllowed, serverutils.ApiError{Err: "Method unsupported, the only supported methods is GET"})
+		serverutils.SendError(w, r, http.StatusMethodNotAllowed, "Method unsupported, the only supported methods is GET")
 		return
 	}
 
 	operatorName, operatorNamespace, err := o.getOperatorMeta(r)
 	if err != nil {
 		klog.Error(err)
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: fmt.Sprint(err)})
+		serverutils.SendError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	client, scheme, err := o.getClientWithScheme(r)
 	if err != nil {
 		klog.Error(err)
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: fmt.Sprint(err)})
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -223,9 +225,9 @@ func (o *OLMHandler) operandsListHandler(w http.ResponseWriter, r *http.Request)
 	operatorListOperands := action.NewOperatorListOperands(cfg)
 	operandsList, err := operatorListOperands.Run(context.TODO(), operatorName)
 	if err != nil {
-		errMsg := fmt.Sprintf("Failed to list operands: %v", err)
-		klog.Error(errMsg)
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: errMsg})
+		err = fmt.Errorf("Failed to list operands: %w", err)
+		klog.Error(err)
+		serverutils.SendUpstreamError(w, r, http.StatusBadGateway, err)
 		return
 	}
 	// Deduplicate operands by UID to prevent duplicate CRs
@@ -238,7 +240,7 @@ func (o *OLMHandler) operandsListHandler(w http.ResponseWriter, r *http.Request)
 	if err != nil {
 		errMsg := fmt.Sprintf("Failed to marshal the list operands response: %v", err)
 		klog.Error(errMsg)
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: errMsg})
+		serverutils.SendError(w, r, http.StatusInternalServerError, errMsg)
 		return
 	}
 	w.Write(resp)
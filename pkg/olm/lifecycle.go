@@ -41,19 +41,19 @@ func (o *OLMHandler) lifecycleHandler(w http.ResponseWriter, r *http.Request) {
 
 	if !isValidK8sName(catalogNamespace) {
 		klog.Infof("[lifecycle] Invalid catalogNamespace: %q", catalogNamespace)
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: "The catalog namespace is not valid."})
+		serverutils.SendError(w, r, http.StatusBadRequest, "The catalog namespace is not valid.")
 		return
 	}
 
 	if !isValidK8sName(catalogName) {
 		klog.Infof("[lifecycle] Invalid catalogName: %q", catalogName)
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: "The catalog name is not valid."})
+		serverutils.SendError(w, r, http.StatusBadRequest, "The catalog name is not valid.")
 		return
 	}
 
 	if packageName == "" {
 		klog.Infof("[lifecycle] Missing packageName")
-		serverutils.SendResponse(w, http.StatusBadRequest, serverutils.ApiError{Err: "The package name is required."})
+		serverutils.SendError(w, r, http.StatusBadRequest, "The package name is required.")
 		return
 	}
 
@@ -63,7 +63,7 @@ func (o *OLMHandler) lifecycleHandler(w http.ResponseWriter, r *http.Request) {
 	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		klog.Errorf("[lifecycle] Failed to create gRPC client for %s: %v", target, err)
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: "Could not connect to the catalog source."})
+		serverutils.SendError(w, r, http.StatusInternalServerError, "Could not connect to the catalog source.")
 		return
 	}
 	defer conn.Close()
@@ -77,7 +77,7 @@ func (o *OLMHandler) lifecycleHandler(w http.ResponseWriter, r *http.Request) {
 		PackageName: packageName,
 	})
 	if err != nil {
-		handleGRPCError(w, catalogName, packageName, err)
+		handleGRPCError(w, r, catalogName, packageName, err)
 		return
 	}
 
@@ -85,10 +85,10 @@ func (o *OLMHandler) lifecycleHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		if err == io.EOF {
 			klog.Infof("[lifecycle] No lifecycle data for %s/%s", catalogName, packageName)
-			serverutils.SendResponse(w, http.StatusNotFound, serverutils.ApiError{Err: "No lifecycle data is available for this package."})
+			serverutils.SendError(w, r, http.StatusNotFound, "No lifecycle data is available for this package.")
 			return
 		}
-		handleGRPCError(w, catalogName, packageName, err)
+		handleGRPCError(w, r, catalogName, packageName, err)
 		return
 	}
 
@@ -96,17 +96,17 @@ func (o *OLMHandler) lifecycleHandler(w http.ResponseWriter, r *http.Request) {
 	// contains additional documents, treat it as ambiguous data.
 	if _, err := stream.Recv(); err == nil {
 		klog.Errorf("[lifecycle] Multiple lifecycle documents returned for %s/%s — expected exactly one", catalogName, packageName)
-		serverutils.SendResponse(w, http.StatusConflict, serverutils.ApiError{Err: "Multiple lifecycle records exist for this package."})
+		serverutils.SendError(w, r, http.StatusConflict, "Multiple lifecycle records exist for this package.")
 		return
 	} else if err != io.EOF {
-		handleGRPCError(w, catalogName, packageName, err)
+		handleGRPCError(w, r, catalogName, packageName, err)
 		return
 	}
 
 	jsonBytes, err := protojson.Marshal(result)
 	if err != nil {
 		klog.Errorf("[lifecycle] Failed to marshal lifecycle response: %v", err)
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: "An error occurred while processing the response."})
+		serverutils.SendError(w, r, http.StatusInternalServerError, "An error occurred while processing the response.")
 		return
 	}
 
@@ -116,23 +116,23 @@ func (o *OLMHandler) lifecycleHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonBytes)
 }
 
-func handleGRPCError(w http.ResponseWriter, catalogName, packageName string, err error) {
+func handleGRPCError(w http.ResponseWriter, r *http.Request, catalogName, packageName string, err error) {
 	st, ok := grpcstatus.FromError(err)
 	if !ok {
 		klog.Errorf("[lifecycle] gRPC call failed for %s/%s: %v", catalogName, packageName, err)
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: "The catalog source is unavailable. Try again later."})
+		serverutils.SendAPIError(w, r, http.StatusBadGateway, serverutils.ApiError{Err: "The catalog source is unavailable. Try again later.", Retryable: true})
 		return
 	}
 
 	switch st.Code() {
 	case codes.Unimplemented:
 		klog.Infof("[lifecycle] ExperimentalListPackageCustomSchemas not supported by catalog %s", catalogName)
-		serverutils.SendResponse(w, http.StatusServiceUnavailable, serverutils.ApiError{Err: "The lifecycle metadata is unavailable for this catalog."})
+		serverutils.SendError(w, r, http.StatusServiceUnavailable, "The lifecycle metadata is unavailable for this catalog.")
 	case codes.Unavailable:
 		klog.Infof("[lifecycle] CatalogSource %s gRPC unavailable: %v", catalogName, st.Message())
-		serverutils.SendResponse(w, http.StatusServiceUnavailable, serverutils.ApiError{Err: "The catalog source is unavailable. Try again later."})
+		serverutils.SendError(w, r, http.StatusServiceUnavailable, "The catalog source is unavailable. Try again later.")
 	default:
 		klog.Errorf("[lifecycle] gRPC error for %s/%s: code=%s msg=%s", catalogName, packageName, st.Code(), st.Message())
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: "An error occurred while contacting the catalog source."})
+		serverutils.SendError(w, r, http.StatusBadGateway, "An error occurred while contacting the catalog source.")
 	}
 }
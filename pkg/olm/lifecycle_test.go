@@ -124,7 +124,7 @@ func TestHandleGRPCError(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			rr := httptest.NewRecorder()
-			handleGRPCError(rr, "test-catalog", "test-package", tc.err)
+			handleGRPCError(rr, httptest.NewRequest(http.MethodGet, "/", nil), "test-catalog", "test-package", tc.err)
 			assert.Equal(t, tc.expectedStatus, rr.Code)
 		})
 	}
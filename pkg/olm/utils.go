@@ -36,6 +36,7 @@ func (o *OLMHandler) getK8sClientConfig(r *http.Request) (*rest.Config, error) {
 		Host:        o.apiServerURL,
 		Transport:   o.client.Transport,
 		BearerToken: user.Token,
+		Impersonate: user.ImpersonationConfig(),
 	}
 
 	return config, nil
@@ -13,9 +13,11 @@ import (
 
 	"k8s.io/klog/v2"
 
+	"github.com/openshift/console/pkg/auth/tokenexchange"
 	"github.com/openshift/console/pkg/proxy"
 	"github.com/openshift/console/pkg/serverconfig"
 	"github.com/openshift/console/pkg/serverutils"
+	"github.com/openshift/console/pkg/static"
 	oscrypto "github.com/openshift/library-go/pkg/crypto"
 )
 
@@ -23,6 +25,7 @@ type PluginsHandler struct {
 	Client             *http.Client
 	PluginsEndpointMap map[string]string
 	PublicDir          string
+	publicFiles        *static.FileServer
 }
 
 type PluginsProxyServiceHandler struct {
@@ -46,11 +49,18 @@ func NewPluginsProxyServiceHandler(consoleEndpoint string, serviceEndpoint *url.
 	}
 }
 
+// Upstream names the proxied service in the proxy metrics after its console API path, like
+// plugin/<plugin-name>/<alias>.
+func (p *PluginsProxyServiceHandler) Upstream() string {
+	return strings.Trim(strings.TrimPrefix(p.ConsoleEndpoint, "/api/proxy/"), "/")
+}
+
 func NewPluginsHandler(client *http.Client, pluginsEndpointMap map[string]string, publicDir string) *PluginsHandler {
 	return &PluginsHandler{
 		Client:             client,
 		PluginsEndpointMap: pluginsEndpointMap,
 		PublicDir:          publicDir,
+		publicFiles:        static.NewFileServer(publicDir),
 	}
 }
 
@@ -87,7 +97,22 @@ func GetPluginProxyServiceHandlers(proxyConfig *serverconfig.Proxy, defaultTLSCo
 			klog.Error(err.Error())
 			return nil, err
 		}
-		proxyServiceHandlers = append(proxyServiceHandlers, NewPluginsProxyServiceHandler(service.ConsoleAPIPath, serviceEndpoint, pluginProxyTLS, service.Authorize))
+		proxyServiceHandler := NewPluginsProxyServiceHandler(service.ConsoleAPIPath, serviceEndpoint, pluginProxyTLS, service.Authorize)
+		if service.TokenExchange != nil {
+			if !service.Authorize {
+				err := fmt.Errorf("token exchange for %q service requires authorize to be enabled", service.Endpoint)
+				klog.Error(err.Error())
+				return nil, err
+			}
+			exchanger, err := tokenexchange.NewExchangerFromConfig(service.TokenExchange)
+			if err != nil {
+				err := fmt.Errorf("failed to set up token exchange for %q service: %w", service.Endpoint, err)
+				klog.Error(err.Error())
+				return nil, err
+			}
+			proxyServiceHandler.ProxyConfig.TokenExchanger = exchanger
+		}
+		proxyServiceHandlers = append(proxyServiceHandlers, proxyServiceHandler)
 	}
 	return proxyServiceHandlers, nil
 }
@@ -112,7 +137,7 @@ func (p *PluginsHandler) HandleI18nResources(w http.ResponseWriter, r *http.Requ
 	}
 
 	if !strings.HasPrefix(namespace, "plugin__") {
-		http.ServeFile(w, r, path.Join(p.PublicDir, "locales", lang, fmt.Sprintf("%s.json", namespace)))
+		p.publicFiles.ServeFile(w, r, path.Join("locales", lang, fmt.Sprintf("%s.json", namespace)))
 		return
 	}
 	// In case of dynamic-plugin we need to trim the "plugin__" prefix, since we are using the ConsolePlugin CR's name
@@ -151,7 +176,7 @@ func (p *PluginsHandler) HandlePluginAssets(w http.ResponseWriter, r *http.Reque
 }
 
 func (p *PluginsHandler) proxyPluginRequest(requestURL *url.URL, pluginName string, w http.ResponseWriter, originalRequest *http.Request) {
-	newRequest, err := http.NewRequest("GET", requestURL.String(), nil)
+	newRequest, err := http.NewRequestWithContext(originalRequest.Context(), "GET", requestURL.String(), nil)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to create GET request for %q plugin: %v", pluginName, err)
 		klog.Error(errMsg)
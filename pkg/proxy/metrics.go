@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const consoleProxyUpstreamDurationSecondsMetric = "console_proxy_upstream_request_duration_seconds"
+
+// Metrics records the latency of the backends the console proxies requests to, apart from
+// the time the console spends on the requests.
+type Metrics struct {
+	upstreamDuration *prometheus.HistogramVec
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    consoleProxyUpstreamDurationSecondsMetric,
+			Help:    "Duration of the requests proxied to upstream backends until the response headers are received, by upstream, method and status class.",
+			Buckets: []float64{0.005, 0.025, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"upstream", "method", "code"}),
+	}
+}
+
+func (m *Metrics) GetCollectors() []prometheus.Collector {
+	return []prometheus.Collector{m.upstreamDuration}
+}
+
+func (m *Metrics) observe(upstream, method string, status int, start time.Time) {
+	code := "error"
+	if status != 0 {
+		code = fmt.Sprintf("%dxx", status/100)
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	default:
+		method = "OTHER"
+	}
+	m.upstreamDuration.WithLabelValues(upstream, method, code).Observe(time.Since(start).Seconds())
+}
+
+// instrumentedRoundTripper records the latency of the requests of a proxy.
+type instrumentedRoundTripper struct {
+	metrics   *Metrics
+	upstream  string
+	transport http.RoundTripper
+}
+
+func (rt *instrumentedRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := rt.transport.RoundTrip(r)
+	status := 0
+	if err == nil {
+		status = resp.StatusCode
+	}
+	rt.metrics.observe(rt.upstream, r.Method, status, start)
+	return resp, err
+}
+
+// WithMetrics records the latency of the requests proxied to the upstream, a name like
+// "kubernetes" that identifies the backend in the metrics.
+func (p *Proxy) WithMetrics(m *Metrics, upstream string) *Proxy {
+	p.metrics = m
+	p.upstream = upstream
+	p.reverseProxy.Transport = &instrumentedRoundTripper{
+		metrics:   m,
+		upstream:  upstream,
+		transport: p.reverseProxy.Transport,
+	}
+	return p
+}
@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/base64"
 	"fmt"
@@ -14,6 +15,8 @@ import (
 
 	"github.com/gorilla/websocket"
 	"k8s.io/klog/v2"
+
+	"github.com/openshift/console/pkg/tracing"
 )
 
 var websocketPingInterval = 30 * time.Second
@@ -25,11 +28,21 @@ type Config struct {
 	TLSClientConfig         *tls.Config
 	Origin                  string
 	UseProxyFromEnvironment bool
+	// TokenExchanger, when set, replaces the user's bearer token with one
+	// issued for the proxied backend.
+	TokenExchanger TokenExchanger
+}
+
+// TokenExchanger swaps a user token for a token accepted by a proxied backend.
+type TokenExchanger interface {
+	ExchangeToken(ctx context.Context, subjectToken string) (string, error)
 }
 
 type Proxy struct {
 	reverseProxy *httputil.ReverseProxy
 	config       *Config
+	metrics      *Metrics
+	upstream     string
 }
 
 // These headers aren't things that proxies should pass along. Some are forbidden by http2.
@@ -67,7 +80,7 @@ func NewProxy(cfg *Config) *Proxy {
 
 	reverseProxy := httputil.NewSingleHostReverseProxy(cfg.Endpoint)
 	reverseProxy.FlushInterval = time.Millisecond * 100
-	reverseProxy.Transport = transport
+	reverseProxy.Transport = tracing.Transport(transport)
 	reverseProxy.ModifyResponse = FilterHeaders
 
 	proxy := &Proxy{
@@ -78,6 +91,21 @@ func NewProxy(cfg *Config) *Proxy {
 	return proxy
 }
 
+// exchangeToken replaces the bearer token of the request with one issued for the backend.
+func (p *Proxy) exchangeToken(r *http.Request) error {
+	subjectToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subjectToken == "" {
+		return fmt.Errorf("request has no bearer token")
+	}
+
+	token, err := p.config.TokenExchanger.ExchangeToken(r.Context(), subjectToken)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
 func SingleJoiningSlash(a, b string) string {
 	aslash := strings.HasSuffix(a, "/")
 	bslash := strings.HasPrefix(b, "/")
@@ -123,6 +151,14 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		r.Header.Del(h)
 	}
 
+	if p.config.TokenExchanger != nil {
+		if err := p.exchangeToken(r); err != nil {
+			klog.Errorf("PROXY: failed to exchange token for %s: %v", p.config.Endpoint.Host, err)
+			http.Error(w, "Failed to obtain a token for the backend service", http.StatusBadGateway)
+			return
+		}
+	}
+
 	// Handle X-Console-Impersonate-Groups header for multi-group impersonation
 	// The fetch() API doesn't support multiple headers with the same name,
 	// so the frontend sends a comma-separated list that we split here
@@ -231,6 +267,7 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// NOTE (ericchiang): K8s might not enforce this but websockets requests are
 	// required to supply an origin.
 	proxiedHeader.Add("Origin", "http://localhost")
+	tracing.InjectHeaders(r.Context(), proxiedHeader)
 
 	dialer := &websocket.Dialer{
 		TLSClientConfig: p.config.TLSClientConfig,
@@ -239,7 +276,15 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		dialer.Proxy = http.ProxyFromEnvironment
 	}
 
+	dialStart := time.Now()
 	backend, resp, err := dialer.Dial(r.URL.String(), proxiedHeader)
+	if p.metrics != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		p.metrics.observe(p.upstream, r.Method, status, dialStart)
+	}
 	if err != nil {
 		errMsg := fmt.Sprintf("Failed to dial backend: '%v'", err)
 		statusCode := http.StatusBadGateway
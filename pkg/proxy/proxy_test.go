@@ -1,6 +1,8 @@
 package proxy
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -9,6 +11,8 @@ import (
 	"testing"
 
 	"github.com/gorilla/websocket"
+
+	"github.com/openshift/console/pkg/metrics"
 )
 
 func TestProxyWebsocket(t *testing.T) {
@@ -63,6 +67,71 @@ func TestProxyHTTP(t *testing.T) {
 
 }
 
+type fakeTokenExchanger map[string]string
+
+func (f fakeTokenExchanger) ExchangeToken(_ context.Context, subjectToken string) (string, error) {
+	if token, ok := f[subjectToken]; ok {
+		return token, nil
+	}
+	return "", fmt.Errorf("token exchange rejected the token")
+}
+
+func TestProxyTokenExchange(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer backend.Close()
+
+	targetURL, err := url.Parse(backend.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewProxy(&Config{
+		Endpoint:       targetURL,
+		TokenExchanger: fakeTokenExchanger{"user-token": "backend-token"},
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantBody      string
+	}{
+		{
+			name:          "token is exchanged",
+			authorization: "Bearer user-token",
+			wantStatus:    http.StatusOK,
+			wantBody:      "Bearer backend-token",
+		},
+		{
+			name:          "exchange fails",
+			authorization: "Bearer unknown-token",
+			wantStatus:    http.StatusBadGateway,
+		},
+		{
+			name:       "no token",
+			wantStatus: http.StatusBadGateway,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/static", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rr := httptest.NewRecorder()
+			p.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && rr.Body.String() != tt.wantBody {
+				t.Errorf("backend received Authorization %q, want %q", rr.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
 func TestProxyDecodeSubprotocol(t *testing.T) {
 	tests := []struct {
 		encoded string
@@ -175,3 +244,33 @@ func toWSScheme(url_ string) string {
 	parsed.Scheme = "ws"
 	return parsed.String()
 }
+
+func TestProxyMetrics(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(staticServer))
+	defer backend.Close()
+	targetURL, err := url.Parse(backend.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMetrics()
+	p := NewProxy(&Config{Endpoint: targetURL}).WithMetrics(m, "plugin/acm/search")
+
+	rr := httptest.NewRecorder()
+	p.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/static", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+
+	unreachable := NewProxy(&Config{Endpoint: &url.URL{Scheme: "http", Host: "127.0.0.1:1"}}).WithMetrics(m, "kubernetes")
+	unreachable.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api", nil))
+
+	got := metrics.FormatMetrics(m.GetCollectors()...)
+	for _, want := range []string{
+		`console_proxy_upstream_request_duration_seconds_count{code="2xx",method="GET",upstream="plugin/acm/search"} 1`,
+		`console_proxy_upstream_request_duration_seconds_count{code="error",method="POST",upstream="kubernetes"} 1`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("metrics do not contain %s:\n%s", want, got)
+		}
+	}
+}
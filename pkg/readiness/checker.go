@@ -0,0 +1,266 @@
+package readiness
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/console/pkg/proxy"
+)
+
+const (
+	consoleBackendAvailableMetric = "console_backend_available"
+
+	// DefaultInterval is how often the backends are probed
+	DefaultInterval = 30 * time.Second
+	// probeTimeout bounds a probe, a backend that does not answer in time is unavailable
+	probeTimeout = 5 * time.Second
+	// failureThreshold is the number of probes in a row that have to fail before a backend is
+	// unavailable, so that a single slow response does not hide features
+	failureThreshold = 2
+)
+
+// Status is the state of a backend as the last probes found it.
+type Status struct {
+	Name      string `json:"name"`
+	Endpoint  string `json:"endpoint"`
+	Available bool   `json:"available"`
+	// Probed is false until the backend was probed, backends are assumed to be available until then
+	Probed bool `json:"probed"`
+	// LastProbe and LastTransition are nil until the backend was probed and changed its state
+	LastProbe           *time.Time `json:"lastProbe,omitempty"`
+	LastTransition      *time.Time `json:"lastTransition,omitempty"`
+	LatencySeconds      float64    `json:"latencySeconds,omitempty"`
+	StatusCode          int        `json:"statusCode,omitempty"`
+	Error               string     `json:"error,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures,omitempty"`
+}
+
+type backend struct {
+	config *proxy.Config
+	path   string
+	client *http.Client
+	status Status
+}
+
+// Checker periodically probes the optional backends the bridge proxies requests to, like
+// Thanos, Alertmanager, catalogd, GitOps and the plugin services. A backend is available as
+// long as it answers, even if it rejects the unauthenticated probe, and unavailable if it
+// cannot be reached or responds with a server error.
+type Checker struct {
+	interval time.Duration
+	now      func() time.Time
+
+	mu       sync.RWMutex
+	backends map[string]*backend
+	onChange func(name string, available bool)
+
+	availableGauge *prometheus.GaugeVec
+}
+
+func NewChecker(interval time.Duration) *Checker {
+	return &Checker{
+		interval: interval,
+		now:      time.Now,
+		backends: map[string]*backend{},
+		availableGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: consoleBackendAvailableMetric,
+			Help: "Whether the optional backend was reachable by the last probes (1) or not (0).",
+		}, []string{"backend"}),
+	}
+}
+
+func (c *Checker) GetCollectors() []prometheus.Collector {
+	return []prometheus.Collector{c.availableGauge}
+}
+
+// Add registers a backend to probe. The path is requested from the endpoint of the backend,
+// the endpoint itself is requested if it is empty.
+func (c *Checker) Add(name string, config *proxy.Config, path string) {
+	if c == nil || config == nil || config.Endpoint == nil {
+		return
+	}
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout: probeTimeout,
+		}).DialContext,
+		TLSClientConfig:     config.TLSClientConfig,
+		TLSHandshakeTimeout: probeTimeout,
+		// the backends are probed rarely, keeping the connections open is not worth it
+		DisableKeepAlives: true,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.backends[name] = &backend{
+		config: config,
+		path:   path,
+		client: &http.Client{Transport: transport, Timeout: probeTimeout},
+		status: Status{Name: name, Endpoint: config.Endpoint.String(), Available: true},
+	}
+	c.availableGauge.WithLabelValues(name).Set(1)
+}
+
+// OnChange sets a function that is called when a backend becomes available or unavailable.
+func (c *Checker) OnChange(f func(name string, available bool)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onChange = f
+}
+
+// Run probes the backends until the context is cancelled.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		c.ProbeAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProbeAll probes all backends in parallel.
+func (c *Checker) ProbeAll(ctx context.Context) {
+	c.mu.RLock()
+	names := make([]string, 0, len(c.backends))
+	for name := range c.backends {
+		names = append(names, name)
+	}
+	c.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.probe(ctx, name)
+		}()
+	}
+	wg.Wait()
+}
+
+func (c *Checker) probe(ctx context.Context, name string) {
+	c.mu.RLock()
+	b := c.backends[name]
+	c.mu.RUnlock()
+
+	start := c.now()
+	statusCode, err := b.get(ctx)
+	latency := c.now().Sub(start)
+	if ctx.Err() != nil {
+		// the probe was cancelled, it says nothing about the backend
+		return
+	}
+
+	c.mu.Lock()
+	status := &b.status
+	wasAvailable := status.Available
+	status.Probed = true
+	status.LastProbe = &start
+	status.LatencySeconds = latency.Seconds()
+	status.StatusCode = statusCode
+	if err != nil {
+		status.Error = err.Error()
+		status.ConsecutiveFailures++
+		if status.ConsecutiveFailures >= failureThreshold {
+			status.Available = false
+		}
+	} else {
+		status.Error = ""
+		status.ConsecutiveFailures = 0
+		status.Available = true
+	}
+	changed := status.Available != wasAvailable
+	if changed {
+		status.LastTransition = &start
+	}
+	onChange := c.onChange
+	available := status.Available
+	c.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if available {
+		klog.Infof("backend %s is available again", name)
+		c.availableGauge.WithLabelValues(name).Set(1)
+	} else {
+		klog.Warningf("backend %s is unavailable: %v", name, err)
+		c.availableGauge.WithLabelValues(name).Set(0)
+	}
+	if onChange != nil {
+		onChange(name, available)
+	}
+}
+
+// get requests the probe path of the backend. Server errors and gateway errors of the
+// routers in front of the backend make it unavailable.
+func (b *backend) get(ctx context.Context) (int, error) {
+	endpoint := *b.config.Endpoint
+	if b.path != "" {
+		endpoint.Path = proxy.SingleJoiningSlash(endpoint.Path, b.path)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Available returns whether a backend is available. Backends that are not registered are.
+func (c *Checker) Available(name string) bool {
+	if c == nil {
+		return true
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	b, ok := c.backends[name]
+	return !ok || b.status.Available
+}
+
+// BackendsAvailable returns whether each registered backend is available.
+func (c *Checker) BackendsAvailable() map[string]bool {
+	available := map[string]bool{}
+	if c == nil {
+		return available
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for name, b := range c.backends {
+		available[name] = b.status.Available
+	}
+	return available
+}
+
+// Statuses returns the status of each registered backend, sorted by name.
+func (c *Checker) Statuses() []Status {
+	c.mu.RLock()
+	statuses := make([]Status, 0, len(c.backends))
+	for _, b := range c.backends {
+		statuses = append(statuses, b.status)
+	}
+	c.mu.RUnlock()
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
@@ -0,0 +1,54 @@
+package readiness
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/openshift/console/pkg/serverutils"
+)
+
+// Details is the body of /health/details.
+type Details struct {
+	// Status is "ok" if all backends are available, "degraded" otherwise
+	Status   string   `json:"status"`
+	Backends []Status `json:"backends"`
+}
+
+// WithBackend fails the requests of a route with 503 Service Unavailable right away while the
+// backend the route proxies to is unavailable, instead of waiting for the proxy to time out.
+// The error has the BackendUnavailable code and the name of the backend in its details.
+func WithBackend(c *Checker, name string, h http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if c.Available(name) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		// the backend is available again at the earliest after the next probe
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(c.interval.Seconds()))))
+		serverutils.SendAPIError(w, r, http.StatusServiceUnavailable, serverutils.ApiError{
+			Err:       fmt.Sprintf("The %s backend is unavailable", name),
+			Code:      serverutils.ErrorCodeBackendUnavailable,
+			Details:   &serverutils.ErrorDetails{Name: name, Kind: "Backend"},
+			Retryable: true,
+		})
+	}
+}
+
+// HandleDetails serves the status of each backend.
+func (c *Checker) HandleDetails(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		serverutils.SendError(w, r, http.StatusMethodNotAllowed, "Method unsupported, the only supported method is GET")
+		return
+	}
+	details := Details{Status: "ok", Backends: c.Statuses()}
+	for _, backend := range details.Backends {
+		if !backend.Available {
+			details.Status = "degraded"
+		}
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	serverutils.SendResponse(w, http.StatusOK, details)
+}
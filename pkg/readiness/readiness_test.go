@@ -0,0 +1,158 @@
+package readiness
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/console/pkg/metrics"
+	"github.com/openshift/console/pkg/proxy"
+	"github.com/openshift/console/pkg/serverutils"
+)
+
+func newBackend(t *testing.T, status *int) *proxy.Config {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(*status)
+	}))
+	t.Cleanup(server.Close)
+	endpoint, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	return &proxy.Config{Endpoint: endpoint}
+}
+
+func TestCheckerProbe(t *testing.T) {
+	thanosStatus := http.StatusUnauthorized
+	gitopsStatus := http.StatusServiceUnavailable
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedEndpoint, err := url.Parse(closed.URL)
+	require.NoError(t, err)
+	closed.Close()
+
+	checker := NewChecker(DefaultInterval)
+	checker.Add("thanos", newBackend(t, &thanosStatus), "")
+	checker.Add("gitops", newBackend(t, &gitopsStatus), "")
+	checker.Add("catalogd", &proxy.Config{Endpoint: closedEndpoint}, "")
+	checker.Add("unconfigured", nil, "")
+
+	var mu sync.Mutex
+	var changes []string
+	checker.OnChange(func(name string, available bool) {
+		// the backends are probed in parallel
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, name)
+	})
+
+	assert.Equal(t, map[string]bool{"thanos": true, "gitops": true, "catalogd": true}, checker.BackendsAvailable(), "backends are available until they are probed")
+
+	checker.ProbeAll(context.Background())
+	assert.Equal(t, map[string]bool{"thanos": true, "gitops": true, "catalogd": true}, checker.BackendsAvailable(), "a single failed probe does not make a backend unavailable")
+	assert.Empty(t, changes)
+
+	checker.ProbeAll(context.Background())
+	assert.Equal(t, map[string]bool{"thanos": true, "gitops": false, "catalogd": false}, checker.BackendsAvailable())
+	assert.ElementsMatch(t, []string{"gitops", "catalogd"}, changes)
+	assert.True(t, checker.Available("unconfigured"))
+
+	statuses := checker.Statuses()
+	require.Len(t, statuses, 3)
+	assert.Equal(t, "catalogd", statuses[0].Name)
+	assert.NotEmpty(t, statuses[0].Error)
+	assert.Equal(t, "gitops", statuses[1].Name)
+	assert.Equal(t, http.StatusServiceUnavailable, statuses[1].StatusCode)
+	assert.Equal(t, 2, statuses[1].ConsecutiveFailures)
+	assert.True(t, statuses[2].Probed)
+	assert.Equal(t, http.StatusUnauthorized, statuses[2].StatusCode)
+
+	gitopsStatus = http.StatusOK
+	checker.ProbeAll(context.Background())
+	assert.True(t, checker.Available("gitops"), "a backend is available again after a single probe")
+
+	expected := `
+console_backend_available{backend="catalogd"} 0
+console_backend_available{backend="gitops"} 1
+console_backend_available{backend="thanos"} 1
+`
+	assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(metrics.RemoveComments(metrics.FormatMetrics(checker.availableGauge))))
+}
+
+func TestWithBackend(t *testing.T) {
+	status := http.StatusBadGateway
+	checker := NewChecker(DefaultInterval)
+	checker.Add("thanos", newBackend(t, &status), "")
+	handler := WithBackend(checker, "thanos", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodGet, "/api/prometheus/api/v1/query", nil))
+	assert.Equal(t, http.StatusTeapot, rr.Code)
+
+	checker.ProbeAll(context.Background())
+	checker.ProbeAll(context.Background())
+	rr = httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodGet, "/api/prometheus/api/v1/query", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+	var body serverutils.ApiError
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, serverutils.ApiError{
+		Err:       "The thanos backend is unavailable",
+		Code:      serverutils.ErrorCodeBackendUnavailable,
+		Details:   &serverutils.ErrorDetails{Name: "thanos", Kind: "Backend"},
+		Retryable: true,
+	}, body)
+
+	rr = httptest.NewRecorder()
+	WithBackend(nil, "thanos", http.NotFoundHandler())(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code, "routes are not checked without a checker")
+}
+
+func TestHandleDetails(t *testing.T) {
+	status := http.StatusOK
+	checker := NewChecker(DefaultInterval)
+	checker.Add("alertmanager", newBackend(t, &status), "")
+
+	get := func() Details {
+		rr := httptest.NewRecorder()
+		checker.HandleDetails(rr, httptest.NewRequest(http.MethodGet, "/health/details", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var details Details
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &details))
+		return details
+	}
+
+	rr := httptest.NewRecorder()
+	checker.HandleDetails(rr, httptest.NewRequest(http.MethodGet, "/health/details", nil))
+	assert.NotContains(t, rr.Body.String(), "lastProbe", "backends that were not probed yet have no probe time")
+	assert.NotContains(t, rr.Body.String(), "lastTransition")
+
+	checker.ProbeAll(context.Background())
+	details := get()
+	assert.Equal(t, "ok", details.Status)
+	require.Len(t, details.Backends, 1)
+	assert.True(t, details.Backends[0].Available)
+	assert.NotNil(t, details.Backends[0].LastProbe)
+	assert.Nil(t, details.Backends[0].LastTransition, "the backend was available from the start")
+
+	status = http.StatusInternalServerError
+	checker.ProbeAll(context.Background())
+	checker.ProbeAll(context.Background())
+	details = get()
+	assert.Equal(t, "degraded", details.Status)
+	assert.Equal(t, "unexpected status 500", details.Backends[0].Error)
+	assert.NotNil(t, details.Backends[0].LastTransition)
+
+	rr = httptest.NewRecorder()
+	checker.HandleDetails(rr, httptest.NewRequest(http.MethodPost, "/health/details", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
@@ -0,0 +1,151 @@
+package reload
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	oscrypto "github.com/openshift/library-go/pkg/crypto"
+)
+
+// ServingCertificate serves a certificate and key pair from disk and picks up
+// new files when the certificate is rotated, e.g. by the service CA operator.
+type ServingCertificate struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+func NewServingCertificate(certFile, keyFile string) (*ServingCertificate, error) {
+	c := &ServingCertificate{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCertificate is meant to be used as tls.Config.GetCertificate.
+func (c *ServingCertificate) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// NotAfter returns the expiration of the certificate currently in use.
+func (c *ServingCertificate) NotAfter() time.Time {
+	return c.cert.Load().Leaf.NotAfter
+}
+
+// Run reloads the certificate whenever one of the files changes until the context is cancelled.
+func (c *ServingCertificate) Run(ctx context.Context) error {
+	return WatchFiles(ctx, func() {
+		err := c.load()
+		RecordReload("serving-certificate", err)
+		if err != nil {
+			klog.Errorf("failed to reload serving certificate, keeping the previous one: %v", err)
+			return
+		}
+		klog.Infof("reloaded serving certificate %s, valid until %s", c.certFile, c.NotAfter())
+	}, c.certFile, c.keyFile)
+}
+
+func (c *ServingCertificate) load() error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load serving certificate: %w", err)
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return fmt.Errorf("failed to parse serving certificate: %w", err)
+		}
+	}
+	c.cert.Store(&cert)
+	recordCertificateExpiration("serving-certificate", cert.Leaf.NotAfter)
+	return nil
+}
+
+// CABundle holds the certificate pool read from a CA file and picks up new CAs
+// when the bundle is rotated.
+type CABundle struct {
+	// name identifies the bundle in logs and metrics
+	name   string
+	caFile string
+	pool   atomic.Pointer[x509.CertPool]
+}
+
+func NewCABundle(name, caFile string) (*CABundle, error) {
+	b := &CABundle{name: name, caFile: caFile}
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// CertPool returns the CAs currently in the bundle.
+func (b *CABundle) CertPool() *x509.CertPool {
+	return b.pool.Load()
+}
+
+// ClientTLSConfig returns a client TLS config that verifies servers against the
+// CAs currently in the bundle, so that transports created from it keep working
+// after the bundle is rotated.
+func (b *CABundle) ClientTLSConfig() *tls.Config {
+	return oscrypto.SecureTLSConfig(&tls.Config{
+		// tls.Config.RootCAs would pin the pool at the time the transport
+		// is created. Verification is done against the current pool in
+		// VerifyConnection instead, which runs on every handshake.
+		InsecureSkipVerify: true,
+		VerifyConnection:   b.verifyConnection,
+	})
+}
+
+func (b *CABundle) verifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("server did not present a certificate")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         b.CertPool(),
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// Run reloads the bundle whenever the CA file changes until the context is cancelled.
+func (b *CABundle) Run(ctx context.Context) error {
+	return WatchFiles(ctx, func() {
+		err := b.load()
+		RecordReload(b.name, err)
+		if err != nil {
+			klog.Errorf("failed to reload %s, keeping the previous CAs: %v", b.name, err)
+			return
+		}
+		klog.Infof("reloaded %s from %s", b.name, b.caFile)
+	}, b.caFile)
+}
+
+func (b *CABundle) load() error {
+	caPEM, err := os.ReadFile(b.caFile)
+	if err != nil {
+		return fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return fmt.Errorf("file %s contained no CA data", b.caFile)
+	}
+	b.pool.Store(pool)
+	return nil
+}
@@ -0,0 +1,179 @@
+package reload
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert creates a certificate for localhost, signed by the parent or self-signed.
+func newTestCert(t *testing.T, commonName string, notAfter time.Time, isCA bool, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		DNSNames:              []string{"localhost"},
+	}
+
+	signerCert, signerKey := template, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func writeFile(t *testing.T, path string, content []byte) {
+	// write and rename, like the kubelet does for mounted secrets
+	tmp := path + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, content, 0600))
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+func fastReload(t *testing.T) {
+	oldDebounce := debounceInterval
+	debounceInterval = 10 * time.Millisecond
+	t.Cleanup(func() { debounceInterval = oldDebounce })
+}
+
+func TestServingCertificateReload(t *testing.T) {
+	fastReload(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+
+	oldCert := newTestCert(t, "old", time.Now().Add(time.Hour), false, nil)
+	writeFile(t, certFile, oldCert.certPEM)
+	writeFile(t, keyFile, oldCert.keyPEM)
+
+	servingCert, err := NewServingCertificate(certFile, keyFile)
+	require.NoError(t, err)
+	require.NoError(t, servingCert.Run(ctx))
+	require.True(t, oldCert.cert.NotAfter.Equal(servingCert.NotAfter()))
+
+	newCert := newTestCert(t, "new", time.Now().Add(48*time.Hour), false, nil)
+	writeFile(t, keyFile, newCert.keyPEM)
+	writeFile(t, certFile, newCert.certPEM)
+
+	require.Eventually(t, func() bool {
+		cert, err := servingCert.GetCertificate(nil)
+		return err == nil && cert.Leaf.Subject.CommonName == "new"
+	}, 5*time.Second, 10*time.Millisecond, "the rotated certificate must be served")
+
+	// a key that does not match the certificate must not replace the working pair
+	writeFile(t, keyFile, oldCert.keyPEM)
+	time.Sleep(100 * time.Millisecond)
+	cert, err := servingCert.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, "new", cert.Leaf.Subject.CommonName)
+}
+
+func TestCABundleClientTLSConfig(t *testing.T) {
+	fastReload(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	oldCA := newTestCert(t, "old-ca", time.Now().Add(time.Hour), true, nil)
+	newCA := newTestCert(t, "new-ca", time.Now().Add(time.Hour), true, nil)
+	serverCert := newTestCert(t, "localhost", time.Now().Add(time.Hour), false, newCA)
+
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	tlsCert, err := tls.X509KeyPair(serverCert.certPEM, serverCert.keyPEM)
+	require.NoError(t, err)
+	backend.TLS = &tls.Config{Certificates: []tls.Certificate{tlsCert}}
+	backend.StartTLS()
+	defer backend.Close()
+	_, port, err := net.SplitHostPort(backend.Listener.Addr().String())
+	require.NoError(t, err)
+	backendURL := "https://localhost:" + port
+
+	caFile := filepath.Join(t.TempDir(), "service-ca.crt")
+	writeFile(t, caFile, oldCA.certPEM)
+
+	caBundle, err := NewCABundle("service-ca", caFile)
+	require.NoError(t, err)
+	require.NoError(t, caBundle.Run(ctx))
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   caBundle.ClientTLSConfig(),
+		DisableKeepAlives: true,
+	}}
+
+	_, err = client.Get(backendURL)
+	require.Error(t, err, "the server is not signed by the CAs in the bundle")
+
+	writeFile(t, caFile, newCA.certPEM)
+	require.Eventually(t, func() bool {
+		resp, err := client.Get(backendURL)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return true
+	}, 5*time.Second, 10*time.Millisecond, "the existing transport must trust the rotated CA")
+
+	// an invalid bundle must keep the previous CAs
+	writeFile(t, caFile, []byte("not a certificate"))
+	time.Sleep(100 * time.Millisecond)
+	resp, err := client.Get(backendURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestNewCABundle(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	writeFile(t, caFile, []byte("not a certificate"))
+
+	_, err := NewCABundle("service-ca", caFile)
+	require.Error(t, err)
+
+	_, err = NewCABundle("service-ca", filepath.Join(t.TempDir(), "missing.crt"))
+	require.Error(t, err)
+}
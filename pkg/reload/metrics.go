@@ -0,0 +1,54 @@
+package reload
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	consoleReloadsTotalMetric          = "console_reloads_total"
+	consoleCertificateExpirationMetric = "console_certificate_expiration_timestamp_seconds"
+
+	consoleReloadTargetLabel = "target"
+	consoleReloadResultLabel = "result"
+
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+var (
+	consoleReloadsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: consoleReloadsTotalMetric,
+			Help: "Number of times files that changed on disk were reloaded, by target and result.",
+		},
+		[]string{consoleReloadTargetLabel, consoleReloadResultLabel},
+	)
+	consoleCertificateExpiration = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: consoleCertificateExpirationMetric,
+			Help: "Expiration of the certificates currently in use, by target.",
+		},
+		[]string{consoleReloadTargetLabel},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(consoleReloadsTotal)
+	prometheus.MustRegister(consoleCertificateExpiration)
+}
+
+// RecordReload counts a reload of the given target. Failed reloads keep the previous
+// content in use, so they need to be visible to whoever rotated the files.
+func RecordReload(target string, err error) {
+	result := ResultSuccess
+	if err != nil {
+		result = ResultFailure
+	}
+	consoleReloadsTotal.WithLabelValues(target, result).Inc()
+}
+
+func recordCertificateExpiration(target string, notAfter time.Time) {
+	consoleCertificateExpiration.WithLabelValues(target).Set(float64(notAfter.Unix()))
+}
@@ -0,0 +1,101 @@
+package reload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+)
+
+var (
+	// debounceInterval groups the burst of events caused by a single update,
+	// e.g. a certificate and its key being written one after the other.
+	debounceInterval = 500 * time.Millisecond
+
+	// pollInterval is a safety net for file systems that do not emit events.
+	pollInterval = time.Minute
+)
+
+// WatchFiles calls onChange whenever the content of one of the files changes
+// until the context is cancelled. The parent directories are watched instead of
+// the files themselves, since Kubernetes updates mounted secrets and config maps
+// by atomically swapping a symlink, which would otherwise drop the watch.
+func WatchFiles(ctx context.Context, onChange func(), files ...string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+
+	dirs := sets.New[string]()
+	for _, file := range files {
+		dirs.Insert(filepath.Dir(file))
+	}
+	for _, dir := range sets.List(dirs) {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+
+	lastChecksum := checksum(files)
+	go func() {
+		defer watcher.Close()
+
+		poll := time.NewTicker(pollInterval)
+		defer poll.Stop()
+
+		debounce := time.NewTimer(debounceInterval)
+		debounce.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				klog.V(4).Infof("file watcher event: %v", event)
+				debounce.Reset(debounceInterval)
+				continue
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				klog.Errorf("error watching %v: %v", files, err)
+				continue
+			case <-debounce.C:
+			case <-poll.C:
+			}
+
+			if sum := checksum(files); !bytes.Equal(sum, lastChecksum) {
+				lastChecksum = sum
+				onChange()
+			}
+		}
+	}()
+
+	return nil
+}
+
+// checksum hashes the content of all files, unreadable files are hashed as empty.
+func checksum(files []string) []byte {
+	h := sha256.New()
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			klog.V(4).Infof("failed to read watched file %s: %v", file, err)
+		}
+		h.Write(content)
+		// separate the files so that moving data between them is a change
+		h.Write([]byte{0})
+	}
+	return h.Sum(nil)
+}
@@ -1,19 +1,37 @@
 package server
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/openshift/console/pkg/proxy"
 	"github.com/openshift/console/pkg/serverutils"
 
+	apidiscoveryv2 "k8s.io/api/apidiscovery/v2"
+	apidiscoveryv2beta1 "k8s.io/api/apidiscovery/v2beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
 	"k8s.io/klog/v2"
 )
 
+// aggregatedDiscoveryAccept asks for aggregated discovery, which returns the resources of all
+// groups in one response, and for the group list from servers that do not support it.
+const aggregatedDiscoveryAccept = discovery.AcceptV2 + "," + discovery.AcceptV2Beta1 + "," + discovery.AcceptV1
+
+var (
+	aggregatedDiscoveryV2GVK      = apidiscoveryv2.SchemeGroupVersion.WithKind("APIGroupDiscoveryList")
+	aggregatedDiscoveryV2Beta1GVK = apidiscoveryv2beta1.SchemeGroupVersion.WithKind("APIGroupDiscoveryList")
+)
+
 type apiGroupList struct {
 	Groups []apiGroup `json:"groups"`
 }
@@ -30,85 +48,325 @@ type apiGroupVersion struct {
 }
 
 type apiDiscoveryResponse struct {
-	Groups        json.RawMessage   `json:"groups"`
-	ResourceLists []json.RawMessage `json:"resourceLists"`
+	Groups        json.RawMessage         `json:"groups"`
+	ResourceLists []json.RawMessage       `json:"resourceLists"`
+	ResourceIndex []apiResourceIndexEntry `json:"resourceIndex"`
+}
+
+// apiResourceIndexEntry is a resource of every served version of every group, without the
+// subresources, so that the frontend can define its models without going through the
+// resource lists.
+type apiResourceIndexEntry struct {
+	Group      string   `json:"group,omitempty"`
+	Version    string   `json:"version"`
+	Kind       string   `json:"kind"`
+	Plural     string   `json:"plural"`
+	Singular   string   `json:"singular,omitempty"`
+	Namespaced bool     `json:"namespaced,omitempty"`
+	Preferred  bool     `json:"preferred,omitempty"`
+	Verbs      []string `json:"verbs,omitempty"`
+	ShortNames []string `json:"shortNames,omitempty"`
 }
 
-func apiDiscoveryHandler(k8sProxy *proxy.Proxy) http.HandlerFunc {
+// apiDiscoveryHandler serves the groups and resources of the API the user can discover. The
+// response is cached for the user, see APIDiscoveryCache, and has an ETag so that the
+// frontend can revalidate it. Requests that miss the cache are only served if allowMiss,
+// the rate limit of discovery, allows them.
+func apiDiscoveryHandler(k8sProxy *proxy.Proxy, discoveryCache *APIDiscoveryCache, allowMiss func(http.ResponseWriter, *http.Request) bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			serverutils.SendResponse(w, http.StatusMethodNotAllowed, serverutils.ApiError{Err: "only GET is allowed"})
 			return
 		}
 
-		groupsBody, err := k8sViaProxy(k8sProxy, "/apis", r)
-		if err != nil {
-			serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("failed to fetch /apis: %v", err)})
-			return
+		key := apiDiscoveryCacheKey(r)
+		cached, ok := discoveryCache.get(key)
+		if !ok {
+			if allowMiss != nil && !allowMiss(w, r) {
+				return
+			}
+			generation := discoveryCache.currentGeneration()
+			var err error
+			cached, err = discoverAPIs(k8sProxy, r)
+			if err != nil {
+				serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: err.Error()})
+				return
+			}
+			discoveryCache.add(key, generation, cached)
 		}
 
-		var groups apiGroupList
-		if err := json.Unmarshal(groupsBody, &groups); err != nil {
-			serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: "failed to parse /apis response"})
+		// the response depends on the user, it must not be shared or used without revalidation
+		w.Header().Set("Cache-Control", "private, no-cache")
+		w.Header().Set("ETag", cached.etag)
+		if etagMatches(r.Header.Get("If-None-Match"), cached.etag) {
+			w.WriteHeader(http.StatusNotModified)
 			return
 		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(cached.body); err != nil {
+			klog.Errorf("api-discovery: failed to write response: %v", err)
+		}
+	}
+}
 
-		var paths []string
-		for _, group := range groups.Groups {
-			for _, version := range group.Versions {
-				paths = append(paths, "/apis/"+version.GroupVersion)
+// discoverAPIs gets the groups and resources with aggregated discovery, or from the resource
+// list of each group version if the API server does not support it.
+func discoverAPIs(k8sProxy *proxy.Proxy, r *http.Request) (*apiDiscovery, error) {
+	groupsBody, contentType, err := k8sViaProxyAccept(k8sProxy, "/apis", aggregatedDiscoveryAccept, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch /apis: %v", err)
+	}
+
+	var resp *apiDiscoveryResponse
+	var complete bool
+	if aggregated, _ := isAggregatedDiscovery(contentType); aggregated {
+		resp, complete, err = aggregatedAPIDiscovery(k8sProxy, r, groupsBody, contentType)
+	} else {
+		resp, complete, err = unaggregatedAPIDiscovery(k8sProxy, r, groupsBody)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize API discovery: %v", err)
+	}
+	sum := sha256.Sum256(body)
+	return &apiDiscovery{
+		body:     body,
+		etag:     `"` + hex.EncodeToString(sum[:16]) + `"`,
+		complete: complete,
+	}, nil
+}
+
+// aggregatedAPIDiscovery converts the aggregated discovery of /apis and /api to the group list
+// and resource lists of unaggregated discovery, which the frontend reads. Group versions whose
+// aggregated API server is unavailable are marked stale and left out.
+func aggregatedAPIDiscovery(k8sProxy *proxy.Proxy, r *http.Request, groupsBody []byte, contentType string) (*apiDiscoveryResponse, bool, error) {
+	groups, resourcesByGV, failed, err := splitAggregatedDiscovery(groupsBody, contentType)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse /apis response")
+	}
+	complete := len(failed) == 0
+
+	var resourceLists []*metav1.APIResourceList
+	preferred := map[string]string{}
+	for _, group := range groups.Groups {
+		preferred[group.Name] = group.PreferredVersion.Version
+		for _, version := range group.Versions {
+			if list, ok := resourcesByGV[schema.GroupVersion{Group: group.Name, Version: version.Version}]; ok {
+				resourceLists = append(resourceLists, list)
 			}
 		}
-		paths = append(paths, "/api/v1")
-
-		results := make([]json.RawMessage, len(paths))
-		var wg sync.WaitGroup
-		sem := make(chan struct{}, 100)
-		wg.Add(len(paths))
-
-		for i, p := range paths {
-			sem <- struct{}{}
-			go func(idx int, path string) {
-				defer wg.Done()
-				defer func() { <-sem }()
-				body, err := k8sViaProxy(k8sProxy, path, r)
-				if err != nil {
-					klog.V(4).Infof("api-discovery: skipping %s", path)
-					return
+	}
+
+	// the core group is discovered from /api, which is not part of the group list
+	core, contentType, err := k8sViaProxyAccept(k8sProxy, "/api", aggregatedDiscoveryAccept, r)
+	if aggregated, _ := isAggregatedDiscovery(contentType); err == nil && aggregated {
+		coreGroups, coreResourcesByGV, coreFailed, err := splitAggregatedDiscovery(core, contentType)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to parse /api response")
+		}
+		complete = complete && len(coreFailed) == 0
+		for _, group := range coreGroups.Groups {
+			preferred[group.Name] = group.PreferredVersion.Version
+			for _, version := range group.Versions {
+				if list, ok := coreResourcesByGV[schema.GroupVersion{Version: version.Version}]; ok {
+					resourceLists = append(resourceLists, list)
 				}
-				results[idx] = body
-			}(i, p)
+			}
 		}
+	} else {
+		preferred[""] = "v1"
+		list, err := fetchAPIResourceList(k8sProxy, "/api/v1", r)
+		if err != nil {
+			klog.V(4).Infof("api-discovery: skipping /api/v1: %v", err)
+			complete = false
+		} else {
+			resourceLists = append(resourceLists, list)
+		}
+	}
 
-		wg.Wait()
+	groups.TypeMeta = metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"}
+	groupsJSON, err := json.Marshal(groups)
+	if err != nil {
+		return nil, false, err
+	}
+	rawLists := make([]json.RawMessage, 0, len(resourceLists))
+	for _, list := range resourceLists {
+		list.TypeMeta = metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"}
+		raw, err := json.Marshal(list)
+		if err != nil {
+			return nil, false, err
+		}
+		rawLists = append(rawLists, raw)
+	}
+
+	return &apiDiscoveryResponse{
+		Groups:        groupsJSON,
+		ResourceLists: rawLists,
+		ResourceIndex: apiResourceIndex(preferred, resourceLists),
+	}, complete, nil
+}
+
+// unaggregatedAPIDiscovery gets the resource list of every group version in parallel.
+// Group versions that fail are left out.
+func unaggregatedAPIDiscovery(k8sProxy *proxy.Proxy, r *http.Request, groupsBody []byte) (*apiDiscoveryResponse, bool, error) {
+	var groups apiGroupList
+	if err := json.Unmarshal(groupsBody, &groups); err != nil {
+		return nil, false, fmt.Errorf("failed to parse /apis response")
+	}
+
+	preferred := map[string]string{"": "v1"}
+	var paths []string
+	for _, group := range groups.Groups {
+		preferred[group.Name] = group.PreferredVersion.Version
+		for _, version := range group.Versions {
+			paths = append(paths, "/apis/"+version.GroupVersion)
+		}
+	}
+	paths = append(paths, "/api/v1")
 
-		resourceLists := make([]json.RawMessage, 0, len(results))
-		for _, res := range results {
-			if res != nil {
-				resourceLists = append(resourceLists, res)
+	results := make([]json.RawMessage, len(paths))
+	lists := make([]*metav1.APIResourceList, len(paths))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 100)
+	wg.Add(len(paths))
+
+	for i, p := range paths {
+		sem <- struct{}{}
+		go func(idx int, path string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			body, err := k8sViaProxy(k8sProxy, path, r)
+			if err != nil {
+				klog.V(4).Infof("api-discovery: skipping %s", path)
+				return
+			}
+			list := &metav1.APIResourceList{}
+			if err := json.Unmarshal(body, list); err != nil {
+				klog.V(4).Infof("api-discovery: skipping %s: %v", path, err)
+				return
 			}
+			results[idx] = body
+			lists[idx] = list
+		}(i, p)
+	}
+
+	wg.Wait()
+
+	resourceLists := make([]json.RawMessage, 0, len(results))
+	parsedLists := make([]*metav1.APIResourceList, 0, len(lists))
+	for i, res := range results {
+		if res != nil {
+			resourceLists = append(resourceLists, res)
+			parsedLists = append(parsedLists, lists[i])
 		}
+	}
+
+	return &apiDiscoveryResponse{
+		Groups:        groupsBody,
+		ResourceLists: resourceLists,
+		ResourceIndex: apiResourceIndex(preferred, parsedLists),
+	}, len(resourceLists) == len(paths), nil
+}
 
-		resp := apiDiscoveryResponse{
-			Groups:        groupsBody,
-			ResourceLists: resourceLists,
+func isAggregatedDiscovery(contentType string) (bool, error) {
+	if v2, err := discovery.ContentTypeIsGVK(contentType, aggregatedDiscoveryV2GVK); err != nil || v2 {
+		return v2, err
+	}
+	return discovery.ContentTypeIsGVK(contentType, aggregatedDiscoveryV2Beta1GVK)
+}
+
+func splitAggregatedDiscovery(body []byte, contentType string) (*metav1.APIGroupList, map[schema.GroupVersion]*metav1.APIResourceList, map[schema.GroupVersion]error, error) {
+	if v2, _ := discovery.ContentTypeIsGVK(contentType, aggregatedDiscoveryV2GVK); v2 {
+		var list apidiscoveryv2.APIGroupDiscoveryList
+		if err := json.Unmarshal(body, &list); err != nil {
+			return nil, nil, nil, err
 		}
+		groups, resources, failed := discovery.SplitGroupsAndResources(list)
+		return groups, resources, failed, nil
+	}
+	var list apidiscoveryv2beta1.APIGroupDiscoveryList
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, nil, nil, err
+	}
+	groups, resources, failed := discovery.SplitGroupsAndResourcesV2Beta1(list) //nolint:staticcheck // served by API servers before 1.30
+	return groups, resources, failed, nil
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(resp); err != nil {
-			klog.Errorf("api-discovery: failed to write response: %v", err)
+func fetchAPIResourceList(k8sProxy *proxy.Proxy, path string, r *http.Request) (*metav1.APIResourceList, error) {
+	body, err := k8sViaProxy(k8sProxy, path, r)
+	if err != nil {
+		return nil, err
+	}
+	list := &metav1.APIResourceList{}
+	if err := json.Unmarshal(body, list); err != nil {
+		return nil, fmt.Errorf("failed to parse %s response: %v", path, err)
+	}
+	return list, nil
+}
+
+// apiResourceIndex lists the resources of the resource lists, sorted by group, version and
+// plural name, given the preferred version of each group.
+func apiResourceIndex(preferred map[string]string, resourceLists []*metav1.APIResourceList) []apiResourceIndexEntry {
+	index := []apiResourceIndexEntry{}
+	for _, list := range resourceLists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			klog.V(4).Infof("api-discovery: skipping resources of %q: %v", list.GroupVersion, err)
+			continue
+		}
+		for _, resource := range list.APIResources {
+			if strings.Contains(resource.Name, "/") {
+				continue
+			}
+			index = append(index, apiResourceIndexEntry{
+				Group:      gv.Group,
+				Version:    gv.Version,
+				Kind:       resource.Kind,
+				Plural:     resource.Name,
+				Singular:   resource.SingularName,
+				Namespaced: resource.Namespaced,
+				Preferred:  preferred[gv.Group] == gv.Version,
+				Verbs:      resource.Verbs,
+				ShortNames: resource.ShortNames,
+			})
 		}
 	}
+	sort.Slice(index, func(i, j int) bool {
+		a, b := index[i], index[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return a.Plural < b.Plural
+	})
+	return index
 }
 
 // k8sViaProxy makes an in-process GET request through the k8s proxy.
 func k8sViaProxy(handler http.Handler, path string, originalReq *http.Request) (body []byte, err error) {
+	body, _, err = k8sViaProxyAccept(handler, path, "", originalReq)
+	return body, err
+}
+
+// k8sViaProxyAccept makes an in-process GET request through the k8s proxy with the given
+// Accept header, or the one of the original request if it is empty, and returns the
+// content type of the response.
+func k8sViaProxyAccept(handler http.Handler, path string, accept string, originalReq *http.Request) (body []byte, contentType string, err error) {
 	req, err := http.NewRequestWithContext(originalReq.Context(), "GET", path, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request for %s", path)
+		return nil, "", fmt.Errorf("failed to create request for %s", path)
 	}
 
 	req.Header = originalReq.Header.Clone()
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
 
 	rec := httptest.NewRecorder()
 
@@ -125,8 +383,9 @@ func k8sViaProxy(handler http.Handler, path string, originalReq *http.Request) (
 	handler.ServeHTTP(rec, req)
 
 	if rec.Code != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status %d for %s", rec.Code, path)
+		return nil, "", fmt.Errorf("unexpected status %d for %s", rec.Code, path)
 	}
 
-	return io.ReadAll(rec.Body)
+	body, err = io.ReadAll(rec.Body)
+	return body, rec.Header().Get("Content-Type"), err
 }
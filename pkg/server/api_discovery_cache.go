@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// apiDiscoveryCacheTTL bounds how long discovery is cached for the changes that are not
+	// watched, like aggregated API servers being registered
+	apiDiscoveryCacheTTL = 5 * time.Minute
+	// apiDiscoveryPartialCacheTTL is the TTL of discovery that misses group versions that
+	// failed, so that they are discovered again soon
+	apiDiscoveryPartialCacheTTL = 30 * time.Second
+	apiDiscoveryCacheMaxEntries = 1000
+)
+
+// APIDiscoveryCache keeps the API discovery of each view of the API. Requests share a view if
+// they are made with the same token and the same impersonation, which is what RBAC decides
+// on. The cache is cleared whenever a CustomResourceDefinition changes, if it watches them.
+type APIDiscoveryCache struct {
+	now func() time.Time
+
+	mu         sync.Mutex
+	generation uint64
+	entries    map[string]*apiDiscovery
+}
+
+// apiDiscovery is the serialized response of the API discovery endpoint.
+type apiDiscovery struct {
+	body []byte
+	etag string
+	// complete is false if group versions were left out because they failed
+	complete bool
+	expires  time.Time
+}
+
+func NewAPIDiscoveryCache() *APIDiscoveryCache {
+	return &APIDiscoveryCache{
+		now:     time.Now,
+		entries: map[string]*apiDiscovery{},
+	}
+}
+
+// WatchCRDs clears the cache whenever a CustomResourceDefinition is created, updated or
+// deleted, until the context is cancelled. Only the metadata of the CRDs is kept in memory.
+func (c *APIDiscoveryCache) WatchCRDs(ctx context.Context, client metadata.Interface) {
+	crds := client.Resource(schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"})
+	listWatch := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			return crds.List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			return crds.Watch(ctx, options)
+		},
+	}
+
+	informer := cache.NewSharedIndexInformer(listWatch, &metav1.PartialObjectMetadata{}, 0, cache.Indexers{})
+	informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		klog.Errorf("failed to list and watch customresourcedefinitions: %v", err)
+	})
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(interface{}) { c.Invalidate() },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// resyncs and relists update the objects with the same version
+			if oldObj.(*metav1.PartialObjectMetadata).ResourceVersion != newObj.(*metav1.PartialObjectMetadata).ResourceVersion {
+				c.Invalidate()
+			}
+		},
+		DeleteFunc: func(interface{}) { c.Invalidate() },
+	})
+	go informer.Run(ctx.Done())
+}
+
+// Invalidate clears the cache. Discovery that is in progress is not cached.
+func (c *APIDiscoveryCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.entries)
+}
+
+func (c *APIDiscoveryCache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+func (c *APIDiscoveryCache) get(key string) (*apiDiscovery, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry, true
+}
+
+// add caches discovery unless the cache was cleared since the given generation, as the
+// discovery may have been made before the change that cleared it.
+func (c *APIDiscoveryCache) add(key string, generation uint64, discovery *apiDiscovery) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+
+	now := c.now()
+	if len(c.entries) >= apiDiscoveryCacheMaxEntries {
+		var oldestKey string
+		var oldest time.Time
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			} else if oldestKey == "" || entry.expires.Before(oldest) {
+				oldestKey, oldest = k, entry.expires
+			}
+		}
+		if len(c.entries) >= apiDiscoveryCacheMaxEntries {
+			delete(c.entries, oldestKey)
+		}
+	}
+
+	ttl := apiDiscoveryCacheTTL
+	if !discovery.complete {
+		ttl = apiDiscoveryPartialCacheTTL
+	}
+	discovery.expires = now.Add(ttl)
+	c.entries[key] = discovery
+}
+
+// apiDiscoveryCacheKey identifies the view of the API of a request by the credentials that
+// are sent to the API server: the token, which belongs to the user or to the console service
+// account for front proxy users, and the impersonation. The username alone is not enough, the
+// groups of a user and the users of identity providers with the same names differ in RBAC.
+func apiDiscoveryCacheKey(r *http.Request) string {
+	var impersonation []string
+	for name, values := range r.Header {
+		if strings.HasPrefix(name, "Impersonate-") || name == "X-Console-Impersonate-Groups" {
+			values = slices.Clone(values)
+			sort.Strings(values)
+			impersonation = append(impersonation, name+":"+strings.Join(values, ","))
+		}
+	}
+	sort.Strings(impersonation)
+
+	hash := sha256.New()
+	for _, part := range append([]string{r.Header.Get("Authorization")}, impersonation...) {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
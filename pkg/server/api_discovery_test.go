@@ -1,15 +1,23 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/openshift/console/pkg/auth"
 	"github.com/openshift/console/pkg/proxy"
+
+	apidiscoveryv2 "k8s.io/api/apidiscovery/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
 )
 
 func newMockK8sAPI(t *testing.T) *httptest.Server {
@@ -103,7 +111,7 @@ func newTestHandler(t *testing.T, backend *httptest.Server) http.HandlerFunc {
 		t.Fatalf("failed to parse backend URL: %v", err)
 	}
 	k8sProxy := proxy.NewProxy(&proxy.Config{Endpoint: endpoint})
-	return apiDiscoveryHandler(k8sProxy)
+	return apiDiscoveryHandler(k8sProxy, NewAPIDiscoveryCache(), nil)
 }
 
 func TestApiDiscoveryHandler(t *testing.T) {
@@ -421,3 +429,352 @@ func TestApiDiscoveryHandler_PartialFailure(t *testing.T) {
 		t.Error("expected failed group to be silently skipped")
 	}
 }
+
+func newMockAggregatedK8sAPI(t *testing.T, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	resource := func(plural, singular, kind string, scope apidiscoveryv2.ResourceScope, subresources ...string) apidiscoveryv2.APIResourceDiscovery {
+		r := apidiscoveryv2.APIResourceDiscovery{
+			Resource:         plural,
+			SingularResource: singular,
+			ResponseKind:     &metav1.GroupVersionKind{Kind: kind},
+			Scope:            scope,
+			Verbs:            []string{"get", "list", "watch"},
+		}
+		for _, subresource := range subresources {
+			r.Subresources = append(r.Subresources, apidiscoveryv2.APISubresourceDiscovery{
+				Subresource:  subresource,
+				ResponseKind: &metav1.GroupVersionKind{Kind: kind},
+				Verbs:        []string{"get", "update"},
+			})
+		}
+		return r
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !strings.Contains(r.Header.Get("Accept"), "g=apidiscovery.k8s.io;v=v2") {
+			t.Errorf("expected aggregated discovery to be requested for %s, got Accept %q", r.URL.Path, r.Header.Get("Accept"))
+		}
+		var list apidiscoveryv2.APIGroupDiscoveryList
+		switch r.URL.Path {
+		case "/apis":
+			list.Items = []apidiscoveryv2.APIGroupDiscovery{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+					Versions: []apidiscoveryv2.APIVersionDiscovery{
+						{Version: "v1", Resources: []apidiscoveryv2.APIResourceDiscovery{resource("deployments", "deployment", "Deployment", apidiscoveryv2.ScopeNamespace, "status", "scale")}},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "metrics.k8s.io"},
+					Versions: []apidiscoveryv2.APIVersionDiscovery{
+						{Version: "v1beta1", Freshness: apidiscoveryv2.DiscoveryFreshnessStale},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "example.com"},
+					Versions: []apidiscoveryv2.APIVersionDiscovery{
+						{Version: "v2", Resources: []apidiscoveryv2.APIResourceDiscovery{resource("widgets", "widget", "Widget", apidiscoveryv2.ScopeCluster)}},
+						{Version: "v1", Resources: []apidiscoveryv2.APIResourceDiscovery{resource("widgets", "widget", "Widget", apidiscoveryv2.ScopeCluster)}},
+					},
+				},
+			}
+		case "/api":
+			list.Items = []apidiscoveryv2.APIGroupDiscovery{
+				{
+					Versions: []apidiscoveryv2.APIVersionDiscovery{
+						{Version: "v1", Resources: []apidiscoveryv2.APIResourceDiscovery{resource("pods", "pod", "Pod", apidiscoveryv2.ScopeNamespace, "log")}},
+					},
+				},
+			}
+		default:
+			t.Errorf("unexpected request for %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", discovery.AcceptV2)
+		json.NewEncoder(w).Encode(list)
+	}))
+}
+
+func TestApiDiscoveryHandler_AggregatedDiscovery(t *testing.T) {
+	var requests atomic.Int32
+	backend := newMockAggregatedK8sAPI(t, &requests)
+	defer backend.Close()
+	handler := newTestHandler(t, backend)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/api-discovery", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected only /apis and /api to be requested, got %d requests", requests.Load())
+	}
+
+	var resp apiDiscoveryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	var groups apiGroupList
+	if err := json.Unmarshal(resp.Groups, &groups); err != nil {
+		t.Fatalf("failed to parse groups: %v", err)
+	}
+	if len(groups.Groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(groups.Groups))
+	}
+	if preferred := groups.Groups[2].PreferredVersion.GroupVersion; preferred != "example.com/v2" {
+		t.Errorf("expected the first version to be preferred, got %q", preferred)
+	}
+
+	// apps/v1 + example.com/v2 + example.com/v1 + core v1, the stale metrics.k8s.io/v1beta1 is left out
+	var groupVersions []string
+	for _, raw := range resp.ResourceLists {
+		var list metav1.APIResourceList
+		if err := json.Unmarshal(raw, &list); err != nil {
+			t.Fatalf("failed to parse resource list: %v", err)
+		}
+		groupVersions = append(groupVersions, list.GroupVersion)
+		if list.GroupVersion == "apps/v1" && len(list.APIResources) != 3 {
+			t.Errorf("expected deployments with its 2 subresources, got %v", list.APIResources)
+		}
+	}
+	expectedGroupVersions := []string{"apps/v1", "example.com/v2", "example.com/v1", "v1"}
+	if strings.Join(groupVersions, ",") != strings.Join(expectedGroupVersions, ",") {
+		t.Errorf("expected resource lists %v, got %v", expectedGroupVersions, groupVersions)
+	}
+
+	expectedIndex := []apiResourceIndexEntry{
+		{Version: "v1", Kind: "Pod", Plural: "pods", Singular: "pod", Namespaced: true, Preferred: true, Verbs: []string{"get", "list", "watch"}},
+		{Group: "apps", Version: "v1", Kind: "Deployment", Plural: "deployments", Singular: "deployment", Namespaced: true, Preferred: true, Verbs: []string{"get", "list", "watch"}},
+		{Group: "example.com", Version: "v1", Kind: "Widget", Plural: "widgets", Singular: "widget", Verbs: []string{"get", "list", "watch"}},
+		{Group: "example.com", Version: "v2", Kind: "Widget", Plural: "widgets", Singular: "widget", Preferred: true, Verbs: []string{"get", "list", "watch"}},
+	}
+	if len(resp.ResourceIndex) != len(expectedIndex) {
+		t.Fatalf("expected %d indexed resources, got %v", len(expectedIndex), resp.ResourceIndex)
+	}
+	for i, expected := range expectedIndex {
+		actual := resp.ResourceIndex[i]
+		if actual.Group != expected.Group || actual.Version != expected.Version || actual.Kind != expected.Kind ||
+			actual.Plural != expected.Plural || actual.Singular != expected.Singular || actual.Namespaced != expected.Namespaced ||
+			actual.Preferred != expected.Preferred || strings.Join(actual.Verbs, ",") != strings.Join(expected.Verbs, ",") {
+			t.Errorf("expected indexed resource %d to be %+v, got %+v", i, expected, actual)
+		}
+	}
+}
+
+func TestApiDiscoveryHandler_ResourceIndexFallback(t *testing.T) {
+	backend := newMockK8sAPI(t)
+	defer backend.Close()
+	handler := newTestHandler(t, backend)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/api-discovery", nil))
+
+	var resp apiDiscoveryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	var plurals []string
+	for _, resource := range resp.ResourceIndex {
+		plurals = append(plurals, resource.Group+"/"+resource.Plural)
+		if !resource.Preferred || !resource.Namespaced {
+			t.Errorf("expected %s to be a preferred namespaced resource", resource.Plural)
+		}
+	}
+	expected := "/pods,/services,apps/deployments,batch/jobs"
+	if strings.Join(plurals, ",") != expected {
+		t.Errorf("expected resource index %s, got %s", expected, strings.Join(plurals, ","))
+	}
+}
+
+func TestApiDiscoveryHandler_Cache(t *testing.T) {
+	var requests atomic.Int32
+	backend := newMockAggregatedK8sAPI(t, &requests)
+	defer backend.Close()
+
+	endpoint, err := url.Parse(backend.URL)
+	if err != nil {
+		t.Fatalf("failed to parse backend URL: %v", err)
+	}
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	discoveryCache := NewAPIDiscoveryCache()
+	discoveryCache.now = func() time.Time { return now }
+	handler := apiDiscoveryHandler(proxy.NewProxy(&proxy.Config{Endpoint: endpoint}), discoveryCache, nil)
+
+	get := func(token, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/api-discovery", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("token-a", "")
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with an ETag, got %d with %q", rec.Code, etag)
+	}
+	if rec.Header().Get("Cache-Control") != "private, no-cache" {
+		t.Errorf("expected the response to be revalidated, got Cache-Control %q", rec.Header().Get("Cache-Control"))
+	}
+
+	rec = get("token-a", etag)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for a matching ETag, got %d", rec.Code)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected discovery to be cached, got %d requests", requests.Load())
+	}
+
+	// another user has another view of the API
+	if rec = get("token-b", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+	if requests.Load() != 4 {
+		t.Errorf("expected discovery to be cached per user, got %d requests", requests.Load())
+	}
+
+	// a CRD changed
+	discoveryCache.Invalidate()
+	if rec = get("token-a", etag); rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304 when discovery did not change, got %d", rec.Code)
+	}
+	if requests.Load() != 6 {
+		t.Errorf("expected discovery again after invalidation, got %d requests", requests.Load())
+	}
+
+	// discovery with stale group versions expires sooner
+	now = now.Add(apiDiscoveryPartialCacheTTL)
+	get("token-a", etag)
+	if requests.Load() != 8 {
+		t.Errorf("expected discovery again after the cache expired, got %d requests", requests.Load())
+	}
+}
+
+func TestApiDiscoveryHandler_RateLimitsCacheMisses(t *testing.T) {
+	var requests atomic.Int32
+	backend := newMockAggregatedK8sAPI(t, &requests)
+	defer backend.Close()
+
+	endpoint, err := url.Parse(backend.URL)
+	if err != nil {
+		t.Fatalf("failed to parse backend URL: %v", err)
+	}
+	var misses int
+	allowMiss := func(w http.ResponseWriter, r *http.Request) bool {
+		misses++
+		if r.Header.Get("Authorization") == "Bearer limited" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return false
+		}
+		return true
+	}
+	handler := apiDiscoveryHandler(proxy.NewProxy(&proxy.Config{Endpoint: endpoint}), NewAPIDiscoveryCache(), allowMiss)
+
+	get := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/api-discovery", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for i := 0; i < 3; i++ {
+		if code := get("token-a"); code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", code)
+		}
+	}
+	if misses != 1 {
+		t.Errorf("expected only the cache miss to be rate limited, got %d", misses)
+	}
+
+	requestsBefore := requests.Load()
+	if code := get("limited"); code != http.StatusTooManyRequests {
+		t.Errorf("expected 429, got %d", code)
+	}
+	if requests.Load() != requestsBefore {
+		t.Errorf("expected no discovery for a rate limited request, got %d requests", requests.Load()-requestsBefore)
+	}
+}
+
+func TestAPIDiscoveryCache(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	discoveryCache := NewAPIDiscoveryCache()
+	discoveryCache.now = func() time.Time { return now }
+
+	generation := discoveryCache.currentGeneration()
+	discoveryCache.Invalidate()
+	discoveryCache.add("a", generation, &apiDiscovery{complete: true})
+	if _, ok := discoveryCache.get("a"); ok {
+		t.Error("expected discovery made before the cache was invalidated not to be cached")
+	}
+
+	generation = discoveryCache.currentGeneration()
+	discoveryCache.add("a", generation, &apiDiscovery{complete: true})
+	now = now.Add(apiDiscoveryCacheTTL - time.Second)
+	if _, ok := discoveryCache.get("a"); !ok {
+		t.Error("expected complete discovery to be cached")
+	}
+	now = now.Add(time.Second)
+	if _, ok := discoveryCache.get("a"); ok {
+		t.Error("expected complete discovery to expire")
+	}
+
+	for i := 0; i <= apiDiscoveryCacheMaxEntries; i++ {
+		discoveryCache.add(string(rune(i)), generation, &apiDiscovery{complete: true})
+	}
+	if len(discoveryCache.entries) != apiDiscoveryCacheMaxEntries {
+		t.Errorf("expected the cache to be bounded to %d entries, got %d", apiDiscoveryCacheMaxEntries, len(discoveryCache.entries))
+	}
+}
+
+func TestApiDiscoveryCacheKey(t *testing.T) {
+	request := func(headers map[string][]string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/api-discovery", nil)
+		for name, values := range headers {
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+		return req
+	}
+
+	base := apiDiscoveryCacheKey(request(map[string][]string{"Authorization": {"Bearer a"}}))
+	if base != apiDiscoveryCacheKey(request(map[string][]string{"Authorization": {"Bearer a"}})) {
+		t.Error("expected the same key for the same token")
+	}
+	impersonating := apiDiscoveryCacheKey(request(map[string][]string{"Authorization": {"Bearer a"}, "Impersonate-User": {"b"}, "Impersonate-Group": {"x", "y"}}))
+	if impersonating == base {
+		t.Error("expected impersonation to change the key")
+	}
+	if impersonating != apiDiscoveryCacheKey(request(map[string][]string{"Authorization": {"Bearer a"}, "Impersonate-User": {"b"}, "Impersonate-Group": {"y", "x"}})) {
+		t.Error("expected the order of the impersonated groups not to change the key")
+	}
+
+	// users of different identity providers may have the same name
+	userA := request(map[string][]string{"Authorization": {"Bearer a"}})
+	userB := request(map[string][]string{"Authorization": {"Bearer b"}})
+	for _, r := range []*http.Request{userA, userB} {
+		*r = *r.WithContext(context.WithValue(r.Context(), auth.UserContextKey, &auth.User{Username: "alice"}))
+	}
+	if apiDiscoveryCacheKey(userA) == apiDiscoveryCacheKey(userB) {
+		t.Error("expected users with the same name and different tokens to have different keys")
+	}
+
+	// front proxy users share the service account token and differ by their impersonation
+	if apiDiscoveryCacheKey(request(map[string][]string{"Authorization": {"Bearer sa"}, "Impersonate-User": {"alice"}, "Impersonate-Group": {"dev"}})) ==
+		apiDiscoveryCacheKey(request(map[string][]string{"Authorization": {"Bearer sa"}, "Impersonate-User": {"alice"}, "Impersonate-Group": {"admins"}})) {
+		t.Error("expected the groups of an impersonated user to change the key")
+	}
+	if apiDiscoveryCacheKey(request(map[string][]string{"Authorization": {"Bearer sa"}, "Impersonate-User": {"alice"}, "Impersonate-Extra-Scopes": {"user:info"}})) ==
+		apiDiscoveryCacheKey(request(map[string][]string{"Authorization": {"Bearer sa"}, "Impersonate-User": {"alice"}})) {
+		t.Error("expected the impersonated extra fields to change the key")
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	releasecommon "helm.sh/helm/v4/pkg/release"
+	releasev1 "helm.sh/helm/v4/pkg/release/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/console/pkg/devconsole"
+	"github.com/openshift/console/pkg/devfile"
+	helmhandlerspkg "github.com/openshift/console/pkg/helm/handlers"
+	"github.com/openshift/console/pkg/knative"
+	"github.com/openshift/console/pkg/serverutils"
+	"github.com/openshift/console/pkg/usage"
+)
+
+// documentAPIs describes the JSON APIs of the bridge that plugins may use. The routes that
+// are registered without operations, like the proxies, are documented by their path only.
+func documentAPIs(docs *serverutils.APIDocs) {
+	namespaceQuery := serverutils.Parameter{Name: "ns", Description: "The namespace of the release", Required: true}
+	releaseQuery := serverutils.Parameter{Name: "name", Description: "The name of the release", Required: true}
+
+	docs.Operations("/api/helm/release",
+		serverutils.Operation{Method: http.MethodGet, Summary: "Get a Helm release", Query: []serverutils.Parameter{namespaceQuery, releaseQuery}, Response: releasev1.Release{}},
+		serverutils.Operation{Method: http.MethodPost, Summary: "Install a Helm chart", Request: helmhandlerspkg.HelmRequest{}, Response: releasev1.Release{}},
+		serverutils.Operation{Method: http.MethodPut, Summary: "Upgrade a Helm release", Request: helmhandlerspkg.HelmRequest{}, Response: releasev1.Release{}},
+		serverutils.Operation{Method: http.MethodPatch, Summary: "Roll back a Helm release to a revision", Request: helmhandlerspkg.HelmRequest{}, Response: releasev1.Release{}},
+		serverutils.Operation{Method: http.MethodDelete, Summary: "Uninstall a Helm release", Query: []serverutils.Parameter{namespaceQuery, releaseQuery}, Response: releasecommon.UninstallReleaseResponse{}},
+	)
+	docs.Operations("/api/helm/release/async",
+		serverutils.Operation{Method: http.MethodPost, Summary: "Install a Helm chart in the background", Request: helmhandlerspkg.HelmRequest{}, Response: corev1.Secret{}, ResponseStatus: http.StatusCreated},
+		serverutils.Operation{Method: http.MethodPut, Summary: "Upgrade a Helm release in the background", Request: helmhandlerspkg.HelmRequest{}, Response: corev1.Secret{}, ResponseStatus: http.StatusCreated},
+		serverutils.Operation{Method: http.MethodDelete, Summary: "Uninstall a Helm release in the background", Query: []serverutils.Parameter{
+			namespaceQuery, releaseQuery, {Name: "version", Description: "The revision of the release", Required: true},
+		}, ResponseStatus: http.StatusNoContent},
+	)
+	docs.Operations("/api/helm/releases",
+		serverutils.Operation{Method: http.MethodGet, Summary: "List the Helm releases of a namespace", Query: []serverutils.Parameter{
+			{Name: "ns", Description: "The namespace, all namespaces if empty"},
+			{Name: "limitInfo", Description: "Leave out the charts and the manifests of the releases"},
+		}, Response: []*releasev1.Release{}},
+	)
+	docs.Operations("/api/helm/release/history",
+		serverutils.Operation{Method: http.MethodGet, Summary: "List the revisions of a Helm release", Query: []serverutils.Parameter{namespaceQuery, releaseQuery}, Response: []*releasev1.Release{}},
+	)
+	docs.Operations("/api/helm/template",
+		serverutils.Operation{Method: http.MethodPost, Summary: "Render the manifests of a Helm chart", Request: helmhandlerspkg.HelmRequest{}, ResponseContentType: "text/yaml"},
+	)
+	docs.Operations("/api/helm/chart",
+		serverutils.Operation{Method: http.MethodGet, Summary: "Get a Helm chart", Query: []serverutils.Parameter{
+			{Name: "url", Description: "The URL of the chart"},
+			{Name: "namespace", Description: "The namespace of the repositories of the chart, default if empty"},
+			{Name: "indexEntry", Description: "The entry of the chart in the index of the repositories"},
+			{Name: "noRepo", Description: "Get the chart from its URL instead of from a repository"},
+			{Name: "basic_auth_secret_name", Description: "The Secret with the credentials of the URL"},
+		}, Response: chart.Chart{}},
+	)
+	docs.Operations("/api/helm/verify",
+		serverutils.Operation{Method: http.MethodPost, Summary: "Verify a Helm chart with the chart verifier", Request: helmhandlerspkg.HelmVerifierRequest{}, Response: json.RawMessage{}},
+	)
+
+	docs.Operations(devfileEndpoint,
+		serverutils.Operation{Method: http.MethodPost, Summary: "Get the resources of an application from its devfile", Request: devfile.DevfileForm{}, Response: devfile.DevfileResources{}},
+	)
+	docs.Operations(devfileConvertEndpoint,
+		serverutils.Operation{Method: http.MethodPost, Summary: "Convert a devfile into the objects of an application", Request: devfile.DevfileConvertForm{}, Response: devfile.DevfileConvertResponse{}},
+	)
+	docs.Operations(devfileSamplesEndpoint,
+		serverutils.Operation{Method: http.MethodGet, Summary: "List the samples of the devfile registries", Query: []serverutils.Parameter{
+			{Name: "registry", Description: "The URL of the registry, all configured registries if empty"},
+		}, Response: json.RawMessage{}},
+	)
+
+	docs.Operations(knativeProxyEndpoint+"namespaces/{namespace}/services/{service}/invoke",
+		serverutils.Operation{Method: http.MethodPost, Summary: "Invoke a Knative service", Request: knative.InvokeServiceRequestBody{}, Response: knative.InvokeServiceResponseBody{}},
+	)
+
+	for route, op := range devconsole.Operations() {
+		docs.Operations(devConsoleEndpoint+route, op)
+	}
+
+	docs.Operations("/api/metrics/usage",
+		serverutils.Operation{Method: http.MethodPost, Summary: "Count a usage event of the console", Request: usage.Request{}, ResponseStatus: http.StatusAccepted},
+	)
+	docs.Operations("/api/console/version",
+		serverutils.Operation{Method: http.MethodGet, Summary: "Get the version of the console", Response: versionResponse{}},
+	)
+	docs.Operations(serverFlagsEndpoint,
+		serverutils.Operation{Method: http.MethodGet, Summary: "Get the server flags of the console", Response: jsGlobals{}},
+	)
+}
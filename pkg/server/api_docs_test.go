@@ -0,0 +1,62 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/openshift/console/pkg/devconsole"
+	"github.com/openshift/console/pkg/serverutils"
+)
+
+func TestDocumentAPIs(t *testing.T) {
+	docs := serverutils.NewAPIDocs("OpenShift Console", "4.99")
+	documentAPIs(docs)
+	data, err := docs.Document()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var document struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	for _, path := range []string{
+		"/api/helm/release",
+		"/api/devfile/convert",
+		"/api/console/knative/namespaces/{namespace}/services/{service}/invoke",
+		"/api/dev-console/webhooks/create",
+		"/api/console/server-flags",
+	} {
+		if _, ok := document.Paths[path]; !ok {
+			t.Errorf("expected path %s to be documented", path)
+		}
+	}
+	for name, schema := range document.Components.Schemas {
+		if string(schema) == "null" {
+			t.Errorf("expected schema %s to be generated", name)
+		}
+	}
+
+	for route, op := range devconsole.Operations() {
+		if _, ok := document.Paths[devConsoleEndpoint+route][strings.ToLower(op.Method)]; !ok {
+			t.Errorf("expected %s %s%s to be documented", op.Method, devConsoleEndpoint, route)
+		}
+	}
+	if _, ok := document.Paths["/api/dev-console/tekton-results/log-stream"]["get"]; !ok {
+		t.Errorf("expected the log stream to be documented as a GET operation")
+	}
+
+	var helmRequest struct {
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}
+	json.Unmarshal(document.Components.Schemas["handlers.HelmRequest"], &helmRequest)
+	if pattern := helmRequest.Properties["chart_url"]["pattern"]; pattern != "^(http|https|oci)://" {
+		t.Errorf("expected the chart URL to be constrained to its schemes, got %v", pattern)
+	}
+}
@@ -1,16 +1,34 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/openshift/console/pkg/serverutils"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 )
 
+// resourceListerSyncTimeout bounds how long a request waits for the initial list.
+var resourceListerSyncTimeout = 10 * time.Second
+
 // ResourceLister handles resource requests
 type ResourceLister interface {
 	HandleResources(w http.ResponseWriter, r *http.Request)
@@ -19,52 +37,286 @@ type ResourceLister interface {
 // FilterFunction shall filter response before propagating
 type FilterFunction func(http.ResponseWriter, *http.Response)
 
-// resourceLister determines the list of resources of a particular kind
+// ListedResource describes the resources served by a ResourceLister.
+type ListedResource struct {
+	Resource schema.GroupVersionResource
+	// ListKind is the kind of the list returned to the client, e.g. ConfigMapList.
+	ListKind      string
+	Namespace     string
+	LabelSelector string
+}
+
+// resourceList is the filtered list as it is sent to the client.
+type resourceList struct {
+	status int
+	body   []byte
+	etag   string
+}
+
+// resourceLister serves a list of resources of a particular kind from an informer cache.
+// The response filter is applied once per change rather than on every request.
 type resourceLister struct {
-	requestURL      *url.URL
-	authenticatedRT http.RoundTripper
-	responseFilter  FilterFunction
+	resource       ListedResource
+	informer       cache.SharedIndexInformer
+	responseFilter FilterFunction
+
+	list    atomic.Pointer[resourceList]
+	synced  chan struct{}
+	changed chan struct{}
+	// listErr is the last error of the informer, reported while the initial list is pending
+	listErr atomic.Pointer[error]
+
+	subscribersLock sync.Mutex
+	subscribers     map[chan struct{}]struct{}
 }
 
-// HandleResources handles resource requests
+// HandleResources handles resource requests. Clients can pass ?watch=true to
+// receive the list again whenever it changes, one JSON document per line.
 func (l *resourceLister) HandleResources(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		serverutils.SendResponse(w, http.StatusMethodNotAllowed, serverutils.ApiError{Err: "invalid method: only GET is allowed"})
 		return
 	}
 
-	req, err := http.NewRequest("GET", l.requestURL.String(), nil)
-	if err != nil {
-		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: fmt.Sprintf("failed to create GET request: %v", err)})
+	if err := l.waitForSync(r.Context()); err != nil {
+		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: err.Error()})
+		return
+	}
+
+	if r.URL.Query().Get("watch") == "true" {
+		l.watch(w, r)
+		return
+	}
+
+	list := l.list.Load()
+	w.Header().Set("ETag", list.etag)
+	if etagMatches(r.Header.Get("If-None-Match"), list.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(list.status)
+	w.Write(list.body)
+}
+
+func (l *resourceLister) waitForSync(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, resourceListerSyncTimeout)
+	defer cancel()
+
+	select {
+	case <-l.synced:
+		return nil
+	case <-ctx.Done():
+		if err := l.listErr.Load(); err != nil {
+			return fmt.Errorf("console service account cannot list resource: %v", *err)
+		}
+		return fmt.Errorf("timed out waiting for %s to be listed", l.resource.Resource.Resource)
+	}
+}
+
+func (l *resourceLister) watch(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: "streaming is not supported"})
+		return
+	}
+
+	notify := l.subscribe()
+	defer l.unsubscribe(notify)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	var lastETag string
+	for {
+		if list := l.list.Load(); list.etag != lastETag {
+			lastETag = list.etag
+			// the body is shared with other requests, so the newline is written separately
+			if _, err := w.Write(bytes.TrimSpace(list.body)); err != nil {
+				return
+			}
+			if _, err := w.Write([]byte("\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-notify:
+		}
+	}
+}
+
+func (l *resourceLister) subscribe() chan struct{} {
+	l.subscribersLock.Lock()
+	defer l.subscribersLock.Unlock()
+	notify := make(chan struct{}, 1)
+	l.subscribers[notify] = struct{}{}
+	return notify
+}
+
+func (l *resourceLister) unsubscribe(notify chan struct{}) {
+	l.subscribersLock.Lock()
+	defer l.subscribersLock.Unlock()
+	delete(l.subscribers, notify)
+}
+
+func (l *resourceLister) notifySubscribers() {
+	l.subscribersLock.Lock()
+	defer l.subscribersLock.Unlock()
+	for notify := range l.subscribers {
+		// subscribers always send the latest list, so pending notifications can be dropped
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (l *resourceLister) run(ctx context.Context) {
+	go l.informer.Run(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), l.informer.HasSynced) {
 		return
 	}
 
-	resp, err := l.authenticatedRT.RoundTrip(req)
+	// the initial list is covered by this update, drop the notifications it caused
+	select {
+	case <-l.changed:
+	default:
+	}
+	l.update()
+	close(l.synced)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-l.changed:
+			l.update()
+		}
+	}
+}
+
+func (l *resourceLister) onChange() {
+	select {
+	case l.changed <- struct{}{}:
+	default:
+	}
+}
+
+// update filters the cached resources and publishes the result if it changed.
+func (l *resourceLister) update() {
+	items := l.informer.GetStore().List()
+	sort.Slice(items, func(i, j int) bool {
+		return cacheKey(items[i]) < cacheKey(items[j])
+	})
+
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{}}
+	list.SetAPIVersion(l.resource.Resource.GroupVersion().String())
+	list.SetKind(l.resource.ListKind)
+	for _, item := range items {
+		list.Items = append(list.Items, *item.(*unstructured.Unstructured))
+	}
+
+	body, err := list.MarshalJSON()
 	if err != nil {
-		serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("GET request failed: %v", err)})
+		klog.Errorf("failed to serialize %s: %v", l.resource.Resource.Resource, err)
 		return
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		err := fmt.Errorf("console service account cannot list resource: %s", resp.Status)
-		serverutils.SendResponse(w, resp.StatusCode, serverutils.ApiError{Err: err.Error()})
+	filtered := &bufferedResponseWriter{header: http.Header{}}
+	l.responseFilter(filtered, &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+	})
+
+	sum := sha256.Sum256(filtered.body.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if current := l.list.Load(); current != nil && current.etag == etag {
 		return
 	}
 
-	w.WriteHeader(resp.StatusCode)
-	l.responseFilter(w, resp)
-	resp.Body.Close()
+	l.list.Store(&resourceList{
+		status: filtered.statusCode(),
+		body:   filtered.body.Bytes(),
+		etag:   etag,
+	})
+	l.notifySubscribers()
+}
+
+func cacheKey(obj interface{}) string {
+	key, _ := cache.MetaNamespaceKeyFunc(obj)
+	return key
 }
 
-// NewResourceLister shall instantiate & return resourceLister instance
-func NewResourceLister(requestURL *url.URL, authenticatedRT http.RoundTripper, respFilter FilterFunction) ResourceLister {
-	r := &resourceLister{
-		requestURL:      requestURL,
-		authenticatedRT: authenticatedRT,
-		responseFilter:  respFilter,
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedResponseWriter captures the output of a FilterFunction.
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	// like net/http, only the first status counts
+	if b.status == 0 {
+		b.status = status
 	}
-	if r.responseFilter == nil {
-		r.responseFilter = func(w http.ResponseWriter, r *http.Response) {
+}
+
+func (b *bufferedResponseWriter) statusCode() int {
+	if b.status == 0 {
+		return http.StatusOK
+	}
+	return b.status
+}
+
+// NewResourceLister shall instantiate & return resourceLister instance. The resources are
+// listed and watched with the given client until the context is cancelled.
+func NewResourceLister(ctx context.Context, client dynamic.Interface, resource ListedResource, respFilter FilterFunction) ResourceLister {
+	resourceClient := client.Resource(resource.Resource).Namespace(resource.Namespace)
+	listWatch := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = resource.LabelSelector
+			return resourceClient.List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = resource.LabelSelector
+			return resourceClient.Watch(ctx, options)
+		},
+	}
+
+	l := &resourceLister{
+		resource:       resource,
+		informer:       cache.NewSharedIndexInformer(cache.ToListWatcherWithWatchListSemantics(listWatch, client), &unstructured.Unstructured{}, 0, cache.Indexers{}),
+		responseFilter: respFilter,
+		synced:         make(chan struct{}),
+		changed:        make(chan struct{}, 1),
+		subscribers:    map[chan struct{}]struct{}{},
+	}
+	if l.responseFilter == nil {
+		l.responseFilter = func(w http.ResponseWriter, r *http.Response) {
 			if _, err := io.Copy(w, r.Body); err != nil {
 				klog.Errorf("console service account cannot list resource: %s", err)
 				serverutils.SendResponse(w.(http.ResponseWriter), http.StatusInternalServerError, serverutils.ApiError{Err: err.Error()})
@@ -72,5 +324,16 @@ func NewResourceLister(requestURL *url.URL, authenticatedRT http.RoundTripper, r
 		}
 	}
 
-	return r
+	l.informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		klog.Errorf("failed to list and watch %s: %v", resource.Resource.Resource, err)
+		l.listErr.Store(&err)
+	})
+	l.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { l.onChange() },
+		UpdateFunc: func(interface{}, interface{}) { l.onChange() },
+		DeleteFunc: func(interface{}) { l.onChange() },
+	})
+	go l.run(ctx)
+
+	return l
 }
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var configMapResource = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+func newDashboardConfigMap(name string) *unstructured.Unstructured {
+	cm := &unstructured.Unstructured{}
+	cm.SetAPIVersion("v1")
+	cm.SetKind("ConfigMap")
+	cm.SetNamespace("openshift-config-managed")
+	cm.SetName(name)
+	cm.SetLabels(map[string]string{"console.openshift.io/dashboard": "true"})
+	return cm
+}
+
+func newTestResourceLister(t *testing.T, filter FilterFunction, objects ...runtime.Object) (*fake.FakeDynamicClient, ResourceLister) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		configMapResource: "ConfigMapList",
+	}, objects...)
+	lister := NewResourceLister(ctx, client, ListedResource{
+		Resource:      configMapResource,
+		ListKind:      "ConfigMapList",
+		Namespace:     "openshift-config-managed",
+		LabelSelector: "console.openshift.io/dashboard=true",
+	}, filter)
+	return client, lister
+}
+
+func listNames(t *testing.T, body []byte) []string {
+	list := &unstructured.UnstructuredList{}
+	require.NoError(t, list.UnmarshalJSON(body))
+	names := []string{}
+	for _, item := range list.Items {
+		names = append(names, item.GetName())
+	}
+	return names
+}
+
+func TestResourceLister(t *testing.T) {
+	var filterCalls atomic.Int32
+	filter := func(w http.ResponseWriter, r *http.Response) {
+		filterCalls.Add(1)
+		var list map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&list))
+		require.NoError(t, json.NewEncoder(w).Encode(list))
+	}
+	_, lister := newTestResourceLister(t, filter, newDashboardConfigMap("b"), newDashboardConfigMap("a"))
+
+	rr := httptest.NewRecorder()
+	lister.HandleResources(rr, httptest.NewRequest(http.MethodGet, "/api/console/monitoring-dashboard-config", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, []string{"a", "b"}, listNames(t, rr.Body.Bytes()))
+	etag := rr.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	filterCallsAfterSync := filterCalls.Load()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/console/monitoring-dashboard-config", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	lister.HandleResources(rr, req)
+	require.Equal(t, http.StatusNotModified, rr.Code)
+	require.Empty(t, rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	lister.HandleResources(rr, httptest.NewRequest(http.MethodGet, "/api/console/monitoring-dashboard-config", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, etag, rr.Header().Get("ETag"))
+	require.Equal(t, filterCallsAfterSync, filterCalls.Load(), "the filter must only run when the resources change")
+
+	rr = httptest.NewRecorder()
+	lister.HandleResources(rr, httptest.NewRequest(http.MethodPost, "/api/console/monitoring-dashboard-config", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+func TestResourceListerWatch(t *testing.T) {
+	client, lister := newTestResourceLister(t, nil, newDashboardConfigMap("a"))
+
+	server := httptest.NewServer(http.HandlerFunc(lister.HandleResources))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?watch=true")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	lines := make(chan []byte)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- append([]byte{}, scanner.Bytes()...)
+		}
+		close(lines)
+	}()
+
+	nextNames := func() []string {
+		select {
+		case line, ok := <-lines:
+			require.True(t, ok, "watch stream ended")
+			return listNames(t, line)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the watch stream")
+			return nil
+		}
+	}
+
+	require.Equal(t, []string{"a"}, nextNames())
+
+	_, err = client.Resource(configMapResource).Namespace("openshift-config-managed").Create(context.Background(), newDashboardConfigMap("b"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, nextNames())
+}
+
+func TestResourceListerSyncTimeout(t *testing.T) {
+	oldTimeout := resourceListerSyncTimeout
+	resourceListerSyncTimeout = 50 * time.Millisecond
+	defer func() { resourceListerSyncTimeout = oldTimeout }()
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		configMapResource: "ConfigMapList",
+	})
+	client.PrependReactor("list", "configmaps", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("configmaps is forbidden")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lister := NewResourceLister(ctx, client, ListedResource{Resource: configMapResource, ListKind: "ConfigMapList"}, nil)
+
+	rr := httptest.NewRecorder()
+	lister.HandleResources(rr, httptest.NewRequest(http.MethodGet, "/api/console/monitoring-dashboard-config", nil))
+	require.Equal(t, http.StatusBadGateway, rr.Code)
+}
@@ -4,17 +4,19 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"html/template"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/coreos/pkg/health"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	authv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -25,17 +27,22 @@ import (
 	"github.com/openshift/console/pkg/auth/csrfverifier"
 	"github.com/openshift/console/pkg/auth/sessions"
 	"github.com/openshift/console/pkg/crdschema"
+	"github.com/openshift/console/pkg/csp"
 	devconsole "github.com/openshift/console/pkg/devconsole"
 	"github.com/openshift/console/pkg/devfile"
 	helmhandlerspkg "github.com/openshift/console/pkg/helm/handlers"
 	"github.com/openshift/console/pkg/knative"
 	"github.com/openshift/console/pkg/middleware"
+	"github.com/openshift/console/pkg/notifications"
 	"github.com/openshift/console/pkg/olm"
 	"github.com/openshift/console/pkg/plugins"
 	"github.com/openshift/console/pkg/proxy"
+	"github.com/openshift/console/pkg/readiness"
 	"github.com/openshift/console/pkg/serverconfig"
 	"github.com/openshift/console/pkg/serverutils"
+	"github.com/openshift/console/pkg/static"
 	"github.com/openshift/console/pkg/terminal"
+	"github.com/openshift/console/pkg/tracing"
 	"github.com/openshift/console/pkg/usage"
 	"github.com/openshift/console/pkg/usersettings"
 	"github.com/openshift/console/pkg/utils"
@@ -59,16 +66,22 @@ const (
 	authLoginEndpoint                     = "/auth/login"
 	authLogoutEndpoint                    = "/api/console/logout"
 	catalogdEndpoint                      = "/api/catalogd/"
+	cspReportEndpoint                     = "/api/csp-report"
+	cspViolationsEndpoint                 = "/api/console/csp-violations"
 	customLogoEndpoint                    = "/custom-logo"
 	devfileEndpoint                       = "/api/devfile/"
+	devfileConvertEndpoint                = "/api/devfile/convert"
 	devfileSamplesEndpoint                = "/api/devfile/samples/"
 	gitopsEndpoint                        = "/api/gitops/"
+	healthDetailsEndpoint                 = "/health/details"
 	helmChartRepoProxyEndpoint            = "/api/helm/charts/"
 	indexPageTemplateName                 = "index.html"
 	k8sProxyEndpoint                      = "/api/kubernetes/"
 	knativeProxyEndpoint                  = "/api/console/knative/"
 	devConsoleEndpoint                    = "/api/dev-console/"
 	localesEndpoint                       = "/locales/resource.json"
+	notificationsEndpoint                 = "/api/console/notifications"
+	openAPIEndpoint                       = "/api/console/openapi.json"
 	packageManifestEndpoint               = "/api/check-package-manifest/"
 	operandsListEndpoint                  = "/api/list-operands/"
 	pluginAssetsEndpoint                  = "/api/plugins/"
@@ -76,10 +89,16 @@ const (
 	prometheusProxyEndpoint               = "/api/prometheus"
 	prometheusTenancyProxyEndpoint        = "/api/prometheus-tenancy"
 	copyLoginEndpoint                     = "/api/copy-login-commands"
+	serverFlagsEndpoint                   = "/api/console/server-flags"
 	sha256Prefix                          = "sha256~"
 	tokenizerPageTemplateName             = "tokener.html"
 	updatesEndpoint                       = "/api/check-updates"
 	crdSchemaEndpoint                     = "/api/console/crd-columns/"
+
+	// cspReportsPerSecond limits the unauthenticated CSP reports the console accepts
+	cspReportsPerSecond = 20
+	// pluginBackendPrefix prefixes the names of the plugin services in backendsAvailable
+	pluginBackendPrefix = "plugins/"
 )
 
 type CustomFaviconPath struct {
@@ -93,6 +112,7 @@ type jsGlobals struct {
 	AlertManagerPublicURL           string                     `json:"alertManagerPublicURL"`
 	AlertmanagerUserWorkloadBaseURL string                     `json:"alertmanagerUserWorkloadBaseURL"`
 	AuthDisabled                    bool                       `json:"authDisabled"`
+	BackendsAvailable               map[string]bool            `json:"backendsAvailable"`
 	BasePath                        string                     `json:"basePath"`
 	Branding                        string                     `json:"branding"`
 	Capabilities                    []operatorv1.Capability    `json:"capabilities"`
@@ -131,6 +151,7 @@ type jsGlobals struct {
 	PrometheusTenancyBaseURL        string                     `json:"prometheusTenancyBaseURL"`
 	QuickStarts                     string                     `json:"quickStarts"`
 	ReleaseVersion                  string                     `json:"releaseVersion"`
+	ServerFlagsVersion              string                     `json:"serverFlagsVersion"`
 	StatuspageID                    string                     `json:"statuspageID"`
 	Telemetry                       serverconfig.MultiKeyValue `json:"telemetry"`
 	ThanosPublicURL                 string                     `json:"thanosPublicURL"`
@@ -141,6 +162,8 @@ type jsGlobals struct {
 }
 
 type Server struct {
+	// AccessLog writes a JSON line for each request to stdout
+	AccessLog                           bool
 	AddPage                             string
 	AlertManagerProxyConfig             *proxy.Config
 	AlertManagerPublicURL               *url.URL
@@ -149,6 +172,7 @@ type Server struct {
 	AlertManagerUserWorkloadHost        string
 	AlertManagerUserWorkloadProxyConfig *proxy.Config
 	AnonymousInternalProxiedK8SRT       http.RoundTripper
+	APIDiscoveryCache                   *APIDiscoveryCache // created if nil
 	AuthDisabled                        bool
 	Authenticator                       auth.Authenticator
 	AuthMetrics                         *auth.Metrics
@@ -162,6 +186,7 @@ type Server struct {
 	CookieEncryptionKey                 []byte
 	CookieAuthenticationKey             []byte
 	ContentSecurityPolicy               serverconfig.MultiKeyValue
+	ContentSecurityPolicyEnforced       bool
 	ControlPlaneTopology                string
 	CopiedCSVsDisabled                  bool
 	CSRFVerifier                        *csrfverifier.CSRFVerifier
@@ -172,6 +197,7 @@ type Server struct {
 	CustomProductName                   string
 	DevCatalogCategories                string
 	DevCatalogTypes                     string
+	DevfileRegistries                   *devfile.Registries
 	DocumentationBaseURL                *url.URL
 	GitOpsProxyConfig                   *proxy.Config
 	GOARCH                              string
@@ -192,6 +218,7 @@ type Server struct {
 	MonitoringDashboardConfigMapLister  ResourceLister
 	NodeArchitectures                   []string
 	NodeOperatingSystems                []string
+	Notifications                       *notifications.Broker // created if nil
 	Perspectives                        string
 	PluginProxy                         string
 	PluginsProxyTLSConfig               *tls.Config
@@ -199,6 +226,8 @@ type Server struct {
 	PrometheusPublicURL                 *url.URL
 	PublicDir                           string
 	QuickStarts                         string
+	RateLimits                          middleware.RateLimiterConfig
+	Readiness                           *readiness.Checker // created if nil, the backends are probed once it runs
 	ReleaseVersion                      string
 	ServiceClient                       *http.Client
 	StatuspageID                        string
@@ -215,6 +244,38 @@ type Server struct {
 	EnabledPluginsOrder                 []string
 	DevConsoleProxyAvailable            bool
 	OLMHandler                          *http.Handler
+
+	// reloadedCustomization replaces the customization fields above once the config file is reloaded.
+	reloadedCustomization atomic.Pointer[serverconfig.ReloadableCustomization]
+	flags                 serverFlagsCache
+	indexPage             *pageTemplate
+	tokenizerPage         *pageTemplate
+}
+
+// UpdateCustomization replaces the customization options handed to the frontend.
+func (s *Server) UpdateCustomization(c *serverconfig.ReloadableCustomization) {
+	s.reloadedCustomization.Store(c)
+	s.invalidateServerFlags()
+	if s.Notifications != nil {
+		if err := s.Notifications.Publish(notifications.EventCustomizationReloaded, struct{}{}); err != nil {
+			klog.Errorf("failed to notify the customization reload: %v", err)
+		}
+	}
+}
+
+func (s *Server) customization() *serverconfig.ReloadableCustomization {
+	if c := s.reloadedCustomization.Load(); c != nil {
+		return c
+	}
+	return &serverconfig.ReloadableCustomization{
+		CustomProductName:         s.CustomProductName,
+		DevCatalogCategories:      s.DevCatalogCategories,
+		DevCatalogTypes:           s.DevCatalogTypes,
+		QuickStarts:               s.QuickStarts,
+		AddPage:                   s.AddPage,
+		ProjectAccessClusterRoles: s.ProjectAccessClusterRoles,
+		Perspectives:              s.Perspectives,
+	}
 }
 
 func disableDirectoryListing(handler http.Handler) http.Handler {
@@ -261,15 +322,61 @@ func (s *Server) HTTPHandler() (http.Handler, error) {
 		return nil, fmt.Errorf("failed to set up a dynamic client: %w", err)
 	}
 
+	s.indexPage = newPageTemplate(s.PublicDir, indexPageTemplateName)
+	s.tokenizerPage = newPageTemplate(s.PublicDir, tokenizerPageTemplateName)
+
 	mux := http.NewServeMux()
-	k8sProxy := proxy.NewProxy(s.K8sProxyConfig)
+	httpMetrics := middleware.NewMetrics()
+	proxyMetrics := proxy.NewMetrics()
+	var accessLogger *middleware.AccessLogger
+	if s.AccessLog {
+		accessLogger = middleware.NewAccessLogger(os.Stdout, s.ProxyHeaderDenyList)
+	}
+	k8sProxy := proxy.NewProxy(s.K8sProxyConfig).WithMetrics(proxyMetrics, "kubernetes")
 	k8sProxyURL := s.K8sProxyConfig.Endpoint.String()
+	// The bridge APIs are documented in the OpenAPI document as they are registered
+	apiDocs := serverutils.NewAPIDocs("OpenShift Console", version.Version)
+	documentAPIs(apiDocs)
+	// Routes are labelled with the path they are registered for in the metrics, the access log
+	// and the traces
 	handle := func(path string, handler http.Handler) {
+		if strings.HasPrefix(path, "/api/") && path != "/api/" {
+			apiDocs.Route(path)
+		}
+		handler = middleware.WithRequestID(middleware.WithRequestMetrics(httpMetrics, path, handler))
+		handler = tracing.WithRoute(path, handler)
+		if accessLogger != nil {
+			handler = middleware.WithAccessLog(accessLogger, path, handler)
+		}
 		mux.Handle(proxy.SingleJoiningSlash(s.BaseURL.Path, path), handler)
 	}
 
 	handleFunc := func(path string, handler http.HandlerFunc) { handle(path, handler) }
 
+	// Expensive routes take their cost from the request budget of the user. The rate limit
+	// runs after the authentication.
+	rateLimiter := middleware.NewRateLimiter(s.RateLimits)
+	rateLimitedRoutes := map[string]bool{}
+	rateLimitedMisses := func(route string, cost int) func(http.ResponseWriter, *http.Request) bool {
+		rateLimitedRoutes[route] = true
+		return func(w http.ResponseWriter, r *http.Request) bool {
+			return rateLimiter.Allow(w, r, route, cost)
+		}
+	}
+	rateLimitedWithUser := func(route string, cost int, h middleware.HandlerWithUser) middleware.HandlerWithUser {
+		rateLimitedRoutes[route] = true
+		return middleware.WithUserRateLimit(rateLimiter, route, cost, h)
+	}
+
+	// Routes of optional backends fail right away while the backend is unavailable
+	if s.Readiness == nil {
+		s.Readiness = readiness.NewChecker(readiness.DefaultInterval)
+	}
+	withBackend := func(name string, config *proxy.Config, h http.Handler) http.HandlerFunc {
+		s.Readiness.Add(name, config, "")
+		return readiness.WithBackend(s.Readiness, name, h)
+	}
+
 	fn := func(loginInfo sessions.LoginJSON, successURL string, w http.ResponseWriter) {
 		templateData := struct {
 			sessions.LoginJSON `json:",inline"`
@@ -280,17 +387,16 @@ func (s *Server) HTTPHandler() (http.Handler, error) {
 			LoginJSON:         loginInfo,
 			LoginSuccessURL:   successURL,
 			Branding:          s.Branding,
-			CustomProductName: s.CustomProductName,
+			CustomProductName: s.customization().CustomProductName,
 		}
 
-		tpl := template.New(tokenizerPageTemplateName)
-		tpl.Delims("[[", "]]")
-		tpls, err := tpl.ParseFiles(path.Join(s.PublicDir, tokenizerPageTemplateName))
+		tpl, err := s.tokenizerPage.get()
 		if err != nil {
-			klog.Fatalf("%v not found in configured public-dir path: %v", tokenizerPageTemplateName, err)
+			sendPageUnavailable(w, err)
+			return
 		}
 
-		if err := tpls.ExecuteTemplate(w, tokenizerPageTemplateName, templateData); err != nil {
+		if err := tpl.Execute(w, templateData); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 	}
@@ -300,6 +406,17 @@ func (s *Server) HTTPHandler() (http.Handler, error) {
 		return middleware.AuthMiddleware(authenticator, s.CSRFVerifier, h)
 	}
 
+	// The proxies of the API server send the requests of users authenticated by a front proxy
+	// with the token of the console service account, impersonating the user
+	kubernetesAuthHandler := func(h http.HandlerFunc) http.HandlerFunc {
+		return middleware.KubernetesAuthMiddleware(authenticator, s.CSRFVerifier, h)
+	}
+	// The proxies of other backends that authorize the token of the user, which users
+	// authenticated by a front proxy do not have
+	proxyAuthHandler := func(h http.HandlerFunc) http.HandlerFunc {
+		return authHandler(middleware.DenyImpersonatedUsers(h))
+	}
+
 	// Deprecated: Use authMiddleware and auth.GetUserFromContext instead so that we can make better
 	// use of the ServeHTTP function on the http.Handler interface, and make it easier to pass around
 	// handler functions with the correct signature.
@@ -327,8 +444,8 @@ func (s *Server) HTTPHandler() (http.Handler, error) {
 
 	handleFunc("/api/", notFoundHandler)
 
-	staticHandler := http.StripPrefix(proxy.SingleJoiningSlash(s.BaseURL.Path, "/static/"), disableDirectoryListing(http.FileServer(http.Dir(s.PublicDir))))
-	handle("/static/", middleware.WithGZIPEncoding(middleware.WithStaticCacheHeaders(staticHandler)))
+	staticHandler := http.StripPrefix(proxy.SingleJoiningSlash(s.BaseURL.Path, "/static/"), disableDirectoryListing(static.NewFileServer(s.PublicDir)))
+	handle("/static/", middleware.WithStaticCacheHeaders(staticHandler))
 
 	// Register robots.txt at the origin root so crawlers can find it at /robots.txt
 	// regardless of s.BaseURL.Path (e.g., /console/).
@@ -350,18 +467,34 @@ func (s *Server) HTTPHandler() (http.Handler, error) {
 	handleFunc("/health", health.Checker{
 		Checks: []health.Checkable{},
 	}.ServeHTTP)
+	handle(healthDetailsEndpoint, authHandlerWithUser(s.clusterAdminOnly(s.Readiness.HandleDetails)))
 
-	handle(catalogdEndpoint, s.CatalogdHandler())
+	handle(catalogdEndpoint, withBackend("catalogd", s.CatalogdProxyConfig, s.CatalogdHandler()))
 
 	handle(k8sProxyEndpoint, http.StripPrefix(
 		proxy.SingleJoiningSlash(s.BaseURL.Path, k8sProxyEndpoint),
-		authHandler(k8sProxy.ServeHTTP),
+		kubernetesAuthHandler(k8sProxy.ServeHTTP),
 	))
 
-	handle(apiDiscoveryEndpoint, middleware.WithGZIPEncoding(authHandler(apiDiscoveryHandler(k8sProxy))))
-
-	handleFunc(devfileEndpoint, devfile.DevfileHandler)
-	handleFunc(devfileSamplesEndpoint, devfile.DevfileSamplesHandler)
+	// the discovery fans out a request for each API group version
+	if s.APIDiscoveryCache == nil {
+		s.APIDiscoveryCache = NewAPIDiscoveryCache()
+	}
+	// discovery served from the cache is cheap, only the requests that discover the API are charged
+	handle(apiDiscoveryEndpoint, middleware.WithGZIPEncoding(kubernetesAuthHandler(
+		apiDiscoveryHandler(k8sProxy, s.APIDiscoveryCache, rateLimitedMisses(apiDiscoveryEndpoint, 20)),
+	)))
+
+	// the devfile routes are authenticated so that their rate limit is per user rather than
+	// per IP address, which all users behind the same router share
+	handle(devfileEndpoint, authHandlerWithUser(rateLimitedWithUser(devfileEndpoint, 10, func(_ *auth.User, w http.ResponseWriter, r *http.Request) {
+		s.DevfileRegistries.DevfileHandler(w, r)
+	})))
+	handle(devfileSamplesEndpoint, authHandlerWithUser(rateLimitedWithUser(devfileSamplesEndpoint, 5, func(_ *auth.User, w http.ResponseWriter, r *http.Request) {
+		s.DevfileRegistries.DevfileSamplesHandler(w, r)
+	})))
+	devfileConvertHandler := devfile.NewConvertHandler(s.DevfileRegistries, s.AnonymousInternalProxiedK8SRT, k8sProxyURL)
+	handle(devfileConvertEndpoint, authHandlerWithUser(rateLimitedWithUser(devfileConvertEndpoint, 10, devfileConvertHandler.HandleConvert)))
 
 	terminalProxy := terminal.NewProxy(
 		s.TerminalProxyTLSConfig,
@@ -389,24 +522,24 @@ func (s *Server) HTTPHandler() (http.Handler, error) {
 			tenancyLabelSourcePath      = prometheusTenancyProxyEndpoint + "/api/v1/label/"
 			tenancyRulesSourcePath      = prometheusTenancyProxyEndpoint + "/api/v1/rules"
 			tenancyTargetAPIPath        = prometheusTenancyProxyEndpoint + "/api/"
-			thanosProxy                 = proxy.NewProxy(s.ThanosProxyConfig)
-			thanosTenancyProxy          = proxy.NewProxy(s.ThanosTenancyProxyConfig)
-			thanosTenancyForRulesProxy  = proxy.NewProxy(s.ThanosTenancyProxyForRulesConfig)
+			thanosProxy                 = proxy.NewProxy(s.ThanosProxyConfig).WithMetrics(proxyMetrics, "thanos")
+			thanosTenancyProxy          = proxy.NewProxy(s.ThanosTenancyProxyConfig).WithMetrics(proxyMetrics, "thanos-tenancy")
+			thanosTenancyForRulesProxy  = proxy.NewProxy(s.ThanosTenancyProxyForRulesConfig).WithMetrics(proxyMetrics, "thanos-tenancy-rules")
 		)
 
 		handleThanosRequest := http.StripPrefix(
 			proxy.SingleJoiningSlash(s.BaseURL.Path, targetAPIPath),
-			authHandler(thanosProxy.ServeHTTP),
+			proxyAuthHandler(withBackend("thanos", s.ThanosProxyConfig, thanosProxy)),
 		)
 
 		handleThanosTenancyRequest := http.StripPrefix(
 			proxy.SingleJoiningSlash(s.BaseURL.Path, tenancyTargetAPIPath),
-			authHandler(thanosTenancyProxy.ServeHTTP),
+			proxyAuthHandler(withBackend("thanos-tenancy", s.ThanosTenancyProxyConfig, thanosTenancyProxy)),
 		)
 
 		handleThanosTenancyForRulesRequest := http.StripPrefix(
 			proxy.SingleJoiningSlash(s.BaseURL.Path, tenancyTargetAPIPath),
-			authHandler(thanosTenancyForRulesProxy.ServeHTTP))
+			proxyAuthHandler(withBackend("thanos-tenancy-rules", s.ThanosTenancyProxyForRulesConfig, thanosTenancyForRulesProxy)))
 
 		// global label, query, and query_range requests have to be proxied via thanos
 		handle(querySourcePath, handleThanosRequest)
@@ -436,28 +569,28 @@ func (s *Server) HTTPHandler() (http.Handler, error) {
 			alertManagerUserWorkloadProxyAPIPath = alertmanagerUserWorkloadProxyEndpoint + "/api/"
 			alertManagerTenancyProxyAPIPath      = alertManagerTenancyProxyEndpoint + "/api/"
 
-			alertManagerProxy             = proxy.NewProxy(s.AlertManagerProxyConfig)
-			alertManagerUserWorkloadProxy = proxy.NewProxy(s.AlertManagerUserWorkloadProxyConfig)
-			alertManagerTenancyProxy      = proxy.NewProxy(s.AlertManagerTenancyProxyConfig)
+			alertManagerProxy             = proxy.NewProxy(s.AlertManagerProxyConfig).WithMetrics(proxyMetrics, "alertmanager")
+			alertManagerUserWorkloadProxy = proxy.NewProxy(s.AlertManagerUserWorkloadProxyConfig).WithMetrics(proxyMetrics, "alertmanager-user-workload")
+			alertManagerTenancyProxy      = proxy.NewProxy(s.AlertManagerTenancyProxyConfig).WithMetrics(proxyMetrics, "alertmanager-tenancy")
 		)
 
 		handle(alertManagerProxyAPIPath, http.StripPrefix(
 			proxy.SingleJoiningSlash(s.BaseURL.Path, alertManagerProxyAPIPath),
-			authHandler(alertManagerProxy.ServeHTTP),
+			proxyAuthHandler(withBackend("alertmanager", s.AlertManagerProxyConfig, alertManagerProxy)),
 		))
 
 		handle(alertManagerUserWorkloadProxyAPIPath, http.StripPrefix(
 			proxy.SingleJoiningSlash(s.BaseURL.Path, alertManagerUserWorkloadProxyAPIPath),
-			authHandler(alertManagerUserWorkloadProxy.ServeHTTP),
+			proxyAuthHandler(withBackend("alertmanager-user-workload", s.AlertManagerUserWorkloadProxyConfig, alertManagerUserWorkloadProxy)),
 		))
 
 		handle(alertManagerTenancyProxyAPIPath, http.StripPrefix(
 			proxy.SingleJoiningSlash(s.BaseURL.Path, alertManagerTenancyProxyAPIPath),
-			authHandler(alertManagerTenancyProxy.ServeHTTP),
+			proxyAuthHandler(withBackend("alertmanager-tenancy", s.AlertManagerTenancyProxyConfig, alertManagerTenancyProxy)),
 		))
 	}
 
-	clusterManagementProxy := proxy.NewProxy(s.ClusterManagementProxyConfig)
+	clusterManagementProxy := proxy.NewProxy(s.ClusterManagementProxyConfig).WithMetrics(proxyMetrics, "cluster-management")
 	handle(accountManagementEndpoint, http.StripPrefix(
 		s.BaseURL.Path,
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -484,17 +617,21 @@ func (s *Server) HTTPHandler() (http.Handler, error) {
 		trimURLPrefix,
 	)
 
-	handle(knativeProxyEndpoint, authHandlerWithUser(knativeHandler.Handle))
+	handle(knativeProxyEndpoint, authHandlerWithUser(rateLimitedWithUser(knativeProxyEndpoint, 5, knativeHandler.Handle)))
 	// TODO: move the knative-event-sources and knative-channels handler into the knative module.
 	handle("/api/console/knative-event-sources", authHandler(s.handleKnativeEventSourceCRDs))
 	handle("/api/console/knative-channels", authHandler(s.handleKnativeChannelCRDs))
 
 	// Dev-Console Endpoints
+	devConsoleAnonConfig := &rest.Config{
+		Host:      k8sProxyURL,
+		Transport: s.AnonymousInternalProxiedK8SRT,
+	}
 	handle(devConsoleEndpoint, http.StripPrefix(
 		proxy.SingleJoiningSlash(s.BaseURL.Path, devConsoleEndpoint),
-		authHandlerWithUser(func(user *auth.User, w http.ResponseWriter, r *http.Request) {
-			devconsole.Handler(user, w, r, internalProxiedDynamic, s.K8sMode, s.ProxyHeaderDenyList)
-		})),
+		authHandlerWithUser(rateLimitedWithUser(devConsoleEndpoint, 5, func(user *auth.User, w http.ResponseWriter, r *http.Request) {
+			devconsole.Handler(user, w, r, internalProxiedDynamic, devConsoleAnonConfig, s.K8sMode, s.ProxyHeaderDenyList)
+		}))),
 	)
 
 	// User settings
@@ -521,21 +658,30 @@ func (s *Server) HTTPHandler() (http.Handler, error) {
 			// 120 seconds matches the webpack require timeout.
 			// Plugins are loaded asynchronously, so this doesn't block page load.
 			Timeout:   120 * time.Second,
-			Transport: &http.Transport{TLSClientConfig: s.PluginsProxyTLSConfig},
+			Transport: tracing.Transport(&http.Transport{TLSClientConfig: s.PluginsProxyTLSConfig}),
 		},
 		s.EnabledPlugins,
 		s.PublicDir,
 	)
 
-	handleFunc(localesEndpoint, func(w http.ResponseWriter, r *http.Request) {
-		pluginsHandler.HandleI18nResources(w, r)
-	})
+	// the services of the plugins are probed for their manifest, the frontend does not load the
+	// plugins whose service is unavailable
+	for name, endpoint := range s.EnabledPlugins {
+		serviceURL, err := url.Parse(endpoint)
+		if err != nil {
+			klog.Errorf("failed to parse the service URL of plugin %s: %v", name, err)
+			continue
+		}
+		s.Readiness.Add(pluginBackendPrefix+name, &proxy.Config{Endpoint: serviceURL, TLSClientConfig: s.PluginsProxyTLSConfig}, "plugin-manifest.json")
+	}
+
+	// the locales of the console are served like the static files, the ones of the plugins and
+	// the plugin assets are compressed unless the plugin services compress them
+	handleFunc(localesEndpoint, static.WithCompression(http.HandlerFunc(pluginsHandler.HandleI18nResources)))
 
 	handle(pluginAssetsEndpoint, http.StripPrefix(
 		proxy.SingleJoiningSlash(s.BaseURL.Path, pluginAssetsEndpoint),
-		authHandler(func(w http.ResponseWriter, r *http.Request) {
-			pluginsHandler.HandlePluginAssets(w, r)
-		}),
+		authHandler(static.WithCompression(http.HandlerFunc(pluginsHandler.HandlePluginAssets))),
 	))
 
 	if len(s.PluginProxy) != 0 {
@@ -552,13 +698,11 @@ func (s *Server) HTTPHandler() (http.Handler, error) {
 		}
 		for _, proxyServiceHandler := range proxyServiceHandlers {
 			klog.Infof(" - %s -> %s\n", proxyServiceHandler.ConsoleEndpoint, proxyServiceHandler.ProxyConfig.Endpoint)
-			serviceProxy := proxy.NewProxy(proxyServiceHandler.ProxyConfig)
-			f := func(w http.ResponseWriter, r *http.Request) {
-				serviceProxy.ServeHTTP(w, r)
-			}
+			serviceProxy := proxy.NewProxy(proxyServiceHandler.ProxyConfig).WithMetrics(proxyMetrics, proxyServiceHandler.Upstream())
+			f := withBackend(proxyServiceHandler.Upstream(), proxyServiceHandler.ProxyConfig, serviceProxy)
 			var h http.Handler
 			if proxyServiceHandler.Authorize {
-				h = authHandler(f)
+				h = proxyAuthHandler(f)
 			} else {
 				h = http.HandlerFunc(f)
 			}
@@ -569,25 +713,45 @@ func (s *Server) HTTPHandler() (http.Handler, error) {
 		}
 	}
 
+	consoleState := func() notifications.State {
+		return notifications.State{
+			ConsoleCommit:         os.Getenv("SOURCE_GIT_COMMIT"),
+			Plugins:               pluginsHandler.GetPluginsList(),
+			Capabilities:          s.Capabilities,
+			ContentSecurityPolicy: s.ContentSecurityPolicy.String(),
+		}
+	}
+
 	handle(updatesEndpoint, authHandler(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "GET" {
 			w.Header().Set("Allow", "GET")
 			serverutils.SendResponse(w, http.StatusMethodNotAllowed, serverutils.ApiError{Err: "Method unsupported, the only supported methods is GET"})
 			return
 		}
-		serverutils.SendResponse(w, http.StatusOK, struct {
-			ConsoleCommit         string                  `json:"consoleCommit"`
-			Plugins               []string                `json:"plugins"`
-			Capabilities          []operatorv1.Capability `json:"capabilities,omitempty"`
-			ContentSecurityPolicy string                  `json:"contentSecurityPolicy,omitempty"`
-		}{
-			ConsoleCommit:         os.Getenv("SOURCE_GIT_COMMIT"),
-			Plugins:               pluginsHandler.GetPluginsList(),
-			Capabilities:          s.Capabilities,
-			ContentSecurityPolicy: s.ContentSecurityPolicy.String(),
-		})
+		serverutils.SendResponse(w, http.StatusOK, consoleState())
 	}))
 
+	if s.Notifications == nil {
+		s.Notifications = notifications.NewBroker()
+	}
+	s.Readiness.OnChange(func(backend string, available bool) {
+		s.invalidateServerFlags()
+		if err := s.Notifications.Publish(notifications.EventBackendAvailabilityChanged, notifications.BackendAvailabilityChanged{Backend: backend, Available: available}); err != nil {
+			klog.Errorf("failed to publish the availability of %s: %v", backend, err)
+		}
+	})
+	notificationsHandler := notifications.NewHandler(s.Notifications, consoleState)
+	handle(notificationsEndpoint, authHandlerWithUser(notificationsHandler.HandleNotifications))
+
+	// CSP reports are sent by browsers without credentials
+	pluginNames := make([]string, 0, len(s.EnabledPlugins))
+	for name := range s.EnabledPlugins {
+		pluginNames = append(pluginNames, name)
+	}
+	cspCollector := csp.NewCollector(pluginNames, cspReportsPerSecond)
+	handleFunc(cspReportEndpoint, cspCollector.HandleReport)
+	handle(cspViolationsEndpoint, authHandler(cspCollector.HandleViolations))
+
 	// Metrics
 	config := &serverconfig.Config{
 		Plugins: s.EnabledPlugins,
@@ -618,6 +782,11 @@ func (s *Server) HTTPHandler() (http.Handler, error) {
 	prometheus.MustRegister(serverconfigMetrics.GetCollectors()...)
 	prometheus.MustRegister(usageMetrics.GetCollectors()...)
 	prometheus.MustRegister(s.AuthMetrics.GetCollectors()...)
+	prometheus.MustRegister(cspCollector.GetCollectors()...)
+	prometheus.MustRegister(httpMetrics.GetCollectors()...)
+	prometheus.MustRegister(proxyMetrics.GetCollectors()...)
+	prometheus.MustRegister(rateLimiter.GetCollectors()...)
+	prometheus.MustRegister(s.Readiness.GetCollectors()...)
 
 	handle("/metrics", bearerTokenReviewHandler(func(w http.ResponseWriter, r *http.Request) {
 		promhttp.Handler().ServeHTTP(w, r)
@@ -626,11 +795,11 @@ func (s *Server) HTTPHandler() (http.Handler, error) {
 		usage.Handle(usageMetrics, w, r)
 	}))
 
-	handle("/api/helm/template", authHandlerWithUser(helmHandlers.HandleHelmRenderManifests))
+	handle("/api/helm/template", authHandlerWithUser(rateLimitedWithUser("/api/helm/template", 10, helmHandlers.HandleHelmRenderManifests)))
 	handle("/api/helm/releases", authHandlerWithUser(helmHandlers.HandleHelmList))
-	handle("/api/helm/chart", authHandlerWithUser(helmHandlers.HandleChartGet))
+	handle("/api/helm/chart", authHandlerWithUser(rateLimitedWithUser("/api/helm/chart", 5, helmHandlers.HandleChartGet)))
 	handle("/api/helm/release/history", authHandlerWithUser(helmHandlers.HandleGetReleaseHistory))
-	handle("/api/helm/charts/index.yaml", authHandlerWithUser(helmHandlers.HandleIndexFile))
+	handle("/api/helm/charts/index.yaml", authHandlerWithUser(rateLimitedWithUser("/api/helm/charts/index.yaml", 10, helmHandlers.HandleIndexFile)))
 
 	handle("/api/helm/release", authHandlerWithUser(func(user *auth.User, w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
@@ -666,14 +835,16 @@ func (s *Server) HTTPHandler() (http.Handler, error) {
 
 	// GitOps proxy endpoints
 	if s.gitopsProxyEnabled() {
-		gitopsProxy := proxy.NewProxy(s.GitOpsProxyConfig)
+		gitopsProxy := proxy.NewProxy(s.GitOpsProxyConfig).WithMetrics(proxyMetrics, "gitops")
 		handle(gitopsEndpoint, http.StripPrefix(
 			proxy.SingleJoiningSlash(s.BaseURL.Path, gitopsEndpoint),
-			authHandler(gitopsProxy.ServeHTTP)),
+			proxyAuthHandler(withBackend("gitops", s.GitOpsProxyConfig, gitopsProxy))),
 		)
 	}
 
 	handle("/api/console/version", authHandler(s.versionHandler))
+	handle(serverFlagsEndpoint, authHandler(s.serverFlagsHandler))
+	handle(openAPIEndpoint, authHandler(apiDocs.ServeHTTP))
 
 	// CRD Schema
 	// NOTE: We are using the InternalProxiedK8SClientConfig service account to make the Kubernetes API request
@@ -687,7 +858,13 @@ func (s *Server) HTTPHandler() (http.Handler, error) {
 
 	mux.HandleFunc(s.BaseURL.Path, s.indexHandler)
 
-	return middleware.WithSecurityHeaders(http.Handler(mux)), nil
+	for route := range s.RateLimits.RouteCosts {
+		if !rateLimitedRoutes[route] {
+			klog.Warningf("ignoring the rate limit cost of %s, it is not a rate limited route", route)
+		}
+	}
+
+	return middleware.WithSecurityHeaders(tracing.WithServerSpans(mux)), nil
 }
 
 func (s *Server) handleMonitoringDashboardConfigmaps(w http.ResponseWriter, r *http.Request) {
@@ -713,112 +890,95 @@ func (s *Server) indexHandler(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 
+	// Support using client provided CSP reporting endpoint for testing purposes.
+	cspReportingEndpoint := r.Header.Get("Test-CSP-Reporting-Endpoint")
+	if cspReportingEndpoint == "" {
+		cspReportingEndpoint = proxy.SingleJoiningSlash(s.BaseURL.Path, cspReportEndpoint)
+	}
 	cspDirectives, err := utils.BuildCSPDirectives(
 		s.K8sMode,
 		s.ContentSecurityPolicy,
 		indexPageScriptNonce,
-		r.Header.Get("Test-CSP-Reporting-Endpoint"),
+		cspReportingEndpoint,
 	)
 	if err != nil {
 		klog.Fatalf("Error building Content Security Policy directives: %s", err)
 	}
-	w.Header().Set("Content-Security-Policy-Report-Only", strings.Join(cspDirectives, "; "))
+	w.Header().Set("Reporting-Endpoints", fmt.Sprintf("%s=%q", utils.CSPReportToGroup, cspReportingEndpoint))
+	w.Header().Set(utils.CSPHeader(s.ContentSecurityPolicyEnforced), strings.Join(cspDirectives, "; "))
 
-	jsg := &jsGlobals{
-		AddPage:                   s.AddPage,
-		AlertManagerPublicURL:     s.AlertManagerPublicURL.String(),
-		AuthDisabled:              s.Authenticator.IsStatic(),
-		BasePath:                  s.BaseURL.Path,
-		Branding:                  s.Branding,
-		Capabilities:              s.Capabilities,
-		ConsolePlugins:            s.EnabledPluginsOrder,
-		ConsoleVersion:            version.Version,
-		ControlPlaneTopology:      s.ControlPlaneTopology,
-		CopiedCSVsDisabled:        s.CopiedCSVsDisabled,
-		CustomFaviconsConfigured:  !s.CustomFaviconFiles.IsEmpty(),
-		CustomLogosConfigured:     !s.CustomLogoFiles.IsEmpty(),
-		CustomProductName:         s.CustomProductName,
-		DevCatalogCategories:      s.DevCatalogCategories,
-		DevCatalogTypes:           s.DevCatalogTypes,
-		DocumentationBaseURL:      s.DocumentationBaseURL.String(),
-		GOARCH:                    s.GOARCH,
-		GOOS:                      s.GOOS,
-		GrafanaPublicURL:          s.GrafanaPublicURL.String(),
-		I18nNamespaces:            s.I18nNamespaces,
-		InactivityTimeout:         s.InactivityTimeout,
-		K8sMode:                   s.K8sMode,
-		KubeAdminLogoutURL:        s.Authenticator.GetSpecialURLs().KubeAdminLogout,
-		KubeAPIServerURL:          s.KubeAPIServerURL,
-		LoadTestFactor:            s.LoadTestFactor,
-		LoginErrorURL:             proxy.SingleJoiningSlash(s.BaseURL.Path, AuthLoginErrorEndpoint),
-		LoginSuccessURL:           proxy.SingleJoiningSlash(s.BaseURL.Path, AuthLoginSuccessEndpoint),
-		LoginURL:                  proxy.SingleJoiningSlash(s.BaseURL.Path, authLoginEndpoint),
-		LogoutRedirect:            s.Authenticator.LogoutRedirectURL(),
-		LogoutURL:                 authLogoutEndpoint,
-		NodeArchitectures:         s.NodeArchitectures,
-		NodeOperatingSystems:      s.NodeOperatingSystems,
-		Perspectives:              s.Perspectives,
-		ProjectAccessClusterRoles: s.ProjectAccessClusterRoles,
-		PrometheusPublicURL:       s.PrometheusPublicURL.String(),
-		QuickStarts:               s.QuickStarts,
-		ReleaseVersion:            s.ReleaseVersion,
-		StatuspageID:              s.StatuspageID,
-		Telemetry:                 s.Telemetry,
-		ThanosPublicURL:           s.ThanosPublicURL.String(),
-		TechPreview:               s.TechPreview,
-		OLMLifecycleMetadata:      s.OLMLifecycleMetadata,
-		UserSettingsLocation:      s.UserSettingsLocation,
-		DevConsoleProxyAvailable:  true,
-	}
-
-	if s.prometheusProxyEnabled() {
-		jsg.PrometheusBaseURL = proxy.SingleJoiningSlash(s.BaseURL.Path, prometheusProxyEndpoint)
-		jsg.PrometheusTenancyBaseURL = proxy.SingleJoiningSlash(s.BaseURL.Path, prometheusTenancyProxyEndpoint)
+	flags, err := s.serverFlags()
+	if err != nil {
+		klog.Errorf("failed to build the server flags: %v", err)
+		http.Error(w, "Failed to build the server flags", http.StatusInternalServerError)
+		return
 	}
-
-	if s.alertManagerProxyEnabled() {
-		jsg.AlertManagerBaseURL = proxy.SingleJoiningSlash(s.BaseURL.Path, alertManagerProxyEndpoint)
-		jsg.AlertmanagerUserWorkloadBaseURL = proxy.SingleJoiningSlash(s.BaseURL.Path, alertmanagerUserWorkloadProxyEndpoint)
+	tpl, err := s.indexPage.get()
+	if err != nil {
+		sendPageUnavailable(w, err)
+		return
 	}
 
 	s.CSRFVerifier.SetCSRFCookie(s.BaseURL.Path, w)
 
-	// set the customLogoURL server flag only when there is a customLogo or customFavicon configuration
-	if !s.CustomLogoFiles.IsEmpty() || !s.CustomFaviconFiles.IsEmpty() {
-		jsg.CustomLogoURL = proxy.SingleJoiningSlash(s.BaseURL.Path, customLogoEndpoint)
-	}
-
 	templateData := struct {
 		ServerFlags *jsGlobals
 		ScriptNonce string
 	}{
-		ServerFlags: jsg,
+		ServerFlags: flags.flags,
 		ScriptNonce: indexPageScriptNonce,
 	}
 
-	tpl := template.New(indexPageTemplateName)
-	tpl.Delims("[[", "]]")
-	tpls, err := tpl.ParseFiles(path.Join(s.PublicDir, indexPageTemplateName))
-	if err != nil {
-		klog.Fatalf("index.html not found in configured public-dir path: %v", err)
-	}
-
-	if err := tpls.ExecuteTemplate(w, indexPageTemplateName, templateData); err != nil {
+	if err := tpl.Execute(w, templateData); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
+type versionResponse struct {
+	Version string `json:"version"`
+}
+
 func (s *Server) versionHandler(w http.ResponseWriter, r *http.Request) {
-	serverutils.SendResponse(w, http.StatusOK, struct {
-		Version string `json:"version"`
-	}{
-		Version: version.Version,
-	})
+	serverutils.SendResponse(w, http.StatusOK, versionResponse{Version: version.Version})
 }
 
 func notFoundHandler(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusNotFound)
-	w.Write([]byte("not found"))
+	serverutils.SendError(w, r, http.StatusNotFound, "not found")
+}
+
+// clusterAdminOnly lets only the users that can do anything on any resource, which cluster
+// admins can, run the handler. Front proxy users are checked as the user they impersonate.
+func (s *Server) clusterAdminOnly(h http.HandlerFunc) middleware.HandlerWithUser {
+	return func(user *auth.User, w http.ResponseWriter, r *http.Request) {
+		client, err := kubernetes.NewForConfig(&rest.Config{
+			Host:        s.InternalProxiedK8SClientConfig.Host,
+			Transport:   s.AnonymousInternalProxiedK8SRT,
+			BearerToken: user.Token,
+			Impersonate: user.ImpersonationConfig(),
+		})
+		if err != nil {
+			serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: fmt.Sprintf("failed to set up k8s client: %v", err)})
+			return
+		}
+		review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(r.Context(), &authv1.SelfSubjectAccessReview{
+			Spec: authv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authv1.ResourceAttributes{
+					Verb:     "*",
+					Group:    "*",
+					Resource: "*",
+				},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			serverutils.SendResponse(w, http.StatusBadGateway, serverutils.ApiError{Err: fmt.Sprintf("failed to check the privileges of the user: %v", err)})
+			return
+		}
+		if !review.Status.Allowed {
+			serverutils.SendResponse(w, http.StatusForbidden, serverutils.ApiError{Err: "only cluster administrators are allowed"})
+			return
+		}
+		h(w, r)
+	}
 }
 
 func (s *Server) handleCopyLogin(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/openshift/console/pkg/proxy"
+	"github.com/openshift/console/pkg/serverutils"
+	"github.com/openshift/console/pkg/version"
+)
+
+// serverFlags is the jsGlobals payload handed to the frontend, built once until the
+// customization is reloaded or a backend becomes available or unavailable.
+type serverFlags struct {
+	// flags must not be modified, it is shared by all requests
+	flags *jsGlobals
+	body  []byte
+	etag  string
+}
+
+type serverFlagsCache struct {
+	mu      sync.Mutex
+	current *serverFlags
+}
+
+// serverFlags returns the current server flags, building them if they are not cached.
+func (s *Server) serverFlags() (*serverFlags, error) {
+	s.flags.mu.Lock()
+	defer s.flags.mu.Unlock()
+	if s.flags.current != nil {
+		return s.flags.current, nil
+	}
+
+	jsg := s.buildServerFlags()
+	// the version is a hash of all other flags, so that it changes with any of them
+	unversioned, err := json.Marshal(jsg)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(unversioned)
+	jsg.ServerFlagsVersion = hex.EncodeToString(sum[:16])
+	body, err := json.Marshal(jsg)
+	if err != nil {
+		return nil, err
+	}
+	s.flags.current = &serverFlags{
+		flags: jsg,
+		body:  body,
+		etag:  `"` + jsg.ServerFlagsVersion + `"`,
+	}
+	return s.flags.current, nil
+}
+
+// invalidateServerFlags makes the next request build the server flags again.
+func (s *Server) invalidateServerFlags() {
+	s.flags.mu.Lock()
+	defer s.flags.mu.Unlock()
+	s.flags.current = nil
+}
+
+func (s *Server) buildServerFlags() *jsGlobals {
+	customization := s.customization()
+	jsg := &jsGlobals{
+		AddPage:                   customization.AddPage,
+		AlertManagerPublicURL:     s.AlertManagerPublicURL.String(),
+		AuthDisabled:              s.Authenticator.IsStatic(),
+		BackendsAvailable:         s.Readiness.BackendsAvailable(),
+		BasePath:                  s.BaseURL.Path,
+		Branding:                  s.Branding,
+		Capabilities:              s.Capabilities,
+		ConsolePlugins:            s.EnabledPluginsOrder,
+		ConsoleVersion:            version.Version,
+		ControlPlaneTopology:      s.ControlPlaneTopology,
+		CopiedCSVsDisabled:        s.CopiedCSVsDisabled,
+		CustomFaviconsConfigured:  !s.CustomFaviconFiles.IsEmpty(),
+		CustomLogosConfigured:     !s.CustomLogoFiles.IsEmpty(),
+		CustomProductName:         customization.CustomProductName,
+		DevCatalogCategories:      customization.DevCatalogCategories,
+		DevCatalogTypes:           customization.DevCatalogTypes,
+		DocumentationBaseURL:      s.DocumentationBaseURL.String(),
+		GOARCH:                    s.GOARCH,
+		GOOS:                      s.GOOS,
+		GrafanaPublicURL:          s.GrafanaPublicURL.String(),
+		I18nNamespaces:            s.I18nNamespaces,
+		InactivityTimeout:         s.InactivityTimeout,
+		K8sMode:                   s.K8sMode,
+		KubeAdminLogoutURL:        s.Authenticator.GetSpecialURLs().KubeAdminLogout,
+		KubeAPIServerURL:          s.KubeAPIServerURL,
+		LoadTestFactor:            s.LoadTestFactor,
+		LoginErrorURL:             proxy.SingleJoiningSlash(s.BaseURL.Path, AuthLoginErrorEndpoint),
+		LoginSuccessURL:           proxy.SingleJoiningSlash(s.BaseURL.Path, AuthLoginSuccessEndpoint),
+		LoginURL:                  proxy.SingleJoiningSlash(s.BaseURL.Path, authLoginEndpoint),
+		LogoutRedirect:            s.Authenticator.LogoutRedirectURL(),
+		LogoutURL:                 authLogoutEndpoint,
+		NodeArchitectures:         s.NodeArchitectures,
+		NodeOperatingSystems:      s.NodeOperatingSystems,
+		Perspectives:              customization.Perspectives,
+		ProjectAccessClusterRoles: customization.ProjectAccessClusterRoles,
+		PrometheusPublicURL:       s.PrometheusPublicURL.String(),
+		QuickStarts:               customization.QuickStarts,
+		ReleaseVersion:            s.ReleaseVersion,
+		StatuspageID:              s.StatuspageID,
+		Telemetry:                 s.Telemetry,
+		ThanosPublicURL:           s.ThanosPublicURL.String(),
+		TechPreview:               s.TechPreview,
+		OLMLifecycleMetadata:      s.OLMLifecycleMetadata,
+		UserSettingsLocation:      s.UserSettingsLocation,
+		DevConsoleProxyAvailable:  true,
+	}
+
+	if s.prometheusProxyEnabled() {
+		jsg.PrometheusBaseURL = proxy.SingleJoiningSlash(s.BaseURL.Path, prometheusProxyEndpoint)
+		jsg.PrometheusTenancyBaseURL = proxy.SingleJoiningSlash(s.BaseURL.Path, prometheusTenancyProxyEndpoint)
+	}
+
+	if s.alertManagerProxyEnabled() {
+		jsg.AlertManagerBaseURL = proxy.SingleJoiningSlash(s.BaseURL.Path, alertManagerProxyEndpoint)
+		jsg.AlertmanagerUserWorkloadBaseURL = proxy.SingleJoiningSlash(s.BaseURL.Path, alertmanagerUserWorkloadProxyEndpoint)
+	}
+
+	// set the customLogoURL server flag only when there is a customLogo or customFavicon configuration
+	if !s.CustomLogoFiles.IsEmpty() || !s.CustomFaviconFiles.IsEmpty() {
+		jsg.CustomLogoURL = proxy.SingleJoiningSlash(s.BaseURL.Path, customLogoEndpoint)
+	}
+	return jsg
+}
+
+// serverFlagsHandler serves the server flags the index page embeds, so that the frontend
+// and the plugins can fetch them again after the config is reloaded.
+func (s *Server) serverFlagsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		serverutils.SendResponse(w, http.StatusMethodNotAllowed, serverutils.ApiError{Err: "Method unsupported, the only supported method is GET"})
+		return
+	}
+	flags, err := s.serverFlags()
+	if err != nil {
+		serverutils.SendResponse(w, http.StatusInternalServerError, serverutils.ApiError{Err: err.Error()})
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", flags.etag)
+	if etagMatches(r.Header.Get("If-None-Match"), flags.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(flags.body)
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openshift/console/pkg/auth"
+	"github.com/openshift/console/pkg/auth/csrfverifier"
+	"github.com/openshift/console/pkg/auth/static"
+	"github.com/openshift/console/pkg/serverconfig"
+)
+
+func newTestFlagsServer(t *testing.T) *Server {
+	t.Helper()
+	dir := t.TempDir()
+	return &Server{
+		Authenticator:         static.NewStaticAuthenticator(auth.User{Username: "kube:admin"}),
+		AlertManagerPublicURL: &url.URL{},
+		BaseURL:               &url.URL{Path: "/"},
+		CSRFVerifier:          csrfverifier.NewCSRFVerifier(nil, false),
+		CustomProductName:     "Console",
+		DocumentationBaseURL:  &url.URL{},
+		GrafanaPublicURL:      &url.URL{},
+		PrometheusPublicURL:   &url.URL{},
+		PublicDir:             dir,
+		ThanosPublicURL:       &url.URL{},
+		indexPage:             newPageTemplate(dir, indexPageTemplateName),
+	}
+}
+
+func getServerFlags(t *testing.T, s *Server, ifNoneMatch string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, serverFlagsEndpoint, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	rec := httptest.NewRecorder()
+	s.serverFlagsHandler(rec, req)
+	return rec
+}
+
+func TestServerFlagsHandler(t *testing.T) {
+	s := newTestFlagsServer(t)
+
+	rec := getServerFlags(t, s, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var flags jsGlobals
+	if err := json.Unmarshal(rec.Body.Bytes(), &flags); err != nil {
+		t.Fatal(err)
+	}
+	if flags.CustomProductName != "Console" {
+		t.Errorf("expected customProductName Console, got %q", flags.CustomProductName)
+	}
+	if flags.ServerFlagsVersion == "" {
+		t.Errorf("expected a serverFlagsVersion")
+	}
+	etag := rec.Header().Get("ETag")
+	if etag != `"`+flags.ServerFlagsVersion+`"` {
+		t.Errorf("expected the ETag to be the version, got %q", etag)
+	}
+
+	rec = getServerFlags(t, s, etag)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected status 304, got %d", rec.Code)
+	}
+
+	s.UpdateCustomization(&serverconfig.ReloadableCustomization{CustomProductName: "Reloaded"})
+	rec = getServerFlags(t, s, etag)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200 once the customization is reloaded, got %d", rec.Code)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &flags); err != nil {
+		t.Fatal(err)
+	}
+	if flags.CustomProductName != "Reloaded" {
+		t.Errorf("expected customProductName Reloaded, got %q", flags.CustomProductName)
+	}
+	if rec.Header().Get("ETag") == etag {
+		t.Errorf("expected the ETag to change with the flags")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, serverFlagsEndpoint, nil)
+	rec = httptest.NewRecorder()
+	s.serverFlagsHandler(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", rec.Code)
+	}
+}
+
+func TestIndexHandler(t *testing.T) {
+	s := newTestFlagsServer(t)
+
+	rec := httptest.NewRecorder()
+	s.indexHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 without an index page, got %d", rec.Code)
+	}
+
+	index := `<script nonce="[[ .ScriptNonce ]]">window.SERVER_FLAGS = [[ .ServerFlags ]];</script>`
+	if err := os.WriteFile(filepath.Join(s.PublicDir, indexPageTemplateName), []byte(index), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	s.indexHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"customProductName":"Console"`) {
+		t.Errorf("expected the server flags in the index page, got %q", rec.Body.String())
+	}
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// pageRetryAfter is when a page that failed to load is worth requesting again, e.g. once the
+// frontend build wrote it.
+const pageRetryAfter = "10"
+
+// pageTemplate is an HTML template of the public dir that is parsed once, and again whenever
+// the file changes, e.g. when the frontend is rebuilt during development.
+type pageTemplate struct {
+	path string
+
+	mu      sync.Mutex
+	tpl     *template.Template
+	modTime time.Time
+	size    int64
+}
+
+func newPageTemplate(publicDir, name string) *pageTemplate {
+	return &pageTemplate{path: filepath.Join(publicDir, name)}
+}
+
+// get returns the parsed template. The template of the previous version of the file is kept
+// until the file parses again.
+func (t *pageTemplate) get() (*template.Template, error) {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		return nil, fmt.Errorf("%s not found in configured public-dir path: %w", filepath.Base(t.path), err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tpl != nil && info.ModTime().Equal(t.modTime) && info.Size() == t.size {
+		return t.tpl, nil
+	}
+
+	tpl, err := template.New(filepath.Base(t.path)).Delims("[[", "]]").ParseFiles(t.path)
+	if err != nil {
+		if t.tpl != nil {
+			return t.tpl, nil
+		}
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(t.path), err)
+	}
+	t.tpl, t.modTime, t.size = tpl, info.ModTime(), info.Size()
+	return tpl, nil
+}
+
+// sendPageUnavailable responds with 503 Service Unavailable to a request for a page that
+// failed to load, the bridge keeps serving the other routes.
+func sendPageUnavailable(w http.ResponseWriter, err error) {
+	klog.Errorf("failed to load page: %v", err)
+	w.Header().Set("Retry-After", pageRetryAfter)
+	http.Error(w, "The console is unavailable, try again later.", http.StatusServiceUnavailable)
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPageTemplate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, indexPageTemplateName)
+	page := newPageTemplate(dir, indexPageTemplateName)
+
+	if _, err := page.get(); err == nil {
+		t.Errorf("expected an error for a missing template")
+	}
+
+	render := func() string {
+		t.Helper()
+		tpl, err := page.get()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var out bytes.Buffer
+		if err := tpl.Execute(&out, struct{ Name string }{Name: "console"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return out.String()
+	}
+	write := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	modTime := time.Now().Add(-time.Hour)
+	write("<p>[[ .Name ]]</p>", modTime)
+	if got := render(); got != "<p>console</p>" {
+		t.Errorf("unexpected output %q", got)
+	}
+	cached, _ := page.get()
+
+	if tpl, _ := page.get(); tpl != cached {
+		t.Errorf("expected the template to be parsed once")
+	}
+
+	write("<h1>[[ .Name ]]</h1>", modTime.Add(time.Minute))
+	if got := render(); got != "<h1>console</h1>" {
+		t.Errorf("expected the changed template to be parsed again, got %q", got)
+	}
+
+	write("<h1>[[ .Name </h1>", modTime.Add(2*time.Minute))
+	if got := render(); got != "<h1>console</h1>" {
+		t.Errorf("expected the last template that parsed to be kept, got %q", got)
+	}
+
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := page.get(); err == nil {
+		t.Errorf("expected an error once the template is removed")
+	}
+}
+
+func TestSendPageUnavailable(t *testing.T) {
+	page := newPageTemplate(t.TempDir(), indexPageTemplateName)
+	_, err := page.get()
+	rec := httptest.NewRecorder()
+	sendPageUnavailable(rec, err)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != pageRetryAfter {
+		t.Errorf("expected Retry-After %s, got %q", pageRetryAfter, got)
+	}
+}
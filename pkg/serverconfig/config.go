@@ -179,7 +179,12 @@ func SetFlagsFromConfig(fs *flag.FlagSet, config *Config) (err error) {
 	}
 
 	addContentSecurityPolicy(fs, config.ContentSecurityPolicy)
+	addContentSecurityPolicyMode(fs, config.ContentSecurityPolicyMode)
 	addTelemetry(fs, config.Telemetry)
+	addTracing(fs, &config.Tracing)
+	if err := addRateLimits(fs, &config.RateLimits); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -318,6 +323,20 @@ func addMonitoringInfo(fs *flag.FlagSet, monitoring *MonitoringInfo) {
 	if monitoring.AlertmanagerTenancyHost != "" {
 		fs.Set("alermanager-tenancy-host", monitoring.AlertmanagerTenancyHost)
 	}
+	if monitoring.ThanosTokenExchange != nil {
+		tokenExchange, err := json.Marshal(monitoring.ThanosTokenExchange)
+		if err != nil {
+			klog.Fatalf("Could not marshal ConsoleConfig monitoringInfo.thanosTokenExchange field: %v", err)
+		}
+		fs.Set("thanos-token-exchange", string(tokenExchange))
+	}
+	if monitoring.AlertmanagerTokenExchange != nil {
+		tokenExchange, err := json.Marshal(monitoring.AlertmanagerTokenExchange)
+		if err != nil {
+			klog.Fatalf("Could not marshal ConsoleConfig monitoringInfo.alertmanagerTokenExchange field: %v", err)
+		}
+		fs.Set("alertmanager-token-exchange", string(tokenExchange))
+	}
 }
 
 func addCustomization(fs *flag.FlagSet, customization *Customization) {
@@ -411,6 +430,15 @@ func addCustomization(fs *flag.FlagSet, customization *Customization) {
 			fs.Set("capabilities", string(capabilities))
 		}
 	}
+
+	if customization.DevfileRegistries != nil {
+		devfileRegistries, err := json.Marshal(customization.DevfileRegistries)
+		if err != nil {
+			klog.Fatalf("Could not marshal ConsoleConfig customization.devfileRegistries field: %v", err)
+		} else {
+			fs.Set("devfile-registries", string(devfileRegistries))
+		}
+	}
 }
 
 func isAlreadySet(fs *flag.FlagSet, name string) bool {
@@ -440,6 +468,12 @@ func addContentSecurityPolicy(fs *flag.FlagSet, csp map[consolev1.DirectiveType]
 	return nil
 }
 
+func addContentSecurityPolicyMode(fs *flag.FlagSet, mode string) {
+	if mode != "" {
+		fs.Set("content-security-policy-mode", mode)
+	}
+}
+
 func getDirectiveName(directive string) string {
 	switch directive {
 	case string(consolev1.DefaultSrc):
@@ -460,6 +494,32 @@ func getDirectiveName(directive string) string {
 	}
 }
 
+func addTracing(fs *flag.FlagSet, tracing *Tracing) {
+	if tracing.OTLPEndpoint != "" {
+		fs.Set("tracing-otlp-endpoint", tracing.OTLPEndpoint)
+	}
+	if tracing.SamplingRatio != nil {
+		fs.Set("tracing-sampling-ratio", strconv.FormatFloat(*tracing.SamplingRatio, 'f', -1, 64))
+	}
+}
+
+func addRateLimits(fs *flag.FlagSet, rateLimits *RateLimits) error {
+	if rateLimits.RequestsPerSecond != nil {
+		fs.Set("rate-limit-requests-per-second", strconv.FormatFloat(*rateLimits.RequestsPerSecond, 'f', -1, 64))
+	}
+	if rateLimits.Burst != nil {
+		fs.Set("rate-limit-burst", strconv.Itoa(*rateLimits.Burst))
+	}
+	if len(rateLimits.RouteCosts) > 0 {
+		routeCosts, err := json.Marshal(rateLimits.RouteCosts)
+		if err != nil {
+			return fmt.Errorf("could not marshal ConsoleConfig rateLimits.routeCosts field: %w", err)
+		}
+		fs.Set("rate-limit-route-costs", string(routeCosts))
+	}
+	return nil
+}
+
 func addPlugins(fs *flag.FlagSet, plugins MultiKeyValue) {
 	for pluginName, pluginEndpoint := range plugins {
 		fs.Set("plugins", fmt.Sprintf("%s=%s", pluginName, pluginEndpoint))
@@ -0,0 +1,76 @@
+package serverconfig
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ReloadableCustomization holds the customization options which are only
+// handed to the frontend and can therefore be changed while the bridge is
+// running. All values are JSON strings, like the flags they correspond to.
+type ReloadableCustomization struct {
+	CustomProductName         string
+	DevCatalogCategories      string
+	DevCatalogTypes           string
+	QuickStarts               string
+	AddPage                   string
+	ProjectAccessClusterRoles string
+	Perspectives              string
+}
+
+// LoadReloadableCustomization reads the reloadable customization options from
+// a YAML config file. An error is returned if the file or any of the options is
+// invalid, in which case the options currently in use should be kept.
+func LoadReloadableCustomization(filename string) (*ReloadableCustomization, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	if err := yaml.Unmarshal(content, config); err != nil {
+		return nil, err
+	}
+	if !(config.APIVersion == "console.openshift.io/v1beta1" || config.APIVersion == "console.openshift.io/v1") || config.Kind != "ConsoleConfig" {
+		return nil, fmt.Errorf("unsupported version (apiVersion: %s, kind: %s), only console.openshift.io/v1 ConsoleConfig is supported", config.APIVersion, config.Kind)
+	}
+
+	// Use the same flag conversion as on startup, so that the options are
+	// encoded the same way no matter whether they were reloaded or not.
+	fs := flag.NewFlagSet("reload", flag.ContinueOnError)
+	c := &ReloadableCustomization{}
+	fs.StringVar(&c.CustomProductName, "custom-product-name", "", "")
+	fs.StringVar(&c.DevCatalogCategories, "developer-catalog-categories", "", "")
+	fs.StringVar(&c.DevCatalogTypes, "developer-catalog-types", "", "")
+	fs.StringVar(&c.QuickStarts, "quick-starts", "", "")
+	fs.StringVar(&c.AddPage, "add-page", "", "")
+	fs.StringVar(&c.ProjectAccessClusterRoles, "project-access-cluster-roles", "", "")
+	fs.StringVar(&c.Perspectives, "perspectives", "", "")
+	fs.Var(&LogosKeyValue{}, "custom-logo-files", "")
+	fs.Var(&LogosKeyValue{}, "custom-favicon-files", "")
+	addCustomization(fs, &config.Customization)
+
+	if _, err := validateDeveloperCatalogCategories(c.DevCatalogCategories); err != nil {
+		return nil, fmt.Errorf("invalid developer catalog categories: %w", err)
+	}
+	if _, err := validateDeveloperCatalogTypes(c.DevCatalogTypes); err != nil {
+		return nil, fmt.Errorf("invalid developer catalog types: %w", err)
+	}
+	if _, err := validateQuickStarts(c.QuickStarts); err != nil {
+		return nil, fmt.Errorf("invalid quick starts: %w", err)
+	}
+	if _, err := validateAddPage(c.AddPage); err != nil {
+		return nil, fmt.Errorf("invalid add page: %w", err)
+	}
+	if _, err := validateProjectAccessClusterRolesJSON(c.ProjectAccessClusterRoles); err != nil {
+		return nil, fmt.Errorf("invalid project access cluster roles: %w", err)
+	}
+	if _, err := validatePerspectives(c.Perspectives); err != nil {
+		return nil, fmt.Errorf("invalid perspectives: %w", err)
+	}
+
+	return c, nil
+}
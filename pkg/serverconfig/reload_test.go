@@ -0,0 +1,78 @@
+package serverconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadReloadableCustomization(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    *ReloadableCustomization
+		wantErr bool
+	}{
+		{
+			name: "customization",
+			config: `apiVersion: console.openshift.io/v1
+kind: ConsoleConfig
+customization:
+  customProductName: Example Console
+  quickStarts:
+    disabled:
+    - add-healthchecks
+  perspectives:
+  - id: dev
+    visibility:
+      state: Disabled
+`,
+			want: &ReloadableCustomization{
+				CustomProductName: "Example Console",
+				QuickStarts:       `{"disabled":["add-healthchecks"]}`,
+				AddPage:           `{}`,
+				Perspectives:      `[{"id":"dev","visibility":{"state":"Disabled"}}]`,
+			},
+		},
+		{
+			name: "invalid perspectives",
+			config: `apiVersion: console.openshift.io/v1
+kind: ConsoleConfig
+customization:
+  perspectives:
+  - visibility:
+      state: Disabled
+`,
+			wantErr: true,
+		},
+		{
+			name: "unsupported kind",
+			config: `apiVersion: console.openshift.io/v1
+kind: ConfigMap
+`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid YAML",
+			config:  `customization: [`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "console-config.yaml")
+			require.NoError(t, os.WriteFile(configFile, []byte(tt.config), 0600))
+
+			got, err := LoadReloadableCustomization(configFile)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -27,8 +27,31 @@ type Config struct {
 	I18nNamespaces        []string                             `yaml:"i18nNamespaces,omitempty"`
 	Proxy                 Proxy                                `yaml:"proxy,omitempty"`
 	ContentSecurityPolicy map[consolev1.DirectiveType][]string `yaml:"contentSecurityPolicy,omitempty"`
-	Telemetry             MultiKeyValue                        `yaml:"telemetry,omitempty"`
-	PluginsOrder          []string                             `yaml:"pluginsOrder,omitempty"`
+	// ContentSecurityPolicyMode is report-only until the policy is enforced.
+	ContentSecurityPolicyMode string        `yaml:"contentSecurityPolicyMode,omitempty"`
+	Telemetry                 MultiKeyValue `yaml:"telemetry,omitempty"`
+	PluginsOrder              []string      `yaml:"pluginsOrder,omitempty"`
+	Tracing                   Tracing       `yaml:"tracing,omitempty"`
+	RateLimits                RateLimits    `yaml:"rateLimits,omitempty"`
+}
+
+// Tracing configures the export of OpenTelemetry traces of the console server.
+type Tracing struct {
+	// OTLPEndpoint is the URL of an OTLP/HTTP collector. Tracing is disabled without it.
+	OTLPEndpoint string `yaml:"otlpEndpoint,omitempty"`
+	// SamplingRatio is the ratio of traces started by the console that are sampled, from 0 to 1.
+	SamplingRatio *float64 `yaml:"samplingRatio,omitempty"`
+}
+
+// RateLimits configures the per-user rate limit of the expensive console endpoints.
+type RateLimits struct {
+	// RequestsPerSecond is the rate the budget of a user refills at. Rate limiting is disabled if it is 0.
+	RequestsPerSecond *float64 `yaml:"requestsPerSecond,omitempty"`
+	// Burst is the largest budget of a user.
+	Burst *int `yaml:"burst,omitempty"`
+	// RouteCosts overrides what a request to a rate limited route, like /api/api-discovery, takes
+	// from the budget. A cost of 0 exempts the route.
+	RouteCosts map[string]int `yaml:"routeCosts,omitempty"`
 }
 
 type Proxy struct {
@@ -40,6 +63,21 @@ type ProxyService struct {
 	ConsoleAPIPath string `yaml:"consoleAPIPath"`
 	CACertificate  string `yaml:"caCertificate"`
 	Authorize      bool   `yaml:"authorize"`
+	// TokenExchange swaps the user's token for one issued for the service. Requires authorize.
+	TokenExchange *TokenExchange `yaml:"tokenExchange,omitempty"`
+}
+
+// TokenExchange configures RFC 8693 token exchange for requests proxied to a
+// backend that does not accept the token the user logged in with.
+type TokenExchange struct {
+	TokenURL         string   `yaml:"tokenURL"`
+	Audience         string   `yaml:"audience"`
+	Scopes           []string `yaml:"scopes,omitempty"`
+	ClientID         string   `yaml:"clientID,omitempty"`
+	ClientSecretFile string   `yaml:"clientSecretFile,omitempty"`
+	// SubjectTokenType defaults to urn:ietf:params:oauth:token-type:id_token.
+	SubjectTokenType string `yaml:"subjectTokenType,omitempty"`
+	CAFile           string `yaml:"caFile,omitempty"`
 }
 
 // ServingInfo holds configuration for serving HTTP.
@@ -68,6 +106,9 @@ type MonitoringInfo struct {
 	GrafanaPublicURL             string `yaml:"grafanaPublicURL,omitempty"`
 	PrometheusPublicURL          string `yaml:"prometheusPublicURL,omitempty"`
 	ThanosPublicURL              string `yaml:"thanosPublicURL,omitempty"`
+	// ThanosTokenExchange and AlertmanagerTokenExchange configure token exchange for the monitoring proxies.
+	ThanosTokenExchange       *TokenExchange `yaml:"thanosTokenExchange,omitempty"`
+	AlertmanagerTokenExchange *TokenExchange `yaml:"alertmanagerTokenExchange,omitempty"`
 }
 
 // ClusterInfo holds information the about the cluster such as master public URL and console public URL.
@@ -96,6 +137,33 @@ type Auth struct {
 	OAuthEndpointCAFile      string   `yaml:"oauthEndpointCAFile,omitempty"`
 	LogoutRedirect           string   `yaml:"logoutRedirect,omitempty"`
 	InactivityTimeoutSeconds int      `yaml:"inactivityTimeoutSeconds,omitempty"`
+	// OIDCProviders lists the identity providers users can choose from when authType is "oidc".
+	// It replaces oidcIssuer, clientID, clientSecretFile, oidcExtraScopes and oauthEndpointCAFile.
+	OIDCProviders []OIDCProvider `yaml:"oidcProviders,omitempty"`
+	// RequestHeader configures authentication by an identity-aware front proxy, used with authType "request-header".
+	RequestHeader RequestHeaderAuth `yaml:"requestHeader,omitempty"`
+}
+
+// OIDCProvider holds the configuration of one of several OIDC issuers the console trusts.
+type OIDCProvider struct {
+	// Name identifies the provider in the login hint, e.g. /auth/login?provider=<name>.
+	Name             string   `yaml:"name"`
+	DisplayName      string   `yaml:"displayName,omitempty"`
+	Issuer           string   `yaml:"issuer"`
+	ClientID         string   `yaml:"clientID"`
+	ClientSecretFile string   `yaml:"clientSecretFile"`
+	ExtraScopes      []string `yaml:"extraScopes,omitempty"`
+	CAFile           string   `yaml:"caFile,omitempty"`
+	LogoutRedirect   string   `yaml:"logoutRedirect,omitempty"`
+}
+
+// RequestHeaderAuth holds configuration for trusting user identities passed in request headers
+// by a front proxy that authenticates to the console with a client certificate.
+type RequestHeaderAuth struct {
+	ClientCAFile    string   `yaml:"clientCAFile,omitempty"`
+	AllowedNames    []string `yaml:"allowedNames,omitempty"`
+	UsernameHeaders []string `yaml:"usernameHeaders,omitempty"`
+	GroupHeaders    []string `yaml:"groupHeaders,omitempty"`
 }
 
 // Session holds configuration for web-session related configuration
@@ -120,6 +188,27 @@ type Customization struct {
 	Perspectives  []Perspective           `yaml:"perspectives,omitempty"`
 	Capabilities  []operatorv1.Capability `yaml:"capabilities,omitempty"`
 	Logos         []operatorv1.Logo       `yaml:"logos,omitempty"`
+	// devfileRegistries are read for devfile samples and parent devfiles, in addition to
+	// or instead of the public devfile registry.
+	DevfileRegistries []DevfileRegistry `yaml:"devfileRegistries,omitempty"`
+}
+
+// DevfileRegistry is a devfile registry configured by the cluster admin, e.g. a mirror of
+// the public registry in a disconnected cluster.
+type DevfileRegistry struct {
+	// name identifies the registry in the samples request of the frontend.
+	Name string `json:"name" yaml:"name"`
+	// url is the base URL of the registry index server.
+	URL string `json:"url" yaml:"url"`
+	// mirrorOf is the URL of a registry this registry mirrors. Parent devfiles referencing
+	// the mirrored registry are read from this registry instead.
+	MirrorOf string `json:"mirrorOf,omitempty" yaml:"mirrorOf,omitempty"`
+	// caFile is a PEM bundle of the certificate authorities that sign the certificate of
+	// the registry, in addition to the system roots.
+	CAFile string `json:"caFile,omitempty" yaml:"caFile,omitempty"`
+	// tokenFile contains a bearer token sent to the registry. It is read for every request,
+	// so that a mounted secret can be rotated.
+	TokenFile string `json:"tokenFile,omitempty" yaml:"tokenFile,omitempty"`
 }
 
 // QuickStarts contains options for ConsoleQuickStarts resource
@@ -22,6 +22,8 @@ func Validate(fs *flag.FlagSet) error {
 
 	flags.FatalIfFailed(flags.ValidateFlagIs("user-settings-location", fs.Lookup("user-settings-location").Value.String(), "configmap", "localstorage"))
 
+	flags.FatalIfFailed(flags.ValidateFlagIs("content-security-policy-mode", fs.Lookup("content-security-policy-mode").Value.String(), "report-only", "enforce"))
+
 	if _, err := validateQuickStarts(fs.Lookup("quick-starts").Value.String()); err != nil {
 		return err
 	}
@@ -46,10 +48,18 @@ func Validate(fs *flag.FlagSet) error {
 		return err
 	}
 
+	if _, err := ValidateDevfileRegistries(fs.Lookup("devfile-registries").Value.String()); err != nil {
+		return err
+	}
+
 	if _, err := validateControlPlaneTopology(fs.Lookup("control-plane-topology-mode").Value.String()); err != nil {
 		return err
 	}
 
+	if _, err := ValidateRateLimitRouteCosts(fs.Lookup("rate-limit-route-costs").Value.String()); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -161,6 +171,65 @@ func validateProjectAccessClusterRolesJSON(value string) ([]string, error) {
 	return projectAccessOptions, nil
 }
 
+// ValidateDevfileRegistries parses the devfile-registries flag.
+func ValidateDevfileRegistries(value string) ([]DevfileRegistry, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var registries []DevfileRegistry
+
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&registries); err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for registryIndex, registry := range registries {
+		if registry.Name == "" {
+			return registries, fmt.Errorf("Devfile registry at index %d must have name property.", registryIndex)
+		}
+		if names[registry.Name] {
+			return registries, fmt.Errorf("Devfile registry name %s is not unique.", registry.Name)
+		}
+		names[registry.Name] = true
+		registryURL, err := flags.ValidateFlagIsURL("devfile-registries", registry.URL, false)
+		if err != nil {
+			return registries, fmt.Errorf("Devfile registry %s must have a valid url: %v", registry.Name, err)
+		}
+		if registryURL.Scheme != "https" && registryURL.Scheme != "http" {
+			return registries, fmt.Errorf("Devfile registry %s must have an http or https url.", registry.Name)
+		}
+		if registry.MirrorOf != "" {
+			if _, err := flags.ValidateFlagIsURL("devfile-registries", registry.MirrorOf, false); err != nil {
+				return registries, fmt.Errorf("Devfile registry %s must have a valid mirrorOf url: %v", registry.Name, err)
+			}
+		}
+	}
+
+	return registries, nil
+}
+
+// ValidateRateLimitRouteCosts parses the JSON object of the rate limit costs by route.
+func ValidateRateLimitRouteCosts(value string) (map[string]int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var routeCosts map[string]int
+	if err := json.Unmarshal([]byte(value), &routeCosts); err != nil {
+		return nil, fmt.Errorf("Rate limit route costs must be a JSON object of routes and costs: %v", err)
+	}
+	for route, cost := range routeCosts {
+		if !strings.HasPrefix(route, "/") {
+			return routeCosts, fmt.Errorf("Rate limit route %q must be a path.", route)
+		}
+		if cost < 0 {
+			return routeCosts, fmt.Errorf("Rate limit cost of route %s must not be negative.", route)
+		}
+	}
+	return routeCosts, nil
+}
+
 func validatePerspectives(value string) ([]Perspective, error) {
 	if value == "" {
 		return nil, nil
@@ -328,3 +328,64 @@ func TestUnknownPropertiesForCustomLogos(t *testing.T) {
 		t.Errorf("Unexpected error: actual \n%v\n. expected \n%v\n", actualMsg2, expectedUnknownLogoThemeErr)
 	}
 }
+
+func TestValidDevfileRegistries(t *testing.T) {
+	registries, err := ValidateDevfileRegistries(`[{"name":"mirror","url":"https://devfile-registry.devfile.svc:8443","mirrorOf":"https://registry.devfile.io","caFile":"/var/devfile-registry/ca.crt","tokenFile":"/var/devfile-registry/token"},{"name":"internal","url":"http://registry.example.com"}]`)
+	if err != nil {
+		t.Error("Unexpected error when parsing devfile registries.", err)
+	}
+	expectedRegistries := []DevfileRegistry{
+		{
+			Name:      "mirror",
+			URL:       "https://devfile-registry.devfile.svc:8443",
+			MirrorOf:  "https://registry.devfile.io",
+			CAFile:    "/var/devfile-registry/ca.crt",
+			TokenFile: "/var/devfile-registry/token",
+		},
+		{
+			Name: "internal",
+			URL:  "http://registry.example.com",
+		},
+	}
+	if !reflect.DeepEqual(registries, expectedRegistries) {
+		t.Errorf("Unexpected value: actual %v, expected %v", registries, expectedRegistries)
+	}
+}
+
+func TestInvalidDevfileRegistries(t *testing.T) {
+	tests := map[string]string{
+		"missing name":     `[{"url":"https://registry.example.com"}]`,
+		"duplicate name":   `[{"name":"mirror","url":"https://a.example.com"},{"name":"mirror","url":"https://b.example.com"}]`,
+		"missing url":      `[{"name":"mirror"}]`,
+		"unsupported url":  `[{"name":"mirror","url":"oci://registry.example.com"}]`,
+		"invalid mirrorOf": `[{"name":"mirror","url":"https://registry.example.com","mirrorOf":"registry.devfile.io"}]`,
+		"unknown property": `[{"name":"mirror","url":"https://registry.example.com","insecure":true}]`,
+	}
+	for name, value := range tests {
+		if _, err := ValidateDevfileRegistries(value); err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+}
+
+func TestValidateRateLimitRouteCosts(t *testing.T) {
+	routeCosts, err := ValidateRateLimitRouteCosts(`{"/api/api-discovery": 20, "/api/devfile/": 0}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if routeCosts["/api/api-discovery"] != 20 || routeCosts["/api/devfile/"] != 0 {
+		t.Errorf("Unexpected route costs: %v", routeCosts)
+	}
+
+	tests := map[string]string{
+		"not an object":   `[20]`,
+		"not a path":      `{"api-discovery": 20}`,
+		"negative cost":   `{"/api/api-discovery": -1}`,
+		"fractional cost": `{"/api/api-discovery": 1.5}`,
+	}
+	for name, value := range tests {
+		if _, err := ValidateRateLimitRouteCosts(value); err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package serverutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// MaxRequestBodyBytes is the size limit of the JSON request bodies of the bridge APIs. It
+// leaves room for the values of large Helm charts and for devfiles.
+const MaxRequestBodyBytes = 4 << 20
+
+// RequestError is returned by DecodeRequest for a request that is rejected before it is
+// handled, like Kubernetes admission rejects an invalid object.
+type RequestError struct {
+	Status  int
+	Message string
+	Causes  []ErrorCause
+}
+
+func (e *RequestError) Error() string {
+	return e.Message
+}
+
+// DecodeRequest decodes the JSON body of a request into v and validates v, see Validate.
+// Bodies larger than MaxRequestBodyBytes, other content types than JSON, unknown fields and
+// data after the JSON value are rejected. The returned errors are *RequestError.
+func DecodeRequest(r *http.Request, v interface{}) error {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		// fetch sends string bodies as text/plain unless the caller sets a content type
+		if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") && mediaType != "text/plain") {
+			return &RequestError{
+				Status:  http.StatusUnsupportedMediaType,
+				Message: fmt.Sprintf("Unsupported content type %q, the request body must be JSON", contentType),
+			}
+		}
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, MaxRequestBodyBytes))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err == nil {
+		if _, err = decoder.Token(); err == io.EOF {
+			err = nil
+		} else if err == nil {
+			err = errors.New("unexpected data after the JSON value")
+		}
+	}
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return &RequestError{
+				Status:  http.StatusRequestEntityTooLarge,
+				Message: fmt.Sprintf("The request body is larger than %d bytes", maxBytesErr.Limit),
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			err = errors.New("the request body is empty")
+		}
+		return &RequestError{Status: http.StatusBadRequest, Message: fmt.Sprintf("Failed to parse request: %v", err)}
+	}
+
+	if errs := Validate(v); len(errs) > 0 {
+		return invalidRequestError(errs)
+	}
+	return nil
+}
+
+func invalidRequestError(errs field.ErrorList) *RequestError {
+	requestErr := &RequestError{
+		Status:  http.StatusUnprocessableEntity,
+		Message: fmt.Sprintf("Invalid request: %v", errs.ToAggregate()),
+	}
+	for _, err := range errs {
+		requestErr.Causes = append(requestErr.Causes, ErrorCause{
+			Field:   err.Field,
+			Reason:  string(err.Type),
+			Message: err.ErrorBody(),
+		})
+	}
+	return requestErr
+}
+
+// SendRequestError responds with the error of DecodeRequest.
+func SendRequestError(w http.ResponseWriter, r *http.Request, err error) {
+	var requestErr *RequestError
+	if !errors.As(err, &requestErr) {
+		SendError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	apiErr := ApiError{Err: requestErr.Message}
+	if len(requestErr.Causes) > 0 {
+		apiErr.Details = &ErrorDetails{Causes: requestErr.Causes}
+	}
+	SendAPIError(w, r, requestErr.Status, apiErr)
+}
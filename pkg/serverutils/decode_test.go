@@ -0,0 +1,144 @@
+package serverutils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testRelease struct {
+	Name      string            `json:"name" validate:"required,dns1123subdomain"`
+	Namespace string            `json:"namespace" validate:"dns1123label"`
+	ChartURL  string            `json:"chart_url" validate:"url=http|https|oci"`
+	Format    string            `json:"format" validate:"oneof=json|yaml"`
+	Values    map[string]string `json:"values"`
+	Hooks     []testHook        `json:"hooks"`
+}
+
+type testHook struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func TestDecodeRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedMsg    string
+		expectedCauses []ErrorCause
+	}{
+		{
+			name:        "valid request",
+			contentType: "application/json",
+			body:        `{"name":"my-release","namespace":"default","chart_url":"https://charts.example.com/a-1.0.0.tgz","format":"yaml"}`,
+		},
+		{
+			name:        "text/plain is accepted",
+			contentType: "text/plain;charset=UTF-8",
+			body:        `{"name":"my-release"}`,
+		},
+		{
+			name:           "unsupported content type",
+			contentType:    "application/x-www-form-urlencoded",
+			body:           `name=my-release`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "empty body",
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Failed to parse request: the request body is empty",
+		},
+		{
+			name:           "unknown field",
+			body:           `{"name":"my-release","nmespace":"default"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    `Failed to parse request: json: unknown field "nmespace"`,
+		},
+		{
+			name:           "data after the JSON value",
+			body:           `{"name":"my-release"}{"name":"other"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Failed to parse request: unexpected data after the JSON value",
+		},
+		{
+			name:           "too large",
+			body:           `{"name":"` + strings.Repeat("a", MaxRequestBodyBytes) + `"}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "invalid fields",
+			body:           `{"namespace":"My_Namespace","chart_url":"ftp://charts.example.com/a.tgz","format":"xml","hooks":[{}]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCauses: []ErrorCause{
+				{Field: "name", Reason: "FieldValueRequired", Message: "Required value"},
+				{Field: "namespace", Reason: "FieldValueInvalid", Message: `Invalid value: "My_Namespace": a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')`},
+				{Field: "chart_url", Reason: "FieldValueNotSupported", Message: `Unsupported value: "ftp://": supported values: "http://", "https://", "oci://"`},
+				{Field: "format", Reason: "FieldValueNotSupported", Message: `Unsupported value: "xml": supported values: "json", "yaml"`},
+				{Field: "hooks[0].name", Reason: "FieldValueRequired", Message: "Required value"},
+			},
+		},
+		{
+			name:           "relative URL",
+			body:           `{"name":"my-release","chart_url":"charts/a.tgz"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCauses: []ErrorCause{
+				{Field: "chart_url", Reason: "FieldValueInvalid", Message: `Invalid value: "charts/a.tgz": must be an absolute URL`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/helm/release", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			var release testRelease
+			err := DecodeRequest(req, &release)
+			if tt.expectedStatus == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var requestErr *RequestError
+			if !errors.As(err, &requestErr) {
+				t.Fatalf("expected a *RequestError, got %v", err)
+			}
+			if requestErr.Status != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, requestErr.Status)
+			}
+			if tt.expectedMsg != "" && requestErr.Message != tt.expectedMsg {
+				t.Errorf("expected message %q, got %q", tt.expectedMsg, requestErr.Message)
+			}
+			if !reflect.DeepEqual(requestErr.Causes, tt.expectedCauses) {
+				t.Errorf("expected causes %#v, got %#v", tt.expectedCauses, requestErr.Causes)
+			}
+		})
+	}
+}
+
+func TestSendRequestError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/helm/release", strings.NewReader(`{"namespace":"default"}`))
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rr := httptest.NewRecorder()
+	SendRequestError(rr, req, DecodeRequest(req, &testRelease{}))
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422, got %d", rr.Code)
+	}
+	expected := `{"error":"Invalid request: name: Required value","code":"Invalid","details":{"causes":[{"field":"name","reason":"FieldValueRequired","message":"Required value"}]},"requestID":"abc-123"}`
+	if got := rr.Body.String(); got != expected {
+		t.Errorf("expected body %s, got %s", expected, got)
+	}
+
+	rr = httptest.NewRecorder()
+	SendRequestError(rr, req, errors.New("bad request"))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", rr.Code)
+	}
+}
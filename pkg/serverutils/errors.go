@@ -0,0 +1,165 @@
+package serverutils
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RequestIDHeader is the header that identifies a request. The ID sent by a client or a
+// router in front of the console is kept, otherwise one is generated.
+const RequestIDHeader = "X-Request-Id"
+
+// The codes of the error responses of the bridge APIs. They are the reasons of the
+// Kubernetes statuses for the errors Kubernetes has a reason for.
+const (
+	ErrorCodeAlreadyExists        = string(metav1.StatusReasonAlreadyExists)
+	ErrorCodeBackendUnavailable   = "BackendUnavailable"
+	ErrorCodeBadGateway           = "BadGateway"
+	ErrorCodeBadRequest           = string(metav1.StatusReasonBadRequest)
+	ErrorCodeConflict             = string(metav1.StatusReasonConflict)
+	ErrorCodeForbidden            = string(metav1.StatusReasonForbidden)
+	ErrorCodeInternalError        = string(metav1.StatusReasonInternalError)
+	ErrorCodeInvalid              = string(metav1.StatusReasonInvalid)
+	ErrorCodeMethodNotAllowed     = string(metav1.StatusReasonMethodNotAllowed)
+	ErrorCodeNotFound             = string(metav1.StatusReasonNotFound)
+	ErrorCodeRangeNotSatisfiable  = "RangeNotSatisfiable"
+	ErrorCodeRequestTooLarge      = string(metav1.StatusReasonRequestEntityTooLarge)
+	ErrorCodeServiceUnavailable   = string(metav1.StatusReasonServiceUnavailable)
+	ErrorCodeTimeout              = string(metav1.StatusReasonTimeout)
+	ErrorCodeTooManyRequests      = string(metav1.StatusReasonTooManyRequests)
+	ErrorCodeUnauthorized         = string(metav1.StatusReasonUnauthorized)
+	ErrorCodeUnsupportedMediaType = string(metav1.StatusReasonUnsupportedMediaType)
+)
+
+var errorCodes = map[int]string{
+	http.StatusBadRequest:                   ErrorCodeBadRequest,
+	http.StatusUnauthorized:                 ErrorCodeUnauthorized,
+	http.StatusForbidden:                    ErrorCodeForbidden,
+	http.StatusNotFound:                     ErrorCodeNotFound,
+	http.StatusMethodNotAllowed:             ErrorCodeMethodNotAllowed,
+	http.StatusConflict:                     ErrorCodeConflict,
+	http.StatusUnsupportedMediaType:         ErrorCodeUnsupportedMediaType,
+	http.StatusRequestedRangeNotSatisfiable: ErrorCodeRangeNotSatisfiable,
+	http.StatusUnprocessableEntity:          ErrorCodeInvalid,
+	http.StatusTooManyRequests:              ErrorCodeTooManyRequests,
+	http.StatusInternalServerError:          ErrorCodeInternalError,
+	http.StatusBadGateway:                   ErrorCodeBadGateway,
+	http.StatusServiceUnavailable:           ErrorCodeServiceUnavailable,
+	http.StatusGatewayTimeout:               ErrorCodeTimeout,
+}
+
+// ApiError is the body of the error responses of the bridge APIs.
+type ApiError struct {
+	// Err is the message of the error
+	Err string `json:"error"`
+	// Code is the machine-readable kind of the error, one of the ErrorCode constants
+	Code      string        `json:"code,omitempty"`
+	Details   *ErrorDetails `json:"details,omitempty"`
+	RequestID string        `json:"requestID,omitempty"`
+	// Retryable is true if the same request may succeed later
+	Retryable bool `json:"retryable,omitempty"`
+}
+
+// ErrorDetails identifies the object an error is about, and the fields that caused it.
+type ErrorDetails struct {
+	Name   string       `json:"name,omitempty"`
+	Group  string       `json:"group,omitempty"`
+	Kind   string       `json:"kind,omitempty"`
+	Causes []ErrorCause `json:"causes,omitempty"`
+}
+
+type ErrorCause struct {
+	Field   string `json:"field,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// SendError responds with an error with the code of the status.
+func SendError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	SendAPIError(w, r, status, ApiError{Err: message})
+}
+
+// SendAPIError responds with the error, with the code of the status unless it has one. The
+// request ID is added, and the errors of statuses that are worth retrying are retryable.
+func SendAPIError(w http.ResponseWriter, r *http.Request, status int, apiErr ApiError) {
+	if apiErr.Code == "" {
+		apiErr.Code = ErrorCode(status)
+	}
+	if apiErr.RequestID == "" && r != nil {
+		apiErr.RequestID = r.Header.Get(RequestIDHeader)
+	}
+	apiErr.Retryable = apiErr.Retryable || retryableStatus(status)
+	SendResponse(w, status, apiErr)
+}
+
+// SendUpstreamError responds with an error a Kubernetes API, or another service the bridge
+// calls, returned. The status, code and details of Kubernetes StatusErrors are kept, timeouts
+// are 504 Gateway Timeout, and other errors are responded with the fallback status.
+func SendUpstreamError(w http.ResponseWriter, r *http.Request, fallbackStatus int, err error) {
+	status, apiErr := UpstreamError(err, fallbackStatus)
+	if seconds, ok := apierrors.SuggestsClientDelay(err); ok && seconds > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+	SendAPIError(w, r, status, apiErr)
+}
+
+// UpstreamError returns the status and the body of the response to an upstream error.
+func UpstreamError(err error, fallbackStatus int) (int, ApiError) {
+	var apiStatus apierrors.APIStatus
+	if errors.As(err, &apiStatus) && apiStatus.Status().Code != 0 {
+		status := apiStatus.Status()
+		apiErr := ApiError{
+			Err:       err.Error(),
+			Code:      string(status.Reason),
+			Details:   errorDetails(status.Details),
+			Retryable: apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) || apierrors.IsTooManyRequests(err) || apierrors.IsServiceUnavailable(err),
+		}
+		if apiErr.Code == "" || apiErr.Code == string(metav1.StatusReasonUnknown) {
+			apiErr.Code = ErrorCode(int(status.Code))
+		}
+		return int(status.Code), apiErr
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return http.StatusGatewayTimeout, ApiError{Err: err.Error(), Code: ErrorCodeTimeout, Retryable: true}
+	}
+	return fallbackStatus, ApiError{Err: err.Error(), Code: ErrorCode(fallbackStatus)}
+}
+
+// ErrorCode returns the code of the errors of a status.
+func ErrorCode(status int) string {
+	if code, ok := errorCodes[status]; ok {
+		return code
+	}
+	return strings.ReplaceAll(http.StatusText(status), " ", "")
+}
+
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+func errorDetails(details *metav1.StatusDetails) *ErrorDetails {
+	if details == nil {
+		return nil
+	}
+	errDetails := &ErrorDetails{Name: details.Name, Group: details.Group, Kind: details.Kind}
+	for _, cause := range details.Causes {
+		errDetails.Causes = append(errDetails.Causes, ErrorCause{
+			Field:   cause.Field,
+			Reason:  string(cause.Type),
+			Message: cause.Message,
+		})
+	}
+	if errDetails.Name == "" && errDetails.Group == "" && errDetails.Kind == "" && len(errDetails.Causes) == 0 {
+		return nil
+	}
+	return errDetails
+}
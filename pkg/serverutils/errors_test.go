@@ -0,0 +1,132 @@
+package serverutils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestSendError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/helm/releases", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rr := httptest.NewRecorder()
+	SendError(rr, req, http.StatusServiceUnavailable, "The catalog source is unavailable.")
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rr.Code)
+	}
+	expected := `{"error":"The catalog source is unavailable.","code":"ServiceUnavailable","requestID":"abc-123","retryable":true}`
+	if got := rr.Body.String(); got != expected {
+		t.Errorf("expected body %s, got %s", expected, got)
+	}
+
+	rr = httptest.NewRecorder()
+	SendError(rr, nil, http.StatusRequestedRangeNotSatisfiable, "invalid range")
+	expected = `{"error":"invalid range","code":"RangeNotSatisfiable"}`
+	if got := rr.Body.String(); got != expected {
+		t.Errorf("expected body %s, got %s", expected, got)
+	}
+}
+
+func TestUpstreamError(t *testing.T) {
+	configMaps := schema.GroupResource{Resource: "configmaps"}
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expected       ApiError
+	}{
+		{
+			name:           "not found",
+			err:            fmt.Errorf("Failed to get user settings: %w", apierrors.NewNotFound(configMaps, "user-settings")),
+			expectedStatus: http.StatusNotFound,
+			expected: ApiError{
+				Err:     `Failed to get user settings: configmaps "user-settings" not found`,
+				Code:    ErrorCodeNotFound,
+				Details: &ErrorDetails{Name: "user-settings", Kind: "configmaps"},
+			},
+		},
+		{
+			name:           "forbidden",
+			err:            apierrors.NewForbidden(configMaps, "user-settings", errors.New("no access")),
+			expectedStatus: http.StatusForbidden,
+			expected: ApiError{
+				Err:     `configmaps "user-settings" is forbidden: no access`,
+				Code:    ErrorCodeForbidden,
+				Details: &ErrorDetails{Name: "user-settings", Kind: "configmaps"},
+			},
+		},
+		{
+			name: "invalid",
+			err: apierrors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "user-settings", field.ErrorList{
+				field.Required(field.NewPath("data"), "is required"),
+			}),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expected: ApiError{
+				Err:  `ConfigMap "user-settings" is invalid: data: Required value: is required`,
+				Code: ErrorCodeInvalid,
+				Details: &ErrorDetails{Name: "user-settings", Kind: "ConfigMap", Causes: []ErrorCause{
+					{Field: "data", Reason: "FieldValueRequired", Message: "Required value: is required"},
+				}},
+			},
+		},
+		{
+			name:           "too many requests",
+			err:            apierrors.NewTooManyRequests("slow down", 5),
+			expectedStatus: http.StatusTooManyRequests,
+			expected:       ApiError{Err: "slow down", Code: ErrorCodeTooManyRequests, Retryable: true},
+		},
+		{
+			name:           "timeout",
+			err:            fmt.Errorf("Failed to list helm releases: %w", context.DeadlineExceeded),
+			expectedStatus: http.StatusGatewayTimeout,
+			expected:       ApiError{Err: "Failed to list helm releases: context deadline exceeded", Code: ErrorCodeTimeout, Retryable: true},
+		},
+		{
+			name:           "other errors",
+			err:            errors.New("Chart path is invalid"),
+			expectedStatus: http.StatusBadGateway,
+			expected:       ApiError{Err: "Chart path is invalid", Code: ErrorCodeBadGateway},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, apiErr := UpstreamError(tt.err, http.StatusBadGateway)
+			if status != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, status)
+			}
+			if !reflect.DeepEqual(apiErr, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, apiErr)
+			}
+		})
+	}
+}
+
+func TestSendUpstreamError(t *testing.T) {
+	rr := httptest.NewRecorder()
+	SendUpstreamError(rr, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusBadGateway, apierrors.NewTooManyRequests("slow down", 5))
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status 429, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "5" {
+		t.Errorf("expected Retry-After 5, got %q", got)
+	}
+	var apiErr ApiError
+	if err := json.Unmarshal(rr.Body.Bytes(), &apiErr); err != nil {
+		t.Fatal(err)
+	}
+	if !apiErr.Retryable {
+		t.Errorf("expected the error to be retryable")
+	}
+}
@@ -0,0 +1,396 @@
+package serverutils
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const openAPIVersion = "3.0.3"
+
+// The formats of the DNS names, that apimachinery does not export.
+const (
+	dns1035LabelFmt     = "[a-z]([-a-z0-9]*[a-z0-9])?"
+	dns1123LabelFmt     = "[a-z0-9]([-a-z0-9]*[a-z0-9])?"
+	dns1123SubdomainFmt = dns1123LabelFmt + "(\\." + dns1123LabelFmt + ")*"
+)
+
+// Operation documents a method of a bridge API. The {parameters} of the route of the
+// operation are path parameters.
+type Operation struct {
+	Method     string
+	Summary    string
+	Deprecated bool
+	Query      []Parameter
+	// Request is a value of the type of the JSON request body, nil without a body
+	Request interface{}
+	// Response is a value of the type of the response body, nil if the response is not JSON
+	Response interface{}
+	// ResponseStatus defaults to 200 OK
+	ResponseStatus int
+	// ResponseContentType defaults to application/json
+	ResponseContentType string
+}
+
+// Parameter is a query parameter of an operation.
+type Parameter struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// APIDocs collects the bridge APIs and describes them as an OpenAPI document. The schemas of
+// the request and response bodies are generated from the types of the operations, the
+// validate tags of the request types become constraints of the schemas, see Validate.
+type APIDocs struct {
+	title   string
+	version string
+
+	mu         sync.Mutex
+	operations map[string][]Operation
+	document   []byte
+}
+
+func NewAPIDocs(title, version string) *APIDocs {
+	return &APIDocs{title: title, version: version, operations: map[string][]Operation{}}
+}
+
+// Route adds a route. Routes that end with a slash are marked with x-path-prefix, they serve
+// all paths under them.
+func (d *APIDocs) Route(route string) {
+	d.Operations(route)
+}
+
+// Operations adds the operations of a route.
+func (d *APIDocs) Operations(route string, operations ...Operation) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.operations[route] = append(d.operations[route], operations...)
+	d.document = nil
+}
+
+// Document returns the OpenAPI document.
+func (d *APIDocs) Document() ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.document != nil {
+		return d.document, nil
+	}
+
+	g := &schemaGenerator{schemas: map[string]openAPISchema{}, names: map[reflect.Type]string{}}
+	errorSchema := g.schema(reflect.TypeOf(ApiError{}), nil)
+	routes := make([]string, 0, len(d.operations))
+	for route := range d.operations {
+		routes = append(routes, route)
+	}
+	// the schemas are generated in the same order every time, so that they get the same names
+	sort.Strings(routes)
+	paths := map[string]openAPIPathItem{}
+	for _, route := range routes {
+		operations := d.operations[route]
+		item := openAPIPathItem{}
+		if strings.HasSuffix(route, "/") {
+			item["x-path-prefix"] = true
+		}
+		if parameters := pathParameters(route); len(parameters) > 0 {
+			item["parameters"] = parameters
+		}
+		for _, op := range operations {
+			item[strings.ToLower(op.Method)] = g.operation(op, errorSchema)
+		}
+		paths[route] = item
+	}
+
+	document, err := json.Marshal(map[string]interface{}{
+		"openapi":    openAPIVersion,
+		"info":       map[string]string{"title": d.title, "version": d.version},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": g.schemas},
+	})
+	if err != nil {
+		return nil, err
+	}
+	d.document = document
+	return document, nil
+}
+
+// ServeHTTP serves the OpenAPI document.
+func (d *APIDocs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		SendError(w, r, http.StatusMethodNotAllowed, "Method unsupported, the only supported method is GET")
+		return
+	}
+	document, err := d.Document()
+	if err != nil {
+		SendError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(document)
+}
+
+type openAPISchema map[string]interface{}
+
+type openAPIPathItem map[string]interface{}
+
+type openAPIParameter struct {
+	Name        string        `json:"name"`
+	In          string        `json:"in"`
+	Description string        `json:"description,omitempty"`
+	Required    bool          `json:"required,omitempty"`
+	Schema      openAPISchema `json:"schema"`
+}
+
+var (
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	// knownSchemas are the types that marshal themselves to JSON with a known schema
+	knownSchemas = map[reflect.Type]openAPISchema{
+		reflect.TypeOf(time.Time{}):        {"type": "string", "format": "date-time"},
+		reflect.TypeOf(metav1.Time{}):      {"type": "string", "format": "date-time"},
+		reflect.TypeOf(metav1.MicroTime{}): {"type": "string", "format": "date-time"},
+		reflect.TypeOf(json.RawMessage{}):  {},
+		reflect.TypeOf(metav1.Duration{}):  {"type": "string"},
+	}
+)
+
+// schemaGenerator generates the schemas of Go types, structs are components of the document
+// that are referenced by their package and name.
+type schemaGenerator struct {
+	schemas map[string]openAPISchema
+	names   map[reflect.Type]string
+}
+
+func (g *schemaGenerator) operation(op Operation, errorSchema openAPISchema) map[string]interface{} {
+	operation := map[string]interface{}{}
+	if op.Summary != "" {
+		operation["summary"] = op.Summary
+	}
+	if op.Deprecated {
+		operation["deprecated"] = true
+	}
+	var parameters []openAPIParameter
+	for _, p := range op.Query {
+		parameters = append(parameters, openAPIParameter{
+			Name:        p.Name,
+			In:          "query",
+			Description: p.Description,
+			Required:    p.Required,
+			Schema:      openAPISchema{"type": "string"},
+		})
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+	if op.Request != nil {
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(op.Request), nil)}},
+		}
+	}
+
+	status := op.ResponseStatus
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := map[string]interface{}{"description": http.StatusText(status)}
+	contentType := op.ResponseContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	if op.Response != nil {
+		response["content"] = map[string]interface{}{contentType: map[string]interface{}{"schema": g.schema(reflect.TypeOf(op.Response), nil)}}
+	} else if op.ResponseContentType != "" {
+		response["content"] = map[string]interface{}{contentType: map[string]interface{}{"schema": openAPISchema{"type": "string"}}}
+	}
+	operation["responses"] = map[string]interface{}{
+		strconv.Itoa(status): response,
+		"default": map[string]interface{}{
+			"description": "Error",
+			"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": errorSchema}},
+		},
+	}
+	return operation
+}
+
+func pathParameters(route string) []openAPIParameter {
+	var parameters []openAPIParameter
+	for _, segment := range strings.Split(route, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			parameters = append(parameters, openAPIParameter{
+				Name:     strings.Trim(segment, "{}"),
+				In:       "path",
+				Required: true,
+				Schema:   openAPISchema{"type": "string"},
+			})
+		}
+	}
+	return parameters
+}
+
+// schema returns the schema of a type, with the constraints of the rules of the field the
+// type is the type of.
+func (g *schemaGenerator) schema(t reflect.Type, rules []rule) openAPISchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if known, ok := knownSchemas[t]; ok {
+		return withRules(known, rules)
+	}
+	if t.Kind() == reflect.Interface {
+		return openAPISchema{}
+	}
+	if implements(t, textMarshaler) {
+		return withRules(openAPISchema{"type": "string"}, rules)
+	}
+	if implements(t, jsonMarshaler) {
+		// the JSON of the type is up to the type
+		return openAPISchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return openAPISchema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return openAPISchema{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64, reflect.Uintptr:
+		return openAPISchema{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return openAPISchema{"type": "number"}
+	case reflect.String:
+		return withRules(openAPISchema{"type": "string"}, rules)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return openAPISchema{"type": "string", "format": "byte"}
+		}
+		return openAPISchema{"type": "array", "items": g.schema(t.Elem(), nil)}
+	case reflect.Map:
+		return openAPISchema{"type": "object", "additionalProperties": g.schema(t.Elem(), nil)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return openAPISchema{"$ref": "#/components/schemas/" + g.component(t)}
+	}
+	return openAPISchema{}
+}
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// component adds the schema of a struct to the components, and returns its name.
+func (g *schemaGenerator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := componentName(t, false)
+	if _, taken := g.schemas[name]; taken {
+		name = componentName(t, true)
+	}
+	// the name is taken before the fields are generated, for the types that contain themselves
+	g.names[t] = name
+	g.schemas[name] = nil
+	g.schemas[name] = g.object(t)
+	return name
+}
+
+func (g *schemaGenerator) object(t reflect.Type) openAPISchema {
+	schema := openAPISchema{"type": "object"}
+	properties := map[string]openAPISchema{}
+	var required []string
+	g.properties(t, properties, &required)
+	if len(properties) > 0 {
+		schema["properties"] = properties
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (g *schemaGenerator) properties(t reflect.Type, properties map[string]openAPISchema, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, inline := jsonFieldName(f)
+		if name == "-" {
+			continue
+		}
+		if inline {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			g.properties(embedded, properties, required)
+			continue
+		}
+		rules := parseRules(f.Tag.Get("validate"))
+		for _, r := range rules {
+			if r.name == ruleRequired {
+				*required = append(*required, name)
+			}
+		}
+		properties[name] = g.schema(f.Type, rules)
+	}
+}
+
+// componentName names a struct by its package and name, or by the full path of its package
+// if another struct of a package of the same name took the short name.
+func componentName(t reflect.Type, qualified bool) string {
+	pkg := path.Base(t.PkgPath())
+	if qualified {
+		pkg = strings.ReplaceAll(t.PkgPath(), "/", ".")
+	}
+	name := t.Name()
+	// the names of instances of generic types contain brackets and the type arguments
+	name = strings.NewReplacer("[", "_", "]", "", "/", ".", "*", "").Replace(name)
+	return pkg + "." + name
+}
+
+// withRules adds the constraints of validate rules to a schema.
+func withRules(schema openAPISchema, rules []rule) openAPISchema {
+	if len(rules) == 0 {
+		return schema
+	}
+	constrained := openAPISchema{}
+	for k, v := range schema {
+		constrained[k] = v
+	}
+	for _, r := range rules {
+		switch r.name {
+		case ruleDNS1035Label:
+			constrained["pattern"] = "^" + dns1035LabelFmt + "$"
+			constrained["maxLength"] = validation.DNS1035LabelMaxLength
+		case ruleDNS1123Label:
+			constrained["pattern"] = "^" + dns1123LabelFmt + "$"
+			constrained["maxLength"] = validation.DNS1123LabelMaxLength
+		case ruleDNS1123Subdomain:
+			constrained["pattern"] = "^" + dns1123SubdomainFmt + "$"
+			constrained["maxLength"] = validation.DNS1123SubdomainMaxLength
+		case ruleURL:
+			constrained["format"] = "uri"
+			if len(r.values) > 0 {
+				constrained["pattern"] = "^(" + strings.Join(r.values, "|") + ")://"
+			}
+		case ruleOneOf:
+			constrained["enum"] = r.values
+		}
+	}
+	return constrained
+}
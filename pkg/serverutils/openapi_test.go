@@ -0,0 +1,121 @@
+package serverutils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type testTree struct {
+	Name     string      `json:"name" validate:"required,dns1123label"`
+	Children []*testTree `json:"children,omitempty"`
+}
+
+func TestAPIDocsDocument(t *testing.T) {
+	docs := NewAPIDocs("Console", "4.99")
+	docs.Route("/api/kubernetes/")
+	docs.Operations("/api/helm/release",
+		Operation{Method: http.MethodPost, Summary: "Install", Request: testRelease{}, Response: testTree{}, ResponseStatus: http.StatusCreated},
+		Operation{Method: http.MethodDelete, Query: []Parameter{{Name: "ns", Required: true}}, Deprecated: true},
+	)
+	docs.Operations("/api/namespaces/{namespace}/invoke", Operation{Method: http.MethodPost, ResponseContentType: "text/yaml"})
+
+	data, err := docs.Document()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var document struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage        `json:"paths"`
+		Components map[string]map[string]map[string]interface{} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	if document.OpenAPI != openAPIVersion {
+		t.Errorf("expected openapi %s, got %s", openAPIVersion, document.OpenAPI)
+	}
+
+	var prefix bool
+	json.Unmarshal(document.Paths["/api/kubernetes/"]["x-path-prefix"], &prefix)
+	if !prefix {
+		t.Errorf("expected the prefix route to be marked with x-path-prefix")
+	}
+
+	var parameters []map[string]interface{}
+	json.Unmarshal(document.Paths["/api/namespaces/{namespace}/invoke"]["parameters"], &parameters)
+	if len(parameters) != 1 || parameters[0]["name"] != "namespace" || parameters[0]["in"] != "path" {
+		t.Errorf("expected the namespace path parameter, got %v", parameters)
+	}
+
+	var install struct {
+		RequestBody struct {
+			Content map[string]struct {
+				Schema map[string]string `json:"schema"`
+			} `json:"content"`
+		} `json:"requestBody"`
+		Responses map[string]json.RawMessage `json:"responses"`
+	}
+	json.Unmarshal(document.Paths["/api/helm/release"]["post"], &install)
+	if ref := install.RequestBody.Content["application/json"].Schema["$ref"]; ref != "#/components/schemas/serverutils.testRelease" {
+		t.Errorf("expected the request to reference serverutils.testRelease, got %q", ref)
+	}
+	if _, ok := install.Responses["201"]; !ok {
+		t.Errorf("expected a 201 response, got %v", install.Responses)
+	}
+	if _, ok := install.Responses["default"]; !ok {
+		t.Errorf("expected a default error response, got %v", install.Responses)
+	}
+
+	var uninstall map[string]interface{}
+	json.Unmarshal(document.Paths["/api/helm/release"]["delete"], &uninstall)
+	if uninstall["deprecated"] != true {
+		t.Errorf("expected the operation to be deprecated, got %v", uninstall)
+	}
+
+	schemas := document.Components["schemas"]
+	release, _ := json.Marshal(schemas["serverutils.testRelease"])
+	expected := `{"properties":{` +
+		`"chart_url":{"format":"uri","pattern":"^(http|https|oci)://","type":"string"},` +
+		`"format":{"enum":["json","yaml"],"type":"string"},` +
+		`"hooks":{"items":{"$ref":"#/components/schemas/serverutils.testHook"},"type":"array"},` +
+		`"name":{"maxLength":253,"pattern":"^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$","type":"string"},` +
+		`"namespace":{"maxLength":63,"pattern":"^[a-z0-9]([-a-z0-9]*[a-z0-9])?$","type":"string"},` +
+		`"values":{"additionalProperties":{"type":"string"},"type":"object"}},` +
+		`"required":["name"],"type":"object"}`
+	if string(release) != expected {
+		t.Errorf("expected schema %s, got %s", expected, release)
+	}
+
+	tree := schemas["serverutils.testTree"]
+	children := tree["properties"].(map[string]interface{})["children"]
+	expectedChildren := map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/components/schemas/serverutils.testTree"}}
+	if !reflect.DeepEqual(children, expectedChildren) {
+		t.Errorf("expected the children to reference the tree, got %v", children)
+	}
+	if _, ok := schemas["serverutils.ApiError"]; !ok {
+		t.Errorf("expected the error schema, got %v", schemas)
+	}
+}
+
+func TestAPIDocsServeHTTP(t *testing.T) {
+	docs := NewAPIDocs("Console", "4.99")
+	docs.Route("/api/kubernetes/")
+
+	rr := httptest.NewRecorder()
+	docs.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/console/openapi.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", rr.Code)
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("expected content type application/json, got %s", contentType)
+	}
+
+	rr = httptest.NewRecorder()
+	docs.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/console/openapi.json", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", rr.Code)
+	}
+}
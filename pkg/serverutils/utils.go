@@ -83,7 +83,3 @@ func ModifiedSince(r *http.Request, lastModified string) (bool, error) {
 
 	return lastModifiedTime.After(ifModifiedSinceTime), nil
 }
-
-type ApiError struct {
-	Err string `json:"error"`
-}
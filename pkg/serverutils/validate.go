@@ -0,0 +1,174 @@
+package serverutils
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// The rules of the validate struct tags, separated by commas:
+//
+//	required          the field is set
+//	dns1035label      a DNS-1035 label, like the names of services
+//	dns1123label      a DNS-1123 label, like the names of namespaces
+//	dns1123subdomain  a DNS-1123 subdomain, like the names of most objects and Helm releases
+//	url=http|https    an absolute URL with one of the schemes
+//	oneof=a|b         one of the values
+//
+// Rules other than required only apply to fields that are set.
+const (
+	ruleRequired         = "required"
+	ruleDNS1035Label     = "dns1035label"
+	ruleDNS1123Label     = "dns1123label"
+	ruleDNS1123Subdomain = "dns1123subdomain"
+	ruleURL              = "url"
+	ruleOneOf            = "oneof"
+)
+
+type rule struct {
+	name   string
+	values []string
+}
+
+func parseRules(tag string) []rule {
+	var rules []rule
+	for _, r := range strings.Split(tag, ",") {
+		if r = strings.TrimSpace(r); r == "" {
+			continue
+		}
+		name, values, _ := strings.Cut(r, "=")
+		parsed := rule{name: name}
+		if values != "" {
+			parsed.values = strings.Split(values, "|")
+		}
+		rules = append(rules, parsed)
+	}
+	return rules
+}
+
+// Validate checks the fields of a struct against the rules of their validate tags. The
+// fields of nested structs, and of the structs in slices and maps, are checked too. Fields
+// are named by their JSON names in the errors.
+func Validate(v interface{}) field.ErrorList {
+	return validateValue(reflect.ValueOf(v), nil)
+}
+
+func validateValue(v reflect.Value, path *field.Path) field.ErrorList {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	var errs field.ErrorList
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, inline := jsonFieldName(f)
+			if name == "-" {
+				continue
+			}
+			fieldPath := path
+			if !inline {
+				fieldPath = path.Child(name)
+			}
+			errs = append(errs, validateField(v.Field(i), fieldPath, parseRules(f.Tag.Get("validate")))...)
+			errs = append(errs, validateValue(v.Field(i), fieldPath)...)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			errs = append(errs, validateValue(v.Index(i), path.Index(i))...)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			errs = append(errs, validateValue(iter.Value(), path.Key(fmt.Sprint(iter.Key().Interface())))...)
+		}
+	}
+	return errs
+}
+
+func validateField(v reflect.Value, path *field.Path, rules []rule) field.ErrorList {
+	if len(rules) == 0 {
+		return nil
+	}
+	if v.IsZero() {
+		if slices.ContainsFunc(rules, func(r rule) bool { return r.name == ruleRequired }) {
+			return field.ErrorList{field.Required(path, "")}
+		}
+		return nil
+	}
+
+	var errs field.ErrorList
+	for _, r := range rules {
+		switch r.name {
+		case ruleRequired:
+		case ruleDNS1035Label:
+			for _, msg := range validation.IsDNS1035Label(v.String()) {
+				errs = append(errs, field.Invalid(path, v.String(), msg))
+			}
+		case ruleDNS1123Label:
+			for _, msg := range validation.IsDNS1123Label(v.String()) {
+				errs = append(errs, field.Invalid(path, v.String(), msg))
+			}
+		case ruleDNS1123Subdomain:
+			for _, msg := range validation.IsDNS1123Subdomain(v.String()) {
+				errs = append(errs, field.Invalid(path, v.String(), msg))
+			}
+		case ruleURL:
+			u, err := url.Parse(v.String())
+			if err != nil || !u.IsAbs() {
+				errs = append(errs, field.Invalid(path, v.String(), "must be an absolute URL"))
+			} else if len(r.values) > 0 && !slices.Contains(r.values, u.Scheme) {
+				errs = append(errs, field.NotSupported(path, u.Scheme+"://", schemes(r.values)))
+			} else if u.Host == "" {
+				errs = append(errs, field.Invalid(path, v.String(), "must have a host"))
+			}
+		case ruleOneOf:
+			if !slices.Contains(r.values, v.String()) {
+				errs = append(errs, field.NotSupported(path, v.String(), r.values))
+			}
+		default:
+			errs = append(errs, field.InternalError(path, fmt.Errorf("unknown validation rule %q", r.name)))
+		}
+	}
+	return errs
+}
+
+func schemes(values []string) []string {
+	s := make([]string, len(values))
+	for i, scheme := range values {
+		s[i] = scheme + "://"
+	}
+	return s
+}
+
+// jsonFieldName returns the JSON name of a struct field, and whether the fields of the
+// field are inlined into its parent like encoding/json does with embedded structs.
+func jsonFieldName(f reflect.StructField) (string, bool) {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" && f.Anonymous {
+		t := f.Type
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			return "", true
+		}
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, false
+}
@@ -0,0 +1,240 @@
+package static
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"container/list"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+type compressedKey struct {
+	name     string
+	encoding string
+}
+
+// compressedEntry is a file compressed on the fly. The content is nil if the file does not get
+// smaller, so that it is not compressed again.
+type compressedEntry struct {
+	key     compressedKey
+	modTime time.Time
+	size    int64
+	content []byte
+	etag    string
+}
+
+func (e *compressedEntry) orNil() *compressedEntry {
+	if e.content == nil {
+		return nil
+	}
+	return e
+}
+
+// compressedCache keeps the most recently used compressed files up to a total size.
+type compressedCache struct {
+	mu      sync.Mutex
+	maxSize int
+	size    int
+	lru     *list.List
+	entries map[compressedKey]*list.Element
+}
+
+func newCompressedCache(maxSize int) *compressedCache {
+	return &compressedCache{
+		maxSize: maxSize,
+		lru:     list.New(),
+		entries: map[compressedKey]*list.Element{},
+	}
+}
+
+// get returns the entry of the key if it was compressed from the current version of the file.
+func (c *compressedCache) get(key compressedKey, info fs.FileInfo) (*compressedEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*compressedEntry)
+	if !entry.modTime.Equal(info.ModTime()) || entry.size != info.Size() {
+		c.remove(element)
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return entry, true
+}
+
+func (c *compressedCache) add(entry *compressedEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[entry.key]; ok {
+		c.remove(element)
+	}
+	if len(entry.content) > c.maxSize {
+		return
+	}
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	c.size += len(entry.content)
+	for c.size > c.maxSize {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *compressedCache) remove(element *list.Element) {
+	entry := c.lru.Remove(element).(*compressedEntry)
+	delete(c.entries, entry.key)
+	c.size -= len(entry.content)
+}
+
+// compress compresses a file once for the cache, so it trades speed for size.
+func compress(encoding string, content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch encoding {
+	case encodingGzip:
+		w, err = gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	case encodingZstd:
+		w, err = zstd.NewWriter(&buf, zstd.WithEncoderLevel(zstd.SpeedBetterCompression), zstd.WithEncoderConcurrency(1))
+	default:
+		err = fmt.Errorf("unsupported encoding %q", encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var (
+	gzipWriters = sync.Pool{New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return w
+	}}
+	zstdWriters = sync.Pool{New: func() interface{} {
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return w
+	}}
+)
+
+// WithCompression compresses the responses of a handler that serves content it does not
+// control, like the assets of the dynamic plugins, with the coding the client prefers.
+// Responses that are compressed already, partial or not compressible are left as they are.
+func WithCompression(h http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		addVary(w.Header(), "Accept-Encoding")
+		encoding := negotiate(r.Header.Get("Accept-Encoding"), dynamicEncodings)
+		if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressResponseWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.finish()
+		h.ServeHTTP(cw, r)
+	}
+}
+
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding    string
+	wroteHeader bool
+	writer      io.Writer
+	closer      func()
+}
+
+func (w *compressResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if w.shouldCompress(code) {
+		header := w.Header()
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		header.Del("Accept-Ranges")
+		// the compressed response is another representation, so it needs another ETag
+		if etag := header.Get("ETag"); strings.HasSuffix(etag, `"`) {
+			header.Set("ETag", strings.TrimSuffix(etag, `"`)+"-"+w.encoding+`"`)
+		}
+		switch w.encoding {
+		case encodingGzip:
+			gz := gzipWriters.Get().(*gzip.Writer)
+			gz.Reset(w.ResponseWriter)
+			w.writer = gz
+			w.closer = func() {
+				gz.Close()
+				gzipWriters.Put(gz)
+			}
+		case encodingZstd:
+			zw := zstdWriters.Get().(*zstd.Encoder)
+			zw.Reset(w.ResponseWriter)
+			w.writer = zw
+			w.closer = func() {
+				zw.Close()
+				zstdWriters.Put(zw)
+			}
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// finish writes the end of the compressed stream.
+func (w *compressResponseWriter) finish() {
+	if w.closer != nil {
+		w.closer()
+	}
+}
+
+func (w *compressResponseWriter) shouldCompress(code int) bool {
+	header := w.Header()
+	if code != http.StatusOK || header.Get("Content-Encoding") != "" || !compressible(header.Get("Content-Type")) {
+		return false
+	}
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	return err != nil || length >= minCompressSize
+}
+
+func (w *compressResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.writer != nil {
+		return w.writer.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressResponseWriter) Flush() {
+	if f, ok := w.writer.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, fmt.Errorf("the response writer does not support hijacking")
+}
+
+func (w *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
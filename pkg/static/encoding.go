@@ -0,0 +1,124 @@
+package static
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Content codings of the responses.
+const (
+	encodingBrotli = "br"
+	encodingZstd   = "zstd"
+	encodingGzip   = "gzip"
+)
+
+// minCompressSize is the size below which compression does not pay off
+const minCompressSize = 1024
+
+// precompressedEncodings are the codings of the siblings produced at build time, with the file
+// extension of the sibling, in the order of preference.
+var precompressedEncodings = []struct {
+	encoding  string
+	extension string
+}{
+	{encoding: encodingBrotli, extension: ".br"},
+	{encoding: encodingZstd, extension: ".zst"},
+	{encoding: encodingGzip, extension: ".gz"},
+}
+
+// dynamicEncodings are the codings the console compresses with, in the order of preference.
+// Brotli is missing because there is no encoder in the console dependencies.
+var dynamicEncodings = []string{encodingZstd, encodingGzip}
+
+// compressibleTypes are the content types worth compressing, images and fonts other than SVG
+// and TTF are compressed already.
+var compressibleTypes = []string{
+	"application/javascript",
+	"application/json",
+	"application/manifest+json",
+	"application/wasm",
+	"application/xml",
+	"font/ttf",
+	"image/svg+xml",
+	"text/",
+}
+
+// acceptedEncodings parses an Accept-Encoding header into the content codings the client
+// accepts. Codings with a zero quality value are refused, and "*" stands for all the codings
+// the header does not list.
+func acceptedEncodings(header string) map[string]bool {
+	accepted := map[string]bool{}
+	for _, value := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(value, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		accepted[coding] = true
+		for _, param := range strings.Split(params, ";") {
+			name, q, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && strings.EqualFold(name, "q") {
+				if quality, err := strconv.ParseFloat(q, 64); err != nil || quality <= 0 {
+					accepted[coding] = false
+				}
+			}
+		}
+	}
+	return accepted
+}
+
+// accepts reports whether the client accepts the content coding.
+func accepts(accepted map[string]bool, encoding string) bool {
+	if ok, listed := accepted[encoding]; listed {
+		return ok
+	}
+	return accepted["*"]
+}
+
+// negotiate returns the first of the content codings the client accepts, or an empty string.
+func negotiate(header string, encodings []string) string {
+	accepted := acceptedEncodings(header)
+	for _, encoding := range encodings {
+		if accepts(accepted, encoding) {
+			return encoding
+		}
+	}
+	return ""
+}
+
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range compressibleTypes {
+		if mediaType == t || (strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// contentType returns the content type of a file from its extension, or by sniffing the
+// beginning of its content.
+func contentType(name string, content []byte) string {
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t
+	}
+	return http.DetectContentType(content)
+}
+
+// addVary adds a field name to the Vary header unless it is listed already.
+func addVary(header http.Header, field string) {
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name == "*" || strings.EqualFold(name, field) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", field)
+}
@@ -0,0 +1,220 @@
+package static
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// maxCompressSize is the size of the largest file compressed on the fly
+	maxCompressSize = 16 << 20
+	// compressedCacheSize bounds the memory of the files compressed on the fly
+	compressedCacheSize = 128 << 20
+)
+
+// FileServer serves the files of a directory. Files are served with the .br, .zst or .gz
+// sibling produced at build time that the client accepts, or are compressed once on the fly
+// and kept in memory if they have no sibling. Responses have a strong ETag computed from their
+// content and support range requests.
+type FileServer struct {
+	root  http.FileSystem
+	cache *compressedCache
+
+	mu    sync.Mutex
+	etags map[string]fileETag
+}
+
+type fileETag struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+func NewFileServer(dir string) *FileServer {
+	return &FileServer{
+		root:  http.Dir(dir),
+		cache: newCompressedCache(compressedCacheSize),
+		etags: map[string]fileETag{},
+	}
+}
+
+// ServeHTTP serves the file of the request path.
+func (s *FileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.ServeFile(w, r, r.URL.Path)
+}
+
+// ServeFile serves the named file of the directory. Names cannot leave the directory.
+func (s *FileServer) ServeFile(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name = path.Clean("/" + name)
+	f, info, err := s.open(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer f.Close()
+
+	addVary(w.Header(), "Accept-Encoding")
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		writeError(w, err)
+		return
+	}
+	ctype := contentType(name, head[:n])
+	// set before http.ServeContent, which would sniff the compressed content otherwise
+	w.Header().Set("Content-Type", ctype)
+
+	accepted := acceptedEncodings(r.Header.Get("Accept-Encoding"))
+	for _, precompressed := range precompressedEncodings {
+		if !accepts(accepted, precompressed.encoding) {
+			continue
+		}
+		sibling, siblingInfo, err := s.open(name + precompressed.extension)
+		if err != nil {
+			continue
+		}
+		defer sibling.Close()
+		// a sibling older than the file is left over from a previous build
+		if siblingInfo.ModTime().Before(info.ModTime()) {
+			continue
+		}
+		s.serve(w, r, name+precompressed.extension, precompressed.encoding, siblingInfo, sibling)
+		return
+	}
+
+	if compressible(ctype) && info.Size() >= minCompressSize && info.Size() <= maxCompressSize {
+		for _, encoding := range dynamicEncodings {
+			if !accepts(accepted, encoding) {
+				continue
+			}
+			compressed, err := s.compressed(name, encoding, info, f)
+			if err != nil {
+				klog.Errorf("failed to compress %s with %s: %v", name, encoding, err)
+				break
+			}
+			if compressed == nil {
+				// the file does not get smaller
+				break
+			}
+			w.Header().Set("Content-Encoding", encoding)
+			w.Header().Set("ETag", compressed.etag)
+			http.ServeContent(w, r, name, info.ModTime(), bytes.NewReader(compressed.content))
+			return
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	s.serve(w, r, name, "", info, f)
+}
+
+func (s *FileServer) open(name string) (http.File, fs.FileInfo, error) {
+	f, err := s.root.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, nil, fs.ErrNotExist
+	}
+	return f, info, nil
+}
+
+func (s *FileServer) serve(w http.ResponseWriter, r *http.Request, name string, encoding string, info fs.FileInfo, f http.File) {
+	etag, err := s.etag(name, info, f)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if encoding != "" {
+		w.Header().Set("Content-Encoding", encoding)
+	}
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, name, info.ModTime(), f)
+}
+
+// etag returns the ETag of a file, which is computed once for each version of the file.
+func (s *FileServer) etag(name string, info fs.FileInfo, f http.File) (string, error) {
+	s.mu.Lock()
+	cached, ok := s.etags[name]
+	s.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.etag, nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := strongETag(hash.Sum(nil))
+	s.mu.Lock()
+	s.etags[name] = fileETag{modTime: info.ModTime(), size: info.Size(), etag: etag}
+	s.mu.Unlock()
+	return etag, nil
+}
+
+// compressed returns the file compressed with the encoding, or nil if it does not get smaller.
+func (s *FileServer) compressed(name string, encoding string, info fs.FileInfo, f http.File) (*compressedEntry, error) {
+	key := compressedKey{name: name, encoding: encoding}
+	if entry, ok := s.cache.get(key, info); ok {
+		return entry.orNil(), nil
+	}
+
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := compress(encoding, content)
+	if err != nil {
+		return nil, err
+	}
+	entry := &compressedEntry{key: key, modTime: info.ModTime(), size: info.Size()}
+	if len(compressed) < len(content) {
+		entry.content = compressed
+		sum := sha256.Sum256(compressed)
+		entry.etag = strongETag(sum[:])
+	}
+	s.cache.add(entry)
+	return entry.orNil(), nil
+}
+
+func strongETag(sum []byte) string {
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		http.Error(w, "404 page not found", http.StatusNotFound)
+	case errors.Is(err, fs.ErrPermission):
+		http.Error(w, "403 Forbidden", http.StatusForbidden)
+	default:
+		klog.Errorf("failed to serve static file: %v", err)
+		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,248 @@
+package static
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var mainJS = strings.Repeat("console.log('openshift console');\n", 100)
+
+func writeFile(t *testing.T, dir, name, content string, modTime time.Time) {
+	t.Helper()
+	file := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+	require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	require.NoError(t, os.Chtimes(file, modTime, modTime))
+}
+
+func get(t *testing.T, h http.Handler, target string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case encodingGzip:
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		r = gz
+	case encodingZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	default:
+		r = bytes.NewReader(body)
+	}
+	decoded, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(decoded)
+}
+
+func TestAcceptedEncodings(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{header: "", expected: ""},
+		{header: "gzip, deflate, br, zstd", expected: encodingZstd},
+		{header: "gzip;q=1.0, zstd;q=0", expected: encodingGzip},
+		{header: "ZSTD", expected: encodingZstd},
+		{header: "*", expected: encodingZstd},
+		{header: "*;q=0.5, zstd;q=0", expected: encodingGzip},
+		{header: "identity", expected: ""},
+		{header: "gzip;q=invalid", expected: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.expected, negotiate(tt.header, dynamicEncodings))
+		})
+	}
+}
+
+func TestFileServerPrecompressed(t *testing.T) {
+	dir := t.TempDir()
+	modTime := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	writeFile(t, dir, "main.js", mainJS, modTime)
+	writeFile(t, dir, "main.js.br", "brotli", modTime)
+	writeFile(t, dir, "main.js.gz", "gzip", modTime)
+	// left over from a previous build
+	writeFile(t, dir, "main.js.zst", "zstd", modTime.Add(-time.Hour))
+	fileServer := NewFileServer(dir)
+
+	tests := []struct {
+		name             string
+		acceptEncoding   string
+		expectedEncoding string
+		expectedBody     string
+	}{
+		{name: "brotli", acceptEncoding: "gzip, deflate, br, zstd", expectedEncoding: "br", expectedBody: "brotli"},
+		{name: "stale sibling", acceptEncoding: "gzip, zstd", expectedEncoding: "gzip", expectedBody: "gzip"},
+		{name: "identity", acceptEncoding: "", expectedEncoding: "", expectedBody: mainJS},
+	}
+	etags := map[string]bool{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := get(t, fileServer, "/main.js", map[string]string{"Accept-Encoding": tt.acceptEncoding})
+			require.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tt.expectedEncoding, rr.Header().Get("Content-Encoding"))
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			assert.Equal(t, "text/javascript; charset=utf-8", rr.Header().Get("Content-Type"))
+			assert.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+
+			etag := rr.Header().Get("ETag")
+			assert.Regexp(t, `^"[A-Za-z0-9_-]+"$`, etag)
+			assert.False(t, etags[etag], "each representation has its own ETag")
+			etags[etag] = true
+
+			rr = get(t, fileServer, "/main.js", map[string]string{"Accept-Encoding": tt.acceptEncoding, "If-None-Match": etag})
+			assert.Equal(t, http.StatusNotModified, rr.Code)
+		})
+	}
+}
+
+func TestFileServerOnTheFly(t *testing.T) {
+	dir := t.TempDir()
+	modTime := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	writeFile(t, dir, "main.js", mainJS, modTime)
+	writeFile(t, dir, "small.css", "body {}", modTime)
+	writeFile(t, dir, "logo.png", strings.Repeat("\x89PNG", 1000), modTime)
+	fileServer := NewFileServer(dir)
+
+	for _, encoding := range []string{encodingZstd, encodingGzip} {
+		rr := get(t, fileServer, "/main.js", map[string]string{"Accept-Encoding": encoding})
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, encoding, rr.Header().Get("Content-Encoding"))
+		assert.Equal(t, mainJS, decode(t, encoding, rr.Body.Bytes()))
+		assert.NotEmpty(t, rr.Header().Get("ETag"))
+	}
+	assert.Len(t, fileServer.cache.entries, 2)
+
+	// the cached file is replaced once the file changes
+	changed := mainJS + "console.log('changed');\n"
+	writeFile(t, dir, "main.js", changed, modTime.Add(time.Hour))
+	rr := get(t, fileServer, "/main.js", map[string]string{"Accept-Encoding": encodingGzip})
+	assert.Equal(t, changed, decode(t, encodingGzip, rr.Body.Bytes()))
+
+	for _, name := range []string{"/small.css", "/logo.png"} {
+		rr := get(t, fileServer, name, map[string]string{"Accept-Encoding": "gzip, zstd"})
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("Content-Encoding"), name)
+	}
+}
+
+func TestFileServerRange(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "main.js", mainJS, time.Now())
+	fileServer := NewFileServer(dir)
+
+	rr := get(t, fileServer, "/main.js", map[string]string{"Range": "bytes=0-6"})
+	assert.Equal(t, http.StatusPartialContent, rr.Code)
+	assert.Equal(t, "console", rr.Body.String())
+	assert.Equal(t, fmt.Sprintf("bytes 0-6/%d", len(mainJS)), rr.Header().Get("Content-Range"))
+
+	etag := get(t, fileServer, "/main.js", nil).Header().Get("ETag")
+	rr = get(t, fileServer, "/main.js", map[string]string{"Range": "bytes=0-6", "If-Range": `"outdated"`})
+	assert.Equal(t, http.StatusOK, rr.Code, "the whole file is sent if it changed")
+	rr = get(t, fileServer, "/main.js", map[string]string{"Range": "bytes=0-6", "If-Range": etag})
+	assert.Equal(t, http.StatusPartialContent, rr.Code)
+}
+
+func TestFileServerErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "locales/en/public.json", `{}`, time.Now())
+	fileServer := NewFileServer(filepath.Join(dir, "locales"))
+
+	assert.Equal(t, http.StatusOK, get(t, fileServer, "/en/public.json", nil).Code)
+	assert.Equal(t, http.StatusNotFound, get(t, fileServer, "/en/missing.json", nil).Code)
+	assert.Equal(t, http.StatusNotFound, get(t, fileServer, "/en", nil).Code)
+
+	rr := httptest.NewRecorder()
+	fileServer.ServeFile(rr, httptest.NewRequest(http.MethodGet, "/", nil), "../../en/public.json")
+	assert.Equal(t, http.StatusOK, rr.Code, "names are resolved inside the directory")
+
+	rr = httptest.NewRecorder()
+	fileServer.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/en/public.json", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+func TestCompressedCache(t *testing.T) {
+	cache := newCompressedCache(10)
+	modTime := time.Now()
+	entry := func(name string, size int) *compressedEntry {
+		return &compressedEntry{key: compressedKey{name: name, encoding: encodingGzip}, modTime: modTime, content: make([]byte, size)}
+	}
+	cache.add(entry("a", 4))
+	cache.add(entry("b", 4))
+	cache.add(entry("c", 4))
+	assert.Equal(t, 8, cache.size)
+	assert.NotContains(t, cache.entries, compressedKey{name: "a", encoding: encodingGzip})
+
+	cache.add(entry("d", 11))
+	assert.NotContains(t, cache.entries, compressedKey{name: "d", encoding: encodingGzip}, "entries larger than the cache are not kept")
+}
+
+func TestWithCompression(t *testing.T) {
+	handler := func(contentType, contentEncoding string, status int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("ETag", `"plugin-etag"`)
+			if contentEncoding != "" {
+				w.Header().Set("Content-Encoding", contentEncoding)
+			}
+			w.WriteHeader(status)
+			_, _ = io.WriteString(w, mainJS)
+		})
+	}
+
+	rr := get(t, WithCompression(handler("application/javascript", "", http.StatusOK)), "/plugin-entry.js", map[string]string{"Accept-Encoding": "gzip, br, zstd"})
+	assert.Equal(t, encodingZstd, rr.Header().Get("Content-Encoding"))
+	assert.Equal(t, `"plugin-etag-zstd"`, rr.Header().Get("ETag"))
+	assert.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+	assert.Equal(t, mainJS, decode(t, encodingZstd, rr.Body.Bytes()))
+
+	rr = get(t, WithCompression(handler("application/javascript", "", http.StatusOK)), "/plugin-entry.js", map[string]string{"Accept-Encoding": "gzip"})
+	assert.Equal(t, encodingGzip, rr.Header().Get("Content-Encoding"))
+	assert.Equal(t, mainJS, decode(t, encodingGzip, rr.Body.Bytes()))
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		headers map[string]string
+	}{
+		{name: "compressed by the plugin", handler: handler("application/javascript", "br", http.StatusOK), headers: map[string]string{"Accept-Encoding": "gzip"}},
+		{name: "not compressible", handler: handler("image/png", "", http.StatusOK), headers: map[string]string{"Accept-Encoding": "gzip"}},
+		{name: "error", handler: handler("application/javascript", "", http.StatusNotFound), headers: map[string]string{"Accept-Encoding": "gzip"}},
+		{name: "range", handler: handler("application/javascript", "", http.StatusOK), headers: map[string]string{"Accept-Encoding": "gzip", "Range": "bytes=0-6"}},
+		{name: "no accepted encoding", handler: handler("application/javascript", "", http.StatusOK), headers: map[string]string{"Accept-Encoding": "br"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := get(t, WithCompression(tt.handler), "/plugin-entry.js", tt.headers)
+			assert.NotEqual(t, encodingGzip, rr.Header().Get("Content-Encoding"))
+			assert.Equal(t, mainJS, rr.Body.String())
+			assert.Equal(t, `"plugin-etag"`, rr.Header().Get("ETag"))
+		})
+	}
+}
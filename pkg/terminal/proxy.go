@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -18,6 +19,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/openshift/console/pkg/auth"
+	"github.com/openshift/console/pkg/serverutils"
 )
 
 const (
@@ -71,39 +73,45 @@ var (
 func (p *Proxy) HandleProxy(user *auth.User, w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		w.Header().Add("Allow", "POST")
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		serverutils.SendError(w, r, http.StatusMethodNotAllowed, "Method unsupported, the only supported method is POST")
+		return
+	}
+
+	// The terminal forwards the token to the workspace, which only accepts its creator's own token.
+	if user.Impersonate {
+		serverutils.SendError(w, r, http.StatusForbidden, "Terminal endpoint is not available for users authenticated by a front proxy.")
 		return
 	}
 
 	isWebTerminalOperatorRunning, err := checkWebTerminalOperatorIsRunning()
 	if err != nil {
-		http.Error(w, "Failed to check web terminal operator state. Cause: "+err.Error(), http.StatusInternalServerError)
+		serverutils.SendError(w, r, http.StatusInternalServerError, "Failed to check web terminal operator state. Cause: "+err.Error())
 		return
 	}
 	if !isWebTerminalOperatorRunning {
-		http.Error(w, "Terminal endpoint is disabled: web terminal operator is not deployed.", http.StatusForbidden)
+		serverutils.SendError(w, r, http.StatusForbidden, "Terminal endpoint is disabled: web terminal operator is not deployed.")
 		return
 	}
 
 	ok, namespace, workspaceName, path := stripTerminalAPIPrefix(r.URL.Path)
 	if !ok {
-		http.NotFound(w, r)
+		serverutils.SendError(w, r, http.StatusNotFound, "The terminal path must include the namespace and the name of the workspace")
 		return
 	}
 
 	isClusterAdmin, err := p.isClusterAdmin(user.Token)
 	if err != nil {
-		http.Error(w, "Failed to check the current users privileges. Cause: "+err.Error(), http.StatusInternalServerError)
+		serverutils.SendUpstreamError(w, r, http.StatusInternalServerError, fmt.Errorf("Failed to check the current users privileges. Cause: %w", err))
 		return
 	}
 	// Cluster admin terminals must live in the openshift-terminal namespace to prevent privilege escalation
 	if isClusterAdmin && namespace != "openshift-terminal" {
-		http.Error(w, "cluster-admin users must create and use terminals in the openshift-terminal namespace", http.StatusForbidden)
+		serverutils.SendError(w, r, http.StatusForbidden, "cluster-admin users must create and use terminals in the openshift-terminal namespace")
 		return
 	}
 
 	if path != WorkspaceInitEndpoint && path != WorkspaceActivityEndpoint {
-		http.Error(w, "Unsupported path", http.StatusForbidden)
+		serverutils.SendError(w, r, http.StatusForbidden, "Unsupported path")
 		return
 	}
 
@@ -111,14 +119,14 @@ func (p *Proxy) HandleProxy(user *auth.User, w http.ResponseWriter, r *http.Requ
 	if userId == "" {
 		client, err := p.createTypedClient(user.Token)
 		if err != nil {
-			http.Error(w, "Failed to create k8s client for the authenticated user. Cause: "+err.Error(), http.StatusInternalServerError)
+			serverutils.SendError(w, r, http.StatusInternalServerError, "Failed to create k8s client for the authenticated user. Cause: "+err.Error())
 			return
 		}
 
 		// user id is missing, auth is used that does not support user info propagated, like OpenShift OAuth
 		userInfo, err := client.AuthenticationV1().SelfSubjectReviews().Create(r.Context(), &v1.SelfSubjectReview{}, metav1.CreateOptions{})
 		if err != nil {
-			http.Error(w, "Failed to retrieve the current user info. Cause: "+err.Error(), http.StatusInternalServerError)
+			serverutils.SendUpstreamError(w, r, http.StatusInternalServerError, fmt.Errorf("Failed to retrieve the current user info. Cause: %w", err))
 			return
 		}
 
@@ -126,7 +134,7 @@ func (p *Proxy) HandleProxy(user *auth.User, w http.ResponseWriter, r *http.Requ
 		if userId == "" {
 			// uid is missing. it must be kube:admin
 			if userInfo.Status.UserInfo.Username != "kube:admin" {
-				http.Error(w, "User must have UID to proceed authorization", http.StatusInternalServerError)
+				serverutils.SendError(w, r, http.StatusInternalServerError, "User must have UID to proceed authorization")
 				return
 			}
 		}
@@ -134,35 +142,35 @@ func (p *Proxy) HandleProxy(user *auth.User, w http.ResponseWriter, r *http.Requ
 
 	client, err := p.createDynamicClient(user.Token)
 	if err != nil {
-		http.Error(w, "Failed to create k8s client for the authenticated user. Cause: "+err.Error(), http.StatusInternalServerError)
+		serverutils.SendError(w, r, http.StatusInternalServerError, "Failed to create k8s client for the authenticated user. Cause: "+err.Error())
 		return
 	}
 
 	ws, err := client.Resource(WorkspaceGroupVersionResource).Namespace(namespace).Get(context.TODO(), workspaceName, metav1.GetOptions{})
 	if err != nil {
-		http.Error(w, "Failed to get the requested workspace. Cause: "+err.Error(), http.StatusForbidden)
+		serverutils.SendUpstreamError(w, r, http.StatusForbidden, fmt.Errorf("Failed to get the requested workspace. Cause: %w", err))
 		return
 	}
 
 	creator := ws.GetLabels()[WorkspaceCreatorLabel]
 	if creator != userId {
-		http.Error(w, "User is not a owner of the requested workspace", http.StatusForbidden)
+		serverutils.SendError(w, r, http.StatusForbidden, "User is not a owner of the requested workspace")
 		return
 	}
 
 	restrictAccess := ws.GetAnnotations()[WorkspaceRestrictedAcccessAnnotation]
 	if restrictAccess != "true" {
-		http.Error(w, "Workspace must have restricted access annotation", http.StatusForbidden)
+		serverutils.SendError(w, r, http.StatusForbidden, "Workspace must have restricted access annotation")
 		return
 	}
 
 	terminalHost, err := p.getBaseTerminalHost(ws)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 	if terminalHost.Scheme != "https" {
-		http.Error(w, "Workspace is not served over https", http.StatusForbidden)
+		serverutils.SendError(w, r, http.StatusForbidden, "Workspace is not served over https")
 		return
 	}
 
@@ -170,38 +178,38 @@ func (p *Proxy) HandleProxy(user *auth.User, w http.ResponseWriter, r *http.Requ
 	if path == WorkspaceInitEndpoint {
 		p.handleExecInit(terminalHost, user.Token, r, w)
 	} else if path == WorkspaceActivityEndpoint {
-		p.handleActivity(terminalHost, user.Token, w)
+		p.handleActivity(terminalHost, user.Token, r, w)
 	} else {
-		http.Error(w, "Unknown path", http.StatusForbidden)
+		serverutils.SendError(w, r, http.StatusForbidden, "Unknown path")
 	}
 }
 
 func (p *Proxy) HandleProxyEnabled(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		w.Header().Set("Allow", "GET")
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		serverutils.SendError(w, r, http.StatusMethodNotAllowed, "Method unsupported, the only supported method is GET")
 		return
 	}
 
 	isWebTerminalOperatorInstalled, err := checkWebTerminalOperatorIsInstalled()
 	if err != nil {
 		klog.Errorf("Failed to check if the web terminal operator is installed: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		serverutils.SendError(w, r, http.StatusInternalServerError, fmt.Sprintf("Failed to check if the web terminal operator is installed: %v", err))
 		return
 	}
 	if !isWebTerminalOperatorInstalled {
 		klog.Error("web terminal operator is not installed")
-		w.WriteHeader(http.StatusServiceUnavailable)
+		serverutils.SendError(w, r, http.StatusServiceUnavailable, "The web terminal operator is not installed")
 		return
 	}
 	isWebTerminalOperatorRunning, err := checkWebTerminalOperatorIsRunning()
 	if err != nil {
 		klog.Errorf("Failed to check if web terminal operator is running: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		serverutils.SendError(w, r, http.StatusInternalServerError, fmt.Sprintf("Failed to check if the web terminal operator is running: %v", err))
 		return
 	}
 	if !isWebTerminalOperatorRunning {
-		w.WriteHeader(http.StatusServiceUnavailable)
+		serverutils.SendError(w, r, http.StatusServiceUnavailable, "The web terminal operator is not running")
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -210,27 +218,26 @@ func (p *Proxy) HandleProxyEnabled(w http.ResponseWriter, r *http.Request) {
 func (p *Proxy) HandleTerminalInstalledNamespace(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		w.Header().Set("Allow", "GET")
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		serverutils.SendError(w, r, http.StatusMethodNotAllowed, "Method unsupported, the only supported method is GET")
 		return
 	}
 
 	subscription, err := getWebTerminalSubscriptions()
 	if err != nil {
 		klog.Errorf("Failed to check the web terminal subscription: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	operatorNamespace, found, err := getWebTerminalNamespace(subscription)
 	if err != nil {
 		klog.Errorf("Failed to get the namespace of the web terminal subscription: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	} else if !found {
 		klog.Error("Web Terminal Operator is not installed")
-		w.WriteHeader(http.StatusServiceUnavailable)
+		serverutils.SendError(w, r, http.StatusServiceUnavailable, "The web terminal operator is not installed")
+		return
 	}
 
 	w.Write([]byte(operatorNamespace))
@@ -239,30 +246,30 @@ func (p *Proxy) HandleTerminalInstalledNamespace(w http.ResponseWriter, r *http.
 func (p *Proxy) handleExecInit(host *url.URL, token string, r *http.Request, w http.ResponseWriter) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, "Failed to read body of request: "+err.Error(), http.StatusInternalServerError)
+		serverutils.SendError(w, r, http.StatusInternalServerError, "Failed to read body of request: "+err.Error())
 		return
 	}
 
 	wkspReq, err := http.NewRequest(http.MethodPost, host.String(), io.NopCloser(bytes.NewReader(body)))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 	wkspReq.Header.Set("Content-type", "application/json")
 	wkspReq.Header.Set("X-Forwarded-Access-Token", token)
 
-	p.proxyToWorkspace(wkspReq, w)
+	p.proxyToWorkspace(wkspReq, r, w)
 }
 
-func (p *Proxy) handleActivity(host *url.URL, token string, w http.ResponseWriter) {
+func (p *Proxy) handleActivity(host *url.URL, token string, r *http.Request, w http.ResponseWriter) {
 	wkspReq, err := http.NewRequest(http.MethodPost, host.String(), nil)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		serverutils.SendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	wkspReq.Header.Set("X-Forwarded-Access-Token", token)
-	p.proxyToWorkspace(wkspReq, w)
+	p.proxyToWorkspace(wkspReq, r, w)
 }
 
 // stripTerminalAPIPrefix strips path prefix that is expected for Terminal API request
@@ -306,10 +313,10 @@ func (p *Proxy) getBaseTerminalHost(ws *unstructured.Unstructured) (*url.URL, er
 	return terminalHost, nil
 }
 
-func (p *Proxy) proxyToWorkspace(wkspReq *http.Request, w http.ResponseWriter) {
+func (p *Proxy) proxyToWorkspace(wkspReq *http.Request, r *http.Request, w http.ResponseWriter) {
 	wkspResp, err := p.workspaceHttpClient.Do(wkspReq)
 	if err != nil {
-		http.Error(w, "Failed to proxy request. Cause: "+err.Error(), http.StatusInternalServerError)
+		serverutils.SendUpstreamError(w, r, http.StatusBadGateway, fmt.Errorf("Failed to proxy request. Cause: %w", err))
 		return
 	}
 
@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const serviceName = "console"
+
+// Config configures the export of the console traces.
+type Config struct {
+	// Endpoint is the URL of an OTLP/HTTP collector, like http://otel-collector:4318. Spans
+	// are sent to its /v1/traces path.
+	Endpoint *url.URL
+	// SamplingRatio is the ratio of the traces started by the console that are sampled.
+	// Traces started by the browser or a proxy in front of the console keep their sampling.
+	SamplingRatio float64
+	// Transport sends the spans to the collector, http.DefaultTransport if nil
+	Transport http.RoundTripper
+}
+
+// Setup installs the tracer provider and the W3C trace context propagator of the console.
+// Without it, spans are not recorded and trace context is not propagated. The returned
+// function exports the remaining spans on shutdown.
+func Setup(config Config) (func(context.Context) error, error) {
+	endpoint := *config.Endpoint
+	endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + "/v1/traces"
+
+	transport := config.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(endpoint.String()),
+		otlptracehttp.WithHTTPClient(&http.Client{Transport: transport}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OTLP exporter: %w", err)
+	}
+
+	attributes := []attribute.KeyValue{attribute.String("service.name", serviceName)}
+	if version := os.Getenv("SOURCE_GIT_COMMIT"); version != "" {
+		attributes = append(attributes, attribute.String("service.version", version))
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		attributes = append(attributes, attribute.String("service.instance.id", hostname))
+	}
+
+	// traces started by the browser or a proxy in front of the console keep their sampling
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SamplingRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attributes...)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// WithServerSpans creates a server span for each request. The span continues the trace of
+// the traceparent header of the request, and its traceparent is returned in the response
+// so that traces of the frontend can join the traces of the server. Spans are named after
+// the method of the request until WithRoute names them after their route.
+func WithServerSpans(h http.Handler) http.Handler {
+	spans := otelhttp.NewHandler(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			otel.GetTextMapPropagator().Inject(r.Context(), propagation.HeaderCarrier(w.Header()))
+			h.ServeHTTP(w, r)
+		}),
+		serviceName,
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			if route := routeFrom(r.Context()); route != nil && *route != "" {
+				return r.Method + " " + *route
+			}
+			return r.Method
+		}),
+	)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the route is only known once the request is routed, the handler keeps it for the
+		// name the span gets when the request is served
+		spans.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeKey{}, new(string))))
+	})
+}
+
+// WithRoute names the server span of the requests of h after the route h is registered for.
+func WithRoute(route string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name := routeFrom(r.Context()); name != nil {
+			*name = route
+		}
+		span := trace.SpanFromContext(r.Context())
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(attribute.String("http.route", route))
+		h.ServeHTTP(w, r)
+	})
+}
+
+type routeKey struct{}
+
+func routeFrom(ctx context.Context) *string {
+	route, _ := ctx.Value(routeKey{}).(*string)
+	return route
+}
+
+// Transport creates a client span for each request sent with the round tripper, and
+// propagates the trace context to the upstream.
+func Transport(rt http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(rt)
+}
+
+// InjectHeaders propagates the trace context of ctx in the headers of a request that is not
+// sent with an instrumented transport, like a websocket dial.
+func InjectHeaders(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
@@ -0,0 +1,160 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	browserTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	browserSpanID      = "00f067aa0ba902b7"
+	browserTraceparent = "00-" + browserTraceID + "-" + browserSpanID + "-01"
+)
+
+// collector is an OTLP/HTTP collector that keeps the spans it receives.
+type collector struct {
+	mu    sync.Mutex
+	spans []*tracev1.Span
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil || r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var data tracev1.TracesData
+	if err := proto.Unmarshal(body, &data); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, resourceSpans := range data.ResourceSpans {
+		for _, scopeSpans := range resourceSpans.ScopeSpans {
+			c.spans = append(c.spans, scopeSpans.Spans...)
+		}
+	}
+}
+
+func (c *collector) span(kind tracev1.Span_SpanKind) *tracev1.Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.spans {
+		if s.Kind == kind {
+			return s
+		}
+	}
+	return nil
+}
+
+func TestTracing(t *testing.T) {
+	spans := &collector{}
+	collectorServer := httptest.NewServer(spans)
+	defer collectorServer.Close()
+
+	var upstreamTraceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+
+	endpoint, err := url.Parse(collectorServer.URL)
+	require.NoError(t, err)
+	shutdown, err := Setup(Config{Endpoint: endpoint, SamplingRatio: 0})
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: Transport(http.DefaultTransport)}
+	// routes are registered under the base path of the console, like the routes of the server
+	mux := http.NewServeMux()
+	mux.Handle("/console/api/proxy/", WithRoute("/api/proxy/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, upstream.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/console/api/proxy/plugin/acm/search", nil)
+	req.Header.Set("traceparent", browserTraceparent)
+	rr := httptest.NewRecorder()
+	WithServerSpans(mux).ServeHTTP(rr, req)
+	require.NoError(t, shutdown(context.Background()))
+
+	// the browser trace is sampled, so it is recorded although the console samples no traces
+	serverSpan := spans.span(tracev1.Span_SPAN_KIND_SERVER)
+	require.NotNil(t, serverSpan)
+	assert.Equal(t, "GET /api/proxy/", serverSpan.Name)
+	assert.Contains(t, serverSpan.Attributes, &commonv1.KeyValue{Key: "http.route", Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: "/api/proxy/"}}})
+	assert.Equal(t, browserTraceID, trace.TraceID(serverSpan.TraceId).String())
+	assert.Equal(t, browserSpanID, trace.SpanID(serverSpan.ParentSpanId).String())
+
+	clientSpan := spans.span(tracev1.Span_SPAN_KIND_CLIENT)
+	require.NotNil(t, clientSpan)
+	assert.Equal(t, serverSpan.TraceId, clientSpan.TraceId)
+	assert.Equal(t, serverSpan.SpanId, clientSpan.ParentSpanId)
+
+	assert.Equal(t, "00-"+browserTraceID+"-"+trace.SpanID(serverSpan.SpanId).String()+"-01", rr.Header().Get("traceparent"))
+	assert.Equal(t, "00-"+browserTraceID+"-"+trace.SpanID(clientSpan.SpanId).String()+"-01", upstreamTraceparent)
+}
+
+func TestUnroutedSpanName(t *testing.T) {
+	spans := &collector{}
+	collectorServer := httptest.NewServer(spans)
+	defer collectorServer.Close()
+	endpoint, err := url.Parse(collectorServer.URL)
+	require.NoError(t, err)
+	shutdown, err := Setup(Config{Endpoint: endpoint, SamplingRatio: 1})
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.Handle("/console/api/proxy/", WithRoute("/api/proxy/", http.NotFoundHandler()))
+	WithServerSpans(mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/console/api/unknown", nil))
+	require.NoError(t, shutdown(context.Background()))
+
+	serverSpan := spans.span(tracev1.Span_SPAN_KIND_SERVER)
+	require.NotNil(t, serverSpan)
+	assert.Equal(t, "GET", serverSpan.Name)
+}
+
+func TestSampling(t *testing.T) {
+	endpoint, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	shutdown, err := Setup(Config{Endpoint: endpoint, SamplingRatio: 0})
+	require.NoError(t, err)
+	defer shutdown(context.Background())
+	tracer := otel.Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	assert.False(t, root.IsRecording())
+	assert.True(t, root.SpanContext().IsValid(), "spans that are not sampled still propagate their trace")
+	_, child := tracer.Start(ctx, "child")
+	assert.False(t, child.IsRecording())
+	assert.Equal(t, root.SpanContext().TraceID(), child.SpanContext().TraceID())
+
+	traceID, err := trace.TraceIDFromHex(browserTraceID)
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex(browserSpanID)
+	require.NoError(t, err)
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	_, sampled := tracer.Start(trace.ContextWithRemoteSpanContext(context.Background(), parent), "sampled")
+	assert.True(t, sampled.IsRecording())
+}
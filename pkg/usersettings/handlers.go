@@ -39,7 +39,7 @@ func (h *UserSettingsHandler) HandleUserSettings(user *auth.User, w http.Respons
 
 	userSettingMeta, err := h.getUserSettingMeta(ctx, user)
 	if err != nil {
-		h.sendErrorResponse("Failed to get user data to handle user setting request: %v", err, w)
+		h.sendErrorResponse("Failed to get user data to handle user setting request", err, w, r)
 		return
 	}
 
@@ -47,40 +47,36 @@ func (h *UserSettingsHandler) HandleUserSettings(user *auth.User, w http.Respons
 	case http.MethodGet:
 		configMap, err := h.getUserSettings(ctx, userSettingMeta)
 		if err != nil {
-			h.sendErrorResponse("Failed to get user settings: %v", err, w)
+			h.sendErrorResponse("Failed to get user settings", err, w, r)
 			return
 		}
 		serverutils.SendResponse(w, http.StatusOK, configMap)
 	case http.MethodPost:
 		configMap, err := h.createUserSettings(ctx, userSettingMeta)
 		if err != nil {
-			h.sendErrorResponse("Failed to create user settings: %v", err, w)
+			h.sendErrorResponse("Failed to create user settings", err, w, r)
 			return
 		}
 		serverutils.SendResponse(w, http.StatusOK, configMap)
 	case http.MethodDelete:
 		err := h.deleteUserSettings(ctx, userSettingMeta)
 		if err != nil {
-			h.sendErrorResponse("Failed to delete user settings: %v", err, w)
+			h.sendErrorResponse("Failed to delete user settings", err, w, r)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 	default:
 		w.Header().Set("Allow", "GET, POST, DELETE")
-		serverutils.SendResponse(w, http.StatusMethodNotAllowed, serverutils.ApiError{Err: "Unsupported method, supported methods are GET POST DELETE"})
+		serverutils.SendError(w, r, http.StatusMethodNotAllowed, "Unsupported method, supported methods are GET POST DELETE")
 	}
 }
 
-func (h *UserSettingsHandler) sendErrorResponse(format string, err error, w http.ResponseWriter) {
-	errMsg := fmt.Sprintf(format, err)
-	klog.Errorf("%s", errMsg)
-	code := http.StatusBadGateway
-	if apierrors.IsNotFound(err) {
-		code = http.StatusNotFound
-	} else if apierrors.IsForbidden(err) {
-		code = http.StatusForbidden
-	}
-	serverutils.SendResponse(w, code, serverutils.ApiError{Err: errMsg})
+// sendErrorResponse responds with the status of the Kubernetes API error, or with 502 Bad
+// Gateway if the error is not from the Kubernetes API.
+func (h *UserSettingsHandler) sendErrorResponse(message string, err error, w http.ResponseWriter, r *http.Request) {
+	err = fmt.Errorf("%s: %w", message, err)
+	klog.Errorf("%v", err)
+	serverutils.SendUpstreamError(w, r, http.StatusBadGateway, err)
 }
 
 // Fetch the user-setting ConfigMap of the current user
@@ -217,6 +213,7 @@ func (h *UserSettingsHandler) getUserSettingMeta(ctx context.Context, user *auth
 	// copy the anon config for the user and authenticate the transport with the user's token
 	userConfig := rest.CopyConfig(h.anonClientConfig)
 	userConfig.BearerToken = user.Token
+	userConfig.Impersonate = user.ImpersonationConfig()
 
 	client, err := kubernetes.NewForConfig(userConfig)
 	if err != nil {
@@ -54,9 +54,23 @@ func RandomString(length int) (string, error) {
 	return encoding.EncodeToString(randomBytes)[:length], nil
 }
 
+// CSPReportToGroup is the name of the Reporting-Endpoints entry the report-to directive refers to.
+const CSPReportToGroup = "csp-endpoint"
+
+// CSPHeader returns the header the directives are sent in. The policy is only reported on
+// until it is enforced.
+func CSPHeader(enforce bool) string {
+	if enforce {
+		return "Content-Security-Policy"
+	}
+	return "Content-Security-Policy-Report-Only"
+}
+
 // buildCSPDirectives takes the content security policy configuration from the server and constructs
-// a complete set of directives for the Content-Security-Policy-Report-Only header.
+// a complete set of directives for the header returned by CSPHeader.
 // The constructed directives will include the default sources and the supplied configuration.
+// Violations are reported to cspReportingEndpoint with both report-uri and the Reporting API,
+// which needs a Reporting-Endpoints header for CSPReportToGroup.
 func BuildCSPDirectives(k8sMode string, pluginsCSP serverconfig.MultiKeyValue, indexPageScriptNonce string, cspReportingEndpoint string) ([]string, error) {
 	nonce := fmt.Sprintf("'nonce-%s'", indexPageScriptNonce)
 
@@ -131,9 +145,11 @@ func BuildCSPDirectives(k8sMode string, pluginsCSP serverconfig.MultiKeyValue, i
 		"frame-ancestors 'none'",
 	}
 
-	// Support using client provided CSP reporting endpoint for testing purposes.
 	if cspReportingEndpoint != "" {
-		resultDirectives = append(resultDirectives, fmt.Sprintf("report-uri %s", cspReportingEndpoint))
+		resultDirectives = append(resultDirectives,
+			fmt.Sprintf("report-uri %s", cspReportingEndpoint),
+			fmt.Sprintf("report-to %s", CSPReportToGroup),
+		)
 	}
 
 	return resultDirectives, nil
@@ -119,6 +119,7 @@ func TestBuildCSPDirectives(t *testing.T) {
 				frameSrcDirective,
 				frameAncestorsDirective,
 				"report-uri http://localhost:7777/csp-test-endpoint",
+				"report-to csp-endpoint",
 			},
 		},
 		{
@@ -165,6 +166,7 @@ func TestBuildCSPDirectives(t *testing.T) {
 				frameSrcDirective,
 				frameAncestorsDirective,
 				"report-uri http://localhost:7777/csp-test-endpoint",
+				"report-to csp-endpoint",
 			},
 		},
 	}
@@ -182,3 +184,12 @@ func TestBuildCSPDirectives(t *testing.T) {
 		})
 	}
 }
+
+func TestCSPHeader(t *testing.T) {
+	if got := CSPHeader(false); got != "Content-Security-Policy-Report-Only" {
+		t.Errorf("CSPHeader(false) = %s", got)
+	}
+	if got := CSPHeader(true); got != "Content-Security-Policy" {
+		t.Errorf("CSPHeader(true) = %s", got)
+	}
+}